@@ -0,0 +1,285 @@
+// Command encryptpii encrypts customer PII columns in place and refreshes their
+// blind indexes. It is safe to re-run: rows already encrypted under the active
+// master key are skipped, so the same command performs key rotation after a
+// new PII_ACTIVE_KEY_ID is configured.
+//
+// Account and phone numbers are unique on their normalised form, so legacy
+// rows that differ only in formatting ("0812-..." and "+62812...") collide.
+// A first pass looks for such collisions before anything is written; the
+// command then stops with a report unless -skip-duplicates is given, in which
+// case every colliding customer but the oldest is encrypted with that blind
+// index left empty, to be resolved through the duplicate review.
+package main
+
+import (
+	"flag"
+	"log"
+	"ml-prediction/config"
+	"ml-prediction/pkg/piicrypto"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type customerPII struct {
+	ID            uint64     `gorm:"column:id"`
+	NomorRekening string     `gorm:"column:nomor_rekening"`
+	NomorHp       string     `gorm:"column:nomor_hp"`
+	Email         string     `gorm:"column:email"`
+	Address       string     `gorm:"column:address"`
+	DeletedAt     *time.Time `gorm:"column:deleted_at"`
+
+	NomorRekeningBidx *string `gorm:"column:nomor_rekening_bidx"`
+	NomorHpBidx       *string `gorm:"column:nomor_hp_bidx"`
+	EmailBidx         *string `gorm:"column:email_bidx"`
+}
+
+// uniqueIndexes are the blind index columns with a unique index over the
+// customers that are not deleted.
+var uniqueIndexes = []string{"nomor_rekening_bidx", "nomor_hp_bidx"}
+
+// collision is a customer whose normalised value is already taken by an
+// older customer.
+type collision struct {
+	column    string
+	customer  uint64
+	conflicts uint64
+}
+
+func main() {
+	batchSize := flag.Int("batch", 500, "number of customers processed per transaction")
+	dryRun := flag.Bool("dry-run", false, "report what would change without writing")
+	decrypt := flag.Bool("decrypt", false, "write plaintext back, e.g. before rolling back the encryption migration")
+	skipDuplicates := flag.Bool("skip-duplicates", false, "leave the blind index of colliding customers empty instead of stopping")
+	flag.Parse()
+
+	cfg := config.NewConfig()
+	keyring, err := piicrypto.LoadKeyring(piicrypto.Options{
+		Keys:          cfg.Encryption.PIIKeys,
+		ActiveKeyID:   cfg.Encryption.PIIActiveKeyID,
+		KeyFile:       cfg.Encryption.PIIKeyFile,
+		BlindIndexKey: cfg.Encryption.BlindIndexKey,
+	})
+	if err != nil {
+		log.Fatalf("failed to load PII keys: %v", err)
+	}
+	if !keyring.Enabled() && !*decrypt {
+		log.Fatalf("PII encryption keys are not configured")
+	}
+	piicrypto.SetDefault(keyring)
+
+	db := config.SetupDatabase(cfg)
+
+	collisions := findCollisions(db, keyring, *batchSize)
+	for _, c := range collisions {
+		log.Printf("customer %d has the same normalised %s as customer %d", c.customer, c.column, c.conflicts)
+	}
+	skip := make(map[string]map[uint64]bool, len(uniqueIndexes))
+	for _, c := range collisions {
+		if skip[c.column] == nil {
+			skip[c.column] = map[uint64]bool{}
+		}
+		skip[c.column][c.customer] = true
+	}
+	if len(collisions) > 0 && !*dryRun && !*skipDuplicates {
+		log.Fatalf("%d blind index collisions found, nothing was written: merge the duplicates first or re-run with -skip-duplicates", len(collisions))
+	}
+
+	// Colliding customers may hold their index from an earlier run; it is
+	// cleared first so the older customer can take it.
+	if !*dryRun {
+		for _, column := range uniqueIndexes {
+			ids := make([]uint64, 0, len(skip[column]))
+			for id := range skip[column] {
+				ids = append(ids, id)
+			}
+			if len(ids) == 0 {
+				continue
+			}
+			if err := db.Table("customers").Where("id IN ?", ids).UpdateColumn(column, nil).Error; err != nil {
+				log.Fatalf("failed to clear colliding %s: %v", column, err)
+			}
+		}
+	}
+
+	var lastID uint64
+	var scanned, updated int
+	for {
+		rows := readBatch(db, lastID, *batchSize)
+		if len(rows) == 0 {
+			break
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			for _, row := range rows {
+				changes, err := migrateRow(keyring, row, *decrypt, skip)
+				if err != nil {
+					return err
+				}
+				if len(changes) == 0 {
+					continue
+				}
+				updated++
+				if *dryRun {
+					continue
+				}
+				if err := tx.Table("customers").Where("id = ?", row.ID).UpdateColumns(changes).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			log.Fatalf("failed to migrate customers after id %d: %v", lastID, err)
+		}
+
+		scanned += len(rows)
+		lastID = rows[len(rows)-1].ID
+		log.Printf("processed %d customers, %d need changes", scanned, updated)
+	}
+
+	if *dryRun {
+		log.Printf("dry run finished: %d of %d customers would be rewritten, %d blind index collisions", updated, scanned, len(collisions))
+		return
+	}
+	log.Printf("finished: %d of %d customers rewritten with key %q, %d blind indexes left empty because of collisions",
+		updated, scanned, keyring.ActiveKeyID(), len(collisions))
+}
+
+func readBatch(db *gorm.DB, afterID uint64, limit int) []customerPII {
+	var rows []customerPII
+	if err := db.Table("customers").
+		Select("id, nomor_rekening, nomor_hp, email, address, deleted_at, nomor_rekening_bidx, nomor_hp_bidx, email_bidx").
+		Where("id > ?", afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&rows).Error; err != nil {
+		log.Fatalf("failed to read customers after id %d: %v", afterID, err)
+	}
+	return rows
+}
+
+// findCollisions reads every customer that is not deleted and returns those
+// whose account or phone blind index is already held by an older customer.
+// Only the indexes are kept in memory, never the plaintext.
+func findCollisions(db *gorm.DB, keyring *piicrypto.Keyring, batchSize int) []collision {
+	owners := make(map[string]map[string]uint64, len(uniqueIndexes))
+	for _, column := range uniqueIndexes {
+		owners[column] = map[string]uint64{}
+	}
+
+	var collisions []collision
+	var lastID uint64
+	for {
+		rows := readBatch(db, lastID, batchSize)
+		if len(rows) == 0 {
+			break
+		}
+		for _, row := range rows {
+			if row.DeletedAt != nil {
+				continue
+			}
+			plaintexts, err := decryptRow(keyring, row)
+			if err != nil {
+				log.Fatalf("failed to decrypt customer %d: %v", row.ID, err)
+			}
+			wanted := wantedIndexes(plaintexts)
+			for _, column := range uniqueIndexes {
+				index := wanted[column]
+				if index == nil {
+					continue
+				}
+				if owner, taken := owners[column][*index]; taken {
+					collisions = append(collisions, collision{column: column, customer: row.ID, conflicts: owner})
+					continue
+				}
+				owners[column][*index] = row.ID
+			}
+		}
+		lastID = rows[len(rows)-1].ID
+	}
+
+	sort.Slice(collisions, func(i, j int) bool { return collisions[i].customer < collisions[j].customer })
+	return collisions
+}
+
+func decryptRow(keyring *piicrypto.Keyring, row customerPII) (map[string]string, error) {
+	columns := map[string]string{
+		"nomor_rekening": row.NomorRekening,
+		"nomor_hp":       row.NomorHp,
+		"email":          row.Email,
+		"address":        row.Address,
+	}
+	plaintexts := make(map[string]string, len(columns))
+	for column, stored := range columns {
+		plaintext, err := keyring.Decrypt(stored)
+		if err != nil {
+			return nil, err
+		}
+		plaintexts[column] = plaintext
+	}
+	return plaintexts, nil
+}
+
+func wantedIndexes(plaintexts map[string]string) map[string]*string {
+	return map[string]*string{
+		"nomor_rekening_bidx": piicrypto.BlindIndexPtr(piicrypto.FieldAccount, plaintexts["nomor_rekening"]),
+		"nomor_hp_bidx":       piicrypto.BlindIndexPtr(piicrypto.FieldPhone, plaintexts["nomor_hp"]),
+		"email_bidx":          piicrypto.BlindIndexPtr(piicrypto.FieldEmail, plaintexts["email"]),
+	}
+}
+
+// migrateRow returns the column updates needed to bring one customer to the
+// target state, or nothing when the row is already current. Blind indexes
+// listed in skip for the customer are left empty.
+func migrateRow(keyring *piicrypto.Keyring, row customerPII, decrypt bool, skip map[string]map[uint64]bool) (map[string]interface{}, error) {
+	stored := map[string]string{
+		"nomor_rekening": row.NomorRekening,
+		"nomor_hp":       row.NomorHp,
+		"email":          row.Email,
+		"address":        row.Address,
+	}
+	plaintexts, err := decryptRow(keyring, row)
+	if err != nil {
+		return nil, err
+	}
+	changes := map[string]interface{}{}
+
+	for column, value := range stored {
+		switch {
+		case decrypt && piicrypto.IsEncrypted(value):
+			changes[column] = plaintexts[column]
+		case !decrypt && !keyring.IsCurrent(value):
+			sealed, err := keyring.Encrypt(plaintexts[column])
+			if err != nil {
+				return nil, err
+			}
+			changes[column] = sealed
+		}
+	}
+
+	// Blind indexes are refreshed as well so rows inserted before the index
+	// columns existed become searchable.
+	current := map[string]*string{
+		"nomor_rekening_bidx": row.NomorRekeningBidx,
+		"nomor_hp_bidx":       row.NomorHpBidx,
+		"email_bidx":          row.EmailBidx,
+	}
+	for column, wanted := range wantedIndexes(plaintexts) {
+		if skip[column][row.ID] {
+			wanted = nil
+		}
+		if !sameIndex(current[column], wanted) {
+			changes[column] = wanted
+		}
+	}
+	return changes, nil
+}
+
+func sameIndex(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -2,15 +2,31 @@ package config
 
 import (
 	"fmt"
+	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Configuration struct {
-	App      AppConfig
-	Postgres PostgresConfig
-	Server   ServerConfig
+	App          AppConfig
+	Postgres     PostgresConfig
+	Server       ServerConfig
+	Encryption   EncryptionConfig
+	Consent      ConsentConfig
+	Retention    RetentionConfig
+	Lead         LeadConfig
+	FollowUp     FollowUpConfig
+	Distribution DistributionConfig
+	Storage      StorageConfig
+	Document     DocumentConfig
+	Leaderboard  LeaderboardConfig
+	Incentive    IncentiveConfig
+	Worklist     WorklistConfig
+	Duplicate    DuplicateConfig
 }
 
 type ServerConfig struct {
@@ -26,6 +42,131 @@ type PostgresConfig struct {
 	PostgresParams     string
 }
 
+type EncryptionConfig struct {
+	PIIKeys        string
+	PIIActiveKeyID string
+	PIIKeyFile     string
+	BlindIndexKey  string
+}
+
+// ConsentConfig controls how contact consent is enforced in lead flows.
+// RequireExplicit treats customers without any consent record as not
+// contactable; HideNonConsenting removes non-contactable customers from the
+// lead pool instead of only flagging them.
+type ConsentConfig struct {
+	RequireExplicit   bool
+	HideNonConsenting bool
+}
+
+// RetentionConfig sets how long customer PII is kept. A zero period disables
+// that rule.
+type RetentionConfig struct {
+	Enabled                  bool
+	Interval                 time.Duration
+	BatchSize                int
+	AnonymizeRejectedMonths  int
+	AnonymizeProspectsMonths int
+	PurgeDeletedDays         int
+}
+
+// LeadConfig points to an optional JSON definition of the lead state machine
+// and sets how long a rejected lead rests before a BM may reopen it.
+// ReservationTTL is how long a marketer keeps an open lead without activity
+// or status progress before the release job returns it to the pool.
+// ClosingBackdateMonths is how many months before the current one a closing
+// may still be dated in, provided the period is not locked.
+// ClosingRequireEvidence makes an evidence reference, such as the
+// application number, mandatory on every closing.
+// StaleAfter is how long an open lead may go without a status change or
+// logged activity before the funnel report counts it as stale.
+type LeadConfig struct {
+	StateMachineFile       string
+	ReopenCooldown         time.Duration
+	ReservationTTL         time.Duration
+	ReleaseEnabled         bool
+	ReleaseInterval        time.Duration
+	ClosingBackdateMonths  int
+	ClosingRequireEvidence bool
+	StaleAfter             time.Duration
+}
+
+// FollowUpConfig controls the background job that turns due follow-ups into
+// reminders. A follow-up becomes overdue once it is OverdueGrace past due.
+type FollowUpConfig struct {
+	RemindersEnabled bool
+	Interval         time.Duration
+	OverdueGrace     time.Duration
+}
+
+// DistributionConfig controls the job that hands new customers to marketers
+// of branches with distribution enabled. Customers older than MaxAge are left
+// in the pool so enabling the engine does not flood marketers with backlog.
+type DistributionConfig struct {
+	Enabled   bool
+	Interval  time.Duration
+	BatchSize int
+	MaxAge    time.Duration
+}
+
+// StorageConfig selects where uploaded files are kept. Driver is "local" or
+// "s3"; the S3 settings also work with S3-compatible stores such as MinIO
+// when S3PathStyle is set.
+type StorageConfig struct {
+	Driver      string
+	LocalPath   string
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3PathStyle bool
+}
+
+// DocumentConfig limits lead document uploads. Content types are detected
+// from the file content. ScanCommand, when set, is run with the path of each
+// upload and must accept it before the file is stored.
+type DocumentConfig struct {
+	MaxSizeBytes int64
+	AllowedTypes []string
+	ScanCommand  string
+}
+
+// LeaderboardConfig sets how much a marketer sees of other marketers on the
+// leaderboards: "full" shows NIP and name, "initials" only the initials of
+// the name and "anonymized" neither. BranchVisibility applies to colleagues
+// in the same branch, NationalVisibility to everyone else.
+type LeaderboardConfig struct {
+	BranchVisibility   string
+	NationalVisibility string
+}
+
+// IncentiveConfig controls the job that calculates the incentive statements
+// of the previous month. Statements stay open to recalculation until a BM
+// approves them.
+type IncentiveConfig struct {
+	Enabled  bool
+	Interval time.Duration
+}
+
+// WorklistConfig holds the ranking weights of branches whose BM has not set
+// their own, and how many pool customers are considered for each worklist
+// next to the marketer's open leads.
+type WorklistConfig struct {
+	WeightScore     int
+	WeightPlafond   int
+	WeightFollowUp  int
+	WeightTargetGap int
+	PoolCandidates  int
+}
+
+// DuplicateConfig controls the job that looks for customers recorded more
+// than once. Customers are read BatchSize at a time.
+type DuplicateConfig struct {
+	ScanEnabled  bool
+	ScanInterval time.Duration
+	BatchSize    int
+}
+
 type AppConfig struct {
 	Environment string
 	JwtSecret   string
@@ -57,8 +198,124 @@ func NewConfig() *Configuration {
 			PostgresqlPassword: os.Getenv("DB_PASSWORD"),
 			PostgresParams:     os.Getenv("DB_PARAMS"),
 		},
+		Encryption: EncryptionConfig{
+			PIIKeys:        os.Getenv("PII_KEYS"),
+			PIIActiveKeyID: os.Getenv("PII_ACTIVE_KEY_ID"),
+			PIIKeyFile:     os.Getenv("PII_KEY_FILE"),
+			BlindIndexKey:  os.Getenv("PII_BLIND_INDEX_KEY"),
+		},
+		Consent: ConsentConfig{
+			RequireExplicit:   os.Getenv("CONSENT_REQUIRE_EXPLICIT") == "true",
+			HideNonConsenting: os.Getenv("CONSENT_HIDE_NON_CONSENTING") == "true",
+		},
+		Retention: RetentionConfig{
+			Enabled:                  os.Getenv("RETENTION_ENABLED") == "true",
+			Interval:                 envInterval("RETENTION_INTERVAL", 24*time.Hour),
+			BatchSize:                envInt("RETENTION_BATCH_SIZE", 500),
+			AnonymizeRejectedMonths:  envInt("RETENTION_ANONYMIZE_REJECTED_MONTHS", 24),
+			AnonymizeProspectsMonths: envInt("RETENTION_ANONYMIZE_PROSPECTS_MONTHS", 0),
+			PurgeDeletedDays:         envInt("RETENTION_PURGE_DELETED_DAYS", 90),
+		},
+		Lead: LeadConfig{
+			StateMachineFile:       os.Getenv("LEAD_STATE_MACHINE_FILE"),
+			ReopenCooldown:         envDuration("LEAD_REOPEN_COOLDOWN", 30*24*time.Hour),
+			ReservationTTL:         envInterval("LEAD_RESERVATION_TTL", 7*24*time.Hour),
+			ReleaseEnabled:         os.Getenv("LEAD_RELEASE_ENABLED") != "false",
+			ReleaseInterval:        envInterval("LEAD_RELEASE_INTERVAL", 15*time.Minute),
+			ClosingBackdateMonths:  envInt("LEAD_CLOSING_BACKDATE_MONTHS", 1),
+			ClosingRequireEvidence: os.Getenv("LEAD_CLOSING_REQUIRE_EVIDENCE") == "true",
+			StaleAfter:             envInterval("LEAD_STALE_AFTER", 14*24*time.Hour),
+		},
+		FollowUp: FollowUpConfig{
+			RemindersEnabled: os.Getenv("FOLLOW_UP_REMINDERS_ENABLED") != "false",
+			Interval:         envInterval("FOLLOW_UP_INTERVAL", 5*time.Minute),
+			OverdueGrace:     envDuration("FOLLOW_UP_OVERDUE_GRACE", time.Hour),
+		},
+		Distribution: DistributionConfig{
+			Enabled:   os.Getenv("DISTRIBUTION_ENABLED") == "true",
+			Interval:  envInterval("DISTRIBUTION_INTERVAL", time.Minute),
+			BatchSize: envInt("DISTRIBUTION_BATCH_SIZE", 200),
+			MaxAge:    envInterval("DISTRIBUTION_MAX_AGE", 72*time.Hour),
+		},
+		Storage: StorageConfig{
+			Driver:      envString("STORAGE_DRIVER", "local"),
+			LocalPath:   envString("STORAGE_LOCAL_PATH", "./storage"),
+			S3Endpoint:  os.Getenv("STORAGE_S3_ENDPOINT"),
+			S3Region:    os.Getenv("STORAGE_S3_REGION"),
+			S3Bucket:    os.Getenv("STORAGE_S3_BUCKET"),
+			S3AccessKey: os.Getenv("STORAGE_S3_ACCESS_KEY"),
+			S3SecretKey: os.Getenv("STORAGE_S3_SECRET_KEY"),
+			S3PathStyle: os.Getenv("STORAGE_S3_PATH_STYLE") == "true",
+		},
+		Document: DocumentConfig{
+			MaxSizeBytes: int64(envInt("DOCUMENT_MAX_SIZE_MB", 5)) << 20,
+			AllowedTypes: strings.Split(envString("DOCUMENT_ALLOWED_TYPES", "application/pdf,image/jpeg,image/png"), ","),
+			ScanCommand:  os.Getenv("DOCUMENT_SCAN_COMMAND"),
+		},
+		Leaderboard: LeaderboardConfig{
+			BranchVisibility:   envString("LEADERBOARD_BRANCH_VISIBILITY", "full"),
+			NationalVisibility: envString("LEADERBOARD_NATIONAL_VISIBILITY", "anonymized"),
+		},
+		Incentive: IncentiveConfig{
+			Enabled:  os.Getenv("INCENTIVE_ENABLED") == "true",
+			Interval: envInterval("INCENTIVE_INTERVAL", 24*time.Hour),
+		},
+		Worklist: WorklistConfig{
+			WeightScore:     envInt("WORKLIST_WEIGHT_SCORE", 40),
+			WeightPlafond:   envInt("WORKLIST_WEIGHT_PLAFOND", 20),
+			WeightFollowUp:  envInt("WORKLIST_WEIGHT_FOLLOW_UP", 25),
+			WeightTargetGap: envInt("WORKLIST_WEIGHT_TARGET_GAP", 15),
+			PoolCandidates:  envInt("WORKLIST_POOL_CANDIDATES", 200),
+		},
+		Duplicate: DuplicateConfig{
+			ScanEnabled:  os.Getenv("DUPLICATE_SCAN_ENABLED") == "true",
+			ScanInterval: envInterval("DUPLICATE_SCAN_INTERVAL", 24*time.Hour),
+			BatchSize:    envInt("DUPLICATE_SCAN_BATCH_SIZE", 1000),
+		},
 		App: *appConfig,
 	}
 
 	return &config
 }
+
+func envString(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func envInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// envDuration reads a duration that may be zero, e.g. to switch a cooldown or
+// grace period off. Unset variables fall back silently; invalid or negative
+// values are logged before falling back.
+func envDuration(key string, fallback time.Duration) time.Duration {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback
+	}
+	value, err := time.ParseDuration(raw)
+	if err != nil || value < 0 {
+		log.Printf("invalid %s %q, using %s", key, raw, fallback)
+		return fallback
+	}
+	return value
+}
+
+// envInterval reads a duration that must be positive, such as a job interval
+// or a TTL, where zero would stop a ticker or expire everything at once.
+func envInterval(key string, fallback time.Duration) time.Duration {
+	value := envDuration(key, fallback)
+	if value == 0 {
+		log.Printf("invalid %s %q, using %s", key, os.Getenv(key), fallback)
+		return fallback
+	}
+	return value
+}
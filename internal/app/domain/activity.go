@@ -0,0 +1,66 @@
+package dto
+
+import "time"
+
+type LogActivityRequest struct {
+	ActivityType    string     `json:"activity_type" validate:"required,oneof=call visit whatsapp email"`
+	Outcome         string     `json:"outcome" validate:"required,oneof=reached no_answer callback_requested interested not_interested wrong_contact"`
+	DurationMinutes *int       `json:"duration_minutes" validate:"omitempty,min=0,max=1440"`
+	NextStep        string     `json:"next_step" validate:"omitempty,max=500"`
+	Notes           string     `json:"notes" validate:"omitempty,max=2000"`
+	Latitude        *float64   `json:"latitude" validate:"omitempty,latitude,required_with=Longitude"`
+	Longitude       *float64   `json:"longitude" validate:"omitempty,longitude,required_with=Latitude"`
+	LocationName    string     `json:"location_name" validate:"omitempty,max=255"`
+	OccurredAt      *time.Time `json:"occurred_at" validate:"omitempty"`
+}
+
+// ActivityListRequest filters a marketer's activities. From and To are
+// inclusive dates in YYYY-MM-DD format.
+type ActivityListRequest struct {
+	From  string `json:"from" query:"from" validate:"omitempty,datetime=2006-01-02"`
+	To    string `json:"to" query:"to" validate:"omitempty,datetime=2006-01-02"`
+	Type  string `json:"type" query:"type" validate:"omitempty,oneof=call visit whatsapp email"`
+	Page  int    `json:"page" query:"page"`
+	Limit int    `json:"limit" query:"limit"`
+}
+
+type LeadActivityResponse struct {
+	ID                  uint64    `json:"id" gorm:"column:id"`
+	MarketingCustomerID uint      `json:"marketing_customer_id" gorm:"column:marketing_customer_id"`
+	CIF                 string    `json:"cif" gorm:"column:cif"`
+	CustomerName        string    `json:"customer_name" gorm:"column:customer_name"`
+	MarketingID         uint      `json:"marketing_id" gorm:"column:marketing_id"`
+	MarketingNIP        string    `json:"marketing_nip" gorm:"column:marketing_nip"`
+	MarketingName       string    `json:"marketing_name" gorm:"column:marketing_name"`
+	ActivityType        string    `json:"activity_type" gorm:"column:activity_type"`
+	Outcome             string    `json:"outcome" gorm:"column:outcome"`
+	DurationMinutes     *int      `json:"duration_minutes" gorm:"column:duration_minutes"`
+	NextStep            string    `json:"next_step" gorm:"column:next_step"`
+	Notes               string    `json:"notes" gorm:"column:notes"`
+	Latitude            *float64  `json:"latitude" gorm:"column:latitude"`
+	Longitude           *float64  `json:"longitude" gorm:"column:longitude"`
+	LocationName        string    `json:"location_name" gorm:"column:location_name"`
+	OccurredAt          time.Time `json:"occurred_at" gorm:"column:occurred_at"`
+	CreatedAt           time.Time `json:"created_at" gorm:"column:created_at"`
+}
+
+// ActivitySummary counts the activities of a marketer in a period. Leads is
+// the number of distinct leads worked.
+type ActivitySummary struct {
+	Total  int64            `json:"total"`
+	Leads  int64            `json:"leads"`
+	ByType map[string]int64 `json:"by_type"`
+}
+
+type LeadActivitiesResponse struct {
+	CIF        string                 `json:"cif"`
+	Activities []LeadActivityResponse `json:"activities"`
+}
+
+type MarketingActivitiesResponse struct {
+	MarketingNIP  string                 `json:"marketing_nip"`
+	MarketingName string                 `json:"marketing_name"`
+	Summary       ActivitySummary        `json:"summary"`
+	Activities    []LeadActivityResponse `json:"activities"`
+	Pagination    *Pagination            `json:"pagination"`
+}
@@ -0,0 +1,84 @@
+package dto
+
+// Outcomes of assigning one customer in a bulk assignment.
+const (
+	AssignmentAssigned        = "assigned"
+	AssignmentAlreadyAssigned = "already_assigned"
+	AssignmentNotFound        = "not_found"
+	AssignmentOutOfBranch     = "out_of_branch"
+	AssignmentFailed          = "failed"
+)
+
+// Outcomes of transferring one lead.
+const (
+	TransferTransferred   = "transferred"
+	TransferNotFound      = "not_found"
+	TransferNotOpen       = "not_open"
+	TransferSameMarketing = "same_marketing"
+	TransferOutOfBranch   = "out_of_branch"
+	TransferFailed        = "failed"
+)
+
+type BulkAssignRequest struct {
+	MarketingNIP string   `json:"marketing_nip" validate:"required"`
+	CIFs         []string `json:"cifs" validate:"required,min=1,max=500,dive,required"`
+}
+
+// FilterAssignRequest assigns up to Limit customers of the unassigned pool
+// that match the same search as GET /marketing/customers.
+type FilterAssignRequest struct {
+	MarketingNIP string `json:"marketing_nip" validate:"required"`
+	Search       string `json:"search" validate:"omitempty,max=100"`
+	SearchBy     string `json:"search_by" validate:"omitempty,oneof=cif nama nomor_hp nomor_rekening email produk_eksisting"`
+	Limit        int    `json:"limit" validate:"required,min=1,max=500"`
+}
+
+// AssignableCustomer identifies a customer selected for assignment.
+type AssignableCustomer struct {
+	ID             uint64 `gorm:"column:id"`
+	CIF            string `gorm:"column:cif"`
+	KantorCabangID *uint  `gorm:"column:kantor_cabang_id"`
+}
+
+type AssignmentResult struct {
+	CIF     string `json:"cif"`
+	Result  string `json:"result"`
+	Message string `json:"message,omitempty"`
+}
+
+type BulkAssignResponse struct {
+	MarketingNIP  string             `json:"marketing_nip"`
+	MarketingName string             `json:"marketing_name"`
+	Assigned      int                `json:"assigned"`
+	Rejected      int                `json:"rejected"`
+	Results       []AssignmentResult `json:"results"`
+}
+
+// TransferLeadsRequest moves the listed leads to another marketer.
+type TransferLeadsRequest struct {
+	ToMarketingNIP string   `json:"to_marketing_nip" validate:"required"`
+	CIFs           []string `json:"cifs" validate:"required,min=1,max=500,dive,required"`
+	Reason         string   `json:"reason" validate:"required,max=255"`
+}
+
+// TransferAllRequest moves every open lead of a marketer, e.g. one who
+// resigned or went on leave.
+type TransferAllRequest struct {
+	ToMarketingNIP string `json:"to_marketing_nip" validate:"required"`
+	Reason         string `json:"reason" validate:"required,max=255"`
+}
+
+type TransferResult struct {
+	CIF              string `json:"cif"`
+	FromMarketingNIP string `json:"from_marketing_nip,omitempty"`
+	Result           string `json:"result"`
+	Message          string `json:"message,omitempty"`
+}
+
+type TransferResponse struct {
+	ToMarketingNIP  string           `json:"to_marketing_nip"`
+	ToMarketingName string           `json:"to_marketing_name"`
+	Transferred     int              `json:"transferred"`
+	Rejected        int              `json:"rejected"`
+	Results         []TransferResult `json:"results"`
+}
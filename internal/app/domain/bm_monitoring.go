@@ -1,13 +1,17 @@
 package dto
 
 type MarketingMonitoringResponse struct {
-	MarketingNIP    string         `json:"marketing_nip" gorm:"column:marketing_nip"`
-	MarketingName   string         `json:"marketing_name" gorm:"column:marketing_name"`
-	MonthlyAchieved float64        `json:"monthly_achieved" gorm:"column:monthly_achieved"`
-	MonthlyTarget   float64        `json:"monthly_target" gorm:"column:monthly_target"`
-	Labels          []string       `json:"labels" gorm:"-"`         // Ignore in GORM as it's for ChartJS
-	Datasets        []ChartDataset `json:"datasets" gorm:"-"`       // Ignore in GORM as it's for ChartJS
-	TargetDetails   []ProductChart `json:"target_details" gorm:"-"` // Ignore in GORM as it's for ChartJS
+	MarketingNIP    string  `json:"marketing_nip" gorm:"column:marketing_nip"`
+	MarketingName   string  `json:"marketing_name" gorm:"column:marketing_name"`
+	MonthlyAchieved float64 `json:"monthly_achieved" gorm:"column:monthly_achieved"`
+	MonthlyTarget   float64 `json:"monthly_target" gorm:"column:monthly_target"`
+	// MonthlyPending is the amount of the month's closings still awaiting
+	// verification; it is not part of MonthlyAchieved.
+	MonthlyPending float64         `json:"monthly_pending" gorm:"column:monthly_pending"`
+	Labels         []string        `json:"labels" gorm:"-"`         // Ignore in GORM as it's for ChartJS
+	Datasets       []ChartDataset  `json:"datasets" gorm:"-"`       // Ignore in GORM as it's for ChartJS
+	TargetDetails  []ProductChart  `json:"target_details" gorm:"-"` // Ignore in GORM as it's for ChartJS
+	Activities     ActivitySummary `json:"activities" gorm:"-"`
 }
 
 type ChartDataset struct {
@@ -30,6 +34,12 @@ type MonitoringRequest struct {
 	HasTarget *bool  `json:"has_target,omitempty"`
 	Search    string `json:"search,omitempty"`
 	NIP       string `json:"nip,omitempty"`
+	Cursor    string `json:"cursor,omitempty" query:"cursor"`
+	Limit     int    `json:"limit,omitempty" query:"limit"`
+	Total     string `json:"total,omitempty" query:"total"`
+
+	// KantorCabangID limits the result to the marketers of the BM's branch.
+	KantorCabangID *uint `json:"-" query:"-"`
 }
 
 type MarketingTargetDetail struct {
@@ -44,6 +54,9 @@ type ProductPerformanceRequest struct {
 	StartDate string `json:"start_date" form:"start_date" validate:"required"`
 	EndDate   string `json:"end_date" form:"end_date" validate:"required"`
 	GroupBy   string `json:"group_by" form:"group_by" validate:"required,oneof=week month year"`
+
+	// KantorCabangID limits the result to the marketers of the BM's branch.
+	KantorCabangID *uint `json:"-" form:"-"`
 }
 type ProductPerformanceResponse struct {
 	Produk   string             `json:"produk"`
@@ -0,0 +1,83 @@
+package dto
+
+import "time"
+
+// AddClosingRequest books one product a customer took up on a lead. ClosedAt
+// is the business date in YYYY-MM-DD format and defaults to today.
+type AddClosingRequest struct {
+	ProductID        uint   `json:"product_id" validate:"required,exists=products.id"`
+	Amount           int64  `json:"amount" validate:"required,min=1"`
+	TenorMonths      *int   `json:"tenor_months" validate:"omitempty,min=1,max=600"`
+	ClosedAt         string `json:"closed_at" validate:"omitempty,datetime=2006-01-02"`
+	AccountReference string `json:"account_reference" validate:"omitempty,max=50"`
+	// EvidenceReference points the BM to proof of the closing, such as the
+	// application number.
+	EvidenceReference string `json:"evidence_reference" validate:"omitempty,max=100"`
+	Notes             string `json:"notes" validate:"omitempty,max=2000"`
+}
+
+type ClosingResponse struct {
+	ID                  uint64     `json:"id" gorm:"column:id"`
+	MarketingCustomerID uint       `json:"marketing_customer_id" gorm:"column:marketing_customer_id"`
+	CIF                 string     `json:"cif" gorm:"column:cif"`
+	MarketingNIP        string     `json:"marketing_nip" gorm:"column:marketing_nip"`
+	MarketingName       string     `json:"marketing_name" gorm:"column:marketing_name"`
+	ProductID           uint       `json:"product_id" gorm:"column:product_id"`
+	ProductName         string     `json:"product_name" gorm:"column:product_name"`
+	Amount              int64      `json:"amount" gorm:"column:amount"`
+	TenorMonths         *int       `json:"tenor_months" gorm:"column:tenor_months"`
+	ClosedAt            time.Time  `json:"closed_at" gorm:"column:closed_at"`
+	AccountReference    *string    `json:"account_reference" gorm:"column:account_reference"`
+	Notes               string     `json:"notes" gorm:"column:notes"`
+	VerificationStatus  string     `json:"verification_status" gorm:"column:verification_status"`
+	EvidenceReference   *string    `json:"evidence_reference" gorm:"column:evidence_reference"`
+	VerifiedByName      *string    `json:"verified_by_name" gorm:"column:verified_by_name"`
+	VerifiedAt          *time.Time `json:"verified_at" gorm:"column:verified_at"`
+	VerificationNote    string     `json:"verification_note" gorm:"column:verification_note"`
+	CreatedAt           time.Time  `json:"created_at" gorm:"column:created_at"`
+}
+
+// LeadClosingsResponse totals the verified closings of a lead; closings
+// awaiting verification are totalled separately.
+type LeadClosingsResponse struct {
+	CIF           string            `json:"cif"`
+	TotalAmount   int64             `json:"total_amount"`
+	PendingAmount int64             `json:"pending_amount"`
+	Closings      []ClosingResponse `json:"closings"`
+}
+
+type RejectClosingRequest struct {
+	Reason string `json:"reason" validate:"required,max=255"`
+}
+
+// PendingClosingsRequest filters the closings of a branch awaiting
+// verification, optionally to one marketer.
+type PendingClosingsRequest struct {
+	MarketingNIP string `json:"marketing_nip" query:"marketing_nip"`
+	Page         int    `json:"page" query:"page"`
+	Limit        int    `json:"limit" query:"limit"`
+}
+
+type PendingClosingsResponse struct {
+	PendingAmount int64             `json:"pending_amount"`
+	Closings      []ClosingResponse `json:"closings"`
+	Pagination    *Pagination       `json:"pagination"`
+}
+
+// ClosingPeriodResponse describes one month of a year. AcceptsClosings is
+// false for locked months, months not yet started and months older than the
+// backdate window.
+type ClosingPeriodResponse struct {
+	Tahun           int        `json:"tahun"`
+	Bulan           int        `json:"bulan"`
+	Locked          bool       `json:"locked"`
+	LockedAt        *time.Time `json:"locked_at"`
+	LockedBy        *uint      `json:"locked_by"`
+	AcceptsClosings bool       `json:"accepts_closings"`
+}
+
+type ClosingPeriodsResponse struct {
+	Tahun          int                     `json:"tahun"`
+	BackdateMonths int                     `json:"backdate_months"`
+	Periods        []ClosingPeriodResponse `json:"periods"`
+}
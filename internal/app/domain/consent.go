@@ -0,0 +1,68 @@
+package dto
+
+import (
+	"ml-prediction/internal/app/model"
+	"time"
+)
+
+type RecordConsentRequest struct {
+	Channel   string     `json:"channel" validate:"required,oneof=phone whatsapp email visit"`
+	Scope     string     `json:"scope" validate:"required,oneof=cross_selling marketing"`
+	Granted   *bool      `json:"granted" validate:"required"`
+	Source    string     `json:"source" validate:"required,oneof=branch_form call whatsapp email app import"`
+	ExpiresAt *time.Time `json:"expires_at" validate:"omitempty"`
+	Notes     string     `json:"notes" validate:"omitempty,max=500"`
+}
+
+// WithdrawConsentRequest records a refusal for the given channels, or for
+// every channel when none are listed (do not contact).
+type WithdrawConsentRequest struct {
+	Channels []string `json:"channels" validate:"omitempty,dive,oneof=phone whatsapp email visit"`
+	Scope    string   `json:"scope" validate:"required,oneof=cross_selling marketing"`
+	Source   string   `json:"source" validate:"required,oneof=branch_form call whatsapp email app import"`
+	Notes    string   `json:"notes" validate:"omitempty,max=500"`
+}
+
+// ConsentStatus is the effective preference of one channel, read from the
+// customer_consent_status view.
+type ConsentStatus struct {
+	CustomerID uint64     `json:"-" gorm:"column:customer_id"`
+	Scope      string     `json:"scope" gorm:"column:scope"`
+	Channel    string     `json:"channel" gorm:"column:channel"`
+	Granted    bool       `json:"granted" gorm:"column:granted"`
+	Source     string     `json:"source" gorm:"column:source"`
+	ExpiresAt  *time.Time `json:"expires_at" gorm:"column:expires_at"`
+	RecordedAt time.Time  `json:"recorded_at" gorm:"column:created_at"`
+}
+
+type CustomerConsentResponse struct {
+	CIF             string                  `json:"cif"`
+	ContactChannels []string                `json:"contact_channels"`
+	Effective       []ConsentStatus         `json:"effective"`
+	History         []model.CustomerConsent `json:"history"`
+}
+
+// ConsentFilter restricts the lead pool to customers that may be contacted.
+type ConsentFilter struct {
+	Scope           string
+	RequireExplicit bool
+}
+
+// AllowedChannels returns the channels through which a customer may be
+// contacted. A channel without an effective record is allowed only when
+// explicit consent is not required.
+func AllowedChannels(statuses []ConsentStatus, requireExplicit bool) []string {
+	effective := make(map[string]bool, len(statuses))
+	for _, status := range statuses {
+		effective[status.Channel] = status.Granted
+	}
+
+	allowed := []string{}
+	for _, channel := range model.ConsentChannels {
+		granted, recorded := effective[channel]
+		if granted || (!recorded && !requireExplicit) {
+			allowed = append(allowed, channel)
+		}
+	}
+	return allowed
+}
@@ -2,6 +2,7 @@ package dto
 
 import (
 	"ml-prediction/internal/app/model"
+	"ml-prediction/pkg/helper"
 	"time"
 
 	"github.com/lib/pq"
@@ -13,6 +14,11 @@ type CustomerSearchRequest struct {
 	Limit    int    `json:"limit" query:"limit"`
 	Search   string `json:"search" query:"search"`
 	SearchBy string `json:"search_by" query:"searchBy"`
+	Cursor   string `json:"cursor" query:"cursor"`
+	Total    string `json:"total" query:"total"`
+
+	Consent        *ConsentFilter `json:"-" query:"-"`
+	KantorCabangID *uint          `json:"-" query:"-"`
 }
 
 type AssignedCustomerRequest struct {
@@ -20,6 +26,8 @@ type AssignedCustomerRequest struct {
 	Limit  int    `json:"limit" query:"limit"`
 	Status string `json:"status" query:"status"`
 	Search string `json:"search" query:"search"`
+	Cursor string `json:"cursor" query:"cursor"`
+	Total  string `json:"total" query:"total"`
 }
 
 type CustomerProductResponse struct {
@@ -38,27 +46,30 @@ type Customer struct {
 	Id                 uint64         `gorm:"primaryKey" json:"id"`
 	Nama               string         `gorm:"type:varchar(100);not null" json:"nama"`
 	CIF                string         `gorm:"type:varchar(50);not null;unique" json:"cif"`
-	NomorRekening      string         `gorm:"type:varchar(50);not null;unique" json:"nomor_rekening"`
+	NomorRekening      string         `gorm:"type:text;not null;serializer:pii" json:"nomor_rekening"`
 	NamaPerusahaan     string         `gorm:"type:varchar(50);not null;"  json:"nama_perusahaan"`
 	ProdukEksisting    pq.StringArray `gorm:"type:varchar[]"  json:"produk_eksisting"`
 	AktivitasTransaksi string         `gorm:"type:varchar(100)"  json:"aktivitas_transaksi"`
-	NomorHp            string         `gorm:"type:varchar(20)"  json:"nomor_hp"`
+	NomorHp            string         `gorm:"type:text;serializer:pii"  json:"nomor_hp"`
 	Segmen             string         `gorm:"type:varchar(20)"  json:"segmen"`
-	Address            string         `gorm:"type:text"  json:"alamat"`
+	Address            string         `gorm:"type:text;serializer:pii"  json:"alamat"`
 	Job                string         `gorm:"type:varchar(100);column:job"  json:"pekerjaan"`
-	Email              string         `gorm:"type:varchar(50)"  json:"email"`
+	Email              string         `gorm:"type:text;serializer:pii"  json:"email"`
 	Penghasilan        int64          `gorm:"type:int"  json:"penghasilan"`
 	Umur               int            `gorm:"type:int"  json:"umur"`
 	Gender             string         `gorm:"type:varchar(10)"  json:"gender"`
 	StatusPerkawinan   bool           `gorm:"type:boolean"  json:"status_perkawinan"`
 	Payroll            bool           `gorm:"type:boolean"  json:"payroll,omitempty"`
+	Source             string         `gorm:"type:varchar(30)"  json:"source"`
+	KantorCabangID     *uint          `gorm:"column:kantor_cabang_id"  json:"kantor_cabang_id"`
 
-	Status              string    `gorm:"type:sting"  json:"status"`
-	Notes               string    `json:"catatan"`
-	MarketingCustomerID uint      `json:"marketing_customer_id,omitempty" gorm:"column:marketing_customer_id"`
-	MarketingID         uint      `json:"marketing_id,omitempty" gorm:"column:marketing_id"`
-	ClosedAmount        uint      `json:"closed_amount,omitempty" gorm:"column:closed_amount"`
-	MCCreatedAt         time.Time `json:"mc_created_at,omitempty" gorm:"column:mc_created_at"`
+	Status              string     `gorm:"type:sting"  json:"status"`
+	Notes               string     `json:"catatan"`
+	MarketingCustomerID uint       `json:"marketing_customer_id,omitempty" gorm:"column:marketing_customer_id"`
+	MarketingID         uint       `json:"marketing_id,omitempty" gorm:"column:marketing_id"`
+	ClosedAmount        uint       `json:"closed_amount,omitempty" gorm:"column:closed_amount"`
+	MCCreatedAt         time.Time  `json:"mc_created_at,omitempty" gorm:"column:mc_created_at"`
+	ReservedUntil       *time.Time `json:"reserved_until,omitempty" gorm:"column:reserved_until"`
 
 	CreatedAt time.Time                 ` json:"created_at"`
 	UpdatedAt time.Time                 ` json:"updated_at"`
@@ -67,6 +78,28 @@ type Customer struct {
 
 	ClosedProductID uint          `json:"closed_produk_id,omitempty" gorm:"column:product_id"`
 	ClosedProduk    model.Product `json:"closed_produk,omitempty" gorm:"-"`
+
+	PIIMasked       bool     `json:"pii_masked" gorm:"-"`
+	Contactable     *bool    `json:"contactable,omitempty" gorm:"-"`
+	ContactChannels []string `json:"contact_channels,omitempty" gorm:"-"`
+}
+
+// MaskPII replaces contact data with masked values for viewers who are not
+// allowed to see it in full.
+func (c *Customer) MaskPII() {
+	c.NomorRekening = helper.MaskAccount(c.NomorRekening)
+	c.NomorHp = helper.MaskPhone(c.NomorHp)
+	c.Email = helper.MaskEmail(c.Email)
+	c.Address = helper.MaskAddress(c.Address)
+	c.PIIMasked = true
+}
+
+// CustomerOwner is the active assignment of a customer and the branch of the
+// marketer holding it.
+type CustomerOwner struct {
+	CustomerID     uint64 `gorm:"column:customer_id"`
+	MarketingID    uint   `gorm:"column:marketing_id"`
+	KantorCabangID *uint  `gorm:"column:kantor_cabang_id"`
 }
 
 type Pagination struct {
@@ -75,3 +108,21 @@ type Pagination struct {
 	TotalItems  int64 `json:"total_items"`
 	TotalPages  int64 `json:"total_pages"`
 }
+
+// Total count modes accepted by cursor-paginated listings.
+const (
+	TotalNone     = ""
+	TotalExact    = "exact"
+	TotalEstimate = "estimate"
+)
+
+// CursorPagination is returned by keyset-paginated listings. TotalItems is only
+// filled when the caller asks for it, and is a planner estimate when
+// TotalIsEstimate is set.
+type CursorPagination struct {
+	NextCursor      string `json:"next_cursor,omitempty"`
+	PerPage         int    `json:"per_page"`
+	HasMore         bool   `json:"has_more"`
+	TotalItems      *int64 `json:"total_items,omitempty"`
+	TotalIsEstimate bool   `json:"total_is_estimate,omitempty"`
+}
@@ -0,0 +1,20 @@
+package dto
+
+type CreateCustomerBranchRuleRequest struct {
+	KantorCabangID uint   `json:"kantor_cabang_id" validate:"required,exists=kantor_cabang.id"`
+	MatchField     string `json:"match_field" validate:"required,oneof=company address"`
+	Pattern        string `json:"pattern" validate:"required,max=100"`
+}
+
+// SetCustomerBranchRequest moves a customer to another branch's pool.
+type SetCustomerBranchRequest struct {
+	KantorCabangID uint `json:"kantor_cabang_id" validate:"required,exists=kantor_cabang.id"`
+}
+
+// ApplyBranchRulesResponse summarises routing the customers that have no
+// branch yet. Unrouted customers matched no rule and wait for an admin.
+type ApplyBranchRulesResponse struct {
+	Checked  int `json:"checked"`
+	Routed   int `json:"routed"`
+	Unrouted int `json:"unrouted"`
+}
@@ -0,0 +1,95 @@
+package dto
+
+import (
+	"ml-prediction/internal/app/model"
+	"time"
+)
+
+type UpdateDistributionSettingsRequest struct {
+	Enabled         *bool  `json:"enabled" validate:"required"`
+	Strategy        string `json:"strategy" validate:"required,oneof=round_robin least_open_leads capacity target_gap"`
+	DefaultCapacity *int   `json:"default_capacity" validate:"omitempty,min=0"`
+}
+
+// SetDistributionCapRequest sets a marketer's cap on open leads. A null value
+// removes the override so the branch default applies again.
+type SetDistributionCapRequest struct {
+	MaxOpenLeads *int `json:"max_open_leads" validate:"omitempty,min=0"`
+}
+
+type PreviewDistributionRequest struct {
+	Limit int `json:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+type TriggerDistributionRequest struct {
+	DryRun bool `json:"dry_run"`
+}
+
+type DistributionListRequest struct {
+	Page  int `json:"page" query:"page"`
+	Limit int `json:"limit" query:"limit"`
+}
+
+// DistributionCandidate is a marketer the engine may assign leads to, with
+// the figures the strategies score on.
+type DistributionCandidate struct {
+	MarketingID    uint       `json:"marketing_id" gorm:"column:marketing_id"`
+	MarketingNIP   string     `json:"marketing_nip" gorm:"column:marketing_nip"`
+	MarketingName  string     `json:"marketing_name" gorm:"column:marketing_name"`
+	OpenLeads      int        `json:"open_leads" gorm:"column:open_leads"`
+	CapOverride    *int       `json:"cap_override" gorm:"column:cap_override"`
+	LastAssignedAt *time.Time `json:"last_assigned_at" gorm:"column:last_assigned_at"`
+}
+
+// DistributionCustomer is an unassigned customer waiting for distribution.
+type DistributionCustomer struct {
+	ID             uint64 `gorm:"column:id"`
+	CIF            string `gorm:"column:cif"`
+	KantorCabangID uint   `gorm:"column:kantor_cabang_id"`
+	TopProductID   *uint  `gorm:"column:top_product_id"`
+}
+
+// DistributionScore is one candidate's evaluation, stored in the decision
+// detail.
+type DistributionScore struct {
+	MarketingID  uint     `json:"marketing_id"`
+	MarketingNIP string   `json:"marketing_nip"`
+	OpenLeads    int      `json:"open_leads"`
+	Capacity     *int     `json:"capacity"`
+	Eligible     bool     `json:"eligible"`
+	Score        *float64 `json:"score,omitempty"`
+}
+
+type DistributionDecisionDetail struct {
+	Reason     string              `json:"reason"`
+	Candidates []DistributionScore `json:"candidates"`
+}
+
+type DistributionMarketer struct {
+	DistributionCandidate
+	Capacity *int `json:"capacity"`
+}
+
+type DistributionSettingsResponse struct {
+	Setting    model.LeadDistributionSetting `json:"setting"`
+	Marketers  []DistributionMarketer        `json:"marketers"`
+	Strategies []string                      `json:"strategies"`
+}
+
+type DistributionDecisionResponse struct {
+	ID                  uint64    `json:"id" gorm:"column:id"`
+	RunID               uint64    `json:"run_id" gorm:"column:run_id"`
+	DryRun              bool      `json:"dry_run" gorm:"column:dry_run"`
+	CustomerID          uint64    `json:"customer_id" gorm:"column:customer_id"`
+	CIF                 string    `json:"cif" gorm:"column:cif"`
+	KantorCabangID      *uint     `json:"kantor_cabang_id" gorm:"column:kantor_cabang_id"`
+	MarketingID         *uint     `json:"marketing_id" gorm:"column:marketing_id"`
+	MarketingNIP        *string   `json:"marketing_nip" gorm:"column:marketing_nip"`
+	MarketingName       *string   `json:"marketing_name" gorm:"column:marketing_name"`
+	MarketingCustomerID *uint     `json:"marketing_customer_id" gorm:"column:marketing_customer_id"`
+	Strategy            string    `json:"strategy" gorm:"column:strategy"`
+	Outcome             string    `json:"outcome" gorm:"column:outcome"`
+	ProductID           *uint     `json:"product_id" gorm:"column:product_id"`
+	Detail              string    `json:"detail" gorm:"column:detail"`
+	CreatedAt           time.Time `json:"created_at" gorm:"column:created_at"`
+}
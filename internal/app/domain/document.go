@@ -0,0 +1,28 @@
+package dto
+
+import "time"
+
+// UploadDocumentRequest carries the form fields sent along with the file.
+type UploadDocumentRequest struct {
+	DocumentType string `json:"document_type" form:"document_type" validate:"required,oneof=ktp slip_gaji signed_application other"`
+}
+
+type LeadDocumentResponse struct {
+	ID                  uint64    `json:"id" gorm:"column:id"`
+	MarketingCustomerID uint      `json:"marketing_customer_id" gorm:"column:marketing_customer_id"`
+	CIF                 string    `json:"cif" gorm:"column:cif"`
+	DocumentType        string    `json:"document_type" gorm:"column:document_type"`
+	FileName            string    `json:"file_name" gorm:"column:file_name"`
+	ContentType         string    `json:"content_type" gorm:"column:content_type"`
+	SizeBytes           int64     `json:"size_bytes" gorm:"column:size_bytes"`
+	ChecksumSHA256      string    `json:"checksum_sha256" gorm:"column:checksum_sha256"`
+	UploadedByNIP       string    `json:"uploaded_by_nip" gorm:"column:uploaded_by_nip"`
+	UploadedByName      string    `json:"uploaded_by_name" gorm:"column:uploaded_by_name"`
+	CreatedAt           time.Time `json:"created_at" gorm:"column:created_at"`
+	DownloadURL         string    `json:"download_url" gorm:"-"`
+}
+
+type LeadDocumentsResponse struct {
+	CIF       string                 `json:"cif"`
+	Documents []LeadDocumentResponse `json:"documents"`
+}
@@ -0,0 +1,30 @@
+package dto
+
+type DuplicateListRequest struct {
+	Page   int    `json:"page" query:"page"`
+	Limit  int    `json:"limit" query:"limit"`
+	Status string `json:"status" query:"status" validate:"omitempty,oneof=pending merged dismissed"`
+}
+
+type MergeDuplicateRequest struct {
+	SurvivorID uint64 `json:"survivor_id" validate:"required"`
+}
+
+// DuplicateScanRow holds the fields the duplicate detector compares. Contact
+// data is compared through the blind indexes, which are already normalised.
+type DuplicateScanRow struct {
+	ID                uint64  `gorm:"column:id"`
+	Nama              string  `gorm:"column:nama"`
+	NamaPerusahaan    string  `gorm:"column:nama_perusahaan"`
+	Umur              int     `gorm:"column:umur"`
+	NomorHpBidx       *string `gorm:"column:nomor_hp_bidx"`
+	EmailBidx         *string `gorm:"column:email_bidx"`
+	NomorRekeningBidx *string `gorm:"column:nomor_rekening_bidx"`
+}
+
+type MergeResult struct {
+	SurvivorID       uint64 `json:"survivor_id"`
+	MergedID         uint64 `json:"merged_id"`
+	MovedProducts    int64  `json:"moved_products"`
+	MovedAssignments int64  `json:"moved_assignments"`
+}
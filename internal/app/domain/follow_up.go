@@ -0,0 +1,44 @@
+package dto
+
+import "time"
+
+type ScheduleFollowUpRequest struct {
+	NextFollowUpAt *time.Time `json:"next_follow_up_at" validate:"required"`
+	Reason         string     `json:"reason" validate:"required,max=255"`
+}
+
+// FollowUpAgendaRequest sets how many days ahead the agenda looks for
+// upcoming follow-ups.
+type FollowUpAgendaRequest struct {
+	Days int `json:"days" query:"days" validate:"omitempty,min=1,max=90"`
+}
+
+type FollowUpItem struct {
+	MarketingCustomerID uint       `json:"marketing_customer_id" gorm:"column:marketing_customer_id"`
+	CIF                 string     `json:"cif" gorm:"column:cif"`
+	CustomerName        string     `json:"customer_name" gorm:"column:customer_name"`
+	Status              string     `json:"status" gorm:"column:status"`
+	MarketingNIP        string     `json:"marketing_nip" gorm:"column:marketing_nip"`
+	MarketingName       string     `json:"marketing_name" gorm:"column:marketing_name"`
+	NextFollowUpAt      time.Time  `json:"next_follow_up_at" gorm:"column:next_follow_up_at"`
+	Reason              string     `json:"reason" gorm:"column:reason"`
+	OverdueAt           *time.Time `json:"overdue_at" gorm:"column:overdue_at"`
+}
+
+type FollowUpAgendaResponse struct {
+	Overdue         []FollowUpItem `json:"overdue"`
+	Today           []FollowUpItem `json:"today"`
+	Upcoming        []FollowUpItem `json:"upcoming"`
+	UnreadReminders int64          `json:"unread_reminders"`
+}
+
+type FollowUpReminderResponse struct {
+	ID                  uint64     `json:"id" gorm:"column:id"`
+	MarketingCustomerID uint       `json:"marketing_customer_id" gorm:"column:marketing_customer_id"`
+	CIF                 string     `json:"cif" gorm:"column:cif"`
+	CustomerName        string     `json:"customer_name" gorm:"column:customer_name"`
+	DueAt               time.Time  `json:"due_at" gorm:"column:due_at"`
+	Reason              string     `json:"reason" gorm:"column:reason"`
+	ReadAt              *time.Time `json:"read_at" gorm:"column:read_at"`
+	CreatedAt           time.Time  `json:"created_at" gorm:"column:created_at"`
+}
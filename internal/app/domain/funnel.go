@@ -0,0 +1,68 @@
+package dto
+
+import "time"
+
+// FunnelReportRequest selects the leads claimed in a period. Admins may
+// narrow the report to one branch; BMs always see their own.
+type FunnelReportRequest struct {
+	From           string `json:"from" query:"from" validate:"omitempty,datetime=2006-01-02"`
+	To             string `json:"to" query:"to" validate:"omitempty,datetime=2006-01-02"`
+	KantorCabangID uint   `json:"kantor_cabang_id" query:"kantor_cabang_id"`
+}
+
+// FunnelFilter is what the repository needs to compute a funnel. Leads in
+// FinalStates are never stale; open leads untouched since StaleBefore are.
+type FunnelFilter struct {
+	From           time.Time
+	To             time.Time
+	StaleBefore    time.Time
+	FinalStates    []string
+	KantorCabangID *uint
+}
+
+// FunnelCounts is the funnel of one combination of the report dimensions as
+// computed by the repository. Durations are in hours.
+type FunnelCounts struct {
+	Key                  string   `gorm:"column:key"`
+	Label                string   `gorm:"column:label"`
+	Claimed              int      `gorm:"column:claimed"`
+	Contacted            int      `gorm:"column:contacted"`
+	Closed               int      `gorm:"column:closed"`
+	Rejected             int      `gorm:"column:rejected"`
+	Stale                int      `gorm:"column:stale"`
+	MedianHoursToContact *float64 `gorm:"column:median_hours_to_contact"`
+	MedianHoursToClose   *float64 `gorm:"column:median_hours_to_close"`
+}
+
+// FunnelStage is one row of the report: stage counts, the conversion rate of
+// each step in percent and the median time spent between stages.
+type FunnelStage struct {
+	Key                  string   `json:"key"`
+	Label                string   `json:"label"`
+	Claimed              int      `json:"claimed"`
+	Contacted            int      `json:"contacted"`
+	Closed               int      `json:"closed"`
+	Rejected             int      `json:"rejected"`
+	Stale                int      `json:"stale"`
+	ContactRate          float64  `json:"contact_rate"`
+	CloseRate            float64  `json:"close_rate"`
+	RejectRate           float64  `json:"reject_rate"`
+	MedianHoursToContact *float64 `json:"median_hours_to_contact"`
+	MedianHoursToClose   *float64 `json:"median_hours_to_close"`
+}
+
+// FunnelReportResponse starts at the customer pool of the report's scope:
+// Pool counts the customers that entered it in the period and ClaimRate the
+// share of them claimed.
+type FunnelReportResponse struct {
+	From        time.Time     `json:"from"`
+	To          time.Time     `json:"to"`
+	StaleAfter  string        `json:"stale_after"`
+	Pool        int           `json:"pool"`
+	ClaimRate   float64       `json:"claim_rate"`
+	Total       FunnelStage   `json:"total"`
+	ByBranch    []FunnelStage `json:"by_branch"`
+	ByMarketing []FunnelStage `json:"by_marketing"`
+	ByProduct   []FunnelStage `json:"by_product"`
+	ByMonth     []FunnelStage `json:"by_month"`
+}
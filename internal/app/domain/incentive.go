@@ -0,0 +1,114 @@
+package dto
+
+import "time"
+
+type IncentiveTierRequest struct {
+	MinAttainment float64 `json:"min_attainment" validate:"gte=0,lte=1000"`
+	FixedAmount   int64   `json:"fixed_amount" validate:"gte=0"`
+	RatePercent   float64 `json:"rate_percent" validate:"gte=0,lte=100"`
+}
+
+// CreateIncentiveSchemeRequest defines a scheme for a product. Months are
+// written as "2006-01"; EffectiveTo is the last month included and may be
+// left empty for an open-ended scheme.
+type CreateIncentiveSchemeRequest struct {
+	ProductID     uint                   `json:"product_id" validate:"required,exists=products.id"`
+	Name          string                 `json:"name" validate:"required,max=100"`
+	EffectiveFrom string                 `json:"effective_from" validate:"required,datetime=2006-01"`
+	EffectiveTo   string                 `json:"effective_to" validate:"omitempty,datetime=2006-01"`
+	PerClosingFee int64                  `json:"per_closing_fee" validate:"gte=0"`
+	CapAmount     *int64                 `json:"cap_amount" validate:"omitempty,gt=0"`
+	Tiers         []IncentiveTierRequest `json:"tiers" validate:"dive"`
+}
+
+// IncentivePeriodRequest names the month to calculate or export.
+type IncentivePeriodRequest struct {
+	Tahun int `json:"tahun" query:"tahun" validate:"required,min=2000,max=2100"`
+	Bulan int `json:"bulan" query:"bulan" validate:"required,min=1,max=12"`
+}
+
+// IncentiveStatementsRequest filters statements. Admins may narrow the list
+// to one branch; BMs always see their own and marketers their own
+// statements.
+type IncentiveStatementsRequest struct {
+	Tahun          int    `json:"tahun" query:"tahun"`
+	Bulan          int    `json:"bulan" query:"bulan"`
+	Status         string `json:"status" query:"status" validate:"omitempty,oneof=pending_approval approved rejected"`
+	KantorCabangID uint   `json:"kantor_cabang_id" query:"kantor_cabang_id"`
+	Page           int    `json:"page" query:"page"`
+	Limit          int    `json:"limit" query:"limit"`
+}
+
+// IncentiveStatementFilter is the resolved scope of a statement listing.
+type IncentiveStatementFilter struct {
+	Tahun          int
+	Bulan          int
+	Status         string
+	KantorCabangID *uint
+	MarketingID    *uint
+}
+
+type RejectIncentiveRequest struct {
+	Reason string `json:"reason" validate:"required,max=255"`
+}
+
+type IncentiveStatementResponse struct {
+	ID             uint64     `json:"id" gorm:"column:id"`
+	MarketingNIP   string     `json:"marketing_nip" gorm:"column:marketing_nip"`
+	MarketingName  string     `json:"marketing_name" gorm:"column:marketing_name"`
+	KantorCabangID *uint      `json:"kantor_cabang_id" gorm:"column:kantor_cabang_id"`
+	BranchName     *string    `json:"branch_name" gorm:"column:branch_name"`
+	Tahun          int        `json:"tahun" gorm:"column:tahun"`
+	Bulan          int        `json:"bulan" gorm:"column:bulan"`
+	TotalAmount    int64      `json:"total_amount" gorm:"column:total_amount"`
+	Status         string     `json:"status" gorm:"column:status"`
+	CalculatedAt   time.Time  `json:"calculated_at" gorm:"column:calculated_at"`
+	ReviewedByNIP  *string    `json:"reviewed_by_nip" gorm:"column:reviewed_by_nip"`
+	ReviewedAt     *time.Time `json:"reviewed_at" gorm:"column:reviewed_at"`
+	ReviewNote     *string    `json:"review_note" gorm:"column:review_note"`
+}
+
+// IncentiveStatementDetailResponse is a statement with the lines that
+// justify it.
+type IncentiveStatementDetailResponse struct {
+	IncentiveStatementResponse
+	Lines []IncentiveStatementLineResponse `json:"lines"`
+}
+
+type IncentiveStatementLineResponse struct {
+	ID             uint64  `json:"id" gorm:"column:id"`
+	LineType       string  `json:"line_type" gorm:"column:line_type"`
+	SchemeID       *uint   `json:"scheme_id" gorm:"column:scheme_id"`
+	ProductID      *uint   `json:"product_id" gorm:"column:product_id"`
+	ProductName    *string `json:"product_name" gorm:"column:product_name"`
+	Description    string  `json:"description" gorm:"column:description"`
+	ClosingID      *uint64 `json:"closing_id" gorm:"column:closing_id"`
+	TargetAmount   int64   `json:"target_amount" gorm:"column:target_amount"`
+	AchievedAmount int64   `json:"achieved_amount" gorm:"column:achieved_amount"`
+	Attainment     float64 `json:"attainment" gorm:"column:attainment"`
+	Amount         int64   `json:"amount" gorm:"column:amount"`
+}
+
+type IncentiveStatementsResponse struct {
+	TotalAmount int64                        `json:"total_amount"`
+	Statements  []IncentiveStatementResponse `json:"statements"`
+	Pagination  *Pagination                  `json:"pagination"`
+}
+
+// IncentiveCalculationResponse summarises a calculation run. Approved
+// statements are never recalculated and are counted as Skipped.
+type IncentiveCalculationResponse struct {
+	Tahun       int   `json:"tahun"`
+	Bulan       int   `json:"bulan"`
+	Calculated  int   `json:"calculated"`
+	Skipped     int   `json:"skipped"`
+	TotalAmount int64 `json:"total_amount"`
+}
+
+// IncentiveTarget is a marketer's target on a product in a month.
+type IncentiveTarget struct {
+	MarketingID    uint  `gorm:"column:marketing_id"`
+	KantorCabangID *uint `gorm:"column:kantor_cabang_id"`
+	ProductID      uint  `gorm:"column:product_id"`
+	Target         int64 `gorm:"column:target"`
+}
@@ -0,0 +1,253 @@
+package dto
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+)
+
+// Fields a transition can require from the status update request.
+const (
+	LeadFieldProductID = "product_id"
+	LeadFieldAmount    = "amount"
+	LeadFieldNotes     = "notes"
+	LeadFieldChannel   = "channel"
+
+	LeadFieldRejectionReason = "rejection_reason"
+)
+
+// Transition error codes returned to clients.
+const (
+	TransitionUnknownState   = "unknown_state"
+	TransitionNotAllowed     = "transition_not_allowed"
+	TransitionRoleNotAllowed = "role_not_allowed"
+	TransitionMissingFields  = "missing_fields"
+	TransitionCooldown       = "cooldown_active"
+)
+
+type LeadState struct {
+	Name  string `json:"name"`
+	Label string `json:"label"`
+	// Final states end the lead. Only transitions marked Reopen leave them.
+	Final bool `json:"final"`
+}
+
+type LeadTransition struct {
+	From     []string `json:"from"`
+	To       string   `json:"to"`
+	Roles    []string `json:"roles"`
+	Required []string `json:"required"`
+	// Reopen transitions leave a final state and are only possible once the
+	// lead has rested for the machine's reopen cooldown.
+	Reopen bool `json:"reopen"`
+}
+
+// LeadStateMachine holds the lead states and the transitions allowed between
+// them. It is loaded once at start-up and is safe for concurrent use.
+type LeadStateMachine struct {
+	States         []LeadState      `json:"states"`
+	Transitions    []LeadTransition `json:"transitions"`
+	ReopenCooldown time.Duration    `json:"-"`
+}
+
+// LeadTransitionInput describes a requested status change.
+type LeadTransitionInput struct {
+	From            string
+	To              string
+	Role            string
+	Provided        map[string]bool
+	StatusChangedAt time.Time
+	Now             time.Time
+}
+
+// TransitionError explains why a status change was refused. It is returned to
+// clients as-is so they can show the allowed next states.
+type TransitionError struct {
+	Code        string     `json:"code"`
+	Message     string     `json:"message"`
+	From        string     `json:"from"`
+	To          string     `json:"to"`
+	Role        string     `json:"role"`
+	AllowedNext []string   `json:"allowed_next"`
+	Missing     []string   `json:"missing_fields,omitempty"`
+	AvailableAt *time.Time `json:"available_at,omitempty"`
+}
+
+func (e *TransitionError) Error() string {
+	return e.Message
+}
+
+// DefaultLeadStateMachine is the lifecycle used when no configuration file is
+// provided.
+func DefaultLeadStateMachine(reopenCooldown time.Duration) *LeadStateMachine {
+	open := []string{"new", "contacted", "follow_up", "interested", "documents_pending"}
+	return &LeadStateMachine{
+		States: []LeadState{
+			{Name: "new", Label: "Baru"},
+			{Name: "contacted", Label: "Sudah Dihubungi"},
+			{Name: "follow_up", Label: "Tindak Lanjut"},
+			{Name: "interested", Label: "Tertarik"},
+			{Name: "documents_pending", Label: "Menunggu Dokumen"},
+			{Name: "closed", Label: "Closing", Final: true},
+			{Name: "rejected", Label: "Ditolak", Final: true},
+		},
+		Transitions: []LeadTransition{
+			{From: []string{"new"}, To: "contacted", Roles: []string{"marketing"}, Required: []string{LeadFieldChannel}},
+			{From: []string{"contacted", "follow_up"}, To: "follow_up", Roles: []string{"marketing"}, Required: []string{LeadFieldNotes}},
+			{From: []string{"contacted", "follow_up"}, To: "interested", Roles: []string{"marketing"}},
+			{From: []string{"interested"}, To: "documents_pending", Roles: []string{"marketing"}},
+			{From: []string{"contacted", "follow_up", "interested", "documents_pending"}, To: "closed", Roles: []string{"marketing"}, Required: []string{LeadFieldProductID, LeadFieldAmount}},
+			{From: open, To: "rejected", Roles: []string{"marketing"}, Required: []string{LeadFieldRejectionReason}},
+			{From: []string{"rejected"}, To: "follow_up", Roles: []string{"bm"}, Required: []string{LeadFieldNotes}, Reopen: true},
+		},
+		ReopenCooldown: reopenCooldown,
+	}
+}
+
+// LoadLeadStateMachine reads the machine from a JSON file, or returns the
+// default when path is empty.
+func LoadLeadStateMachine(path string, reopenCooldown time.Duration) (*LeadStateMachine, error) {
+	if path == "" {
+		return DefaultLeadStateMachine(reopenCooldown), nil
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca konfigurasi status lead: %v", err)
+	}
+	machine := &LeadStateMachine{ReopenCooldown: reopenCooldown}
+	if err := json.Unmarshal(raw, machine); err != nil {
+		return nil, fmt.Errorf("format konfigurasi status lead tidak valid: %v", err)
+	}
+	if err := machine.check(); err != nil {
+		return nil, err
+	}
+	return machine, nil
+}
+
+// check rejects configurations that reference undeclared states.
+func (m *LeadStateMachine) check() error {
+	if _, ok := m.State("new"); !ok {
+		return fmt.Errorf("status lead 'new' wajib ada")
+	}
+	for _, t := range m.Transitions {
+		if _, ok := m.State(t.To); !ok {
+			return fmt.Errorf("transisi ke status tidak dikenal: %s", t.To)
+		}
+		for _, from := range t.From {
+			if _, ok := m.State(from); !ok {
+				return fmt.Errorf("transisi dari status tidak dikenal: %s", from)
+			}
+		}
+	}
+	return nil
+}
+
+func (m *LeadStateMachine) State(name string) (LeadState, bool) {
+	for _, state := range m.States {
+		if state.Name == name {
+			return state, true
+		}
+	}
+	return LeadState{}, false
+}
+
+// IsFinal reports whether the state ends the lead.
+func (m *LeadStateMachine) IsFinal(name string) bool {
+	state, ok := m.State(name)
+	return ok && state.Final
+}
+
+// FinalStates returns the names of the states that end a lead.
+func (m *LeadStateMachine) FinalStates() []string {
+	var names []string
+	for _, state := range m.States {
+		if state.Final {
+			names = append(names, state.Name)
+		}
+	}
+	return names
+}
+
+// AllowedNext lists the states the role may move a lead to from the given
+// state.
+func (m *LeadStateMachine) AllowedNext(from, role string) []string {
+	next := []string{}
+	for _, t := range m.Transitions {
+		if slices.Contains(t.From, from) && slices.Contains(t.Roles, role) && !slices.Contains(next, t.To) {
+			next = append(next, t.To)
+		}
+	}
+	return next
+}
+
+// Validate checks a status change against the machine and returns nil when
+// it may proceed.
+func (m *LeadStateMachine) Validate(in LeadTransitionInput) *TransitionError {
+	fail := func(code, message string) *TransitionError {
+		return &TransitionError{
+			Code:        code,
+			Message:     message,
+			From:        in.From,
+			To:          in.To,
+			Role:        in.Role,
+			AllowedNext: m.AllowedNext(in.From, in.Role),
+		}
+	}
+
+	if _, ok := m.State(in.To); !ok {
+		return fail(TransitionUnknownState, fmt.Sprintf("status %s tidak dikenal", in.To))
+	}
+
+	var matched []LeadTransition
+	for _, t := range m.Transitions {
+		if t.To == in.To && slices.Contains(t.From, in.From) {
+			matched = append(matched, t)
+		}
+	}
+	if len(matched) == 0 {
+		return fail(TransitionNotAllowed, fmt.Sprintf("status tidak dapat diubah dari %s ke %s", in.From, in.To))
+	}
+
+	var transition *LeadTransition
+	for i := range matched {
+		if slices.Contains(matched[i].Roles, in.Role) {
+			transition = &matched[i]
+			break
+		}
+	}
+	if transition == nil {
+		return fail(TransitionRoleNotAllowed, fmt.Sprintf("role %s tidak dapat mengubah status dari %s ke %s", in.Role, in.From, in.To))
+	}
+
+	var missing []string
+	for _, field := range transition.Required {
+		if !in.Provided[field] {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		err := fail(TransitionMissingFields, fmt.Sprintf("field wajib untuk status %s belum diisi", in.To))
+		err.Missing = missing
+		return err
+	}
+
+	if transition.Reopen {
+		availableAt := in.StatusChangedAt.Add(m.ReopenCooldown)
+		if in.Now.Before(availableAt) {
+			err := fail(TransitionCooldown, fmt.Sprintf("lead baru dapat dibuka kembali setelah %s", availableAt.Format("2006-01-02 15:04")))
+			err.AvailableAt = &availableAt
+			return err
+		}
+	}
+	return nil
+}
+
+type LeadStatesResponse struct {
+	States         []LeadState         `json:"states"`
+	Transitions    []LeadTransition    `json:"transitions"`
+	AllowedNext    map[string][]string `json:"allowed_next"`
+	ReopenCooldown string              `json:"reopen_cooldown"`
+}
@@ -0,0 +1,113 @@
+package dto
+
+import "time"
+
+// Leaderboard scopes, periods and ranking metrics.
+const (
+	LeaderboardScopeBranch   = "branch"
+	LeaderboardScopeNational = "national"
+
+	LeaderboardPeriodMonth   = "month"
+	LeaderboardPeriodQuarter = "quarter"
+	LeaderboardPeriodYear    = "year"
+
+	LeaderboardMetricAchievement = "achievement"
+	LeaderboardMetricAmount      = "amount"
+	LeaderboardMetricClosings    = "closings"
+	LeaderboardMetricConversion  = "conversion"
+)
+
+// LeaderboardRequest selects the ranking. Empty fields default to the branch
+// scope, the current month and the achievement percentage. Admins pick the
+// branch of a branch-scoped board with KantorCabangID.
+type LeaderboardRequest struct {
+	Scope          string `json:"scope" query:"scope" validate:"omitempty,oneof=branch national"`
+	Period         string `json:"period" query:"period" validate:"omitempty,oneof=month quarter year"`
+	Tahun          int    `json:"tahun" query:"tahun" validate:"omitempty,min=2000,max=2100"`
+	Bulan          int    `json:"bulan" query:"bulan" validate:"omitempty,min=1,max=12"`
+	Kuartal        int    `json:"kuartal" query:"kuartal" validate:"omitempty,min=1,max=4"`
+	Metric         string `json:"metric" query:"metric" validate:"omitempty,oneof=achievement amount closings conversion"`
+	KantorCabangID uint   `json:"kantor_cabang_id" query:"kantor_cabang_id"`
+	Limit          int    `json:"limit" query:"limit" validate:"omitempty,min=1,max=500"`
+}
+
+// LeaderboardStanding is a marketer's raw result for a period. Conversion
+// counts the leads the marketer decided in the period: ClosedLeads of
+// DecidedLeads ended closed rather than rejected.
+type LeaderboardStanding struct {
+	MarketingID    uint    `gorm:"column:marketing_id"`
+	MarketingNIP   string  `gorm:"column:marketing_nip"`
+	MarketingName  string  `gorm:"column:marketing_name"`
+	KantorCabangID *uint   `gorm:"column:kantor_cabang_id"`
+	BranchName     *string `gorm:"column:branch_name"`
+	Target         int64   `gorm:"column:target"`
+	Achieved       int64   `gorm:"column:achieved"`
+	Closings       int     `gorm:"column:closings"`
+	ClosedLeads    int     `gorm:"column:closed_leads"`
+	DecidedLeads   int     `gorm:"column:decided_leads"`
+}
+
+// MonthlyResult is a marketer's target and verified achievement in one
+// month.
+type MonthlyResult struct {
+	MarketingID uint  `gorm:"column:marketing_id"`
+	Tahun       int   `gorm:"column:tahun"`
+	Bulan       int   `gorm:"column:bulan"`
+	Target      int64 `gorm:"column:target"`
+	Achieved    int64 `gorm:"column:achieved"`
+	Closings    int   `gorm:"column:closings"`
+}
+
+// LeaderboardEntry is one ranked marketer. Depending on the configured
+// visibility the NIP and name of other marketers may be masked.
+type LeaderboardEntry struct {
+	Rank           int     `json:"rank"`
+	IsMe           bool    `json:"is_me"`
+	MarketingNIP   string  `json:"marketing_nip,omitempty"`
+	MarketingName  string  `json:"marketing_name"`
+	BranchName     string  `json:"branch_name"`
+	Target         int64   `json:"target"`
+	Achieved       int64   `json:"achieved"`
+	Percentage     float64 `json:"percentage"`
+	Closings       int     `json:"closings"`
+	ConversionRate float64 `json:"conversion_rate"`
+	CurrentStreak  int     `json:"current_streak"`
+}
+
+type LeaderboardResponse struct {
+	Scope      string             `json:"scope"`
+	BranchName string             `json:"branch_name,omitempty"`
+	Period     string             `json:"period"`
+	Label      string             `json:"label"`
+	From       time.Time          `json:"from"`
+	To         time.Time          `json:"to"`
+	Metric     string             `json:"metric"`
+	Entries    []LeaderboardEntry `json:"entries"`
+	// Me is the caller's own entry, also when it falls outside the limit.
+	Me *LeaderboardEntry `json:"me,omitempty"`
+}
+
+type Badge struct {
+	Code  string `json:"code"`
+	Label string `json:"label"`
+}
+
+type AchievementMonth struct {
+	Tahun      int     `json:"tahun"`
+	Bulan      int     `json:"bulan"`
+	Target     int64   `json:"target"`
+	Achieved   int64   `json:"achieved"`
+	Percentage float64 `json:"percentage"`
+	HitTarget  bool    `json:"hit_target"`
+}
+
+// MarketingAchievementsResponse shows a marketer's streaks of consecutive
+// months over target, the badges earned and the last twelve months.
+type MarketingAchievementsResponse struct {
+	MarketingNIP  string             `json:"marketing_nip"`
+	MarketingName string             `json:"marketing_name"`
+	CurrentStreak int                `json:"current_streak"`
+	LongestStreak int                `json:"longest_streak"`
+	Badges        []Badge            `json:"badges"`
+	History       []AchievementMonth `json:"history"`
+}
@@ -1,9 +1,74 @@
 package dto
 
+import (
+	"fmt"
+	"time"
+)
+
+// UpdateCustomerStatusRequest moves a lead to another state. Which of the
+// optional fields are required depends on the transition, see
+// LeadStateMachine.
 type UpdateCustomerStatusRequest struct {
 	CIF       string  `json:"cif" validate:"required,exists=customers.cif"`
-	Status    string  `json:"status" validate:"required,oneof=contacted rejected closed"`
-	ProductID *uint   `json:"product_id" validate:"required_if=Status closed"`
-	Amount    *int64  `json:"amount" validate:"required_if=Status closed,omitempty,min=1"`
+	Status    string  `json:"status" validate:"required,max=20"`
+	ProductID *uint   `json:"product_id" validate:"omitempty"`
+	Amount    *int64  `json:"amount" validate:"omitempty,min=1"`
 	Notes     *string `json:"notes" validate:"omitempty"`
+	Channel   string  `json:"channel" validate:"omitempty,oneof=phone whatsapp email visit"`
+	// RejectionReason is the code of an active rejection reason.
+	RejectionReason string `json:"rejection_reason" validate:"omitempty,max=50"`
+	// ClosedAt is the business date of the closing in YYYY-MM-DD format when
+	// the lead is closed. It defaults to today.
+	ClosedAt string `json:"closed_at" validate:"omitempty,datetime=2006-01-02"`
+	// EvidenceReference is attached to the closing for the BM to verify.
+	EvidenceReference string `json:"evidence_reference" validate:"omitempty,max=100"`
+}
+
+// LeadEventScope limits lead history to the leads of one marketer or to the
+// leads handled by the marketers of one branch.
+type LeadEventScope struct {
+	MarketingID    *uint
+	KantorCabangID *uint
+}
+
+type LeadEventResponse struct {
+	ID                  uint64    `json:"id" gorm:"column:id"`
+	MarketingCustomerID uint      `json:"marketing_customer_id" gorm:"column:marketing_customer_id"`
+	MarketingID         uint      `json:"marketing_id" gorm:"column:marketing_id"`
+	MarketingName       string    `json:"marketing_name" gorm:"column:marketing_name"`
+	EventType           string    `json:"event_type" gorm:"column:event_type"`
+	OldStatus           *string   `json:"old_status" gorm:"column:old_status"`
+	NewStatus           string    `json:"new_status" gorm:"column:new_status"`
+	ActorID             *uint     `json:"actor_id" gorm:"column:actor_id"`
+	ActorName           *string   `json:"actor_name" gorm:"column:actor_name"`
+	ActorNIP            *string   `json:"actor_nip" gorm:"column:actor_nip"`
+	ActorRole           *string   `json:"actor_role" gorm:"column:actor_role"`
+	Note                string    `json:"note" gorm:"column:note"`
+	ProductID           *uint     `json:"product_id" gorm:"column:product_id"`
+	ProductName         *string   `json:"product_name" gorm:"column:product_name"`
+	Amount              *int64    `json:"amount" gorm:"column:amount"`
+	Channel             *string   `json:"channel" gorm:"column:channel"`
+	RejectionReason     *string   `json:"rejection_reason" gorm:"column:rejection_reason"`
+	RejectionLabel      *string   `json:"rejection_label" gorm:"column:rejection_label"`
+	CreatedAt           time.Time `json:"created_at" gorm:"column:created_at"`
+}
+
+type LeadHistoryResponse struct {
+	CIF    string              `json:"cif"`
+	Events []LeadEventResponse `json:"events"`
+}
+
+// LeadHeldError is returned when a customer is already reserved by a
+// marketer. It names the holder so the caller knows whom to contact.
+type LeadHeldError struct {
+	CustomerID    uint64     `json:"customer_id"`
+	HolderID      uint       `json:"holder_id"`
+	HolderNIP     string     `json:"holder_nip"`
+	HolderName    string     `json:"holder_name"`
+	Status        string     `json:"status"`
+	ReservedUntil *time.Time `json:"reserved_until"`
+}
+
+func (e *LeadHeldError) Error() string {
+	return fmt.Sprintf("customer sedang dipegang oleh %s (%s)", e.HolderName, e.HolderNIP)
 }
@@ -17,6 +17,11 @@ type PredictionRequest struct {
 	Segmen             string   `json:"category_segmen" validate:"required"`
 	ProdukEksisting    []string `json:"existing_product" validate:"required"`
 	AktivitasTransaksi string   `json:"transaction_activity" validate:"required,oneof=Active Inactive"`
+
+	// KantorCabangID places the customer in a branch's pool. It is only
+	// honoured for admins and system callers; it is left out of the model
+	// input.
+	KantorCabangID *uint `json:"kantor_cabang_id,omitempty" validate:"omitempty,exists=kantor_cabang.id"`
 }
 
 type PredictionResult struct {
@@ -0,0 +1,59 @@
+package dto
+
+import "time"
+
+type CreateRejectionReasonRequest struct {
+	Code      string `json:"code" validate:"required,max=50"`
+	Label     string `json:"label" validate:"required,max=100"`
+	SortOrder int    `json:"sort_order"`
+}
+
+type UpdateRejectionReasonRequest struct {
+	Label     *string `json:"label" validate:"omitempty,min=1,max=100"`
+	Active    *bool   `json:"active"`
+	SortOrder *int    `json:"sort_order"`
+}
+
+// RejectionReportRequest selects the rejections recorded in a period. Admins
+// may narrow the report to one branch; BMs always see their own.
+type RejectionReportRequest struct {
+	From           string `json:"from" query:"from" validate:"omitempty,datetime=2006-01-02"`
+	To             string `json:"to" query:"to" validate:"omitempty,datetime=2006-01-02"`
+	KantorCabangID uint   `json:"kantor_cabang_id" query:"kantor_cabang_id"`
+}
+
+// RejectionCount is the number of rejections for one combination of the
+// report dimensions. The product is the one the marketer named on rejection,
+// or else the customer's top recommendation; PredictedRank is its position
+// in the customer's recommendations.
+type RejectionCount struct {
+	ReasonCode    string  `gorm:"column:reason_code"`
+	ReasonLabel   string  `gorm:"column:reason_label"`
+	ProductID     *uint   `gorm:"column:product_id"`
+	ProductName   *string `gorm:"column:product_name"`
+	Segment       string  `gorm:"column:segment"`
+	MarketingNIP  string  `gorm:"column:marketing_nip"`
+	MarketingName string  `gorm:"column:marketing_name"`
+	PredictedRank *int    `gorm:"column:predicted_rank"`
+	Total         int     `gorm:"column:total"`
+}
+
+// RejectionBreakdown is one row of a report dimension with its rejections
+// split by reason code.
+type RejectionBreakdown struct {
+	Key     string         `json:"key"`
+	Label   string         `json:"label"`
+	Total   int            `json:"total"`
+	Reasons map[string]int `json:"reasons,omitempty"`
+}
+
+type RejectionReportResponse struct {
+	From            time.Time            `json:"from"`
+	To              time.Time            `json:"to"`
+	Total           int                  `json:"total"`
+	ByReason        []RejectionBreakdown `json:"by_reason"`
+	ByProduct       []RejectionBreakdown `json:"by_product"`
+	BySegment       []RejectionBreakdown `json:"by_segment"`
+	ByMarketing     []RejectionBreakdown `json:"by_marketing"`
+	ByPredictedRank []RejectionBreakdown `json:"by_predicted_rank"`
+}
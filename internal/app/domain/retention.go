@@ -0,0 +1,23 @@
+package dto
+
+import "time"
+
+type RetentionRunListRequest struct {
+	Page  int `json:"page" query:"page"`
+	Limit int `json:"limit" query:"limit"`
+}
+
+type TriggerRetentionRequest struct {
+	DryRun bool `json:"dry_run"`
+}
+
+// RetentionPolicy is the snapshot of the configured policies stored with each
+// run, so a report can be read without knowing the configuration at the time.
+type RetentionPolicy struct {
+	AnonymizeRejectedMonths  int        `json:"anonymize_rejected_months"`
+	AnonymizeProspectsMonths int        `json:"anonymize_prospects_months"`
+	PurgeDeletedDays         int        `json:"purge_deleted_days"`
+	RejectedCutoff           *time.Time `json:"rejected_cutoff,omitempty"`
+	ProspectsCutoff          *time.Time `json:"prospects_cutoff,omitempty"`
+	DeletedCutoff            *time.Time `json:"deleted_cutoff,omitempty"`
+}
@@ -68,6 +68,8 @@ type TargetSummaryResponse struct {
 	// Total achievement amount across all products
 	// @example 7550000000
 	Achieved float64 `json:"achieved"`
+	// Amount of closings awaiting verification, not yet part of Achieved
+	Pending float64 `json:"pending"`
 	// Overall branch target achievement percentage
 	// @example 75.5
 	Percentage float64 `json:"percentage"`
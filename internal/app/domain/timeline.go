@@ -0,0 +1,65 @@
+package dto
+
+import "time"
+
+// Timeline entry types, in the order they usually occur in a lead's life.
+const (
+	TimelineCustomerCreated = "customer_created"
+	TimelinePredictionRun   = "prediction_run"
+	TimelineAssigned        = "assigned"
+	TimelineReassigned      = "reassigned"
+	TimelineStatusChanged   = "status_changed"
+	TimelineClosed          = "closed"
+	TimelineClosingVoided   = "closing_voided"
+	TimelineUnassigned      = "unassigned"
+	TimelineProfileUpdated  = "profile_updated"
+)
+
+type TimelineActor struct {
+	ID   uint   `json:"id"`
+	Nama string `json:"nama"`
+	NIP  string `json:"nip"`
+	Role string `json:"role,omitempty"`
+}
+
+type TimelineEntry struct {
+	Type       string                 `json:"type"`
+	OccurredAt time.Time              `json:"occurred_at"`
+	Actor      *TimelineActor         `json:"actor,omitempty"`
+	Note       string                 `json:"note,omitempty"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+}
+
+type CustomerTimelineResponse struct {
+	CustomerID uint64          `json:"customer_id"`
+	CIF        string          `json:"cif"`
+	Nama       string          `json:"nama"`
+	Source     string          `json:"source"`
+	Entries    []TimelineEntry `json:"entries"`
+}
+
+// TimelineAssignment is one marketing_customers row, including soft-deleted
+// ones, joined with the marketer.
+type TimelineAssignment struct {
+	ID             uint       `gorm:"column:id"`
+	MarketingID    uint       `gorm:"column:marketing_id"`
+	MarketingName  string     `gorm:"column:marketing_name"`
+	MarketingNIP   string     `gorm:"column:marketing_nip"`
+	KantorCabangID *uint      `gorm:"column:kantor_cabang_id"`
+	AssignedByID   *uint      `gorm:"column:assigned_by_id"`
+	AssignedByName *string    `gorm:"column:assigned_by_name"`
+	AssignedByNIP  *string    `gorm:"column:assigned_by_nip"`
+	AssignedByRole *string    `gorm:"column:assigned_by_role"`
+	CreatedAt      time.Time  `gorm:"column:created_at"`
+	DeletedAt      *time.Time `gorm:"column:deleted_at"`
+}
+
+// TimelineRecommendation is a product currently recommended to the customer.
+// It stands in for the prediction run of customers created before runs were
+// recorded.
+type TimelineRecommendation struct {
+	ProductID   uint      `gorm:"column:product_id"`
+	ProductName string    `gorm:"column:nama"`
+	Order       int       `gorm:"column:order"`
+	CreatedAt   time.Time `gorm:"column:created_at"`
+}
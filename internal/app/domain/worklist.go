@@ -0,0 +1,75 @@
+package dto
+
+import (
+	"ml-prediction/internal/app/model"
+	"time"
+)
+
+type WorklistRequest struct {
+	Limit int `json:"limit" query:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+type UpdateWorklistSettingsRequest struct {
+	WeightScore     *int `json:"weight_score" validate:"required,min=0,max=100"`
+	WeightPlafond   *int `json:"weight_plafond" validate:"required,min=0,max=100"`
+	WeightFollowUp  *int `json:"weight_follow_up" validate:"required,min=0,max=100"`
+	WeightTargetGap *int `json:"weight_target_gap" validate:"required,min=0,max=100"`
+}
+
+// WorklistCandidate is a customer the marketer could work on today: one of
+// the marketer's open leads or an unassigned customer of the branch pool.
+type WorklistCandidate struct {
+	CustomerID          uint64     `gorm:"column:customer_id"`
+	CIF                 string     `gorm:"column:cif"`
+	Nama                string     `gorm:"column:nama"`
+	MarketingCustomerID *uint      `gorm:"column:marketing_customer_id"`
+	Status              *string    `gorm:"column:status"`
+	ProductID           *uint      `gorm:"column:product_id"`
+	ProductName         *string    `gorm:"column:product_name"`
+	Score               *float64   `gorm:"column:score"`
+	PlafonMax           *uint64    `gorm:"column:plafon_max"`
+	NextFollowUpAt      *time.Time `gorm:"column:next_follow_up_at"`
+	OverdueAt           *time.Time `gorm:"column:overdue_at"`
+}
+
+// WorklistTarget is the marketer's target and verified closings of a product
+// in the current month.
+type WorklistTarget struct {
+	ProductID uint    `gorm:"column:product_id"`
+	Target    float64 `gorm:"column:target"`
+	Achieved  float64 `gorm:"column:achieved"`
+}
+
+// WorklistFactor is how much one factor contributed to an item's rank.
+// Value is the factor normalised to 0..1; Points is its weighted share of
+// the item's total score.
+type WorklistFactor struct {
+	Factor string  `json:"factor"`
+	Value  float64 `json:"value"`
+	Weight int     `json:"weight"`
+	Points float64 `json:"points"`
+}
+
+type WorklistItem struct {
+	Rank                int              `json:"rank"`
+	Score               float64          `json:"score"`
+	Source              string           `json:"source"`
+	CIF                 string           `json:"cif"`
+	Nama                string           `json:"nama"`
+	MarketingCustomerID *uint            `json:"marketing_customer_id"`
+	Status              *string          `json:"status"`
+	ProductID           *uint            `json:"product_id"`
+	ProductName         *string          `json:"product_name"`
+	RecommendationScore *float64         `json:"recommendation_score"`
+	PlafonMax           *uint64          `json:"plafon_max"`
+	NextFollowUpAt      *time.Time       `json:"next_follow_up_at"`
+	TargetGap           *float64         `json:"target_gap"`
+	Factors             []WorklistFactor `json:"factors"`
+	Reasons             []string         `json:"reasons"`
+}
+
+type WorklistResponse struct {
+	GeneratedAt time.Time             `json:"generated_at"`
+	Setting     model.WorklistSetting `json:"setting"`
+	Items       []WorklistItem        `json:"items"`
+}
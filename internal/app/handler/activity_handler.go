@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"ml-prediction/config"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/usecase"
+	"ml-prediction/pkg/helper"
+	"ml-prediction/pkg/response"
+	"ml-prediction/pkg/validation"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ActivityHandler struct {
+	activityUsecase usecase.ActivityUsecase
+	cfg             config.Configuration
+	val             *validator.Validate
+}
+
+func NewActivityHandler(activityUsecase usecase.ActivityUsecase, cfg config.Configuration, val *validator.Validate) *ActivityHandler {
+	return &ActivityHandler{activityUsecase, cfg, val}
+}
+
+func (h *ActivityHandler) Log(c *fiber.Ctx) error {
+	var req dto.LogActivityRequest
+	if err := c.BodyParser(&req); err != nil {
+		errors := helper.MapUnmarshalErrors(err)
+		return response.ErrorValidation(c, fiber.StatusBadRequest, "Format JSON tidak valid", errors)
+	}
+	if err := h.val.Struct(&req); err != nil {
+		if errs, ok := err.(validator.ValidationErrors); ok {
+			errors := validation.MapValidationErrors(errs, &req)
+			return response.ErrorValidation(c, fiber.StatusBadRequest, "Kesalahan Validasi", errors)
+		}
+		return response.Error(c, fiber.StatusBadRequest, "Kesalahan Validasi", err.Error())
+	}
+
+	activity, err := h.activityUsecase.Log(c.Context(), c.Locals("nip").(string), c.Params("cif"), &req)
+	if err != nil {
+		return response.Error(c, clientErrorStatus(err), "Gagal mencatat aktivitas", err.Error())
+	}
+	return response.SuccessCreated(c, "Aktivitas berhasil dicatat", activity)
+}
+
+func (h *ActivityHandler) GetLeadActivities(c *fiber.Ctx) error {
+	activities, err := h.activityUsecase.GetLeadActivities(c.Context(), c.Locals("nip").(string), c.Params("cif"))
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal mengambil aktivitas lead", err.Error())
+	}
+	return response.Success(c, "Aktivitas lead berhasil diambil", activities)
+}
+
+func (h *ActivityHandler) GetMarketingActivities(c *fiber.Ctx) error {
+	var req dto.ActivityListRequest
+	if err := c.QueryParser(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Format request tidak valid", err.Error())
+	}
+	if err := h.val.Struct(&req); err != nil {
+		if errs, ok := err.(validator.ValidationErrors); ok {
+			errors := validation.MapValidationErrors(errs, &req)
+			return response.ErrorValidation(c, fiber.StatusBadRequest, "Kesalahan Validasi", errors)
+		}
+		return response.Error(c, fiber.StatusBadRequest, "Kesalahan Validasi", err.Error())
+	}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Limit < 1 || req.Limit > 100 {
+		req.Limit = 10
+	}
+
+	activities, err := h.activityUsecase.GetMarketingActivities(c.Context(), c.Locals("nip").(string), c.Params("nip"), &req)
+	if err != nil {
+		return response.Error(c, clientErrorStatus(err), "Gagal mengambil aktivitas marketing", err.Error())
+	}
+	return response.Success(c, "Aktivitas marketing berhasil diambil", activities)
+}
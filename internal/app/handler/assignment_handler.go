@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"ml-prediction/config"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/usecase"
+	"ml-prediction/pkg/helper"
+	"ml-prediction/pkg/response"
+	"ml-prediction/pkg/validation"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type AssignmentHandler struct {
+	assignmentUsecase usecase.AssignmentUsecase
+	cfg               config.Configuration
+	val               *validator.Validate
+}
+
+func NewAssignmentHandler(assignmentUsecase usecase.AssignmentUsecase, cfg config.Configuration, val *validator.Validate) *AssignmentHandler {
+	return &AssignmentHandler{assignmentUsecase, cfg, val}
+}
+
+func (h *AssignmentHandler) AssignSelected(c *fiber.Ctx) error {
+	var req dto.BulkAssignRequest
+	if err := c.BodyParser(&req); err != nil {
+		errors := helper.MapUnmarshalErrors(err)
+		return response.ErrorValidation(c, fiber.StatusBadRequest, "Format JSON tidak valid", errors)
+	}
+	if err := h.val.Struct(&req); err != nil {
+		if errs, ok := err.(validator.ValidationErrors); ok {
+			errors := validation.MapValidationErrors(errs, &req)
+			return response.ErrorValidation(c, fiber.StatusBadRequest, "Kesalahan Validasi", errors)
+		}
+		return response.Error(c, fiber.StatusBadRequest, "Kesalahan Validasi", err.Error())
+	}
+
+	result, err := h.assignmentUsecase.AssignSelected(c.Context(), c.Locals("nip").(string), &req)
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal mengassign customer", err.Error())
+	}
+	return response.Success(c, "Assignment customer selesai diproses", result)
+}
+
+func (h *AssignmentHandler) AssignFiltered(c *fiber.Ctx) error {
+	var req dto.FilterAssignRequest
+	if err := c.BodyParser(&req); err != nil {
+		errors := helper.MapUnmarshalErrors(err)
+		return response.ErrorValidation(c, fiber.StatusBadRequest, "Format JSON tidak valid", errors)
+	}
+	if err := h.val.Struct(&req); err != nil {
+		if errs, ok := err.(validator.ValidationErrors); ok {
+			errors := validation.MapValidationErrors(errs, &req)
+			return response.ErrorValidation(c, fiber.StatusBadRequest, "Kesalahan Validasi", errors)
+		}
+		return response.Error(c, fiber.StatusBadRequest, "Kesalahan Validasi", err.Error())
+	}
+
+	result, err := h.assignmentUsecase.AssignFiltered(c.Context(), c.Locals("nip").(string), &req)
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal mengassign customer", err.Error())
+	}
+	return response.Success(c, "Assignment customer selesai diproses", result)
+}
+
+func (h *AssignmentHandler) Transfer(c *fiber.Ctx) error {
+	var req dto.TransferLeadsRequest
+	if err := c.BodyParser(&req); err != nil {
+		errors := helper.MapUnmarshalErrors(err)
+		return response.ErrorValidation(c, fiber.StatusBadRequest, "Format JSON tidak valid", errors)
+	}
+	if err := h.val.Struct(&req); err != nil {
+		if errs, ok := err.(validator.ValidationErrors); ok {
+			errors := validation.MapValidationErrors(errs, &req)
+			return response.ErrorValidation(c, fiber.StatusBadRequest, "Kesalahan Validasi", errors)
+		}
+		return response.Error(c, fiber.StatusBadRequest, "Kesalahan Validasi", err.Error())
+	}
+
+	result, err := h.assignmentUsecase.Transfer(c.Context(), c.Locals("nip").(string), &req)
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal memindahkan lead", err.Error())
+	}
+	return response.Success(c, "Transfer lead selesai diproses", result)
+}
+
+func (h *AssignmentHandler) TransferAll(c *fiber.Ctx) error {
+	var req dto.TransferAllRequest
+	if err := c.BodyParser(&req); err != nil {
+		errors := helper.MapUnmarshalErrors(err)
+		return response.ErrorValidation(c, fiber.StatusBadRequest, "Format JSON tidak valid", errors)
+	}
+	if err := h.val.Struct(&req); err != nil {
+		if errs, ok := err.(validator.ValidationErrors); ok {
+			errors := validation.MapValidationErrors(errs, &req)
+			return response.ErrorValidation(c, fiber.StatusBadRequest, "Kesalahan Validasi", errors)
+		}
+		return response.Error(c, fiber.StatusBadRequest, "Kesalahan Validasi", err.Error())
+	}
+
+	result, err := h.assignmentUsecase.TransferAll(c.Context(), c.Locals("nip").(string), c.Params("nip"), &req)
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal memindahkan lead", err.Error())
+	}
+	return response.Success(c, "Transfer lead selesai diproses", result)
+}
@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"fmt"
+	"ml-prediction/config"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/usecase"
+	"ml-prediction/pkg/helper"
+	"ml-prediction/pkg/response"
+	"ml-prediction/pkg/validation"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ClosingHandler struct {
+	closingUsecase usecase.ClosingUsecase
+	cfg            config.Configuration
+	val            *validator.Validate
+}
+
+func NewClosingHandler(closingUsecase usecase.ClosingUsecase, cfg config.Configuration, val *validator.Validate) *ClosingHandler {
+	return &ClosingHandler{closingUsecase, cfg, val}
+}
+
+func (h *ClosingHandler) Add(c *fiber.Ctx) error {
+	var req dto.AddClosingRequest
+	if err := c.BodyParser(&req); err != nil {
+		errors := helper.MapUnmarshalErrors(err)
+		return response.ErrorValidation(c, fiber.StatusBadRequest, "Format JSON tidak valid", errors)
+	}
+	if err := h.val.Struct(&req); err != nil {
+		if errs, ok := err.(validator.ValidationErrors); ok {
+			errors := validation.MapValidationErrors(errs, &req)
+			return response.ErrorValidation(c, fiber.StatusBadRequest, "Kesalahan Validasi", errors)
+		}
+		return response.Error(c, fiber.StatusBadRequest, "Kesalahan Validasi", err.Error())
+	}
+
+	closing, err := h.closingUsecase.Add(c.Context(), c.Locals("nip").(string), c.Params("cif"), &req)
+	if err != nil {
+		return response.Error(c, clientErrorStatus(err), "Gagal mencatat closing", err.Error())
+	}
+	return response.SuccessCreated(c, "Closing berhasil dicatat", closing)
+}
+
+func (h *ClosingHandler) Void(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "ID tidak valid", "ID closing harus berupa angka")
+	}
+
+	if err := h.closingUsecase.Void(c.Context(), c.Locals("nip").(string), c.Params("cif"), id); err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal membatalkan closing", err.Error())
+	}
+	return response.Success(c, "Closing berhasil dibatalkan", nil)
+}
+
+func (h *ClosingHandler) GetLeadClosings(c *fiber.Ctx) error {
+	closings, err := h.closingUsecase.GetLeadClosings(c.Context(), c.Locals("nip").(string), c.Params("cif"))
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal mengambil closing lead", err.Error())
+	}
+	return response.Success(c, "Closing lead berhasil diambil", closings)
+}
+
+func (h *ClosingHandler) GetPeriods(c *fiber.Ctx) error {
+	year := c.QueryInt("tahun", 0)
+	periods, err := h.closingUsecase.GetPeriods(c.Context(), year)
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal mengambil periode closing", err.Error())
+	}
+	return response.Success(c, "Periode closing berhasil diambil", periods)
+}
+
+func (h *ClosingHandler) LockPeriod(c *fiber.Ctx) error {
+	year, month, err := closingPeriodParams(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Periode tidak valid", err.Error())
+	}
+
+	period, err := h.closingUsecase.LockPeriod(c.Context(), c.Locals("nip").(string), year, month)
+	if err != nil {
+		return response.Error(c, clientErrorStatus(err), "Gagal mengunci periode", err.Error())
+	}
+	return response.SuccessCreated(c, "Periode berhasil dikunci", period)
+}
+
+func (h *ClosingHandler) UnlockPeriod(c *fiber.Ctx) error {
+	year, month, err := closingPeriodParams(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Periode tidak valid", err.Error())
+	}
+
+	if err := h.closingUsecase.UnlockPeriod(c.Context(), year, month); err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal membuka periode", err.Error())
+	}
+	return response.Success(c, "Periode berhasil dibuka kembali", nil)
+}
+
+func closingPeriodParams(c *fiber.Ctx) (int, int, error) {
+	year, err := strconv.Atoi(c.Params("tahun"))
+	if err != nil || year < 2000 {
+		return 0, 0, fmt.Errorf("tahun harus berupa angka")
+	}
+	month, err := strconv.Atoi(c.Params("bulan"))
+	if err != nil || month < 1 || month > 12 {
+		return 0, 0, fmt.Errorf("bulan harus antara 1 dan 12")
+	}
+	return year, month, nil
+}
+
+func (h *ClosingHandler) GetPending(c *fiber.Ctx) error {
+	var req dto.PendingClosingsRequest
+	if err := c.QueryParser(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Format request tidak valid", err.Error())
+	}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Limit < 1 || req.Limit > 100 {
+		req.Limit = 10
+	}
+
+	closings, err := h.closingUsecase.GetPending(c.Context(), c.Locals("nip").(string), &req)
+	if err != nil {
+		return response.Error(c, clientErrorStatus(err), "Gagal mengambil closing yang menunggu verifikasi", err.Error())
+	}
+	return response.Success(c, "Closing yang menunggu verifikasi berhasil diambil", closings)
+}
+
+func (h *ClosingHandler) Verify(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "ID tidak valid", "ID closing harus berupa angka")
+	}
+
+	closing, err := h.closingUsecase.Verify(c.Context(), c.Locals("nip").(string), id)
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal memverifikasi closing", err.Error())
+	}
+	return response.Success(c, "Closing berhasil diverifikasi", closing)
+}
+
+func (h *ClosingHandler) Reject(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "ID tidak valid", "ID closing harus berupa angka")
+	}
+
+	var req dto.RejectClosingRequest
+	if err := c.BodyParser(&req); err != nil {
+		errors := helper.MapUnmarshalErrors(err)
+		return response.ErrorValidation(c, fiber.StatusBadRequest, "Format JSON tidak valid", errors)
+	}
+	if err := h.val.Struct(&req); err != nil {
+		if errs, ok := err.(validator.ValidationErrors); ok {
+			errors := validation.MapValidationErrors(errs, &req)
+			return response.ErrorValidation(c, fiber.StatusBadRequest, "Kesalahan Validasi", errors)
+		}
+		return response.Error(c, fiber.StatusBadRequest, "Kesalahan Validasi", err.Error())
+	}
+
+	closing, err := h.closingUsecase.Reject(c.Context(), c.Locals("nip").(string), id, &req)
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal menolak closing", err.Error())
+	}
+	return response.Success(c, "Closing berhasil ditolak", closing)
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"ml-prediction/config"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/usecase"
+	"ml-prediction/pkg/helper"
+	"ml-prediction/pkg/response"
+	"ml-prediction/pkg/validation"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ConsentHandler struct {
+	consentUsecase usecase.ConsentUsecase
+	cfg            config.Configuration
+	val            *validator.Validate
+}
+
+func NewConsentHandler(consentUsecase usecase.ConsentUsecase, cfg config.Configuration, val *validator.Validate) *ConsentHandler {
+	return &ConsentHandler{consentUsecase, cfg, val}
+}
+
+func (h *ConsentHandler) GetConsents(c *fiber.Ctx) error {
+	consents, err := h.consentUsecase.GetConsents(c.Context(), c.Locals("nip").(string), c.Params("cif"))
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal mengambil persetujuan customer", err.Error())
+	}
+	return response.Success(c, "Persetujuan customer berhasil diambil", consents)
+}
+
+func (h *ConsentHandler) Record(c *fiber.Ctx) error {
+	var req dto.RecordConsentRequest
+	if err := c.BodyParser(&req); err != nil {
+		errors := helper.MapUnmarshalErrors(err)
+		return response.ErrorValidation(c, fiber.StatusBadRequest, "Format JSON tidak valid", errors)
+	}
+	if err := h.val.Struct(&req); err != nil {
+		if errs, ok := err.(validator.ValidationErrors); ok {
+			errors := validation.MapValidationErrors(errs, &req)
+			return response.ErrorValidation(c, fiber.StatusBadRequest, "Kesalahan Validasi", errors)
+		}
+		return response.Error(c, fiber.StatusBadRequest, "Kesalahan Validasi", err.Error())
+	}
+
+	consents, err := h.consentUsecase.Record(c.Context(), c.Locals("nip").(string), c.Params("cif"), &req)
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal menyimpan persetujuan customer", err.Error())
+	}
+	return response.SuccessCreated(c, "Persetujuan customer berhasil disimpan", consents)
+}
+
+func (h *ConsentHandler) Withdraw(c *fiber.Ctx) error {
+	var req dto.WithdrawConsentRequest
+	if err := c.BodyParser(&req); err != nil {
+		errors := helper.MapUnmarshalErrors(err)
+		return response.ErrorValidation(c, fiber.StatusBadRequest, "Format JSON tidak valid", errors)
+	}
+	if err := h.val.Struct(&req); err != nil {
+		if errs, ok := err.(validator.ValidationErrors); ok {
+			errors := validation.MapValidationErrors(errs, &req)
+			return response.ErrorValidation(c, fiber.StatusBadRequest, "Kesalahan Validasi", errors)
+		}
+		return response.Error(c, fiber.StatusBadRequest, "Kesalahan Validasi", err.Error())
+	}
+
+	consents, err := h.consentUsecase.Withdraw(c.Context(), c.Locals("nip").(string), c.Params("cif"), &req)
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal mencabut persetujuan customer", err.Error())
+	}
+	return response.Success(c, "Persetujuan customer berhasil dicabut", consents)
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"ml-prediction/config"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/usecase"
+	"ml-prediction/pkg/helper"
+	"ml-prediction/pkg/response"
+	"ml-prediction/pkg/validation"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type CustomerBranchHandler struct {
+	customerBranchUsecase usecase.CustomerBranchUsecase
+	cfg                   config.Configuration
+	val                   *validator.Validate
+}
+
+func NewCustomerBranchHandler(customerBranchUsecase usecase.CustomerBranchUsecase, cfg config.Configuration, val *validator.Validate) *CustomerBranchHandler {
+	return &CustomerBranchHandler{customerBranchUsecase, cfg, val}
+}
+
+func (h *CustomerBranchHandler) CreateRule(c *fiber.Ctx) error {
+	var req dto.CreateCustomerBranchRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		errors := helper.MapUnmarshalErrors(err)
+		return response.ErrorValidation(c, fiber.StatusBadRequest, "Format JSON tidak valid", errors)
+	}
+	if err := h.val.Struct(&req); err != nil {
+		if errs, ok := err.(validator.ValidationErrors); ok {
+			errors := validation.MapValidationErrors(errs, &req)
+			return response.ErrorValidation(c, fiber.StatusBadRequest, "Kesalahan Validasi", errors)
+		}
+		return response.Error(c, fiber.StatusBadRequest, "Kesalahan Validasi", err.Error())
+	}
+
+	rule, err := h.customerBranchUsecase.CreateRule(c.Context(), c.Locals("nip").(string), &req)
+	if err != nil {
+		return response.Error(c, clientErrorStatus(err), "Gagal membuat aturan kantor cabang", err.Error())
+	}
+	return response.SuccessCreated(c, "Aturan kantor cabang berhasil dibuat", rule)
+}
+
+func (h *CustomerBranchHandler) GetRules(c *fiber.Ctx) error {
+	rules, err := h.customerBranchUsecase.GetRules(c.Context())
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal mengambil aturan kantor cabang", err.Error())
+	}
+	return response.Success(c, "Aturan kantor cabang berhasil diambil", rules)
+}
+
+func (h *CustomerBranchHandler) DeleteRule(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "ID tidak valid", "ID aturan harus berupa angka")
+	}
+
+	if err := h.customerBranchUsecase.DeleteRule(c.Context(), uint(id)); err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal menghapus aturan kantor cabang", err.Error())
+	}
+	return response.Success(c, "Aturan kantor cabang berhasil dihapus", nil)
+}
+
+func (h *CustomerBranchHandler) ApplyRules(c *fiber.Ctx) error {
+	result, err := h.customerBranchUsecase.ApplyRules(c.Context())
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal menerapkan aturan kantor cabang", err.Error())
+	}
+	return response.Success(c, "Aturan kantor cabang berhasil diterapkan", result)
+}
+
+func (h *CustomerBranchHandler) SetBranch(c *fiber.Ctx) error {
+	var req dto.SetCustomerBranchRequest
+	if err := c.BodyParser(&req); err != nil {
+		errors := helper.MapUnmarshalErrors(err)
+		return response.ErrorValidation(c, fiber.StatusBadRequest, "Format JSON tidak valid", errors)
+	}
+	if err := h.val.Struct(&req); err != nil {
+		if errs, ok := err.(validator.ValidationErrors); ok {
+			errors := validation.MapValidationErrors(errs, &req)
+			return response.ErrorValidation(c, fiber.StatusBadRequest, "Kesalahan Validasi", errors)
+		}
+		return response.Error(c, fiber.StatusBadRequest, "Kesalahan Validasi", err.Error())
+	}
+
+	if err := h.customerBranchUsecase.SetBranch(c.Context(), c.Params("cif"), &req); err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal memindahkan customer", err.Error())
+	}
+	return response.Success(c, "Customer berhasil dipindahkan ke kantor cabang", nil)
+}
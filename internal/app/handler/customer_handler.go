@@ -58,9 +58,30 @@ func (h *CustomerHandler) GetNewCustomers(c *fiber.Ctx) error {
 		Limit:    limit,
 		Search:   search,
 		SearchBy: searchBy,
+		Cursor:   c.Query("cursor"),
+		Total:    c.Query("total"),
 	}
 	NIP := c.Locals("nip").(string)
 
+	if useCursorPagination(c) {
+		if !validTotalMode(req.Total) {
+			return response.Error(c, fiber.StatusBadRequest, "Parameter tidak valid", "total harus salah satu dari [exact estimate]")
+		}
+		customers, meta, err := h.CustomerUsecase.GetNewCustomersCursor(c.Context(), NIP, &req)
+		if err != nil {
+			return c.Status(usecaseErrorStatus(err)).JSON(fiber.Map{
+				"success": false,
+				"message": "Failed to get customers: " + err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"success": true,
+			"message": "Customers retrieved successfully",
+			"data":    customers,
+			"meta":    meta,
+		})
+	}
+
 	customers, meta, err := h.CustomerUsecase.GetNewCustomers(c.Context(), NIP, &req)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -89,9 +110,30 @@ func (h *CustomerHandler) GetAssignedCustomers(c *fiber.Ctx) error {
 		Limit:  limit,
 		Status: status,
 		Search: search,
+		Cursor: c.Query("cursor"),
+		Total:  c.Query("total"),
 	}
 	NIP := c.Locals("nip").(string)
 
+	if useCursorPagination(c) {
+		if !validTotalMode(req.Total) {
+			return response.Error(c, fiber.StatusBadRequest, "Parameter tidak valid", "total harus salah satu dari [exact estimate]")
+		}
+		customers, meta, err := h.CustomerUsecase.GetAssignedCustomersCursor(c.Context(), NIP, &req)
+		if err != nil {
+			return c.Status(usecaseErrorStatus(err)).JSON(fiber.Map{
+				"success": false,
+				"message": "Failed to get assigned customers: " + err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"success": true,
+			"message": "Assigned customers retrieved successfully",
+			"data":    customers,
+			"meta":    meta,
+		})
+	}
+
 	customers, meta, err := h.CustomerUsecase.GetAssignedCustomers(c.Context(), NIP, &req)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -128,3 +170,14 @@ func (h *CustomerHandler) GetCustomerDetail(c *fiber.Ctx) error {
 		"data":    customer,
 	})
 }
+
+// useCursorPagination reports whether the client opted into keyset pagination,
+// either explicitly or by sending a cursor from a previous page. Offset
+// pagination stays the default for existing clients.
+func useCursorPagination(c *fiber.Ctx) bool {
+	return c.Query("pagination") == "cursor" || c.Query("cursor") != ""
+}
+
+func validTotalMode(mode string) bool {
+	return mode == dto.TotalNone || mode == dto.TotalExact || mode == dto.TotalEstimate
+}
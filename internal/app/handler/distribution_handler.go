@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"ml-prediction/config"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/usecase"
+	"ml-prediction/pkg/helper"
+	"ml-prediction/pkg/response"
+	"ml-prediction/pkg/validation"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type DistributionHandler struct {
+	distributionUsecase usecase.DistributionUsecase
+	cfg                 config.Configuration
+	val                 *validator.Validate
+}
+
+func NewDistributionHandler(distributionUsecase usecase.DistributionUsecase, cfg config.Configuration, val *validator.Validate) *DistributionHandler {
+	return &DistributionHandler{distributionUsecase, cfg, val}
+}
+
+func (h *DistributionHandler) GetSettings(c *fiber.Ctx) error {
+	settings, err := h.distributionUsecase.GetSettings(c.Context(), c.Locals("nip").(string))
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal mengambil pengaturan distribusi", err.Error())
+	}
+	return response.Success(c, "Pengaturan distribusi berhasil diambil", settings)
+}
+
+func (h *DistributionHandler) UpdateSettings(c *fiber.Ctx) error {
+	var req dto.UpdateDistributionSettingsRequest
+	if err := c.BodyParser(&req); err != nil {
+		errors := helper.MapUnmarshalErrors(err)
+		return response.ErrorValidation(c, fiber.StatusBadRequest, "Format JSON tidak valid", errors)
+	}
+	if err := h.val.Struct(&req); err != nil {
+		if errs, ok := err.(validator.ValidationErrors); ok {
+			errors := validation.MapValidationErrors(errs, &req)
+			return response.ErrorValidation(c, fiber.StatusBadRequest, "Kesalahan Validasi", errors)
+		}
+		return response.Error(c, fiber.StatusBadRequest, "Kesalahan Validasi", err.Error())
+	}
+
+	setting, err := h.distributionUsecase.UpdateSettings(c.Context(), c.Locals("nip").(string), &req)
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal menyimpan pengaturan distribusi", err.Error())
+	}
+	return response.Success(c, "Pengaturan distribusi berhasil disimpan", setting)
+}
+
+func (h *DistributionHandler) SetCap(c *fiber.Ctx) error {
+	var req dto.SetDistributionCapRequest
+	if err := c.BodyParser(&req); err != nil {
+		errors := helper.MapUnmarshalErrors(err)
+		return response.ErrorValidation(c, fiber.StatusBadRequest, "Format JSON tidak valid", errors)
+	}
+	if err := h.val.Struct(&req); err != nil {
+		if errs, ok := err.(validator.ValidationErrors); ok {
+			errors := validation.MapValidationErrors(errs, &req)
+			return response.ErrorValidation(c, fiber.StatusBadRequest, "Kesalahan Validasi", errors)
+		}
+		return response.Error(c, fiber.StatusBadRequest, "Kesalahan Validasi", err.Error())
+	}
+
+	if err := h.distributionUsecase.SetCap(c.Context(), c.Locals("nip").(string), c.Params("nip"), &req); err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal menyimpan batas lead", err.Error())
+	}
+	return response.Success(c, "Batas lead berhasil disimpan", nil)
+}
+
+func (h *DistributionHandler) Preview(c *fiber.Ctx) error {
+	var req dto.PreviewDistributionRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			errors := helper.MapUnmarshalErrors(err)
+			return response.ErrorValidation(c, fiber.StatusBadRequest, "Format JSON tidak valid", errors)
+		}
+	}
+	if err := h.val.Struct(&req); err != nil {
+		if errs, ok := err.(validator.ValidationErrors); ok {
+			errors := validation.MapValidationErrors(errs, &req)
+			return response.ErrorValidation(c, fiber.StatusBadRequest, "Kesalahan Validasi", errors)
+		}
+		return response.Error(c, fiber.StatusBadRequest, "Kesalahan Validasi", err.Error())
+	}
+
+	run, err := h.distributionUsecase.Preview(c.Context(), c.Locals("nip").(string), req.Limit)
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal membuat simulasi distribusi", err.Error())
+	}
+	return response.Success(c, "Simulasi distribusi berhasil dibuat", run)
+}
+
+func (h *DistributionHandler) GetDecisions(c *fiber.Ctx) error {
+	req := distributionListRequest(c)
+	decisions, pagination, err := h.distributionUsecase.GetDecisions(c.Context(), c.Locals("nip").(string), &req)
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal mengambil log distribusi", err.Error())
+	}
+	return response.Success(c, "Log distribusi berhasil diambil", fiber.Map{
+		"decisions":  decisions,
+		"pagination": pagination,
+	})
+}
+
+func (h *DistributionHandler) Trigger(c *fiber.Ctx) error {
+	var req dto.TriggerDistributionRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			errors := helper.MapUnmarshalErrors(err)
+			return response.ErrorValidation(c, fiber.StatusBadRequest, "Format JSON tidak valid", errors)
+		}
+	}
+
+	run, err := h.distributionUsecase.Trigger(c.Context(), c.Locals("nip").(string), req.DryRun)
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal menjalankan distribusi lead", err.Error())
+	}
+	return response.Success(c, "Distribusi lead selesai dijalankan", run)
+}
+
+func (h *DistributionHandler) GetRuns(c *fiber.Ctx) error {
+	req := distributionListRequest(c)
+	runs, pagination, err := h.distributionUsecase.GetRuns(c.Context(), &req)
+	if err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, "Gagal mengambil laporan distribusi", err.Error())
+	}
+	return response.Success(c, "Laporan distribusi berhasil diambil", fiber.Map{
+		"runs":       runs,
+		"pagination": pagination,
+	})
+}
+
+func (h *DistributionHandler) GetRun(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "ID tidak valid", "ID laporan harus berupa angka")
+	}
+
+	run, err := h.distributionUsecase.GetRun(c.Context(), id)
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal mengambil laporan distribusi", err.Error())
+	}
+	return response.Success(c, "Laporan distribusi berhasil diambil", run)
+}
+
+func distributionListRequest(c *fiber.Ctx) dto.DistributionListRequest {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	req := dto.DistributionListRequest{Page: page, Limit: limit}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Limit < 1 || req.Limit > 100 {
+		req.Limit = 10
+	}
+	return req
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"ml-prediction/config"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/usecase"
+	"ml-prediction/pkg/helper"
+	"ml-prediction/pkg/response"
+	"ml-prediction/pkg/storage"
+	"ml-prediction/pkg/validation"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type DocumentHandler struct {
+	documentUsecase usecase.DocumentUsecase
+	cfg             config.Configuration
+	val             *validator.Validate
+}
+
+func NewDocumentHandler(documentUsecase usecase.DocumentUsecase, cfg config.Configuration, val *validator.Validate) *DocumentHandler {
+	return &DocumentHandler{documentUsecase, cfg, val}
+}
+
+func (h *DocumentHandler) Upload(c *fiber.Ctx) error {
+	var req dto.UploadDocumentRequest
+	if err := c.BodyParser(&req); err != nil {
+		errors := helper.MapUnmarshalErrors(err)
+		return response.ErrorValidation(c, fiber.StatusBadRequest, "Format form tidak valid", errors)
+	}
+	if err := h.val.Struct(&req); err != nil {
+		if errs, ok := err.(validator.ValidationErrors); ok {
+			errors := validation.MapValidationErrors(errs, &req)
+			return response.ErrorValidation(c, fiber.StatusBadRequest, "Kesalahan Validasi", errors)
+		}
+		return response.Error(c, fiber.StatusBadRequest, "Kesalahan Validasi", err.Error())
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Kesalahan Validasi", "file wajib diunggah")
+	}
+	contentType, err := validation.SniffDocument(fileHeader, h.cfg.Document.MaxSizeBytes, h.cfg.Document.AllowedTypes)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Kesalahan Validasi", err.Error())
+	}
+
+	doc, err := h.documentUsecase.Upload(c.Context(), c.Locals("nip").(string), c.Params("cif"), req.DocumentType, contentType, fileHeader)
+	if err != nil {
+		status := usecaseErrorStatus(err)
+		if errors.Is(err, storage.ErrInfected) {
+			status = fiber.StatusUnprocessableEntity
+		}
+		return response.Error(c, status, "Gagal mengunggah dokumen", err.Error())
+	}
+	return response.SuccessCreated(c, "Dokumen berhasil diunggah", doc)
+}
+
+func (h *DocumentHandler) List(c *fiber.Ctx) error {
+	docs, err := h.documentUsecase.List(c.Context(), c.Locals("nip").(string), c.Params("cif"))
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal mengambil dokumen lead", err.Error())
+	}
+
+	// Download links stay under the caller's role prefix, e.g.
+	// /api/v1/marketing/documents/7/download.
+	prefix := c.Path()
+	if i := strings.Index(prefix, "/customers/"); i >= 0 {
+		prefix = prefix[:i]
+	}
+	for i := range docs.Documents {
+		docs.Documents[i].DownloadURL = fmt.Sprintf("%s/documents/%d/download", prefix, docs.Documents[i].ID)
+	}
+	return response.Success(c, "Dokumen lead berhasil diambil", docs)
+}
+
+func (h *DocumentHandler) Download(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "ID tidak valid", "ID dokumen harus berupa angka")
+	}
+
+	doc, reader, err := h.documentUsecase.Download(c.Context(), c.Locals("nip").(string), id)
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal mengunduh dokumen", err.Error())
+	}
+
+	c.Set(fiber.HeaderContentType, doc.ContentType)
+	c.Set(fiber.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": doc.FileName}))
+	c.Set("X-Content-Type-Options", "nosniff")
+	return c.SendStream(reader, int(doc.SizeBytes))
+}
+
+func (h *DocumentHandler) Delete(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "ID tidak valid", "ID dokumen harus berupa angka")
+	}
+
+	if err := h.documentUsecase.Delete(c.Context(), c.Locals("nip").(string), c.Params("cif"), id); err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal menghapus dokumen", err.Error())
+	}
+	return response.Success(c, "Dokumen berhasil dihapus", nil)
+}
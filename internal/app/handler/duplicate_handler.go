@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"ml-prediction/config"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/usecase"
+	"ml-prediction/pkg/helper"
+	"ml-prediction/pkg/response"
+	"ml-prediction/pkg/validation"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type DuplicateHandler struct {
+	duplicateUsecase usecase.DuplicateUsecase
+	cfg              config.Configuration
+	val              *validator.Validate
+}
+
+func NewDuplicateHandler(duplicateUsecase usecase.DuplicateUsecase, cfg config.Configuration, val *validator.Validate) *DuplicateHandler {
+	return &DuplicateHandler{duplicateUsecase, cfg, val}
+}
+
+func (h *DuplicateHandler) Scan(c *fiber.Ctx) error {
+	run, err := h.duplicateUsecase.Scan(c.Context(), c.Locals("nip").(string))
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal memindai duplikat customer", err.Error())
+	}
+	return response.Success(c, "Pemindaian duplikat dimulai", run)
+}
+
+func (h *DuplicateHandler) GetScanRun(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "ID tidak valid", "ID pemindaian harus berupa angka")
+	}
+
+	run, err := h.duplicateUsecase.GetScanRun(c.Context(), id)
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal mengambil pemindaian duplikat", err.Error())
+	}
+	return response.Success(c, "Pemindaian duplikat berhasil diambil", run)
+}
+
+func (h *DuplicateHandler) GetCandidates(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	req := dto.DuplicateListRequest{
+		Page:   page,
+		Limit:  limit,
+		Status: c.Query("status", "pending"),
+	}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Limit < 1 || req.Limit > 100 {
+		req.Limit = 10
+	}
+	if err := h.val.Struct(&req); err != nil {
+		if errs, ok := err.(validator.ValidationErrors); ok {
+			errors := validation.MapValidationErrors(errs, &req)
+			return response.ErrorValidation(c, fiber.StatusBadRequest, "Kesalahan Validasi", errors)
+		}
+		return response.Error(c, fiber.StatusBadRequest, "Kesalahan Validasi", err.Error())
+	}
+
+	candidates, pagination, err := h.duplicateUsecase.GetCandidates(c.Context(), &req)
+	if err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, "Gagal mengambil kandidat duplikat", err.Error())
+	}
+	return response.Success(c, "Kandidat duplikat berhasil diambil", fiber.Map{
+		"candidates": candidates,
+		"pagination": pagination,
+	})
+}
+
+func (h *DuplicateHandler) Dismiss(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "ID tidak valid", "ID kandidat harus berupa angka")
+	}
+
+	if err := h.duplicateUsecase.Dismiss(c.Context(), id, c.Locals("nip").(string)); err != nil {
+		return response.Error(c, clientErrorStatus(err), "Gagal menolak kandidat duplikat", err.Error())
+	}
+	return response.Success(c, "Kandidat duplikat ditandai bukan duplikat", nil)
+}
+
+func (h *DuplicateHandler) Merge(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "ID tidak valid", "ID kandidat harus berupa angka")
+	}
+
+	var req dto.MergeDuplicateRequest
+	if err := c.BodyParser(&req); err != nil {
+		errors := helper.MapUnmarshalErrors(err)
+		return response.ErrorValidation(c, fiber.StatusBadRequest, "Format JSON tidak valid", errors)
+	}
+	if err := h.val.Struct(&req); err != nil {
+		if errs, ok := err.(validator.ValidationErrors); ok {
+			errors := validation.MapValidationErrors(errs, &req)
+			return response.ErrorValidation(c, fiber.StatusBadRequest, "Kesalahan Validasi", errors)
+		}
+		return response.Error(c, fiber.StatusBadRequest, "Kesalahan Validasi", err.Error())
+	}
+
+	result, err := h.duplicateUsecase.Merge(c.Context(), id, &req, c.Locals("nip").(string))
+	if err != nil {
+		return response.Error(c, clientErrorStatus(err), "Gagal menggabungkan customer", err.Error())
+	}
+	return response.Success(c, "Customer berhasil digabungkan", result)
+}
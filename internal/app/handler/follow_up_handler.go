@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"ml-prediction/config"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/usecase"
+	"ml-prediction/pkg/helper"
+	"ml-prediction/pkg/response"
+	"ml-prediction/pkg/validation"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type FollowUpHandler struct {
+	followUpUsecase usecase.FollowUpUsecase
+	cfg             config.Configuration
+	val             *validator.Validate
+}
+
+func NewFollowUpHandler(followUpUsecase usecase.FollowUpUsecase, cfg config.Configuration, val *validator.Validate) *FollowUpHandler {
+	return &FollowUpHandler{followUpUsecase, cfg, val}
+}
+
+func (h *FollowUpHandler) Schedule(c *fiber.Ctx) error {
+	var req dto.ScheduleFollowUpRequest
+	if err := c.BodyParser(&req); err != nil {
+		errors := helper.MapUnmarshalErrors(err)
+		return response.ErrorValidation(c, fiber.StatusBadRequest, "Format JSON tidak valid", errors)
+	}
+	if err := h.val.Struct(&req); err != nil {
+		if errs, ok := err.(validator.ValidationErrors); ok {
+			errors := validation.MapValidationErrors(errs, &req)
+			return response.ErrorValidation(c, fiber.StatusBadRequest, "Kesalahan Validasi", errors)
+		}
+		return response.Error(c, fiber.StatusBadRequest, "Kesalahan Validasi", err.Error())
+	}
+
+	if err := h.followUpUsecase.Schedule(c.Context(), c.Locals("nip").(string), c.Params("cif"), &req); err != nil {
+		return response.Error(c, clientErrorStatus(err), "Gagal menjadwalkan tindak lanjut", err.Error())
+	}
+	return response.Success(c, "Tindak lanjut berhasil dijadwalkan", nil)
+}
+
+func (h *FollowUpHandler) Clear(c *fiber.Ctx) error {
+	if err := h.followUpUsecase.Clear(c.Context(), c.Locals("nip").(string), c.Params("cif")); err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal menghapus jadwal tindak lanjut", err.Error())
+	}
+	return response.Success(c, "Jadwal tindak lanjut berhasil dihapus", nil)
+}
+
+func (h *FollowUpHandler) GetAgenda(c *fiber.Ctx) error {
+	var req dto.FollowUpAgendaRequest
+	if err := c.QueryParser(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Format request tidak valid", err.Error())
+	}
+	if err := h.val.Struct(&req); err != nil {
+		if errs, ok := err.(validator.ValidationErrors); ok {
+			errors := validation.MapValidationErrors(errs, &req)
+			return response.ErrorValidation(c, fiber.StatusBadRequest, "Kesalahan Validasi", errors)
+		}
+		return response.Error(c, fiber.StatusBadRequest, "Kesalahan Validasi", err.Error())
+	}
+
+	agenda, err := h.followUpUsecase.GetAgenda(c.Context(), c.Locals("nip").(string), &req)
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal mengambil agenda tindak lanjut", err.Error())
+	}
+	return response.Success(c, "Agenda tindak lanjut berhasil diambil", agenda)
+}
+
+func (h *FollowUpHandler) GetBranchOverdue(c *fiber.Ctx) error {
+	items, err := h.followUpUsecase.GetBranchOverdue(c.Context(), c.Locals("nip").(string))
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal mengambil tindak lanjut terlambat", err.Error())
+	}
+	return response.Success(c, "Tindak lanjut terlambat berhasil diambil", items)
+}
+
+func (h *FollowUpHandler) GetReminders(c *fiber.Ctx) error {
+	reminders, err := h.followUpUsecase.GetReminders(c.Context(), c.Locals("nip").(string), c.QueryBool("unread"))
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal mengambil pengingat", err.Error())
+	}
+	return response.Success(c, "Pengingat berhasil diambil", reminders)
+}
+
+func (h *FollowUpHandler) MarkReminderRead(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "ID tidak valid", "ID pengingat harus berupa angka")
+	}
+
+	if err := h.followUpUsecase.MarkReminderRead(c.Context(), c.Locals("nip").(string), id); err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal memperbarui pengingat", err.Error())
+	}
+	return response.Success(c, "Pengingat ditandai sudah dibaca", nil)
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"ml-prediction/config"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/usecase"
+	"ml-prediction/pkg/response"
+	"ml-prediction/pkg/validation"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type FunnelHandler struct {
+	funnelUsecase usecase.FunnelUsecase
+	cfg           config.Configuration
+	val           *validator.Validate
+}
+
+func NewFunnelHandler(funnelUsecase usecase.FunnelUsecase, cfg config.Configuration, val *validator.Validate) *FunnelHandler {
+	return &FunnelHandler{funnelUsecase, cfg, val}
+}
+
+func (h *FunnelHandler) GetReport(c *fiber.Ctx) error {
+	var req dto.FunnelReportRequest
+	if err := c.QueryParser(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Format request tidak valid", err.Error())
+	}
+	if err := h.val.Struct(&req); err != nil {
+		if errs, ok := err.(validator.ValidationErrors); ok {
+			errors := validation.MapValidationErrors(errs, &req)
+			return response.ErrorValidation(c, fiber.StatusBadRequest, "Kesalahan Validasi", errors)
+		}
+		return response.Error(c, fiber.StatusBadRequest, "Kesalahan Validasi", err.Error())
+	}
+
+	report, err := h.funnelUsecase.GetReport(c.Context(), c.Locals("nip").(string), &req)
+	if err != nil {
+		return response.Error(c, clientErrorStatus(err), "Gagal mengambil laporan funnel", err.Error())
+	}
+	return response.Success(c, "Laporan funnel berhasil diambil", report)
+}
@@ -0,0 +1,228 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"ml-prediction/config"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/usecase"
+	"ml-prediction/pkg/helper"
+	"ml-prediction/pkg/response"
+	"ml-prediction/pkg/validation"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type IncentiveHandler struct {
+	incentiveUsecase usecase.IncentiveUsecase
+	cfg              config.Configuration
+	val              *validator.Validate
+}
+
+func NewIncentiveHandler(incentiveUsecase usecase.IncentiveUsecase, cfg config.Configuration, val *validator.Validate) *IncentiveHandler {
+	return &IncentiveHandler{incentiveUsecase, cfg, val}
+}
+
+func (h *IncentiveHandler) CreateScheme(c *fiber.Ctx) error {
+	var req dto.CreateIncentiveSchemeRequest
+	if err := c.BodyParser(&req); err != nil {
+		errors := helper.MapUnmarshalErrors(err)
+		return response.ErrorValidation(c, fiber.StatusBadRequest, "Format JSON tidak valid", errors)
+	}
+	if err := h.val.Struct(&req); err != nil {
+		if errs, ok := err.(validator.ValidationErrors); ok {
+			errors := validation.MapValidationErrors(errs, &req)
+			return response.ErrorValidation(c, fiber.StatusBadRequest, "Kesalahan Validasi", errors)
+		}
+		return response.Error(c, fiber.StatusBadRequest, "Kesalahan Validasi", err.Error())
+	}
+
+	scheme, err := h.incentiveUsecase.CreateScheme(c.Context(), c.Locals("nip").(string), &req)
+	if err != nil {
+		return response.Error(c, clientErrorStatus(err), "Gagal membuat skema insentif", err.Error())
+	}
+	return response.SuccessCreated(c, "Skema insentif berhasil dibuat", scheme)
+}
+
+func (h *IncentiveHandler) GetSchemes(c *fiber.Ctx) error {
+	productID := c.QueryInt("product_id", 0)
+	if productID < 0 {
+		return response.Error(c, fiber.StatusBadRequest, "Format request tidak valid", "product_id harus berupa angka positif")
+	}
+
+	schemes, err := h.incentiveUsecase.GetSchemes(c.Context(), uint(productID))
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal mengambil skema insentif", err.Error())
+	}
+	return response.Success(c, "Skema insentif berhasil diambil", schemes)
+}
+
+func (h *IncentiveHandler) DeleteScheme(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "ID tidak valid", "ID skema harus berupa angka")
+	}
+
+	if err := h.incentiveUsecase.DeleteScheme(c.Context(), uint(id)); err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal menghapus skema insentif", err.Error())
+	}
+	return response.Success(c, "Skema insentif berhasil dihapus", nil)
+}
+
+func (h *IncentiveHandler) Calculate(c *fiber.Ctx) error {
+	var req dto.IncentivePeriodRequest
+	if err := c.BodyParser(&req); err != nil {
+		errors := helper.MapUnmarshalErrors(err)
+		return response.ErrorValidation(c, fiber.StatusBadRequest, "Format JSON tidak valid", errors)
+	}
+	if err := h.val.Struct(&req); err != nil {
+		if errs, ok := err.(validator.ValidationErrors); ok {
+			errors := validation.MapValidationErrors(errs, &req)
+			return response.ErrorValidation(c, fiber.StatusBadRequest, "Kesalahan Validasi", errors)
+		}
+		return response.Error(c, fiber.StatusBadRequest, "Kesalahan Validasi", err.Error())
+	}
+
+	result, err := h.incentiveUsecase.Calculate(c.Context(), req.Tahun, req.Bulan)
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal menghitung insentif", err.Error())
+	}
+	return response.Success(c, "Insentif berhasil dihitung", result)
+}
+
+func (h *IncentiveHandler) GetStatements(c *fiber.Ctx) error {
+	var req dto.IncentiveStatementsRequest
+	if err := c.QueryParser(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Format request tidak valid", err.Error())
+	}
+	if err := h.val.Struct(&req); err != nil {
+		if errs, ok := err.(validator.ValidationErrors); ok {
+			errors := validation.MapValidationErrors(errs, &req)
+			return response.ErrorValidation(c, fiber.StatusBadRequest, "Kesalahan Validasi", errors)
+		}
+		return response.Error(c, fiber.StatusBadRequest, "Kesalahan Validasi", err.Error())
+	}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Limit < 1 || req.Limit > 100 {
+		req.Limit = 10
+	}
+
+	statements, err := h.incentiveUsecase.GetStatements(c.Context(), c.Locals("nip").(string), &req)
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal mengambil laporan insentif", err.Error())
+	}
+	return response.Success(c, "Laporan insentif berhasil diambil", statements)
+}
+
+func (h *IncentiveHandler) GetStatement(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "ID tidak valid", "ID laporan harus berupa angka")
+	}
+
+	statement, err := h.incentiveUsecase.GetStatement(c.Context(), c.Locals("nip").(string), id)
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal mengambil laporan insentif", err.Error())
+	}
+	return response.Success(c, "Laporan insentif berhasil diambil", statement)
+}
+
+func (h *IncentiveHandler) Approve(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "ID tidak valid", "ID laporan harus berupa angka")
+	}
+
+	statement, err := h.incentiveUsecase.Approve(c.Context(), c.Locals("nip").(string), id)
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal menyetujui laporan insentif", err.Error())
+	}
+	return response.Success(c, "Laporan insentif berhasil disetujui", statement)
+}
+
+func (h *IncentiveHandler) Reject(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "ID tidak valid", "ID laporan harus berupa angka")
+	}
+	var req dto.RejectIncentiveRequest
+	if err := c.BodyParser(&req); err != nil {
+		errors := helper.MapUnmarshalErrors(err)
+		return response.ErrorValidation(c, fiber.StatusBadRequest, "Format JSON tidak valid", errors)
+	}
+	if err := h.val.Struct(&req); err != nil {
+		if errs, ok := err.(validator.ValidationErrors); ok {
+			errors := validation.MapValidationErrors(errs, &req)
+			return response.ErrorValidation(c, fiber.StatusBadRequest, "Kesalahan Validasi", errors)
+		}
+		return response.Error(c, fiber.StatusBadRequest, "Kesalahan Validasi", err.Error())
+	}
+
+	statement, err := h.incentiveUsecase.Reject(c.Context(), c.Locals("nip").(string), id, &req)
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal menolak laporan insentif", err.Error())
+	}
+	return response.Success(c, "Laporan insentif berhasil ditolak", statement)
+}
+
+// Export writes the approved statements of a month as CSV for payroll.
+func (h *IncentiveHandler) Export(c *fiber.Ctx) error {
+	var req dto.IncentivePeriodRequest
+	if err := c.QueryParser(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Format request tidak valid", err.Error())
+	}
+	if err := h.val.Struct(&req); err != nil {
+		if errs, ok := err.(validator.ValidationErrors); ok {
+			errors := validation.MapValidationErrors(errs, &req)
+			return response.ErrorValidation(c, fiber.StatusBadRequest, "Kesalahan Validasi", errors)
+		}
+		return response.Error(c, fiber.StatusBadRequest, "Kesalahan Validasi", err.Error())
+	}
+	kantorCabangID := c.QueryInt("kantor_cabang_id", 0)
+	if kantorCabangID < 0 {
+		return response.Error(c, fiber.StatusBadRequest, "Format request tidak valid", "kantor_cabang_id harus berupa angka positif")
+	}
+
+	statements, err := h.incentiveUsecase.GetPayroll(c.Context(), c.Locals("nip").(string), &req, uint(kantorCabangID))
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal mengekspor insentif", err.Error())
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"nip", "nama", "kantor_cabang", "tahun", "bulan", "total_insentif", "disetujui_oleh", "tanggal_persetujuan"})
+	for _, s := range statements {
+		var branch, reviewer, reviewedAt string
+		if s.BranchName != nil {
+			branch = *s.BranchName
+		}
+		if s.ReviewedByNIP != nil {
+			reviewer = *s.ReviewedByNIP
+		}
+		if s.ReviewedAt != nil {
+			reviewedAt = s.ReviewedAt.Format("2006-01-02")
+		}
+		w.Write([]string{
+			s.MarketingNIP,
+			s.MarketingName,
+			branch,
+			strconv.Itoa(s.Tahun),
+			strconv.Itoa(s.Bulan),
+			strconv.FormatInt(s.TotalAmount, 10),
+			reviewer,
+			reviewedAt,
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, "Gagal mengekspor insentif", err.Error())
+	}
+
+	c.Attachment(fmt.Sprintf("insentif-%04d-%02d.csv", req.Tahun, req.Bulan))
+	return c.Send(buf.Bytes())
+}
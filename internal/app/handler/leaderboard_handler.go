@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"ml-prediction/config"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/usecase"
+	"ml-prediction/pkg/response"
+	"ml-prediction/pkg/validation"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type LeaderboardHandler struct {
+	leaderboardUsecase usecase.LeaderboardUsecase
+	cfg                config.Configuration
+	val                *validator.Validate
+}
+
+func NewLeaderboardHandler(leaderboardUsecase usecase.LeaderboardUsecase, cfg config.Configuration, val *validator.Validate) *LeaderboardHandler {
+	return &LeaderboardHandler{leaderboardUsecase, cfg, val}
+}
+
+func (h *LeaderboardHandler) GetLeaderboard(c *fiber.Ctx) error {
+	var req dto.LeaderboardRequest
+	if err := c.QueryParser(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Format request tidak valid", err.Error())
+	}
+	if err := h.val.Struct(&req); err != nil {
+		if errs, ok := err.(validator.ValidationErrors); ok {
+			errors := validation.MapValidationErrors(errs, &req)
+			return response.ErrorValidation(c, fiber.StatusBadRequest, "Kesalahan Validasi", errors)
+		}
+		return response.Error(c, fiber.StatusBadRequest, "Kesalahan Validasi", err.Error())
+	}
+
+	leaderboard, err := h.leaderboardUsecase.GetLeaderboard(c.Context(), c.Locals("nip").(string), &req)
+	if err != nil {
+		return response.Error(c, clientErrorStatus(err), "Gagal mengambil leaderboard", err.Error())
+	}
+	return response.Success(c, "Leaderboard berhasil diambil", leaderboard)
+}
+
+// GetAchievements serves the caller's own achievements, or those of the
+// marketer named by the :nip parameter.
+func (h *LeaderboardHandler) GetAchievements(c *fiber.Ctx) error {
+	achievements, err := h.leaderboardUsecase.GetAchievements(c.Context(), c.Locals("nip").(string), c.Params("nip"))
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal mengambil pencapaian", err.Error())
+	}
+	return response.Success(c, "Pencapaian berhasil diambil", achievements)
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"ml-prediction/config"
 	dto "ml-prediction/internal/app/domain"
@@ -20,6 +21,8 @@ type MarketingCustomerHandler interface {
 	GetMonthlyMonitoring(c *fiber.Ctx) error
 	GetMonthlyMonitoringMarketing(c *fiber.Ctx) error
 	GetProductPerformance(c *fiber.Ctx) error
+	GetLeadHistory(c *fiber.Ctx) error
+	GetLeadStates(c *fiber.Ctx) error
 }
 type marketingCustomerHandler struct {
 	usecase usecase.MarketingCustomerUsecase
@@ -60,9 +63,17 @@ func (h *marketingCustomerHandler) UpdateCustomerStatus(c *fiber.Ctx) error {
 		return response.Error(c, fiber.StatusBadRequest, "Kesalahan Validasi", err.Error())
 	}
 
-	marketingNIP := c.Locals("nip").(string)
-	if err := h.usecase.UpdateCustomerStatus(&req, marketingNIP); err != nil {
-		return response.Error(c, fiber.StatusBadRequest, "Gagal mengassign customer", err.Error())
+	nip := c.Locals("nip").(string)
+	if err := h.usecase.UpdateCustomerStatus(&req, nip); err != nil {
+		var transitionErr *dto.TransitionError
+		if errors.As(err, &transitionErr) {
+			return response.ErrorDetail(c, fiber.StatusConflict, "Perubahan status tidak diizinkan", transitionErr.Message, transitionErr)
+		}
+		var heldErr *dto.LeadHeldError
+		if errors.As(err, &heldErr) {
+			return response.ErrorDetail(c, fiber.StatusConflict, "Customer sedang dipegang marketing lain", heldErr.Error(), heldErr)
+		}
+		return response.Error(c, clientErrorStatus(err), "Gagal mengassign customer", err.Error())
 	}
 
 	return response.Success(c, "Customer berhasil diassign", nil)
@@ -78,9 +89,9 @@ func (h *marketingCustomerHandler) GetMonthlyMonitoring(c *fiber.Ctx) error {
 		return response.ErrorValidation(c, fiber.StatusBadRequest, "Validasi gagal", validation.MapValidationErrors(err, &req))
 	}
 
-	result, err := h.usecase.GetMonthlyMonitoring(&req)
+	result, err := h.usecase.GetMonthlyMonitoring(c.Locals("nip").(string), &req)
 	if err != nil {
-		return response.Error(c, fiber.StatusInternalServerError, "Gagal mendapatkan data monitoring", err.Error())
+		return response.Error(c, usecaseErrorStatus(err), "Gagal mendapatkan data monitoring", err.Error())
 	}
 
 	return response.Success(c, "Berhasil mendapatkan data monitoring", result)
@@ -152,10 +163,31 @@ func (h *marketingCustomerHandler) GetProductPerformance(c *fiber.Ctx) error {
 		return response.Error(c, fiber.StatusBadRequest, "Kesalahan Validasi", err.Error())
 	}
 
-	result, err := h.usecase.GetProductPerformance(c.Context(), &req)
+	result, err := h.usecase.GetProductPerformance(c.Context(), c.Locals("nip").(string), &req)
 	if err != nil {
-		return response.Error(c, fiber.StatusInternalServerError, "Failed to get product performance", err.Error())
+		return response.Error(c, usecaseErrorStatus(err), "Failed to get product performance", err.Error())
 	}
 
 	return response.Success(c, "Successfully retrieved product performance", result)
 }
+
+func (h *marketingCustomerHandler) GetLeadHistory(c *fiber.Ctx) error {
+	cif := c.Params("cif")
+	if cif == "" {
+		return response.Error(c, fiber.StatusBadRequest, "CIF tidak valid", "CIF harus diisi")
+	}
+
+	history, err := h.usecase.GetLeadHistory(c.Context(), c.Locals("nip").(string), cif)
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal mengambil riwayat lead", err.Error())
+	}
+	return response.Success(c, "Riwayat lead berhasil diambil", history)
+}
+
+func (h *marketingCustomerHandler) GetLeadStates(c *fiber.Ctx) error {
+	states, err := h.usecase.GetLeadStates(c.Context(), c.Locals("nip").(string))
+	if err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, "Gagal mengambil status lead", err.Error())
+	}
+	return response.Success(c, "Status lead berhasil diambil", states)
+}
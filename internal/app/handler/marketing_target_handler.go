@@ -87,9 +87,25 @@ func (h *MarketingTargetHandler) GetMarketingTargets(c *fiber.Ctx) error {
 		return response.ErrorValidation(c, fiber.StatusBadRequest, "Validasi gagal", validation.MapValidationErrors(err, &req))
 	}
 
-	result, err := h.targetUsecase.GetMarketingTargets(&req)
+	if useCursorPagination(c) {
+		if !validTotalMode(req.Total) {
+			return response.Error(c, fiber.StatusBadRequest, "Parameter tidak valid", "total harus salah satu dari [exact estimate]")
+		}
+		result, meta, err := h.targetUsecase.GetMarketingTargetsCursor(c.Locals("nip").(string), &req)
+		if err != nil {
+			return response.Error(c, usecaseErrorStatus(err), "Gagal mendapatkan data target", err.Error())
+		}
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"success": true,
+			"message": "Berhasil mendapatkan data target",
+			"data":    result,
+			"meta":    meta,
+		})
+	}
+
+	result, err := h.targetUsecase.GetMarketingTargets(c.Locals("nip").(string), &req)
 	if err != nil {
-		return response.Error(c, fiber.StatusInternalServerError, "Gagal mendapatkan data target", err.Error())
+		return response.Error(c, usecaseErrorStatus(err), "Gagal mendapatkan data target", err.Error())
 	}
 
 	return response.Success(c, "Berhasil mendapatkan data target", result)
@@ -111,9 +127,9 @@ func (h *MarketingTargetHandler) GetMarketingTargetsDetail(c *fiber.Ctx) error {
 		return response.ErrorValidation(c, fiber.StatusBadRequest, "Validasi gagal", validation.MapValidationErrors(err, &req))
 	}
 
-	result, err := h.targetUsecase.GetMarketingTargets(&req)
+	result, err := h.targetUsecase.GetMarketingTargets(c.Locals("nip").(string), &req)
 	if err != nil {
-		return response.Error(c, fiber.StatusInternalServerError, "Gagal mendapatkan data target", err.Error())
+		return response.Error(c, usecaseErrorStatus(err), "Gagal mendapatkan data target", err.Error())
 	}
 
 	return response.Success(c, "Berhasil mendapatkan data target", result)
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"ml-prediction/config"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/usecase"
+	"ml-prediction/pkg/helper"
+	"ml-prediction/pkg/response"
+	"ml-prediction/pkg/validation"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type RejectionHandler struct {
+	rejectionUsecase usecase.RejectionUsecase
+	cfg              config.Configuration
+	val              *validator.Validate
+}
+
+func NewRejectionHandler(rejectionUsecase usecase.RejectionUsecase, cfg config.Configuration, val *validator.Validate) *RejectionHandler {
+	return &RejectionHandler{rejectionUsecase, cfg, val}
+}
+
+// GetActiveReasons lists the reasons marketers can choose from.
+func (h *RejectionHandler) GetActiveReasons(c *fiber.Ctx) error {
+	reasons, err := h.rejectionUsecase.GetReasons(c.Context(), true)
+	if err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, "Gagal mengambil alasan penolakan", err.Error())
+	}
+	return response.Success(c, "Alasan penolakan berhasil diambil", reasons)
+}
+
+func (h *RejectionHandler) GetAllReasons(c *fiber.Ctx) error {
+	reasons, err := h.rejectionUsecase.GetReasons(c.Context(), false)
+	if err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, "Gagal mengambil alasan penolakan", err.Error())
+	}
+	return response.Success(c, "Alasan penolakan berhasil diambil", reasons)
+}
+
+func (h *RejectionHandler) CreateReason(c *fiber.Ctx) error {
+	var req dto.CreateRejectionReasonRequest
+	if err := c.BodyParser(&req); err != nil {
+		errors := helper.MapUnmarshalErrors(err)
+		return response.ErrorValidation(c, fiber.StatusBadRequest, "Format JSON tidak valid", errors)
+	}
+	if err := h.val.Struct(&req); err != nil {
+		if errs, ok := err.(validator.ValidationErrors); ok {
+			errors := validation.MapValidationErrors(errs, &req)
+			return response.ErrorValidation(c, fiber.StatusBadRequest, "Kesalahan Validasi", errors)
+		}
+		return response.Error(c, fiber.StatusBadRequest, "Kesalahan Validasi", err.Error())
+	}
+
+	reason, err := h.rejectionUsecase.CreateReason(c.Context(), &req)
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal membuat alasan penolakan", err.Error())
+	}
+	return response.SuccessCreated(c, "Alasan penolakan berhasil dibuat", reason)
+}
+
+func (h *RejectionHandler) UpdateReason(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "ID tidak valid", "ID alasan harus berupa angka")
+	}
+
+	var req dto.UpdateRejectionReasonRequest
+	if err := c.BodyParser(&req); err != nil {
+		errors := helper.MapUnmarshalErrors(err)
+		return response.ErrorValidation(c, fiber.StatusBadRequest, "Format JSON tidak valid", errors)
+	}
+	if err := h.val.Struct(&req); err != nil {
+		if errs, ok := err.(validator.ValidationErrors); ok {
+			errors := validation.MapValidationErrors(errs, &req)
+			return response.ErrorValidation(c, fiber.StatusBadRequest, "Kesalahan Validasi", errors)
+		}
+		return response.Error(c, fiber.StatusBadRequest, "Kesalahan Validasi", err.Error())
+	}
+
+	reason, err := h.rejectionUsecase.UpdateReason(c.Context(), uint(id), &req)
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal menyimpan alasan penolakan", err.Error())
+	}
+	return response.Success(c, "Alasan penolakan berhasil disimpan", reason)
+}
+
+func (h *RejectionHandler) GetReport(c *fiber.Ctx) error {
+	var req dto.RejectionReportRequest
+	if err := c.QueryParser(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Format request tidak valid", err.Error())
+	}
+	if err := h.val.Struct(&req); err != nil {
+		if errs, ok := err.(validator.ValidationErrors); ok {
+			errors := validation.MapValidationErrors(errs, &req)
+			return response.ErrorValidation(c, fiber.StatusBadRequest, "Kesalahan Validasi", errors)
+		}
+		return response.Error(c, fiber.StatusBadRequest, "Kesalahan Validasi", err.Error())
+	}
+
+	report, err := h.rejectionUsecase.GetReport(c.Context(), c.Locals("nip").(string), &req)
+	if err != nil {
+		return response.Error(c, clientErrorStatus(err), "Gagal mengambil laporan penolakan", err.Error())
+	}
+	return response.Success(c, "Laporan penolakan berhasil diambil", report)
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"ml-prediction/config"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/usecase"
+	"ml-prediction/pkg/helper"
+	"ml-prediction/pkg/response"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type RetentionHandler struct {
+	retentionUsecase usecase.RetentionUsecase
+	cfg              config.Configuration
+	val              *validator.Validate
+}
+
+func NewRetentionHandler(retentionUsecase usecase.RetentionUsecase, cfg config.Configuration, val *validator.Validate) *RetentionHandler {
+	return &RetentionHandler{retentionUsecase, cfg, val}
+}
+
+func (h *RetentionHandler) Trigger(c *fiber.Ctx) error {
+	var req dto.TriggerRetentionRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			errors := helper.MapUnmarshalErrors(err)
+			return response.ErrorValidation(c, fiber.StatusBadRequest, "Format JSON tidak valid", errors)
+		}
+	}
+
+	run, err := h.retentionUsecase.Run(c.Context(), c.Locals("nip").(string), req.DryRun)
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal menjalankan retensi data", err.Error())
+	}
+	return response.Success(c, "Retensi data selesai dijalankan", run)
+}
+
+func (h *RetentionHandler) GetRuns(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	req := dto.RetentionRunListRequest{Page: page, Limit: limit}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Limit < 1 || req.Limit > 100 {
+		req.Limit = 10
+	}
+
+	runs, pagination, err := h.retentionUsecase.GetRuns(c.Context(), &req)
+	if err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, "Gagal mengambil laporan retensi", err.Error())
+	}
+	return response.Success(c, "Laporan retensi berhasil diambil", fiber.Map{
+		"runs":       runs,
+		"pagination": pagination,
+	})
+}
+
+func (h *RetentionHandler) GetRun(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "ID tidak valid", "ID laporan harus berupa angka")
+	}
+
+	run, err := h.retentionUsecase.GetRun(c.Context(), id)
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal mengambil laporan retensi", err.Error())
+	}
+	return response.Success(c, "Laporan retensi berhasil diambil", run)
+}
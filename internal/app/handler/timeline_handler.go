@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"errors"
+	"ml-prediction/config"
+	"ml-prediction/internal/app/usecase"
+	"ml-prediction/pkg/helper"
+	"ml-prediction/pkg/response"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type TimelineHandler struct {
+	timelineUsecase usecase.TimelineUsecase
+	cfg             config.Configuration
+	val             *validator.Validate
+}
+
+func NewTimelineHandler(timelineUsecase usecase.TimelineUsecase, cfg config.Configuration, val *validator.Validate) *TimelineHandler {
+	return &TimelineHandler{timelineUsecase, cfg, val}
+}
+
+func (h *TimelineHandler) GetCustomerTimeline(c *fiber.Ctx) error {
+	cif := c.Params("cif")
+	NIP := c.Locals("nip").(string)
+
+	timeline, err := h.timelineUsecase.GetCustomerTimeline(c.Context(), NIP, cif)
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal mengambil timeline customer", err.Error())
+	}
+
+	return response.Success(c, "Timeline customer berhasil diambil", timeline)
+}
+
+// usecaseErrorStatus maps the usecase sentinel errors to HTTP status codes.
+func usecaseErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, helper.ErrInvalidCursor):
+		return fiber.StatusBadRequest
+	case errors.Is(err, usecase.ErrNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, usecase.ErrForbidden):
+		return fiber.StatusForbidden
+	case errors.Is(err, usecase.ErrConflict):
+		return fiber.StatusConflict
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// clientErrorStatus is usecaseErrorStatus for operations whose remaining
+// failures are caused by the request, such as a rule violation.
+func clientErrorStatus(err error) int {
+	if status := usecaseErrorStatus(err); status != fiber.StatusInternalServerError {
+		return status
+	}
+	return fiber.StatusBadRequest
+}
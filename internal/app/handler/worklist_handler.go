@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"ml-prediction/config"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/usecase"
+	"ml-prediction/pkg/helper"
+	"ml-prediction/pkg/response"
+	"ml-prediction/pkg/validation"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type WorklistHandler struct {
+	worklistUsecase usecase.WorklistUsecase
+	cfg             config.Configuration
+	val             *validator.Validate
+}
+
+func NewWorklistHandler(worklistUsecase usecase.WorklistUsecase, cfg config.Configuration, val *validator.Validate) *WorklistHandler {
+	return &WorklistHandler{worklistUsecase, cfg, val}
+}
+
+func (h *WorklistHandler) GetWorklist(c *fiber.Ctx) error {
+	var req dto.WorklistRequest
+	if err := c.QueryParser(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Format request tidak valid", err.Error())
+	}
+	if err := h.val.Struct(&req); err != nil {
+		if errs, ok := err.(validator.ValidationErrors); ok {
+			errors := validation.MapValidationErrors(errs, &req)
+			return response.ErrorValidation(c, fiber.StatusBadRequest, "Kesalahan Validasi", errors)
+		}
+		return response.Error(c, fiber.StatusBadRequest, "Kesalahan Validasi", err.Error())
+	}
+
+	worklist, err := h.worklistUsecase.GetWorklist(c.Context(), c.Locals("nip").(string), &req)
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal mengambil worklist", err.Error())
+	}
+	return response.Success(c, "Worklist berhasil diambil", worklist)
+}
+
+func (h *WorklistHandler) GetSettings(c *fiber.Ctx) error {
+	setting, err := h.worklistUsecase.GetSettings(c.Context(), c.Locals("nip").(string))
+	if err != nil {
+		return response.Error(c, usecaseErrorStatus(err), "Gagal mengambil pengaturan worklist", err.Error())
+	}
+	return response.Success(c, "Pengaturan worklist berhasil diambil", setting)
+}
+
+func (h *WorklistHandler) UpdateSettings(c *fiber.Ctx) error {
+	var req dto.UpdateWorklistSettingsRequest
+	if err := c.BodyParser(&req); err != nil {
+		errors := helper.MapUnmarshalErrors(err)
+		return response.ErrorValidation(c, fiber.StatusBadRequest, "Format JSON tidak valid", errors)
+	}
+	if err := h.val.Struct(&req); err != nil {
+		if errs, ok := err.(validator.ValidationErrors); ok {
+			errors := validation.MapValidationErrors(errs, &req)
+			return response.ErrorValidation(c, fiber.StatusBadRequest, "Kesalahan Validasi", errors)
+		}
+		return response.Error(c, fiber.StatusBadRequest, "Kesalahan Validasi", err.Error())
+	}
+
+	setting, err := h.worklistUsecase.UpdateSettings(c.Context(), c.Locals("nip").(string), &req)
+	if err != nil {
+		return response.Error(c, clientErrorStatus(err), "Gagal menyimpan pengaturan worklist", err.Error())
+	}
+	return response.Success(c, "Pengaturan worklist berhasil disimpan", setting)
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Verification states of a closing. Only verified closings count toward
+// achievement.
+const (
+	ClosingPendingVerification = "pending_verification"
+	ClosingVerified            = "verified"
+	ClosingRejected            = "rejected"
+)
+
+// Closing is one product a customer took up through a lead. Achievement is
+// counted from verified closings, attributed to MarketingID on ClosedAt.
+type Closing struct {
+	ID                  uint64    `gorm:"primaryKey" json:"id"`
+	MarketingCustomerID uint      `gorm:"not null" json:"marketing_customer_id"`
+	CustomerID          uint64    `gorm:"not null" json:"customer_id"`
+	MarketingID         uint      `gorm:"not null" json:"marketing_id"`
+	ProductID           uint      `gorm:"not null" json:"product_id"`
+	Amount              int64     `gorm:"not null" json:"amount"`
+	TenorMonths         *int      `gorm:"null" json:"tenor_months"`
+	ClosedAt            time.Time `gorm:"type:date;not null" json:"closed_at"`
+	AccountReference    *string   `gorm:"type:varchar(50)" json:"account_reference"`
+	Notes               string    `gorm:"type:text" json:"notes"`
+	CreatedBy           *uint     `gorm:"null" json:"created_by"`
+
+	VerificationStatus string     `gorm:"type:varchar(20);not null;default:'pending_verification'" json:"verification_status"`
+	EvidenceReference  *string    `gorm:"type:varchar(100)" json:"evidence_reference"`
+	VerifiedBy         *uint      `gorm:"null" json:"verified_by"`
+	VerifiedAt         *time.Time `gorm:"null" json:"verified_at"`
+	VerificationNote   string     `gorm:"type:text" json:"verification_note"`
+
+	Product   *Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Marketing *User    `gorm:"foreignKey:MarketingID" json:"-"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// ClosingPeriod marks a month as locked. Months without a row are open.
+type ClosingPeriod struct {
+	ID       uint      `gorm:"primaryKey" json:"id"`
+	Tahun    int       `gorm:"not null" json:"tahun"`
+	Bulan    int       `gorm:"not null" json:"bulan"`
+	LockedAt time.Time `gorm:"not null" json:"locked_at"`
+	LockedBy *uint     `gorm:"null" json:"locked_by"`
+}
@@ -1,32 +1,62 @@
 package model
 
 import (
+	"ml-prediction/pkg/piicrypto"
 	"time"
 
 	"github.com/lib/pq"
 	"gorm.io/gorm"
 )
 
+// Customer sources record how a customer entered the system.
+const (
+	CustomerSourceAPI       = "api"
+	CustomerSourceCSVImport = "csv_import"
+	CustomerSourceLegacy    = "legacy"
+)
+
 type Customer struct {
 	Id                 uint64             `gorm:"primaryKey" json:"id"`
 	Nama               string             `gorm:"type:varchar(100);not null" json:"nama"`
 	CIF                string             `gorm:"type:varchar(50);not null;unique" json:"cif"`
-	NomorRekening      string             `gorm:"type:varchar(50);not null;unique" json:"nomor_rekening"`
+	NomorRekening      string             `gorm:"type:text;not null;serializer:pii" json:"nomor_rekening"`
 	NamaPerusahaan     string             `gorm:"type:varchar(50);not null;"  json:"nama_perusahaan"`
 	ProdukEksisting    pq.StringArray     `gorm:"type:varchar[]"  json:"produk_eksisting"`
 	AktivitasTransaksi string             `gorm:"type:varchar(100)"  json:"aktivitas_transaksi"`
-	NomorHp            string             `gorm:"type:varchar(20)"  json:"nomor_hp"`
+	NomorHp            string             `gorm:"type:text;serializer:pii"  json:"nomor_hp"`
 	Segmen             string             `gorm:"type:varchar(20)"  json:"segmen"`
-	Address            string             `gorm:"type:text"  json:"alamat"`
+	Address            string             `gorm:"type:text;serializer:pii"  json:"alamat"`
 	Job                string             `gorm:"type:varchar(100)"  json:"pekerjaan"`
-	Email              string             `gorm:"type:varchar(50)"  json:"email"`
+	Email              string             `gorm:"type:text;serializer:pii"  json:"email"`
 	Penghasilan        int64              `gorm:"type:int"  json:"penghasilan"`
 	Umur               int                `gorm:"type:int"  json:"umur"`
 	Gender             string             `gorm:"type:varchar(10)"  json:"gender"`
 	StatusPerkawinan   bool               `gorm:"type:boolean"  json:"status_perkawinan"`
 	Payroll            bool               `gorm:"type:boolean"  json:"payroll"`
+	Source             string             `gorm:"type:varchar(30);default:'api'" json:"source"`
+	KantorCabangID     *uint              `gorm:"null" json:"kantor_cabang_id"`
+	MergedIntoID       *uint64            `gorm:"null" json:"merged_into_id,omitempty"`
+	AnonymizedAt       *time.Time         `gorm:"null" json:"anonymized_at,omitempty"`
+	NomorRekeningBidx  *string            `gorm:"type:varchar(64)" json:"-"`
+	NomorHpBidx        *string            `gorm:"type:varchar(64)" json:"-"`
+	EmailBidx          *string            `gorm:"type:varchar(64)" json:"-"`
 	CustomerProduk     []*CustomerProduct `gorm:"foreignKey:CustomerID" json:"customer_produk"`
 	CreatedAt          time.Time          ` json:"created_at"`
 	UpdatedAt          time.Time          ` json:"updated_at"`
 	DeletedAt          gorm.DeletedAt     `gorm:"index" json:"deleted_at"`
 }
+
+// BeforeSave refreshes the blind indexes from the plaintext values so exact
+// match lookups keep working while the columns themselves are encrypted.
+//
+// Hooks only run when a Customer struct is saved. Code that writes
+// nomor_rekening, nomor_hp or email through UpdateColumn(s), Updates with a
+// map or raw SQL must set nomor_rekening_bidx, nomor_hp_bidx and email_bidx in
+// the same statement (see piicrypto.BlindIndexPtr), or lookups and the
+// uniqueness of account and phone numbers silently break.
+func (c *Customer) BeforeSave(tx *gorm.DB) error {
+	c.NomorRekeningBidx = piicrypto.BlindIndexPtr(piicrypto.FieldAccount, c.NomorRekening)
+	c.NomorHpBidx = piicrypto.BlindIndexPtr(piicrypto.FieldPhone, c.NomorHp)
+	c.EmailBidx = piicrypto.BlindIndexPtr(piicrypto.FieldEmail, c.Email)
+	return nil
+}
@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// Fields a customer branch rule can match on.
+const (
+	BranchRuleCompany = "company"
+	BranchRuleAddress = "address"
+)
+
+// CustomerBranchRule routes customers to a branch. Company rules match the
+// payroll company of payroll customers exactly; address rules match any part
+// of the address. Both ignore case.
+type CustomerBranchRule struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	KantorCabangID uint      `gorm:"not null" json:"kantor_cabang_id"`
+	MatchField     string    `gorm:"type:varchar(20);not null" json:"match_field"`
+	Pattern        string    `gorm:"type:varchar(100);not null" json:"pattern"`
+	CreatedBy      *uint     `gorm:"null" json:"created_by"`
+	CreatedAt      time.Time `json:"created_at"`
+
+	KantorCabang *KantorCabang `gorm:"foreignKey:KantorCabangID" json:"kantor_cabang,omitempty"`
+}
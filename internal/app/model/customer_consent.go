@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+// Contact channels a customer can consent to.
+const (
+	ConsentChannelPhone    = "phone"
+	ConsentChannelWhatsApp = "whatsapp"
+	ConsentChannelEmail    = "email"
+	ConsentChannelVisit    = "visit"
+)
+
+// ConsentScopeCrossSelling covers offers made from the prediction lead pool.
+const ConsentScopeCrossSelling = "cross_selling"
+
+// ConsentChannels lists every channel in display order.
+var ConsentChannels = []string{ConsentChannelPhone, ConsentChannelWhatsApp, ConsentChannelEmail, ConsentChannelVisit}
+
+// CustomerConsent is an append-only record of a customer granting or refusing
+// contact through one channel.
+type CustomerConsent struct {
+	ID         uint64     `gorm:"primaryKey" json:"id"`
+	CustomerID uint64     `gorm:"not null" json:"customer_id"`
+	Channel    string     `gorm:"type:varchar(20);not null" json:"channel"`
+	Scope      string     `gorm:"type:varchar(30);not null" json:"scope"`
+	Granted    bool       `gorm:"not null" json:"granted"`
+	Source     string     `gorm:"type:varchar(30);not null" json:"source"`
+	ExpiresAt  *time.Time `gorm:"null" json:"expires_at"`
+	RecordedBy *uint      `gorm:"null" json:"recorded_by"`
+	Notes      string     `gorm:"type:text" json:"notes"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	DuplicateStatusPending   = "pending"
+	DuplicateStatusMerged    = "merged"
+	DuplicateStatusDismissed = "dismissed"
+)
+
+// CustomerDuplicateCandidate is a pair of customers that probably describe the
+// same person. CustomerID is always the lower id of the pair.
+type CustomerDuplicateCandidate struct {
+	ID          uint64         `gorm:"primaryKey" json:"id"`
+	CustomerID  uint64         `gorm:"not null" json:"customer_id"`
+	DuplicateID uint64         `gorm:"not null" json:"duplicate_id"`
+	Score       float64        `gorm:"type:numeric(5,4);not null" json:"score"`
+	Reasons     pq.StringArray `gorm:"type:varchar[]" json:"reasons"`
+	Status      string         `gorm:"type:varchar(20);default:'pending'" json:"status"`
+	SurvivorID  *uint64        `gorm:"null" json:"survivor_id"`
+	ReviewedBy  *uint          `gorm:"null" json:"reviewed_by"`
+	ReviewedAt  *time.Time     `gorm:"null" json:"reviewed_at"`
+
+	Customer  Customer `gorm:"foreignKey:CustomerID" json:"customer"`
+	Duplicate Customer `gorm:"foreignKey:DuplicateID" json:"duplicate"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
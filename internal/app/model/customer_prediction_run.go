@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// CustomerPredictionRun stores the raw model scores of every prediction made
+// for a customer, so re-scores stay visible after customer_products is
+// overwritten.
+type CustomerPredictionRun struct {
+	ID          uint64    `gorm:"primaryKey" json:"id"`
+	CustomerID  uint64    `gorm:"not null" json:"customer_id"`
+	Source      string    `gorm:"type:varchar(30);not null" json:"source"`
+	TriggeredBy *uint     `gorm:"null" json:"triggered_by"`
+	Scores      string    `gorm:"type:jsonb;not null" json:"scores"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+const (
+	DuplicateScanTriggerScheduled = "scheduled"
+	DuplicateScanTriggerManual    = "manual"
+
+	DuplicateScanStatusRunning   = "running"
+	DuplicateScanStatusSucceeded = "succeeded"
+	DuplicateScanStatusFailed    = "failed"
+)
+
+// DuplicateScanRun records one pass of the duplicate detector over the
+// customers.
+type DuplicateScanRun struct {
+	ID          uint64     `gorm:"primaryKey" json:"id"`
+	Trigger     string     `gorm:"type:varchar(20);not null" json:"trigger"`
+	TriggeredBy *uint      `gorm:"null" json:"triggered_by"`
+	Status      string     `gorm:"type:varchar(20);not null" json:"status"`
+	Scanned     int        `json:"scanned"`
+	Candidates  int        `json:"candidates"`
+	Saved       int64      `json:"saved"`
+	Error       *string    `gorm:"type:text" json:"error"`
+	StartedAt   time.Time  `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at"`
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// FollowUpReminder is created by the follow-up job when a scheduled follow-up
+// falls due.
+type FollowUpReminder struct {
+	ID                  uint64     `gorm:"primaryKey" json:"id"`
+	MarketingCustomerID uint       `gorm:"not null" json:"marketing_customer_id"`
+	MarketingID         uint       `gorm:"not null" json:"marketing_id"`
+	DueAt               time.Time  `gorm:"not null" json:"due_at"`
+	Reason              string     `gorm:"type:varchar(255)" json:"reason"`
+	ReadAt              *time.Time `gorm:"null" json:"read_at"`
+	CreatedAt           time.Time  `json:"created_at"`
+}
@@ -0,0 +1,84 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	IncentivePendingApproval = "pending_approval"
+	IncentiveApproved        = "approved"
+	IncentiveRejected        = "rejected"
+
+	IncentiveLineTier       = "tier"
+	IncentiveLineClosingFee = "closing_fee"
+	IncentiveLineCap        = "cap"
+)
+
+// IncentiveScheme sets how marketers are paid for one product in the months
+// from EffectiveFrom to EffectiveTo, both stored as the first day of the
+// month. An open-ended scheme has no EffectiveTo.
+type IncentiveScheme struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	ProductID     uint       `gorm:"not null" json:"product_id"`
+	Name          string     `gorm:"type:varchar(100);not null" json:"name"`
+	EffectiveFrom time.Time  `gorm:"type:date;not null" json:"effective_from"`
+	EffectiveTo   *time.Time `gorm:"type:date" json:"effective_to"`
+	PerClosingFee int64      `gorm:"not null;default:0" json:"per_closing_fee"`
+	CapAmount     *int64     `gorm:"null" json:"cap_amount"`
+	CreatedBy     *uint      `gorm:"null" json:"created_by"`
+
+	Product *Product              `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Tiers   []IncentiveSchemeTier `gorm:"foreignKey:SchemeID" json:"tiers"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// IncentiveSchemeTier pays FixedAmount plus RatePercent of the achieved
+// amount once attainment reaches MinAttainment percent. Only the highest
+// tier reached pays.
+type IncentiveSchemeTier struct {
+	ID            uint    `gorm:"primaryKey" json:"id"`
+	SchemeID      uint    `gorm:"not null" json:"scheme_id"`
+	MinAttainment float64 `gorm:"type:numeric(6,2);not null" json:"min_attainment"`
+	FixedAmount   int64   `gorm:"not null;default:0" json:"fixed_amount"`
+	RatePercent   float64 `gorm:"type:numeric(6,3);not null;default:0" json:"rate_percent"`
+}
+
+// IncentiveStatement is a marketer's incentive for one month. It is
+// recalculated until a BM approves it.
+type IncentiveStatement struct {
+	ID             uint64     `gorm:"primaryKey" json:"id"`
+	MarketingID    uint       `gorm:"not null" json:"marketing_id"`
+	KantorCabangID *uint      `gorm:"null" json:"kantor_cabang_id"`
+	Tahun          int        `gorm:"not null" json:"tahun"`
+	Bulan          int        `gorm:"not null" json:"bulan"`
+	TotalAmount    int64      `gorm:"not null" json:"total_amount"`
+	Status         string     `gorm:"type:varchar(20);not null" json:"status"`
+	CalculatedAt   time.Time  `gorm:"not null" json:"calculated_at"`
+	ReviewedBy     *uint      `gorm:"null" json:"reviewed_by"`
+	ReviewedAt     *time.Time `gorm:"null" json:"reviewed_at"`
+	ReviewNote     *string    `gorm:"type:text" json:"review_note"`
+
+	Marketing *User                    `gorm:"foreignKey:MarketingID" json:"marketing,omitempty"`
+	Lines     []IncentiveStatementLine `gorm:"foreignKey:StatementID" json:"lines,omitempty"`
+}
+
+// IncentiveStatementLine justifies part of a statement: the tier reached on
+// a product, the fee for one closing, or the deduction down to the cap.
+type IncentiveStatementLine struct {
+	ID             uint64  `gorm:"primaryKey" json:"id"`
+	StatementID    uint64  `gorm:"not null" json:"statement_id"`
+	SchemeID       *uint   `gorm:"null" json:"scheme_id"`
+	ProductID      *uint   `gorm:"null" json:"product_id"`
+	LineType       string  `gorm:"type:varchar(20);not null" json:"line_type"`
+	Description    string  `gorm:"type:text;not null" json:"description"`
+	ClosingID      *uint64 `gorm:"null" json:"closing_id"`
+	TargetAmount   int64   `gorm:"not null" json:"target_amount"`
+	AchievedAmount int64   `gorm:"not null" json:"achieved_amount"`
+	Attainment     float64 `gorm:"type:numeric(8,2);not null" json:"attainment"`
+	Amount         int64   `gorm:"not null" json:"amount"`
+}
@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// Activity types a marketer can log against a lead.
+const (
+	ActivityTypeCall     = "call"
+	ActivityTypeVisit    = "visit"
+	ActivityTypeWhatsApp = "whatsapp"
+	ActivityTypeEmail    = "email"
+)
+
+// ActivityTypes lists every activity type in display order.
+var ActivityTypes = []string{ActivityTypeCall, ActivityTypeVisit, ActivityTypeWhatsApp, ActivityTypeEmail}
+
+// ActivityChannels maps each activity type to the contact channel the
+// customer must have consented to.
+var ActivityChannels = map[string]string{
+	ActivityTypeCall:     ConsentChannelPhone,
+	ActivityTypeVisit:    ConsentChannelVisit,
+	ActivityTypeWhatsApp: ConsentChannelWhatsApp,
+	ActivityTypeEmail:    ConsentChannelEmail,
+}
+
+// LeadActivity is one contact attempt a marketer made on a lead.
+type LeadActivity struct {
+	ID                  uint64    `gorm:"primaryKey" json:"id"`
+	MarketingCustomerID uint      `gorm:"not null" json:"marketing_customer_id"`
+	MarketingID         uint      `gorm:"not null" json:"marketing_id"`
+	ActivityType        string    `gorm:"type:varchar(20);not null" json:"activity_type"`
+	Outcome             string    `gorm:"type:varchar(30);not null" json:"outcome"`
+	DurationMinutes     *int      `gorm:"null" json:"duration_minutes"`
+	NextStep            string    `gorm:"type:text" json:"next_step"`
+	Notes               string    `gorm:"type:text" json:"notes"`
+	Latitude            *float64  `gorm:"type:numeric(9,6)" json:"latitude"`
+	Longitude           *float64  `gorm:"type:numeric(9,6)" json:"longitude"`
+	LocationName        string    `gorm:"type:varchar(255)" json:"location_name"`
+	OccurredAt          time.Time `gorm:"not null" json:"occurred_at"`
+	CreatedAt           time.Time `json:"created_at"`
+}
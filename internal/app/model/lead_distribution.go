@@ -0,0 +1,75 @@
+package model
+
+import "time"
+
+// Distribution strategies a branch can choose from.
+const (
+	DistributionRoundRobin     = "round_robin"
+	DistributionLeastOpenLeads = "least_open_leads"
+	DistributionCapacity       = "capacity"
+	DistributionTargetGap      = "target_gap"
+
+	DistributionTriggerScheduled = "scheduled"
+	DistributionTriggerManual    = "manual"
+
+	DistributionStatusRunning   = "running"
+	DistributionStatusSucceeded = "succeeded"
+	DistributionStatusFailed    = "failed"
+
+	DistributionOutcomeAssigned   = "assigned"
+	DistributionOutcomeNoCapacity = "no_capacity"
+)
+
+// LeadDistributionSetting is the distribution configuration of one branch.
+// DefaultCapacity caps the open leads of each marketer unless a
+// LeadDistributionCap overrides it; nil means no cap.
+type LeadDistributionSetting struct {
+	KantorCabangID  uint      `gorm:"primaryKey;autoIncrement:false" json:"kantor_cabang_id"`
+	Enabled         bool      `gorm:"not null" json:"enabled"`
+	Strategy        string    `gorm:"type:varchar(30);not null" json:"strategy"`
+	DefaultCapacity *int      `gorm:"null" json:"default_capacity"`
+	UpdatedBy       *uint     `gorm:"null" json:"updated_by"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+type LeadDistributionCap struct {
+	MarketingID  uint      `gorm:"primaryKey;autoIncrement:false" json:"marketing_id"`
+	MaxOpenLeads int       `gorm:"not null" json:"max_open_leads"`
+	UpdatedBy    *uint     `gorm:"null" json:"updated_by"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// LeadDistributionRun is one pass of the distribution engine over the
+// unassigned pool, or a dry-run preview of it.
+type LeadDistributionRun struct {
+	ID             uint64     `gorm:"primaryKey" json:"id"`
+	Trigger        string     `gorm:"type:varchar(20);not null" json:"trigger"`
+	TriggeredBy    *uint      `gorm:"null" json:"triggered_by"`
+	DryRun         bool       `gorm:"not null" json:"dry_run"`
+	KantorCabangID *uint      `gorm:"null" json:"kantor_cabang_id"`
+	Status         string     `gorm:"type:varchar(20);not null" json:"status"`
+	Assigned       int        `json:"assigned"`
+	Skipped        int        `json:"skipped"`
+	Error          *string    `gorm:"type:text" json:"error"`
+	StartedAt      time.Time  `json:"started_at"`
+	FinishedAt     *time.Time `json:"finished_at"`
+
+	Decisions []LeadDistributionDecision `gorm:"foreignKey:RunID" json:"decisions,omitempty"`
+}
+
+// LeadDistributionDecision records why a customer went to a marketer, or why
+// it could not be placed. Detail holds the candidates and their scores.
+type LeadDistributionDecision struct {
+	ID                  uint64    `gorm:"primaryKey" json:"id"`
+	RunID               uint64    `gorm:"not null" json:"run_id"`
+	CustomerID          uint64    `gorm:"not null" json:"customer_id"`
+	KantorCabangID      *uint     `gorm:"null" json:"kantor_cabang_id"`
+	MarketingID         *uint     `gorm:"null" json:"marketing_id"`
+	MarketingCustomerID *uint     `gorm:"null" json:"marketing_customer_id"`
+	Strategy            string    `gorm:"type:varchar(30)" json:"strategy"`
+	Outcome             string    `gorm:"type:varchar(20);not null" json:"outcome"`
+	ProductID           *uint     `gorm:"null" json:"product_id"`
+	Detail              string    `gorm:"type:jsonb" json:"detail"`
+	CreatedAt           time.Time `json:"created_at"`
+}
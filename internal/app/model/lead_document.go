@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Document types a marketer can attach to a lead.
+const (
+	DocumentTypeKTP               = "ktp"
+	DocumentTypeSlipGaji          = "slip_gaji"
+	DocumentTypeSignedApplication = "signed_application"
+	DocumentTypeOther             = "other"
+)
+
+// LeadDocument is a file attached to a lead. The content is kept in the
+// configured storage under StorageKey.
+type LeadDocument struct {
+	ID                  uint64         `gorm:"primaryKey" json:"id"`
+	MarketingCustomerID uint           `gorm:"not null" json:"marketing_customer_id"`
+	CustomerID          uint64         `gorm:"not null" json:"customer_id"`
+	DocumentType        string         `gorm:"type:varchar(30);not null" json:"document_type"`
+	FileName            string         `gorm:"type:varchar(255);not null" json:"file_name"`
+	ContentType         string         `gorm:"type:varchar(100);not null" json:"content_type"`
+	SizeBytes           int64          `gorm:"not null" json:"size_bytes"`
+	ChecksumSHA256      string         `gorm:"column:checksum_sha256;type:char(64);not null" json:"checksum_sha256"`
+	StorageKey          string         `gorm:"type:varchar(255);not null;unique" json:"-"`
+	UploadedBy          uint           `gorm:"not null" json:"uploaded_by"`
+	CreatedAt           time.Time      `json:"created_at"`
+	DeletedAt           gorm.DeletedAt `gorm:"index" json:"-"`
+}
@@ -22,6 +22,19 @@ type MarketingCustomer struct {
 	ProductID   *uint  `gorm:"null" json:"product_id"`
 	Amount      *int64 `gorm:"null" json:"amount"`
 	Notes       string `gorm:"type:text" json:"notes"`
+	AssignedBy  *uint  `gorm:"null" json:"assigned_by"`
+	// TransferredFromID points to the lead row this one continues after a
+	// transfer between marketers.
+	TransferredFromID *uint `gorm:"null" json:"transferred_from_id"`
+
+	StatusChangedAt *time.Time `gorm:"null" json:"status_changed_at"`
+	ReservedUntil   *time.Time `gorm:"null" json:"reserved_until"`
+
+	RejectionReasonID *uint `gorm:"null" json:"rejection_reason_id"`
+
+	NextFollowUpAt    *time.Time `gorm:"null" json:"next_follow_up_at"`
+	FollowUpReason    string     `gorm:"type:varchar(255)" json:"follow_up_reason"`
+	FollowUpOverdueAt *time.Time `gorm:"null" json:"follow_up_overdue_at"`
 
 	Customer  Customer `gorm:"foreignKey:CustomerID" json:"customer"`
 	Marketing User     `gorm:"foreignKey:MarketingID" json:"marketing"`
@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+const (
+	LeadEventAssigned      = "assigned"
+	LeadEventStatusChanged = "status_changed"
+	LeadEventReleased      = "released"
+	LeadEventTransferOut   = "transferred_out"
+	LeadEventTransferIn    = "transferred_in"
+	LeadEventClosingAdded  = "closing_added"
+	LeadEventClosingVoided = "closing_voided"
+)
+
+// MarketingCustomerEvent is one append-only entry in the history of a lead.
+type MarketingCustomerEvent struct {
+	ID                  uint64    `gorm:"primaryKey" json:"id"`
+	MarketingCustomerID uint      `gorm:"not null" json:"marketing_customer_id"`
+	EventType           string    `gorm:"type:varchar(30);not null" json:"event_type"`
+	OldStatus           *string   `gorm:"type:varchar(20)" json:"old_status"`
+	NewStatus           string    `gorm:"type:varchar(20);not null" json:"new_status"`
+	ActorID             *uint     `gorm:"null" json:"actor_id"`
+	Note                string    `gorm:"type:text" json:"note"`
+	ProductID           *uint     `gorm:"null" json:"product_id"`
+	Amount              *int64    `gorm:"null" json:"amount"`
+	Channel             *string   `gorm:"type:varchar(20)" json:"channel"`
+	RejectionReasonID   *uint     `gorm:"null" json:"rejection_reason_id"`
+	CreatedAt           time.Time `json:"created_at"`
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// PIIAccessLog records every response in which a customer's contact data was
+// shown unmasked. ViewerID and CustomerID carry no foreign keys so the log
+// survives the purge of the customer and the deletion of the viewer.
+type PIIAccessLog struct {
+	ID         uint64    `gorm:"primaryKey" json:"id"`
+	ViewerID   uint      `gorm:"not null" json:"viewer_id"`
+	ViewerRole string    `gorm:"type:varchar(20);not null" json:"viewer_role"`
+	CustomerID uint64    `gorm:"not null" json:"customer_id"`
+	Endpoint   string    `gorm:"type:varchar(100);not null" json:"endpoint"`
+	Reason     string    `gorm:"type:varchar(50);not null" json:"reason"`
+	CreatedAt  time.Time `json:"created_at"`
+}
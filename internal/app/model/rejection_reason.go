@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// RejectionReason is one entry of the managed taxonomy marketers pick from
+// when a lead is rejected. Reasons are deactivated rather than deleted so
+// past rejections keep their meaning.
+type RejectionReason struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Code      string    `gorm:"type:varchar(50);not null;unique" json:"code"`
+	Label     string    `gorm:"type:varchar(100);not null" json:"label"`
+	Active    bool      `gorm:"not null;default:true" json:"active"`
+	SortOrder int       `gorm:"not null;default:0" json:"sort_order"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
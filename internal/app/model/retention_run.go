@@ -0,0 +1,48 @@
+package model
+
+import "time"
+
+const (
+	RetentionTriggerScheduled = "scheduled"
+	RetentionTriggerManual    = "manual"
+
+	RetentionStatusRunning   = "running"
+	RetentionStatusSucceeded = "succeeded"
+	RetentionStatusFailed    = "failed"
+
+	RetentionActionAnonymize = "anonymize"
+	RetentionActionPurge     = "purge"
+
+	RetentionReasonRejected = "rejected"
+	RetentionReasonProspect = "stale_prospect"
+	RetentionReasonDeleted  = "deleted"
+)
+
+// RetentionRun is the audit report of one execution of the retention
+// policies.
+type RetentionRun struct {
+	ID                  uint64     `gorm:"primaryKey" json:"id"`
+	Trigger             string     `gorm:"type:varchar(20);not null" json:"trigger"`
+	TriggeredBy         *uint      `gorm:"null" json:"triggered_by"`
+	DryRun              bool       `gorm:"not null" json:"dry_run"`
+	Status              string     `gorm:"type:varchar(20);not null" json:"status"`
+	Policy              string     `gorm:"type:jsonb;not null" json:"policy"`
+	AnonymizedRejected  int        `json:"anonymized_rejected"`
+	AnonymizedProspects int        `json:"anonymized_prospects"`
+	AnonymizedDeleted   int        `json:"anonymized_deleted"`
+	Purged              int        `json:"purged"`
+	Error               *string    `gorm:"type:text" json:"error"`
+	StartedAt           time.Time  `json:"started_at"`
+	FinishedAt          *time.Time `json:"finished_at"`
+
+	Items []RetentionRunItem `gorm:"foreignKey:RunID" json:"items,omitempty"`
+}
+
+type RetentionRunItem struct {
+	ID         uint64    `gorm:"primaryKey" json:"id"`
+	RunID      uint64    `gorm:"not null" json:"run_id"`
+	CustomerID uint64    `gorm:"not null" json:"customer_id"`
+	Action     string    `gorm:"type:varchar(30);not null" json:"action"`
+	Reason     string    `gorm:"type:varchar(30);not null" json:"reason"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// Factors the marketers' worklist ranks customers on.
+const (
+	WorklistFactorScore     = "score"
+	WorklistFactorPlafond   = "plafond"
+	WorklistFactorFollowUp  = "follow_up"
+	WorklistFactorTargetGap = "target_gap"
+)
+
+// WorklistSetting holds a branch's weights for ranking the worklist. The
+// weights are relative to each other; only their proportions matter.
+type WorklistSetting struct {
+	KantorCabangID  uint      `gorm:"primaryKey;autoIncrement:false" json:"kantor_cabang_id"`
+	WeightScore     int       `gorm:"not null" json:"weight_score"`
+	WeightPlafond   int       `gorm:"not null" json:"weight_plafond"`
+	WeightFollowUp  int       `gorm:"not null" json:"weight_follow_up"`
+	WeightTargetGap int       `gorm:"not null" json:"weight_target_gap"`
+	UpdatedBy       *uint     `gorm:"null" json:"updated_by"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"fmt"
+	"math"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/model"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type ActivityRepository interface {
+	CreateWithTx(tx *gorm.DB, activity *model.LeadActivity) error
+	GetByLead(cif string, scope dto.LeadEventScope) ([]dto.LeadActivityResponse, error)
+	GetByMarketing(marketingID uint, req *dto.ActivityListRequest, from, to time.Time) ([]dto.LeadActivityResponse, *dto.Pagination, error)
+	GetSummaries(nips []string, from, to time.Time) (map[string]dto.ActivitySummary, error)
+}
+
+type activityRepository struct {
+	db  *gorm.DB
+	log *zap.Logger
+}
+
+func NewActivityRepository(db *gorm.DB, log *zap.Logger) ActivityRepository {
+	return &activityRepository{db: db, log: log}
+}
+
+func (r *activityRepository) CreateWithTx(tx *gorm.DB, activity *model.LeadActivity) error {
+	return tx.Create(activity).Error
+}
+
+func (r *activityRepository) baseQuery() *gorm.DB {
+	return r.db.Table("lead_activities la").
+		Select(`la.id, la.marketing_customer_id, c.cif, c.nama AS customer_name,
+			la.marketing_id, m.nip AS marketing_nip, m.nama AS marketing_name,
+			la.activity_type, la.outcome, la.duration_minutes, COALESCE(la.next_step, '') AS next_step,
+			COALESCE(la.notes, '') AS notes, la.latitude, la.longitude, COALESCE(la.location_name, '') AS location_name,
+			la.occurred_at, la.created_at`).
+		Joins("JOIN marketing_customers mc ON mc.id = la.marketing_customer_id").
+		Joins("JOIN customers c ON c.id = mc.customer_id").
+		Joins("JOIN users m ON m.id = la.marketing_id")
+}
+
+func (r *activityRepository) GetByLead(cif string, scope dto.LeadEventScope) ([]dto.LeadActivityResponse, error) {
+	var activities []dto.LeadActivityResponse
+
+	query := r.baseQuery().Where("c.cif = ?", cif)
+	if scope.MarketingID != nil {
+		query = query.Where("la.marketing_id = ?", *scope.MarketingID)
+	}
+	if scope.KantorCabangID != nil {
+		query = query.Where("m.kantor_cabang_id = ?", *scope.KantorCabangID)
+	}
+
+	if err := query.Order("la.occurred_at DESC, la.id DESC").Scan(&activities).Error; err != nil {
+		return nil, fmt.Errorf("error getting lead activities: %v", err)
+	}
+	return activities, nil
+}
+
+func (r *activityRepository) GetByMarketing(marketingID uint, req *dto.ActivityListRequest, from, to time.Time) ([]dto.LeadActivityResponse, *dto.Pagination, error) {
+	var activities []dto.LeadActivityResponse
+	var count int64
+
+	query := r.baseQuery().
+		Where("la.marketing_id = ? AND la.occurred_at >= ? AND la.occurred_at < ?", marketingID, from, to)
+	if req.Type != "" {
+		query = query.Where("la.activity_type = ?", req.Type)
+	}
+
+	if err := query.Session(&gorm.Session{}).Count(&count).Error; err != nil {
+		return nil, nil, fmt.Errorf("error counting activities: %v", err)
+	}
+	if err := query.Order("la.occurred_at DESC, la.id DESC").
+		Offset((req.Page - 1) * req.Limit).
+		Limit(req.Limit).
+		Scan(&activities).Error; err != nil {
+		return nil, nil, fmt.Errorf("error getting activities: %v", err)
+	}
+
+	meta := &dto.Pagination{
+		CurrentPage: req.Page,
+		PerPage:     req.Limit,
+		TotalItems:  count,
+		TotalPages:  int64(math.Ceil(float64(count) / float64(req.Limit))),
+	}
+	return activities, meta, nil
+}
+
+// GetSummaries counts the activities of each marketer, keyed by NIP, that
+// occurred in [from, to). Marketers without activities get an empty summary.
+func (r *activityRepository) GetSummaries(nips []string, from, to time.Time) (map[string]dto.ActivitySummary, error) {
+	summaries := make(map[string]dto.ActivitySummary, len(nips))
+	for _, nip := range nips {
+		summaries[nip] = dto.ActivitySummary{ByType: map[string]int64{}}
+	}
+	if len(nips) == 0 {
+		return summaries, nil
+	}
+
+	var rows []struct {
+		NIP          string `gorm:"column:nip"`
+		ActivityType string `gorm:"column:activity_type"`
+		Total        int64  `gorm:"column:total"`
+		Leads        int64  `gorm:"column:leads"`
+	}
+	// GROUPING SETS adds one row per marketer (activity_type NULL) carrying the
+	// distinct lead count across all types.
+	if err := r.db.Table("lead_activities la").
+		Select("u.nip, COALESCE(la.activity_type, '') AS activity_type, COUNT(*) AS total, COUNT(DISTINCT la.marketing_customer_id) AS leads").
+		Joins("JOIN users u ON u.id = la.marketing_id").
+		Where("u.nip IN ? AND la.occurred_at >= ? AND la.occurred_at < ?", nips, from, to).
+		Group("GROUPING SETS ((u.nip, la.activity_type), (u.nip))").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("error counting activities: %v", err)
+	}
+
+	for _, row := range rows {
+		summary := summaries[row.NIP]
+		if row.ActivityType == "" {
+			summary.Total = row.Total
+			summary.Leads = row.Leads
+		} else {
+			summary.ByType[row.ActivityType] = row.Total
+		}
+		summaries[row.NIP] = summary
+	}
+	return summaries, nil
+}
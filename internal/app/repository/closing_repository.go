@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"fmt"
+	"math"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/model"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ClosingRepository interface {
+	CreateWithTx(tx *gorm.DB, closing *model.Closing) error
+	AccountBookedWithTx(tx *gorm.DB, productID uint, accountReference string) (bool, error)
+	FindByIDWithTx(tx *gorm.DB, id uint64) (*model.Closing, error)
+	VoidWithTx(tx *gorm.DB, closing *model.Closing) error
+	ReviewWithTx(tx *gorm.DB, closing *model.Closing) error
+	GetByLead(cif string, scope dto.LeadEventScope) ([]dto.ClosingResponse, error)
+	GetPending(kantorCabangID uint, marketingID *uint, page, limit int) ([]dto.ClosingResponse, *dto.Pagination, int64, error)
+	IsPeriodLockedWithTx(tx *gorm.DB, year, month int) (bool, error)
+	GetPeriodLocks(year int) ([]model.ClosingPeriod, error)
+	LockPeriod(period *model.ClosingPeriod) (bool, error)
+	UnlockPeriod(year, month int) (bool, error)
+}
+
+type closingRepository struct {
+	db  *gorm.DB
+	log *zap.Logger
+}
+
+func NewClosingRepository(db *gorm.DB, log *zap.Logger) ClosingRepository {
+	return &closingRepository{db: db, log: log}
+}
+
+func (r *closingRepository) CreateWithTx(tx *gorm.DB, closing *model.Closing) error {
+	if err := tx.Omit(clause.Associations).Create(closing).Error; err != nil {
+		return fmt.Errorf("Gagal menyimpan closing: %v", err)
+	}
+	return nil
+}
+
+// AccountBookedWithTx reports whether the account reference is already
+// booked for the product by a closing that was not rejected.
+func (r *closingRepository) AccountBookedWithTx(tx *gorm.DB, productID uint, accountReference string) (bool, error) {
+	var count int64
+	if err := tx.Model(&model.Closing{}).
+		Where("product_id = ? AND account_reference = ? AND verification_status <> ?", productID, accountReference, model.ClosingRejected).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("error checking account reference: %v", err)
+	}
+	return count > 0, nil
+}
+
+// FindByIDWithTx locks the closing so it cannot be voided or reviewed twice.
+// The marketer is loaded even after resigning so their closings can still be
+// reviewed.
+func (r *closingRepository) FindByIDWithTx(tx *gorm.DB, id uint64) (*model.Closing, error) {
+	var closing model.Closing
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Marketing", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("id = ?", id).
+		First(&closing).Error; err != nil {
+		return nil, err
+	}
+	return &closing, nil
+}
+
+func (r *closingRepository) VoidWithTx(tx *gorm.DB, closing *model.Closing) error {
+	if err := tx.Delete(closing).Error; err != nil {
+		return fmt.Errorf("Gagal membatalkan closing: %v", err)
+	}
+	return nil
+}
+
+// ReviewWithTx stores the BM's verification decision.
+func (r *closingRepository) ReviewWithTx(tx *gorm.DB, closing *model.Closing) error {
+	if err := tx.Model(closing).UpdateColumns(map[string]interface{}{
+		"verification_status": closing.VerificationStatus,
+		"verified_by":         closing.VerifiedBy,
+		"verified_at":         closing.VerifiedAt,
+		"verification_note":   closing.VerificationNote,
+		"updated_at":          time.Now(),
+	}).Error; err != nil {
+		return fmt.Errorf("Gagal menyimpan verifikasi closing: %v", err)
+	}
+	return nil
+}
+
+func (r *closingRepository) baseQuery() *gorm.DB {
+	return r.db.Table("closings cl").
+		Select(`cl.id, cl.marketing_customer_id, c.cif, m.nip AS marketing_nip, m.nama AS marketing_name,
+			cl.product_id, p.nama AS product_name, cl.amount, cl.tenor_months, cl.closed_at,
+			cl.account_reference, COALESCE(cl.notes, '') AS notes,
+			cl.verification_status, cl.evidence_reference, v.nama AS verified_by_name, cl.verified_at,
+			COALESCE(cl.verification_note, '') AS verification_note, cl.created_at`).
+		Joins("JOIN customers c ON c.id = cl.customer_id").
+		Joins("JOIN users m ON m.id = cl.marketing_id").
+		Joins("JOIN products p ON p.id = cl.product_id").
+		Joins("LEFT JOIN users v ON v.id = cl.verified_by").
+		Where("cl.deleted_at IS NULL")
+}
+
+// GetByLead lists the closings booked on any lead of the customer, limited to
+// the given scope.
+func (r *closingRepository) GetByLead(cif string, scope dto.LeadEventScope) ([]dto.ClosingResponse, error) {
+	var closings []dto.ClosingResponse
+
+	query := r.baseQuery().Where("c.cif = ?", cif)
+	if scope.MarketingID != nil {
+		query = query.Where("cl.marketing_id = ?", *scope.MarketingID)
+	}
+	if scope.KantorCabangID != nil {
+		query = query.Where("m.kantor_cabang_id = ?", *scope.KantorCabangID)
+	}
+
+	if err := query.Order("cl.closed_at DESC, cl.id DESC").Scan(&closings).Error; err != nil {
+		return nil, fmt.Errorf("error getting lead closings: %v", err)
+	}
+	return closings, nil
+}
+
+// GetPending lists the closings of the branch awaiting verification, oldest
+// first, together with their total amount.
+func (r *closingRepository) GetPending(kantorCabangID uint, marketingID *uint, page, limit int) ([]dto.ClosingResponse, *dto.Pagination, int64, error) {
+	var closings []dto.ClosingResponse
+	var summary struct {
+		Count  int64 `gorm:"column:count"`
+		Amount int64 `gorm:"column:amount"`
+	}
+
+	query := r.baseQuery().
+		Where("m.kantor_cabang_id = ? AND cl.verification_status = ?", kantorCabangID, model.ClosingPendingVerification)
+	if marketingID != nil {
+		query = query.Where("cl.marketing_id = ?", *marketingID)
+	}
+
+	if err := query.Session(&gorm.Session{}).
+		Select("COUNT(*) AS count, COALESCE(SUM(cl.amount), 0) AS amount").
+		Scan(&summary).Error; err != nil {
+		return nil, nil, 0, fmt.Errorf("error counting pending closings: %v", err)
+	}
+	if err := query.Order("cl.created_at ASC, cl.id ASC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Scan(&closings).Error; err != nil {
+		return nil, nil, 0, fmt.Errorf("error getting pending closings: %v", err)
+	}
+
+	meta := &dto.Pagination{
+		CurrentPage: page,
+		PerPage:     limit,
+		TotalItems:  summary.Count,
+		TotalPages:  int64(math.Ceil(float64(summary.Count) / float64(limit))),
+	}
+	return closings, meta, summary.Amount, nil
+}
+
+func (r *closingRepository) IsPeriodLockedWithTx(tx *gorm.DB, year, month int) (bool, error) {
+	var count int64
+	if err := tx.Model(&model.ClosingPeriod{}).
+		Where("tahun = ? AND bulan = ?", year, month).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("error checking closing period: %v", err)
+	}
+	return count > 0, nil
+}
+
+func (r *closingRepository) GetPeriodLocks(year int) ([]model.ClosingPeriod, error) {
+	var periods []model.ClosingPeriod
+	if err := r.db.Where("tahun = ?", year).Order("bulan ASC").Find(&periods).Error; err != nil {
+		return nil, fmt.Errorf("error getting closing periods: %v", err)
+	}
+	return periods, nil
+}
+
+// LockPeriod locks the month and reports false when it already was.
+func (r *closingRepository) LockPeriod(period *model.ClosingPeriod) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(period)
+	if result.Error != nil {
+		return false, fmt.Errorf("Gagal mengunci periode: %v", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// UnlockPeriod reopens the month and reports false when it was not locked.
+func (r *closingRepository) UnlockPeriod(year, month int) (bool, error) {
+	result := r.db.Where("tahun = ? AND bulan = ?", year, month).Delete(&model.ClosingPeriod{})
+	if result.Error != nil {
+		return false, fmt.Errorf("Gagal membuka periode: %v", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
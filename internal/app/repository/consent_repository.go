@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"fmt"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/model"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type ConsentRepository interface {
+	FindCustomerByCIF(cif string) (*model.Customer, error)
+	Create(records []model.CustomerConsent) error
+	GetHistory(customerID uint64) ([]model.CustomerConsent, error)
+	GetEffective(customerIDs []uint64, scope string) (map[uint64][]dto.ConsentStatus, error)
+}
+
+type consentRepository struct {
+	db  *gorm.DB
+	log *zap.Logger
+}
+
+func NewConsentRepository(db *gorm.DB, log *zap.Logger) ConsentRepository {
+	return &consentRepository{db: db, log: log}
+}
+
+func (r *consentRepository) FindCustomerByCIF(cif string) (*model.Customer, error) {
+	var customer model.Customer
+	if err := r.db.Where("cif = ?", cif).First(&customer).Error; err != nil {
+		return nil, err
+	}
+	return &customer, nil
+}
+
+func (r *consentRepository) Create(records []model.CustomerConsent) error {
+	if len(records) == 0 {
+		return nil
+	}
+	if err := r.db.Create(&records).Error; err != nil {
+		return fmt.Errorf("error saving consent: %v", err)
+	}
+	return nil
+}
+
+func (r *consentRepository) GetHistory(customerID uint64) ([]model.CustomerConsent, error) {
+	var records []model.CustomerConsent
+	if err := r.db.Where("customer_id = ?", customerID).
+		Order("created_at DESC, id DESC").
+		Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("error getting consent history: %v", err)
+	}
+	return records, nil
+}
+
+func (r *consentRepository) GetEffective(customerIDs []uint64, scope string) (map[uint64][]dto.ConsentStatus, error) {
+	effective := make(map[uint64][]dto.ConsentStatus, len(customerIDs))
+	if len(customerIDs) == 0 {
+		return effective, nil
+	}
+
+	var statuses []dto.ConsentStatus
+	query := r.db.Table("customer_consent_status").Where("customer_id IN ?", customerIDs)
+	if scope != "" {
+		query = query.Where("scope = ?", scope)
+	}
+	if err := query.Order("customer_id, scope, channel").Scan(&statuses).Error; err != nil {
+		return nil, fmt.Errorf("error getting consent status: %v", err)
+	}
+	for _, status := range statuses {
+		effective[status.CustomerID] = append(effective[status.CustomerID], status)
+	}
+	return effective, nil
+}
+
+// contactableCondition matches customers with at least one channel they may
+// be contacted through, mirroring dto.AllowedChannels. Every listing or export
+// of leads for outreach must apply it.
+func contactableCondition(alias string, filter *dto.ConsentFilter) (string, []interface{}) {
+	if filter.RequireExplicit {
+		return fmt.Sprintf(`EXISTS (SELECT 1 FROM customer_consent_status cs
+			WHERE cs.customer_id = %s.id AND cs.scope = ? AND cs.granted)`, alias),
+			[]interface{}{filter.Scope}
+	}
+	return fmt.Sprintf(`(SELECT COUNT(*) FROM customer_consent_status cs
+		WHERE cs.customer_id = %s.id AND cs.scope = ? AND NOT cs.granted) < ?`, alias),
+		[]interface{}{filter.Scope, len(model.ConsentChannels)}
+}
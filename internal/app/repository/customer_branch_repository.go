@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"fmt"
+	"ml-prediction/internal/app/model"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type CustomerBranchRepository interface {
+	CreateRule(rule *model.CustomerBranchRule) error
+	RuleExists(matchField, pattern string) (bool, error)
+	GetRules() ([]model.CustomerBranchRule, error)
+	FindRule(id uint) (*model.CustomerBranchRule, error)
+	DeleteRule(rule *model.CustomerBranchRule) error
+	FindCustomerByCIF(cif string) (*model.Customer, error)
+	FindUnrouted(afterID uint64, limit int) ([]model.Customer, error)
+	SetBranch(customerID uint64, kantorCabangID uint) error
+}
+
+type customerBranchRepository struct {
+	db  *gorm.DB
+	log *zap.Logger
+}
+
+func NewCustomerBranchRepository(db *gorm.DB, log *zap.Logger) CustomerBranchRepository {
+	return &customerBranchRepository{db: db, log: log}
+}
+
+func (r *customerBranchRepository) CreateRule(rule *model.CustomerBranchRule) error {
+	if err := r.db.Create(rule).Error; err != nil {
+		return fmt.Errorf("error creating customer branch rule: %v", err)
+	}
+	return nil
+}
+
+func (r *customerBranchRepository) RuleExists(matchField, pattern string) (bool, error) {
+	var count int64
+	if err := r.db.Model(&model.CustomerBranchRule{}).
+		Where("match_field = ? AND LOWER(pattern) = LOWER(?)", matchField, pattern).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("error checking customer branch rule: %v", err)
+	}
+	return count > 0, nil
+}
+
+func (r *customerBranchRepository) GetRules() ([]model.CustomerBranchRule, error) {
+	var rules []model.CustomerBranchRule
+	if err := r.db.Preload("KantorCabang").
+		Order("match_field ASC, pattern ASC").
+		Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("error getting customer branch rules: %v", err)
+	}
+	return rules, nil
+}
+
+func (r *customerBranchRepository) FindRule(id uint) (*model.CustomerBranchRule, error) {
+	var rule model.CustomerBranchRule
+	if err := r.db.Where("id = ?", id).First(&rule).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *customerBranchRepository) DeleteRule(rule *model.CustomerBranchRule) error {
+	if err := r.db.Delete(rule).Error; err != nil {
+		return fmt.Errorf("error deleting customer branch rule: %v", err)
+	}
+	return nil
+}
+
+func (r *customerBranchRepository) FindCustomerByCIF(cif string) (*model.Customer, error) {
+	var customer model.Customer
+	if err := r.db.Where("cif = ? AND anonymized_at IS NULL", cif).First(&customer).Error; err != nil {
+		return nil, err
+	}
+	return &customer, nil
+}
+
+// FindUnrouted returns the next batch of customers without a branch after the
+// given id. The address is decrypted on load so rules can match it.
+func (r *customerBranchRepository) FindUnrouted(afterID uint64, limit int) ([]model.Customer, error) {
+	var customers []model.Customer
+	if err := r.db.
+		Where("id > ? AND kantor_cabang_id IS NULL AND anonymized_at IS NULL AND merged_into_id IS NULL", afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&customers).Error; err != nil {
+		return nil, fmt.Errorf("error finding unrouted customers: %v", err)
+	}
+	return customers, nil
+}
+
+// SetBranch moves a customer to a branch's pool. It writes the column
+// directly so the encrypted fields are not re-saved.
+func (r *customerBranchRepository) SetBranch(customerID uint64, kantorCabangID uint) error {
+	if err := r.db.Table("customers").
+		Where("id = ?", customerID).
+		Updates(map[string]interface{}{"kantor_cabang_id": kantorCabangID, "updated_at": time.Now()}).Error; err != nil {
+		return fmt.Errorf("error setting customer branch: %v", err)
+	}
+	return nil
+}
@@ -6,6 +6,8 @@ import (
 	"math"
 	dto "ml-prediction/internal/app/domain"
 	"ml-prediction/internal/app/model"
+	"ml-prediction/pkg/helper"
+	"ml-prediction/pkg/piicrypto"
 
 	"github.com/gofiber/fiber/v2"
 	"go.uber.org/zap"
@@ -18,7 +20,11 @@ type CustomerRepository interface {
 	ExistsByCif(c *fiber.Ctx, cif string) (bool, error)
 	GetAssignedCustomers(marketingID uint, req *dto.AssignedCustomerRequest) ([]dto.Customer, *dto.Pagination, error)
 	GetNewCustomers(req *dto.CustomerSearchRequest) ([]dto.Customer, *dto.Pagination, error)
-	GetCustomerDetail(marketingID uint, customerID string) (*dto.Customer, error)
+	GetNewCustomersCursor(req *dto.CustomerSearchRequest) ([]dto.Customer, *dto.CursorPagination, error)
+	GetAssignedCustomersCursor(marketingID uint, req *dto.AssignedCustomerRequest) ([]dto.Customer, *dto.CursorPagination, error)
+	GetCustomerDetail(marketingID uint, kantorCabangID *uint, customerID string) (*dto.Customer, error)
+	FindUnassigned(req *dto.CustomerSearchRequest, limit int) ([]dto.AssignableCustomer, error)
+	FindByCIFs(cifs []string) ([]dto.AssignableCustomer, error)
 }
 
 type customerRepository struct {
@@ -59,39 +65,90 @@ func (r *customerRepository) ExistsByCif(c *fiber.Ctx, cif string) (bool, error)
 	return count > 0, err
 }
 
-func (r *customerRepository) GetNewCustomers(req *dto.CustomerSearchRequest) ([]dto.Customer, *dto.Pagination, error) {
-	var customers []dto.Customer
-	var count int64
+// customerSearchCondition matches free text against the plaintext columns and,
+// since contact data is encrypted at rest, exact matches against the blind
+// indexes of phone, account number and email.
+func customerSearchCondition(search string) (string, []interface{}) {
+	like := "%" + search + "%"
+	return "cif ILIKE ? OR nama ILIKE ? OR array_to_string(produk_eksisting, ',') ILIKE ? OR job ILIKE ? " +
+			"OR c.nomor_hp_bidx = ? OR c.nomor_rekening_bidx = ? OR c.email_bidx = ?",
+		[]interface{}{
+			like, like, like, like,
+			piicrypto.BlindIndex(piicrypto.FieldPhone, search),
+			piicrypto.BlindIndex(piicrypto.FieldAccount, search),
+			piicrypto.BlindIndex(piicrypto.FieldEmail, search),
+		}
+}
 
+func (r *customerRepository) newCustomersQuery(req *dto.CustomerSearchRequest) *gorm.DB {
 	query := r.db.Table("customers c").
 		Select(`c.*`, `CASE WHEN mc.status IS NULL THEN 'new' ELSE mc.status END AS status`).
-		Joins("LEFT JOIN marketing_customers mc ON mc.customer_id = c.id")
+		Joins("LEFT JOIN marketing_customers mc ON mc.customer_id = c.id AND mc.deleted_at IS NULL")
 
 	if req.Search != "" {
 		switch req.SearchBy {
 		case "cif":
 			query = query.Where("cif LIKE ?", "%"+req.Search+"%")
 		case "nomor_hp":
-			query = query.Where("nomor_hp LIKE ?", "%"+req.Search+"%")
+			query = query.Where("c.nomor_hp_bidx = ?", piicrypto.BlindIndex(piicrypto.FieldPhone, req.Search))
 		case "nama":
 			query = query.Where("nama LIKE ?", "%"+req.Search+"%")
 		case "nomor_rekening":
-			query = query.Where("nomor_rekening LIKE ?", "%"+req.Search+"%")
+			query = query.Where("c.nomor_rekening_bidx = ?", piicrypto.BlindIndex(piicrypto.FieldAccount, req.Search))
+		case "email":
+			query = query.Where("c.email_bidx = ?", piicrypto.BlindIndex(piicrypto.FieldEmail, req.Search))
 		case "produk_eksisting":
 			query = query.Where("array_to_string(produk_eksisting, ',') ILIKE ?", "%"+req.Search+"%")
 		// case "product":
 		// 	query = query.Where("product LIKE ?", "%"+req.Search+"%")
 		default:
-			query = query.Where(
-				"cif ILIKE ? OR nomor_hp LIKE ? OR nama ILIKE ? OR nomor_rekening ILIKE ? OR array_to_string(produk_eksisting, ',')  ILIKE ?  OR email ILIKE ?  OR job ILIKE ?  OR address ILIKE ?",
-				"%"+req.Search+"%", "%"+req.Search+"%", "%"+req.Search+"%",
-				"%"+req.Search+"%", "%"+req.Search+"%", "%"+req.Search+"%",
-				"%"+req.Search+"%", "%"+req.Search+"%",
-			)
+			condition, args := customerSearchCondition(req.Search)
+			query = query.Where(condition, args...)
 		}
 	}
 
-	query = query.Where("status is NULL")
+	if req.Consent != nil {
+		condition, args := contactableCondition("c", req.Consent)
+		query = query.Where(condition, args...)
+	}
+
+	if req.KantorCabangID != nil {
+		query = query.Where("c.kantor_cabang_id = ?", *req.KantorCabangID)
+	}
+
+	return query.Where("mc.status IS NULL AND c.deleted_at IS NULL AND c.anonymized_at IS NULL")
+}
+
+// FindUnassigned returns the oldest customers of the unassigned pool that
+// match the search, limited to one branch's pool if the search names one.
+func (r *customerRepository) FindUnassigned(req *dto.CustomerSearchRequest, limit int) ([]dto.AssignableCustomer, error) {
+	var customers []dto.AssignableCustomer
+	if err := r.newCustomersQuery(req).
+		Select("c.id, c.cif, c.kantor_cabang_id").
+		Order("c.id ASC").
+		Limit(limit).
+		Scan(&customers).Error; err != nil {
+		return nil, fmt.Errorf("error finding unassigned customers: %v", err)
+	}
+	return customers, nil
+}
+
+func (r *customerRepository) FindByCIFs(cifs []string) ([]dto.AssignableCustomer, error) {
+	var customers []dto.AssignableCustomer
+	if err := r.db.Table("customers").
+		Select("id, cif, kantor_cabang_id").
+		Where("cif IN ? AND deleted_at IS NULL AND anonymized_at IS NULL", cifs).
+		Scan(&customers).Error; err != nil {
+		return nil, fmt.Errorf("error finding customers: %v", err)
+	}
+	return customers, nil
+}
+
+func (r *customerRepository) GetNewCustomers(req *dto.CustomerSearchRequest) ([]dto.Customer, *dto.Pagination, error) {
+	var customers []dto.Customer
+	var count int64
+
+	query := r.newCustomersQuery(req)
 
 	if err := query.Count(&count).Error; err != nil {
 		return nil, nil, fmt.Errorf("error counting customers: %v", err)
@@ -114,30 +171,78 @@ func (r *customerRepository) GetNewCustomers(req *dto.CustomerSearchRequest) ([]
 	return customers, meta, nil
 }
 
-func (r *customerRepository) GetAssignedCustomers(marketingID uint, req *dto.AssignedCustomerRequest) ([]dto.Customer, *dto.Pagination, error) {
+// customerCursor is the keyset position for the unassigned customer pool,
+// ordered by customer id ascending.
+type customerCursor struct {
+	ID uint64 `json:"id"`
+}
+
+func (r *customerRepository) GetNewCustomersCursor(req *dto.CustomerSearchRequest) ([]dto.Customer, *dto.CursorPagination, error) {
+	limit := normalizeCursorLimit(req.Limit)
+	query := r.newCustomersQuery(req)
+
+	total, estimated, err := countForCursor(r.db, query, req.Total)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if req.Cursor != "" {
+		var position customerCursor
+		if err := helper.DecodeCursor(req.Cursor, &position); err != nil {
+			return nil, nil, err
+		}
+		query = query.Where("c.id > ?", position.ID)
+	}
+
 	var customers []dto.Customer
-	var count int64
+	if err := query.Order("c.id ASC").Limit(limit + 1).Find(&customers).Error; err != nil {
+		return nil, nil, fmt.Errorf("error finding customers: %v", err)
+	}
 
+	meta := &dto.CursorPagination{
+		PerPage:         limit,
+		TotalItems:      total,
+		TotalIsEstimate: estimated,
+	}
+	if len(customers) > limit {
+		customers = customers[:limit]
+		meta.HasMore = true
+		next, err := helper.EncodeCursor(customerCursor{ID: customers[limit-1].Id})
+		if err != nil {
+			return nil, nil, err
+		}
+		meta.NextCursor = next
+	}
+
+	return customers, meta, nil
+}
+
+func (r *customerRepository) assignedCustomersQuery(marketingID uint, req *dto.AssignedCustomerRequest) *gorm.DB {
 	query := r.db.Table("marketing_customers mc").
 		Joins("JOIN customers c ON mc.customer_id = c.id").
 		Where("mc.marketing_id = ?", marketingID).
 		Where("mc.deleted_at IS NULL")
 
 	if req.Search != "" {
-		query = query.Where(
-			"cif ILIKE ? OR nomor_hp LIKE ? OR nama ILIKE ? OR nomor_rekening ILIKE ? OR array_to_string(produk_eksisting, ',')  ILIKE ?  OR email ILIKE ?  OR job ILIKE ?  OR address ILIKE ?",
-			"%"+req.Search+"%", "%"+req.Search+"%", "%"+req.Search+"%",
-			"%"+req.Search+"%", "%"+req.Search+"%", "%"+req.Search+"%",
-			"%"+req.Search+"%", "%"+req.Search+"%",
-		)
+		condition, args := customerSearchCondition(req.Search)
+		query = query.Where(condition, args...)
 	}
 
 	if req.Status != "all" {
 		query = query.Where("mc.status = ?", req.Status)
 	} else {
-		query = query.Where("mc.status IN ('contacted', 'rejected', 'closed')")
+		query = query.Where("mc.status <> ?", model.CustomerStatusNew)
 	}
 
+	return query
+}
+
+func (r *customerRepository) GetAssignedCustomers(marketingID uint, req *dto.AssignedCustomerRequest) ([]dto.Customer, *dto.Pagination, error) {
+	var customers []dto.Customer
+	var count int64
+
+	query := r.assignedCustomersQuery(marketingID, req)
+
 	if err := query.Count(&count).Error; err != nil {
 		return nil, nil, fmt.Errorf("error counting assigned customers: %v", err)
 	}
@@ -146,7 +251,7 @@ func (r *customerRepository) GetAssignedCustomers(marketingID uint, req *dto.Ass
 	query = query.Offset(offset).Limit(req.Limit)
 
 	query = query.Select(
-		"c.*, mc.status, mc.notes, mc.created_at, mc.updated_at",
+		"c.*, mc.status, mc.notes, mc.reserved_until, mc.created_at, mc.updated_at",
 	)
 
 	if err := query.Find(&customers).Error; err != nil {
@@ -163,13 +268,65 @@ func (r *customerRepository) GetAssignedCustomers(marketingID uint, req *dto.Ass
 	return customers, meta, nil
 }
 
-func (r *customerRepository) GetCustomerDetail(marketingID uint, customerID string) (*dto.Customer, error) {
+// assignmentCursor is the keyset position for a marketer's own leads, ordered
+// by assignment id descending so the newest claims come first.
+type assignmentCursor struct {
+	ID uint `json:"id"`
+}
+
+func (r *customerRepository) GetAssignedCustomersCursor(marketingID uint, req *dto.AssignedCustomerRequest) ([]dto.Customer, *dto.CursorPagination, error) {
+	limit := normalizeCursorLimit(req.Limit)
+	query := r.assignedCustomersQuery(marketingID, req)
+
+	total, estimated, err := countForCursor(r.db, query, req.Total)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if req.Cursor != "" {
+		var position assignmentCursor
+		if err := helper.DecodeCursor(req.Cursor, &position); err != nil {
+			return nil, nil, err
+		}
+		query = query.Where("mc.id < ?", position.ID)
+	}
+
+	var customers []dto.Customer
+	if err := query.
+		Select("c.*, mc.id as marketing_customer_id, mc.status, mc.notes, mc.reserved_until, mc.created_at, mc.updated_at").
+		Order("mc.id DESC").
+		Limit(limit + 1).
+		Find(&customers).Error; err != nil {
+		return nil, nil, fmt.Errorf("error finding assigned customers: %v", err)
+	}
+
+	meta := &dto.CursorPagination{
+		PerPage:         limit,
+		TotalItems:      total,
+		TotalIsEstimate: estimated,
+	}
+	if len(customers) > limit {
+		customers = customers[:limit]
+		meta.HasMore = true
+		next, err := helper.EncodeCursor(assignmentCursor{ID: customers[limit-1].MarketingCustomerID})
+		if err != nil {
+			return nil, nil, err
+		}
+		meta.NextCursor = next
+	}
+
+	return customers, meta, nil
+}
+
+// GetCustomerDetail returns a customer of the marketer's branch pool or one
+// assigned to the marketer.
+func (r *customerRepository) GetCustomerDetail(marketingID uint, kantorCabangID *uint, customerID string) (*dto.Customer, error) {
 	var customer dto.Customer
 
 	var count int64
 	if err := r.db.Table("customers c").
 		Joins("LEFT JOIN marketing_customers mc ON c.id = mc.customer_id AND mc.marketing_id = ? AND mc.deleted_at IS NULL", marketingID).
-		Where("c.cif = ? AND mc.status IS NULL", customerID).
+		Where("c.cif = ? AND mc.status IS NULL AND c.kantor_cabang_id = ?", customerID, kantorCabangID).
 		Or("marketing_id = ? AND c.cif = ? AND mc.deleted_at IS NULL", marketingID, customerID).
 		Count(&count).Error; err != nil {
 		return nil, fmt.Errorf("error checking customer: %v", err)
@@ -183,8 +340,9 @@ func (r *customerRepository) GetCustomerDetail(marketingID uint, customerID stri
 		Select(
 			"c.*,"+
 				"COALESCE(mc.status, 'new') as status, COALESCE(mc.notes, '') as notes, "+
-				"mc.id as marketing_customer_id, mc.marketing_id, mc.product_id as product_id, "+
-				"COALESCE(mc.created_at, c.created_at) as mc_created_at, mc.amount as closed_amount, "+
+				"mc.id as marketing_customer_id, mc.marketing_id, mc.product_id as product_id, mc.reserved_until, "+
+				"COALESCE(mc.created_at, c.created_at) as mc_created_at, "+
+				"(SELECT SUM(cl.amount) FROM closings cl WHERE cl.marketing_customer_id = mc.id AND cl.verification_status = 'verified' AND cl.deleted_at IS NULL) as closed_amount, "+
 				"COALESCE(mc.updated_at, c.updated_at) as updated_at",
 		).
 		Joins("LEFT JOIN marketing_customers mc ON c.id = mc.customer_id AND mc.marketing_id = ? AND mc.deleted_at IS NULL", marketingID).
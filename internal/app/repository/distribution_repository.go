@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"fmt"
+	"math"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/model"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DistributionRepository interface {
+	GetSetting(kantorCabangID uint) (*model.LeadDistributionSetting, error)
+	GetEnabledSettings() ([]model.LeadDistributionSetting, error)
+	SaveSetting(setting *model.LeadDistributionSetting) error
+	SaveCap(cap *model.LeadDistributionCap) error
+	DeleteCap(marketingID uint) error
+
+	GetCandidates(kantorCabangID uint, finalStates []string) ([]dto.DistributionCandidate, error)
+	GetTargetGaps(marketingIDs []uint, productID uint, month, year int) (map[uint]float64, error)
+	FindPendingCustomers(kantorCabangIDs []uint, createdSince time.Time, consent *dto.ConsentFilter, limit int) ([]dto.DistributionCustomer, error)
+
+	CreateRun(run *model.LeadDistributionRun) error
+	UpdateRun(run *model.LeadDistributionRun) error
+	AddDecisionWithTx(tx *gorm.DB, decision *model.LeadDistributionDecision) error
+	GetRuns(req *dto.DistributionListRequest) ([]model.LeadDistributionRun, *dto.Pagination, error)
+	GetRun(id uint64) (*model.LeadDistributionRun, error)
+	GetDecisions(kantorCabangID uint, req *dto.DistributionListRequest) ([]dto.DistributionDecisionResponse, *dto.Pagination, error)
+}
+
+type distributionRepository struct {
+	db  *gorm.DB
+	log *zap.Logger
+}
+
+func NewDistributionRepository(db *gorm.DB, log *zap.Logger) DistributionRepository {
+	return &distributionRepository{db: db, log: log}
+}
+
+func (r *distributionRepository) GetSetting(kantorCabangID uint) (*model.LeadDistributionSetting, error) {
+	var setting model.LeadDistributionSetting
+	if err := r.db.Where("kantor_cabang_id = ?", kantorCabangID).First(&setting).Error; err != nil {
+		return nil, err
+	}
+	return &setting, nil
+}
+
+func (r *distributionRepository) GetEnabledSettings() ([]model.LeadDistributionSetting, error) {
+	var settings []model.LeadDistributionSetting
+	if err := r.db.Where("enabled").Order("kantor_cabang_id ASC").Find(&settings).Error; err != nil {
+		return nil, fmt.Errorf("error getting distribution settings: %v", err)
+	}
+	return settings, nil
+}
+
+func (r *distributionRepository) SaveSetting(setting *model.LeadDistributionSetting) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "kantor_cabang_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "strategy", "default_capacity", "updated_by", "updated_at"}),
+	}).Create(setting).Error
+}
+
+func (r *distributionRepository) SaveCap(cap *model.LeadDistributionCap) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "marketing_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"max_open_leads", "updated_by", "updated_at"}),
+	}).Create(cap).Error
+}
+
+func (r *distributionRepository) DeleteCap(marketingID uint) error {
+	return r.db.Where("marketing_id = ?", marketingID).Delete(&model.LeadDistributionCap{}).Error
+}
+
+// GetCandidates returns the marketers of a branch with their open lead count
+// and the time the engine last gave them a lead.
+func (r *distributionRepository) GetCandidates(kantorCabangID uint, finalStates []string) ([]dto.DistributionCandidate, error) {
+	var candidates []dto.DistributionCandidate
+	if err := r.db.Table("users u").
+		Select(`u.id AS marketing_id, u.nip AS marketing_nip, u.nama AS marketing_name,
+			(SELECT COUNT(*) FROM marketing_customers mc
+				WHERE mc.marketing_id = u.id AND mc.deleted_at IS NULL AND mc.status NOT IN ?) AS open_leads,
+			cap.max_open_leads AS cap_override,
+			(SELECT MAX(d.created_at) FROM lead_distribution_decisions d
+				JOIN lead_distribution_runs r ON r.id = d.run_id
+				WHERE d.marketing_id = u.id AND d.outcome = ? AND NOT r.dry_run) AS last_assigned_at`,
+			finalStates, model.DistributionOutcomeAssigned).
+		Joins("LEFT JOIN lead_distribution_caps cap ON cap.marketing_id = u.id").
+		Where("u.role = 'marketing' AND u.kantor_cabang_id = ? AND u.deleted_at IS NULL", kantorCabangID).
+		Order("u.id ASC").
+		Scan(&candidates).Error; err != nil {
+		return nil, fmt.Errorf("error getting distribution candidates: %v", err)
+	}
+	return candidates, nil
+}
+
+// GetTargetGaps returns, per marketer, how far the month's closings of a
+// product are behind the marketer's target for it.
+func (r *distributionRepository) GetTargetGaps(marketingIDs []uint, productID uint, month, year int) (map[uint]float64, error) {
+	gaps := make(map[uint]float64, len(marketingIDs))
+	if len(marketingIDs) == 0 {
+		return gaps, nil
+	}
+
+	var rows []struct {
+		MarketingID uint    `gorm:"column:marketing_id"`
+		Gap         float64 `gorm:"column:gap"`
+	}
+	if err := r.db.Raw(`
+		SELECT
+			u.id AS marketing_id,
+			COALESCE((
+				SELECT SUM(mt.target_amount) FROM marketing_target_bulanan mt
+				WHERE mt.marketing_id = u.id AND mt.product_id = ? AND mt.bulan = ? AND mt.tahun = ?
+				AND mt.deleted_at IS NULL
+			), 0) - COALESCE((
+				SELECT SUM(cl.amount) FROM closings cl
+				WHERE cl.marketing_id = u.id AND cl.product_id = ? AND cl.verification_status = 'verified'
+				AND cl.deleted_at IS NULL
+				AND EXTRACT(MONTH FROM cl.closed_at) = ? AND EXTRACT(YEAR FROM cl.closed_at) = ?
+			), 0) AS gap
+		FROM users u
+		WHERE u.id IN ?
+	`, productID, month, year, productID, month, year, marketingIDs).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("error getting target gaps: %v", err)
+	}
+
+	for _, row := range rows {
+		gaps[row.MarketingID] = row.Gap
+	}
+	return gaps, nil
+}
+
+// FindPendingCustomers returns unassigned customers of the given branches
+// created since the given time with their top recommended product.
+func (r *distributionRepository) FindPendingCustomers(kantorCabangIDs []uint, createdSince time.Time, consent *dto.ConsentFilter, limit int) ([]dto.DistributionCustomer, error) {
+	var customers []dto.DistributionCustomer
+
+	query := r.db.Table("customers c").
+		Select(`c.id, c.cif, c.kantor_cabang_id,
+			(SELECT cp.product_id FROM customer_products cp
+				WHERE cp.customer_id = c.id ORDER BY cp."order" ASC LIMIT 1) AS top_product_id`).
+		Where("c.deleted_at IS NULL AND c.anonymized_at IS NULL AND c.merged_into_id IS NULL AND c.created_at >= ?", createdSince).
+		Where("c.kantor_cabang_id IN ?", kantorCabangIDs).
+		Where("NOT EXISTS (SELECT 1 FROM marketing_customers mc WHERE mc.customer_id = c.id AND mc.deleted_at IS NULL)")
+	if consent != nil {
+		condition, args := contactableCondition("c", consent)
+		query = query.Where(condition, args...)
+	}
+
+	if err := query.Order("c.id ASC").Limit(limit).Scan(&customers).Error; err != nil {
+		return nil, fmt.Errorf("error finding customers to distribute: %v", err)
+	}
+	return customers, nil
+}
+
+func (r *distributionRepository) CreateRun(run *model.LeadDistributionRun) error {
+	return r.db.Create(run).Error
+}
+
+func (r *distributionRepository) UpdateRun(run *model.LeadDistributionRun) error {
+	return r.db.Omit("Decisions").Save(run).Error
+}
+
+func (r *distributionRepository) AddDecisionWithTx(tx *gorm.DB, decision *model.LeadDistributionDecision) error {
+	if err := tx.Create(decision).Error; err != nil {
+		return fmt.Errorf("error recording distribution decision: %v", err)
+	}
+	return nil
+}
+
+func (r *distributionRepository) GetRuns(req *dto.DistributionListRequest) ([]model.LeadDistributionRun, *dto.Pagination, error) {
+	var runs []model.LeadDistributionRun
+	var count int64
+
+	if err := r.db.Model(&model.LeadDistributionRun{}).Count(&count).Error; err != nil {
+		return nil, nil, fmt.Errorf("error counting distribution runs: %v", err)
+	}
+	if err := r.db.Order("started_at DESC, id DESC").
+		Offset((req.Page - 1) * req.Limit).
+		Limit(req.Limit).
+		Find(&runs).Error; err != nil {
+		return nil, nil, fmt.Errorf("error finding distribution runs: %v", err)
+	}
+
+	meta := &dto.Pagination{
+		CurrentPage: req.Page,
+		PerPage:     req.Limit,
+		TotalItems:  count,
+		TotalPages:  int64(math.Ceil(float64(count) / float64(req.Limit))),
+	}
+	return runs, meta, nil
+}
+
+func (r *distributionRepository) GetRun(id uint64) (*model.LeadDistributionRun, error) {
+	var run model.LeadDistributionRun
+	if err := r.db.Preload("Decisions", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).Where("id = ?", id).First(&run).Error; err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// GetDecisions lists the decisions that placed customers in a branch, or
+// tried to, newest first.
+func (r *distributionRepository) GetDecisions(kantorCabangID uint, req *dto.DistributionListRequest) ([]dto.DistributionDecisionResponse, *dto.Pagination, error) {
+	var decisions []dto.DistributionDecisionResponse
+	var count int64
+
+	query := r.db.Table("lead_distribution_decisions d").
+		Joins("JOIN lead_distribution_runs r ON r.id = d.run_id").
+		Joins("JOIN customers c ON c.id = d.customer_id").
+		Joins("LEFT JOIN users m ON m.id = d.marketing_id").
+		Where("d.kantor_cabang_id = ?", kantorCabangID)
+
+	if err := query.Session(&gorm.Session{}).Count(&count).Error; err != nil {
+		return nil, nil, fmt.Errorf("error counting distribution decisions: %v", err)
+	}
+	if err := query.
+		Select(`d.id, d.run_id, r.dry_run, d.customer_id, c.cif, d.kantor_cabang_id,
+			d.marketing_id, m.nip AS marketing_nip, m.nama AS marketing_name, d.marketing_customer_id,
+			COALESCE(d.strategy, '') AS strategy, d.outcome, d.product_id, COALESCE(d.detail::text, '') AS detail, d.created_at`).
+		Order("d.created_at DESC, d.id DESC").
+		Offset((req.Page - 1) * req.Limit).
+		Limit(req.Limit).
+		Scan(&decisions).Error; err != nil {
+		return nil, nil, fmt.Errorf("error getting distribution decisions: %v", err)
+	}
+
+	meta := &dto.Pagination{
+		CurrentPage: req.Page,
+		PerPage:     req.Limit,
+		TotalItems:  count,
+		TotalPages:  int64(math.Ceil(float64(count) / float64(req.Limit))),
+	}
+	return decisions, meta, nil
+}
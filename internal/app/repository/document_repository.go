@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"fmt"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/model"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type DocumentRepository interface {
+	CreateWithTx(tx *gorm.DB, doc *model.LeadDocument) error
+	FindInScope(id uint64, scope dto.LeadEventScope) (*model.LeadDocument, error)
+	GetByLead(cif string, scope dto.LeadEventScope) ([]dto.LeadDocumentResponse, error)
+	Delete(doc *model.LeadDocument) error
+}
+
+type documentRepository struct {
+	db  *gorm.DB
+	log *zap.Logger
+}
+
+func NewDocumentRepository(db *gorm.DB, log *zap.Logger) DocumentRepository {
+	return &documentRepository{db: db, log: log}
+}
+
+func (r *documentRepository) CreateWithTx(tx *gorm.DB, doc *model.LeadDocument) error {
+	if err := tx.Create(doc).Error; err != nil {
+		return fmt.Errorf("Gagal menyimpan dokumen: %v", err)
+	}
+	return nil
+}
+
+// scoped limits documents to those reachable by the scope: documents of the
+// marketer's own leads or of the lead the customer is currently assigned to,
+// so documents follow the customer across transfers.
+func (r *documentRepository) scoped(query *gorm.DB, scope dto.LeadEventScope) *gorm.DB {
+	query = query.
+		Joins("JOIN marketing_customers mc ON mc.id = d.marketing_customer_id").
+		Joins("JOIN users m ON m.id = mc.marketing_id").
+		Joins("LEFT JOIN marketing_customers amc ON amc.customer_id = d.customer_id AND amc.deleted_at IS NULL").
+		Joins("LEFT JOIN users am ON am.id = amc.marketing_id").
+		Where("d.deleted_at IS NULL")
+
+	if scope.MarketingID != nil {
+		query = query.Where("(mc.marketing_id = ? OR amc.marketing_id = ?)", *scope.MarketingID, *scope.MarketingID)
+	}
+	if scope.KantorCabangID != nil {
+		query = query.Where("(m.kantor_cabang_id = ? OR am.kantor_cabang_id = ?)", *scope.KantorCabangID, *scope.KantorCabangID)
+	}
+	return query
+}
+
+func (r *documentRepository) FindInScope(id uint64, scope dto.LeadEventScope) (*model.LeadDocument, error) {
+	var doc model.LeadDocument
+	if err := r.scoped(r.db.Table("lead_documents d").Select("d.*"), scope).
+		Where("d.id = ?", id).
+		Take(&doc).Error; err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+func (r *documentRepository) GetByLead(cif string, scope dto.LeadEventScope) ([]dto.LeadDocumentResponse, error) {
+	var docs []dto.LeadDocumentResponse
+
+	query := r.db.Table("lead_documents d").
+		Select(`d.id, d.marketing_customer_id, c.cif, d.document_type, d.file_name, d.content_type,
+			d.size_bytes, d.checksum_sha256, u.nip AS uploaded_by_nip, u.nama AS uploaded_by_name, d.created_at`).
+		Joins("JOIN customers c ON c.id = d.customer_id").
+		Joins("JOIN users u ON u.id = d.uploaded_by").
+		Where("c.cif = ?", cif)
+
+	if err := r.scoped(query, scope).Order("d.created_at DESC, d.id DESC").Scan(&docs).Error; err != nil {
+		return nil, fmt.Errorf("error getting lead documents: %v", err)
+	}
+	return docs, nil
+}
+
+func (r *documentRepository) Delete(doc *model.LeadDocument) error {
+	if err := r.db.Delete(doc).Error; err != nil {
+		return fmt.Errorf("Gagal menghapus dokumen: %v", err)
+	}
+	return nil
+}
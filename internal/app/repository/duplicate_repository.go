@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"fmt"
+	"math"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/model"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DuplicateRepository interface {
+	CreateScanRun(run *model.DuplicateScanRun) error
+	UpdateScanRun(run *model.DuplicateScanRun) error
+	GetScanRun(id uint64) (*model.DuplicateScanRun, error)
+
+	GetScanRows(afterID uint64, limit int) ([]dto.DuplicateScanRow, error)
+	SaveCandidates(candidates []model.CustomerDuplicateCandidate) (int64, error)
+	GetCandidates(req *dto.DuplicateListRequest) ([]model.CustomerDuplicateCandidate, *dto.Pagination, error)
+	FindCandidateForUpdate(tx *gorm.DB, id uint64) (*model.CustomerDuplicateCandidate, error)
+	UpdateCandidateWithTx(tx *gorm.DB, candidate *model.CustomerDuplicateCandidate) error
+	MergeCustomersWithTx(tx *gorm.DB, survivorID, duplicateID uint64) (*dto.MergeResult, error)
+}
+
+type duplicateRepository struct {
+	db  *gorm.DB
+	log *zap.Logger
+}
+
+func NewDuplicateRepository(db *gorm.DB, log *zap.Logger) DuplicateRepository {
+	return &duplicateRepository{db: db, log: log}
+}
+
+func (r *duplicateRepository) CreateScanRun(run *model.DuplicateScanRun) error {
+	return r.db.Create(run).Error
+}
+
+func (r *duplicateRepository) UpdateScanRun(run *model.DuplicateScanRun) error {
+	return r.db.Save(run).Error
+}
+
+func (r *duplicateRepository) GetScanRun(id uint64) (*model.DuplicateScanRun, error) {
+	var run model.DuplicateScanRun
+	if err := r.db.Where("id = ?", id).First(&run).Error; err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// GetScanRows returns the next batch of customers after afterID in id order.
+func (r *duplicateRepository) GetScanRows(afterID uint64, limit int) ([]dto.DuplicateScanRow, error) {
+	var rows []dto.DuplicateScanRow
+	if err := r.db.Table("customers").
+		Select("id, nama, nama_perusahaan, umur, nomor_hp_bidx, email_bidx, nomor_rekening_bidx").
+		Where("deleted_at IS NULL AND id > ?", afterID).
+		Order("id ASC").
+		Limit(limit).
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("error reading customers for duplicate scan: %v", err)
+	}
+	return rows, nil
+}
+
+// SaveCandidates inserts new pairs and refreshes the score of pairs that are
+// still pending. Reviewed pairs are left untouched so a dismissed pair is not
+// raised again.
+func (r *duplicateRepository) SaveCandidates(candidates []model.CustomerDuplicateCandidate) (int64, error) {
+	if len(candidates) == 0 {
+		return 0, nil
+	}
+	result := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "customer_id"}, {Name: "duplicate_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"score", "reasons", "updated_at"}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Eq{Column: clause.Column{Table: "customer_duplicate_candidates", Name: "status"}, Value: model.DuplicateStatusPending},
+		}},
+	}).CreateInBatches(&candidates, 500)
+	if result.Error != nil {
+		return 0, fmt.Errorf("error saving duplicate candidates: %v", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+func (r *duplicateRepository) GetCandidates(req *dto.DuplicateListRequest) ([]model.CustomerDuplicateCandidate, *dto.Pagination, error) {
+	var candidates []model.CustomerDuplicateCandidate
+	var count int64
+
+	query := r.db.Model(&model.CustomerDuplicateCandidate{})
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+	if err := query.Count(&count).Error; err != nil {
+		return nil, nil, fmt.Errorf("error counting duplicate candidates: %v", err)
+	}
+
+	// Merged customers are soft-deleted, so preload without the default scope.
+	unscoped := func(db *gorm.DB) *gorm.DB { return db.Unscoped() }
+	if err := query.
+		Preload("Customer", unscoped).
+		Preload("Duplicate", unscoped).
+		Order("score DESC, id ASC").
+		Offset((req.Page - 1) * req.Limit).
+		Limit(req.Limit).
+		Find(&candidates).Error; err != nil {
+		return nil, nil, fmt.Errorf("error finding duplicate candidates: %v", err)
+	}
+
+	meta := &dto.Pagination{
+		CurrentPage: req.Page,
+		PerPage:     req.Limit,
+		TotalItems:  count,
+		TotalPages:  int64(math.Ceil(float64(count) / float64(req.Limit))),
+	}
+	return candidates, meta, nil
+}
+
+func (r *duplicateRepository) FindCandidateForUpdate(tx *gorm.DB, id uint64) (*model.CustomerDuplicateCandidate, error) {
+	var candidate model.CustomerDuplicateCandidate
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).
+		First(&candidate).Error; err != nil {
+		return nil, err
+	}
+	return &candidate, nil
+}
+
+func (r *duplicateRepository) UpdateCandidateWithTx(tx *gorm.DB, candidate *model.CustomerDuplicateCandidate) error {
+	return tx.Save(candidate).Error
+}
+
+// MergeCustomersWithTx folds the duplicate into the survivor: recommended
+// products the survivor lacks, every assignment (including history),
+// closings, lead documents, consent records and prediction runs move over;
+// the duplicate's open notes are appended to the survivor's active
+// assignment; the duplicate is soft-deleted and points to the survivor.
+func (r *duplicateRepository) MergeCustomersWithTx(tx *gorm.DB, survivorID, duplicateID uint64) (*dto.MergeResult, error) {
+	result := &dto.MergeResult{SurvivorID: survivorID, MergedID: duplicateID}
+
+	moved := tx.Exec(`UPDATE customer_products SET customer_id = ?
+		WHERE customer_id = ? AND product_id NOT IN (SELECT product_id FROM customer_products WHERE customer_id = ?)`,
+		survivorID, duplicateID, survivorID)
+	if moved.Error != nil {
+		return nil, fmt.Errorf("error moving customer products: %v", moved.Error)
+	}
+	result.MovedProducts = moved.RowsAffected
+	if err := tx.Exec("DELETE FROM customer_products WHERE customer_id = ?", duplicateID).Error; err != nil {
+		return nil, fmt.Errorf("error removing duplicate products: %v", err)
+	}
+
+	var survivorActive, duplicateActive model.MarketingCustomer
+	survivorErr := tx.Where("customer_id = ?", survivorID).First(&survivorActive).Error
+	if survivorErr != nil && survivorErr != gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("error finding survivor assignment: %v", survivorErr)
+	}
+	duplicateErr := tx.Where("customer_id = ?", duplicateID).First(&duplicateActive).Error
+	if duplicateErr != nil && duplicateErr != gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("error finding duplicate assignment: %v", duplicateErr)
+	}
+
+	// Only one active assignment may exist per customer. When both records are
+	// being worked, the survivor's assignment wins and the other one is closed
+	// into history with its notes carried over.
+	if survivorErr == nil && duplicateErr == nil {
+		if duplicateActive.Notes != "" {
+			survivorActive.Notes = appendMergeNote(survivorActive.Notes, duplicateActive.Notes, duplicateID)
+			if err := tx.Model(&survivorActive).UpdateColumn("notes", survivorActive.Notes).Error; err != nil {
+				return nil, fmt.Errorf("error merging notes: %v", err)
+			}
+		}
+		if err := tx.Delete(&duplicateActive).Error; err != nil {
+			return nil, fmt.Errorf("error closing duplicate assignment: %v", err)
+		}
+	}
+
+	movedAssignments := tx.Exec("UPDATE marketing_customers SET customer_id = ? WHERE customer_id = ?", survivorID, duplicateID)
+	if movedAssignments.Error != nil {
+		return nil, fmt.Errorf("error moving assignments: %v", movedAssignments.Error)
+	}
+	result.MovedAssignments = movedAssignments.RowsAffected
+
+	if err := tx.Exec("UPDATE closings SET customer_id = ? WHERE customer_id = ?", survivorID, duplicateID).Error; err != nil {
+		return nil, fmt.Errorf("error moving closings: %v", err)
+	}
+	if err := tx.Exec("UPDATE lead_documents SET customer_id = ? WHERE customer_id = ?", survivorID, duplicateID).Error; err != nil {
+		return nil, fmt.Errorf("error moving lead documents: %v", err)
+	}
+	// Consents are an append-only log whose effective value is the latest
+	// record per scope and channel, so moving the duplicate's records merges
+	// both histories and keeps every opt-out that is still the latest word.
+	if err := tx.Exec("UPDATE customer_consents SET customer_id = ? WHERE customer_id = ?", survivorID, duplicateID).Error; err != nil {
+		return nil, fmt.Errorf("error moving consents: %v", err)
+	}
+
+	if err := tx.Exec("UPDATE customer_prediction_runs SET customer_id = ? WHERE customer_id = ?", survivorID, duplicateID).Error; err != nil {
+		return nil, fmt.Errorf("error moving prediction runs: %v", err)
+	}
+
+	if err := tx.Model(&model.Customer{}).
+		Where("id = ?", duplicateID).
+		UpdateColumns(map[string]interface{}{
+			"merged_into_id": survivorID,
+			"deleted_at":     time.Now(),
+		}).Error; err != nil {
+		return nil, fmt.Errorf("error retiring duplicate customer: %v", err)
+	}
+
+	// Other pending pairs of the retired record are obsolete.
+	if err := tx.Where("status = ? AND (customer_id = ? OR duplicate_id = ?)", model.DuplicateStatusPending, duplicateID, duplicateID).
+		Delete(&model.CustomerDuplicateCandidate{}).Error; err != nil {
+		return nil, fmt.Errorf("error clearing obsolete candidates: %v", err)
+	}
+
+	return result, nil
+}
+
+func appendMergeNote(notes, merged string, duplicateID uint64) string {
+	note := fmt.Sprintf("[digabung dari customer #%d] %s", duplicateID, merged)
+	if notes == "" {
+		return note
+	}
+	return notes + "\n" + note
+}
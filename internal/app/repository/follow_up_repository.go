@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"fmt"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/model"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type FollowUpRepository interface {
+	Schedule(mcID uint, at time.Time, reason string) error
+	Clear(mcID uint) error
+	GetFollowUps(scope dto.LeadEventScope, dueBefore time.Time) ([]dto.FollowUpItem, error)
+
+	GenerateReminders(now time.Time) (int64, error)
+	MarkOverdue(cutoff time.Time) (int64, error)
+
+	GetReminders(marketingID uint, unreadOnly bool) ([]dto.FollowUpReminderResponse, error)
+	CountUnreadReminders(marketingID uint) (int64, error)
+	MarkReminderRead(id uint64, marketingID uint) (int64, error)
+}
+
+type followUpRepository struct {
+	db  *gorm.DB
+	log *zap.Logger
+}
+
+func NewFollowUpRepository(db *gorm.DB, log *zap.Logger) FollowUpRepository {
+	return &followUpRepository{db: db, log: log}
+}
+
+// Schedule sets the next follow-up of a lead. Rescheduling clears the overdue
+// mark so the lead leaves the overdue queue.
+func (r *followUpRepository) Schedule(mcID uint, at time.Time, reason string) error {
+	return r.db.Model(&model.MarketingCustomer{}).
+		Where("id = ?", mcID).
+		Updates(map[string]interface{}{
+			"next_follow_up_at":    at,
+			"follow_up_reason":     reason,
+			"follow_up_overdue_at": nil,
+		}).Error
+}
+
+func (r *followUpRepository) Clear(mcID uint) error {
+	return r.db.Model(&model.MarketingCustomer{}).
+		Where("id = ?", mcID).
+		Updates(map[string]interface{}{
+			"next_follow_up_at":    nil,
+			"follow_up_reason":     nil,
+			"follow_up_overdue_at": nil,
+		}).Error
+}
+
+// GetFollowUps lists scheduled follow-ups due before the given time, oldest
+// first.
+func (r *followUpRepository) GetFollowUps(scope dto.LeadEventScope, dueBefore time.Time) ([]dto.FollowUpItem, error) {
+	var items []dto.FollowUpItem
+
+	query := r.db.Table("marketing_customers mc").
+		Select(`mc.id AS marketing_customer_id, c.cif, c.nama AS customer_name, mc.status,
+			m.nip AS marketing_nip, m.nama AS marketing_name,
+			mc.next_follow_up_at, COALESCE(mc.follow_up_reason, '') AS reason, mc.follow_up_overdue_at AS overdue_at`).
+		Joins("JOIN customers c ON c.id = mc.customer_id").
+		Joins("JOIN users m ON m.id = mc.marketing_id").
+		Where("mc.deleted_at IS NULL AND mc.next_follow_up_at IS NOT NULL AND mc.next_follow_up_at < ?", dueBefore)
+
+	if scope.MarketingID != nil {
+		query = query.Where("mc.marketing_id = ?", *scope.MarketingID)
+	}
+	if scope.KantorCabangID != nil {
+		query = query.Where("m.kantor_cabang_id = ?", *scope.KantorCabangID)
+	}
+
+	if err := query.Order("mc.next_follow_up_at ASC, mc.id ASC").Scan(&items).Error; err != nil {
+		return nil, fmt.Errorf("error getting follow-ups: %v", err)
+	}
+	return items, nil
+}
+
+// GenerateReminders creates one reminder for every follow-up that has fallen
+// due. Reminders that already exist are left untouched, so re-running is
+// harmless.
+func (r *followUpRepository) GenerateReminders(now time.Time) (int64, error) {
+	result := r.db.Exec(`INSERT INTO follow_up_reminders (marketing_customer_id, marketing_id, due_at, reason)
+		SELECT id, marketing_id, next_follow_up_at, follow_up_reason
+		FROM marketing_customers
+		WHERE deleted_at IS NULL AND next_follow_up_at IS NOT NULL AND next_follow_up_at <= ?
+		ON CONFLICT (marketing_customer_id, due_at) DO NOTHING`, now)
+	if result.Error != nil {
+		return 0, fmt.Errorf("error generating follow-up reminders: %v", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+func (r *followUpRepository) MarkOverdue(cutoff time.Time) (int64, error) {
+	result := r.db.Exec(`UPDATE marketing_customers SET follow_up_overdue_at = NOW()
+		WHERE deleted_at IS NULL AND follow_up_overdue_at IS NULL
+		AND next_follow_up_at IS NOT NULL AND next_follow_up_at < ?`, cutoff)
+	if result.Error != nil {
+		return 0, fmt.Errorf("error marking overdue follow-ups: %v", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+func (r *followUpRepository) GetReminders(marketingID uint, unreadOnly bool) ([]dto.FollowUpReminderResponse, error) {
+	var reminders []dto.FollowUpReminderResponse
+
+	query := r.db.Table("follow_up_reminders fr").
+		Select(`fr.id, fr.marketing_customer_id, c.cif, c.nama AS customer_name,
+			fr.due_at, COALESCE(fr.reason, '') AS reason, fr.read_at, fr.created_at`).
+		Joins("JOIN marketing_customers mc ON mc.id = fr.marketing_customer_id").
+		Joins("JOIN customers c ON c.id = mc.customer_id").
+		Where("fr.marketing_id = ?", marketingID)
+	if unreadOnly {
+		query = query.Where("fr.read_at IS NULL")
+	}
+
+	if err := query.Order("fr.due_at DESC, fr.id DESC").Limit(100).Scan(&reminders).Error; err != nil {
+		return nil, fmt.Errorf("error getting follow-up reminders: %v", err)
+	}
+	return reminders, nil
+}
+
+func (r *followUpRepository) CountUnreadReminders(marketingID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.FollowUpReminder{}).
+		Where("marketing_id = ? AND read_at IS NULL", marketingID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *followUpRepository) MarkReminderRead(id uint64, marketingID uint) (int64, error) {
+	result := r.db.Model(&model.FollowUpReminder{}).
+		Where("id = ? AND marketing_id = ?", id, marketingID).
+		Update("read_at", gorm.Expr("COALESCE(read_at, ?)", time.Now()))
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"fmt"
+	dto "ml-prediction/internal/app/domain"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Dimensions a funnel can be broken down by.
+const (
+	FunnelTotal     = "total"
+	FunnelBranch    = "branch"
+	FunnelMarketing = "marketing"
+	FunnelProduct   = "product"
+	FunnelMonth     = "month"
+)
+
+// funnelDimensions holds the key and label expression of each dimension over
+// the per-lead rows of funnelLeadsQuery.
+var funnelDimensions = map[string][2]string{
+	FunnelTotal:     {"'total'", "'Total'"},
+	FunnelBranch:    {"COALESCE(kantor_cabang_id::text, '-')", "COALESCE(branch_name, 'Tanpa kantor cabang')"},
+	FunnelMarketing: {"marketing_nip", "marketing_name"},
+	FunnelProduct:   {"COALESCE(product_id::text, '-')", "COALESCE(product_name, 'Tanpa rekomendasi')"},
+	FunnelMonth:     {"TO_CHAR(claimed_at, 'YYYY-MM')", "TO_CHAR(claimed_at, 'YYYY-MM')"},
+}
+
+// funnelLeadsQuery returns one row per lead, including released and
+// transferred ones, with the time it reached each stage according to its
+// history. A lead is contacted once it leaves "new" or has a logged
+// activity. The product is the one the lead closed on, or else the
+// customer's top recommendation. Besides the leads claimed in the period it
+// returns every open lead, which only counts toward Stale.
+const funnelLeadsQuery = `
+	SELECT mc.created_at AS claimed_at,
+		(mc.created_at >= @from AND mc.created_at < @to) AS in_period,
+		m.kantor_cabang_id, kc.nama AS branch_name, m.nip AS marketing_nip, m.nama AS marketing_name,
+		p.id AS product_id, p.nama AS product_name,
+		LEAST(ev.first_contact_at, act.first_activity_at) AS first_contact_at,
+		ev.closed_at, ev.rejected_at,
+		(mc.deleted_at IS NULL AND mc.status NOT IN @final AND
+			GREATEST(mc.created_at, COALESCE(mc.status_changed_at, mc.created_at),
+				COALESCE(act.last_activity_at, mc.created_at)) < @stale_before) AS stale
+	FROM marketing_customers mc
+	JOIN users m ON m.id = mc.marketing_id
+	LEFT JOIN kantor_cabang kc ON kc.id = m.kantor_cabang_id
+	LEFT JOIN LATERAL (SELECT top.product_id FROM customer_products top
+		WHERE top.customer_id = mc.customer_id ORDER BY top."order" ASC LIMIT 1) top ON TRUE
+	LEFT JOIN products p ON p.id = COALESCE(mc.product_id, top.product_id)
+	LEFT JOIN LATERAL (SELECT
+			MIN(e.created_at) FILTER (WHERE e.new_status <> 'new') AS first_contact_at,
+			MIN(e.created_at) FILTER (WHERE e.new_status = 'closed') AS closed_at,
+			MIN(e.created_at) FILTER (WHERE e.new_status = 'rejected') AS rejected_at
+		FROM marketing_customer_events e
+		WHERE e.marketing_customer_id = mc.id AND e.event_type IN ('status_changed', 'transferred_in')) ev ON TRUE
+	LEFT JOIN LATERAL (SELECT MIN(a.occurred_at) AS first_activity_at, MAX(a.occurred_at) AS last_activity_at
+		FROM lead_activities a WHERE a.marketing_customer_id = mc.id) act ON TRUE
+	WHERE ((mc.created_at >= @from AND mc.created_at < @to) OR (mc.deleted_at IS NULL AND mc.status NOT IN @final))`
+
+type FunnelRepository interface {
+	CountPoolEntries(from, to time.Time, kantorCabangID *uint) (int, error)
+	GetFunnel(filter dto.FunnelFilter, dimension string) ([]dto.FunnelCounts, error)
+}
+
+type funnelRepository struct {
+	db  *gorm.DB
+	log *zap.Logger
+}
+
+func NewFunnelRepository(db *gorm.DB, log *zap.Logger) FunnelRepository {
+	return &funnelRepository{db: db, log: log}
+}
+
+// CountPoolEntries counts the customers that entered the pool in the period,
+// of one branch if given. Customers merged into another record are left out.
+func (r *funnelRepository) CountPoolEntries(from, to time.Time, kantorCabangID *uint) (int, error) {
+	var total int64
+	query := r.db.Table("customers").
+		Where("created_at >= ? AND created_at < ? AND merged_into_id IS NULL", from, to)
+	if kantorCabangID != nil {
+		query = query.Where("kantor_cabang_id = ?", *kantorCabangID)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return 0, fmt.Errorf("error counting pool entries: %v", err)
+	}
+	return int(total), nil
+}
+
+func (r *funnelRepository) GetFunnel(filter dto.FunnelFilter, dimension string) ([]dto.FunnelCounts, error) {
+	exprs, ok := funnelDimensions[dimension]
+	if !ok {
+		return nil, fmt.Errorf("dimensi funnel tidak dikenal: %s", dimension)
+	}
+
+	args := map[string]interface{}{
+		"from":         filter.From,
+		"to":           filter.To,
+		"final":        filter.FinalStates,
+		"stale_before": filter.StaleBefore,
+	}
+	leads := funnelLeadsQuery
+	if filter.KantorCabangID != nil {
+		leads += " AND m.kantor_cabang_id = @kantor_cabang_id"
+		args["kantor_cabang_id"] = *filter.KantorCabangID
+	}
+	where := ""
+	if dimension == FunnelMonth {
+		// Open leads claimed before the period would add months outside it.
+		where = "WHERE in_period"
+	}
+
+	query := fmt.Sprintf(`
+		WITH leads AS (%s)
+		SELECT %s AS key, %s AS label,
+			COUNT(*) FILTER (WHERE in_period) AS claimed,
+			COUNT(*) FILTER (WHERE in_period AND first_contact_at IS NOT NULL) AS contacted,
+			COUNT(*) FILTER (WHERE in_period AND closed_at IS NOT NULL) AS closed,
+			COUNT(*) FILTER (WHERE in_period AND rejected_at IS NOT NULL) AS rejected,
+			COUNT(*) FILTER (WHERE stale) AS stale,
+			PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM first_contact_at - claimed_at) / 3600)
+				FILTER (WHERE in_period AND first_contact_at IS NOT NULL) AS median_hours_to_contact,
+			PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM closed_at - first_contact_at) / 3600)
+				FILTER (WHERE in_period AND closed_at IS NOT NULL AND first_contact_at IS NOT NULL) AS median_hours_to_close
+		FROM leads
+		%s
+		GROUP BY 1, 2
+		ORDER BY 1`, leads, exprs[0], exprs[1], where)
+
+	var counts []dto.FunnelCounts
+	if err := r.db.Raw(query, args).Scan(&counts).Error; err != nil {
+		return nil, fmt.Errorf("error computing lead funnel: %v", err)
+	}
+	return counts, nil
+}
@@ -0,0 +1,292 @@
+package repository
+
+import (
+	"fmt"
+	"math"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/model"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IncentiveRepository interface {
+	CreateScheme(scheme *model.IncentiveScheme) error
+	SchemeOverlaps(productID uint, from time.Time, to *time.Time) (bool, error)
+	GetSchemes(productID uint) ([]model.IncentiveScheme, error)
+	FindScheme(id uint) (*model.IncentiveScheme, error)
+	DeleteScheme(scheme *model.IncentiveScheme) error
+	GetActiveSchemes(month time.Time) ([]model.IncentiveScheme, error)
+
+	GetTargets(tahun, bulan int, productIDs []uint) ([]dto.IncentiveTarget, error)
+	GetVerifiedClosings(from, to time.Time, productIDs []uint) ([]model.Closing, error)
+	GetStatementMarketers(tahun, bulan int) ([]uint, error)
+	FindStatementForUpdateWithTx(tx *gorm.DB, marketingID uint, tahun, bulan int) (*model.IncentiveStatement, error)
+	SaveStatementWithTx(tx *gorm.DB, statement *model.IncentiveStatement, lines []model.IncentiveStatementLine) error
+
+	GetStatements(filter dto.IncentiveStatementFilter, page, limit int) ([]dto.IncentiveStatementResponse, *dto.Pagination, int64, error)
+	GetAllStatements(filter dto.IncentiveStatementFilter) ([]dto.IncentiveStatementResponse, error)
+	GetStatement(id uint64) (*dto.IncentiveStatementResponse, error)
+	GetStatementLines(id uint64) ([]dto.IncentiveStatementLineResponse, error)
+	FindStatementByIDWithTx(tx *gorm.DB, id uint64) (*model.IncentiveStatement, error)
+	ReviewStatementWithTx(tx *gorm.DB, statement *model.IncentiveStatement) error
+}
+
+type incentiveRepository struct {
+	db  *gorm.DB
+	log *zap.Logger
+}
+
+func NewIncentiveRepository(db *gorm.DB, log *zap.Logger) IncentiveRepository {
+	return &incentiveRepository{db: db, log: log}
+}
+
+func (r *incentiveRepository) CreateScheme(scheme *model.IncentiveScheme) error {
+	if err := r.db.Create(scheme).Error; err != nil {
+		return fmt.Errorf("Gagal menyimpan skema insentif: %v", err)
+	}
+	return nil
+}
+
+// SchemeOverlaps reports whether another scheme of the product covers any
+// month between from and to; a nil to means open-ended.
+func (r *incentiveRepository) SchemeOverlaps(productID uint, from time.Time, to *time.Time) (bool, error) {
+	query := r.db.Model(&model.IncentiveScheme{}).
+		Where("product_id = ?", productID).
+		Where("(effective_to IS NULL OR effective_to >= ?)", from.Format("2006-01-02"))
+	if to != nil {
+		query = query.Where("effective_from <= ?", to.Format("2006-01-02"))
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return false, fmt.Errorf("error checking incentive schemes: %v", err)
+	}
+	return count > 0, nil
+}
+
+func (r *incentiveRepository) GetSchemes(productID uint) ([]model.IncentiveScheme, error) {
+	var schemes []model.IncentiveScheme
+	query := r.db.Preload("Product").Preload("Tiers", func(db *gorm.DB) *gorm.DB {
+		return db.Order("min_attainment ASC")
+	})
+	if productID != 0 {
+		query = query.Where("product_id = ?", productID)
+	}
+	if err := query.Order("product_id ASC, effective_from DESC").Find(&schemes).Error; err != nil {
+		return nil, fmt.Errorf("error getting incentive schemes: %v", err)
+	}
+	return schemes, nil
+}
+
+func (r *incentiveRepository) FindScheme(id uint) (*model.IncentiveScheme, error) {
+	var scheme model.IncentiveScheme
+	if err := r.db.Where("id = ?", id).First(&scheme).Error; err != nil {
+		return nil, err
+	}
+	return &scheme, nil
+}
+
+func (r *incentiveRepository) DeleteScheme(scheme *model.IncentiveScheme) error {
+	if err := r.db.Delete(scheme).Error; err != nil {
+		return fmt.Errorf("Gagal menghapus skema insentif: %v", err)
+	}
+	return nil
+}
+
+// GetActiveSchemes returns the schemes covering the month starting at month.
+func (r *incentiveRepository) GetActiveSchemes(month time.Time) ([]model.IncentiveScheme, error) {
+	var schemes []model.IncentiveScheme
+	day := month.Format("2006-01-02")
+	if err := r.db.Preload("Product").Preload("Tiers", func(db *gorm.DB) *gorm.DB {
+		return db.Order("min_attainment ASC")
+	}).
+		Where("effective_from <= ? AND (effective_to IS NULL OR effective_to >= ?)", day, day).
+		Order("product_id ASC").
+		Find(&schemes).Error; err != nil {
+		return nil, fmt.Errorf("error getting active incentive schemes: %v", err)
+	}
+	return schemes, nil
+}
+
+func (r *incentiveRepository) GetTargets(tahun, bulan int, productIDs []uint) ([]dto.IncentiveTarget, error) {
+	var targets []dto.IncentiveTarget
+	if err := r.db.Table("marketing_target_bulanan mt").
+		Select("mt.marketing_id, u.kantor_cabang_id, mt.product_id, SUM(mt.target_amount) AS target").
+		Joins("JOIN users u ON u.id = mt.marketing_id AND u.deleted_at IS NULL").
+		Where("mt.tahun = ? AND mt.bulan = ? AND mt.product_id IN ? AND mt.deleted_at IS NULL", tahun, bulan, productIDs).
+		Group("mt.marketing_id, u.kantor_cabang_id, mt.product_id").
+		Scan(&targets).Error; err != nil {
+		return nil, fmt.Errorf("error getting incentive targets: %v", err)
+	}
+	return targets, nil
+}
+
+func (r *incentiveRepository) GetVerifiedClosings(from, to time.Time, productIDs []uint) ([]model.Closing, error) {
+	var closings []model.Closing
+	if err := r.db.Preload("Marketing").
+		Where("closed_at >= ? AND closed_at < ?", from.Format("2006-01-02"), to.Format("2006-01-02")).
+		Where("verification_status = ? AND product_id IN ?", model.ClosingVerified, productIDs).
+		Order("closed_at ASC, id ASC").
+		Find(&closings).Error; err != nil {
+		return nil, fmt.Errorf("error getting verified closings: %v", err)
+	}
+	return closings, nil
+}
+
+// GetStatementMarketers returns the marketers that already have a statement
+// for the month, so a recalculation also reaches those left without basis.
+func (r *incentiveRepository) GetStatementMarketers(tahun, bulan int) ([]uint, error) {
+	var ids []uint
+	if err := r.db.Model(&model.IncentiveStatement{}).
+		Where("tahun = ? AND bulan = ?", tahun, bulan).
+		Pluck("marketing_id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("error getting incentive statements: %v", err)
+	}
+	return ids, nil
+}
+
+func (r *incentiveRepository) FindStatementForUpdateWithTx(tx *gorm.DB, marketingID uint, tahun, bulan int) (*model.IncentiveStatement, error) {
+	var statement model.IncentiveStatement
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("marketing_id = ? AND tahun = ? AND bulan = ?", marketingID, tahun, bulan).
+		First(&statement).Error; err != nil {
+		return nil, err
+	}
+	return &statement, nil
+}
+
+// SaveStatementWithTx creates or updates the statement and replaces its
+// lines.
+func (r *incentiveRepository) SaveStatementWithTx(tx *gorm.DB, statement *model.IncentiveStatement, lines []model.IncentiveStatementLine) error {
+	if err := tx.Save(statement).Error; err != nil {
+		return fmt.Errorf("Gagal menyimpan laporan insentif: %v", err)
+	}
+	if err := tx.Where("statement_id = ?", statement.ID).Delete(&model.IncentiveStatementLine{}).Error; err != nil {
+		return fmt.Errorf("Gagal menyimpan rincian insentif: %v", err)
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	for i := range lines {
+		lines[i].StatementID = statement.ID
+	}
+	if err := tx.Create(&lines).Error; err != nil {
+		return fmt.Errorf("Gagal menyimpan rincian insentif: %v", err)
+	}
+	return nil
+}
+
+func (r *incentiveRepository) statementsQuery(filter dto.IncentiveStatementFilter) *gorm.DB {
+	query := r.db.Table("incentive_statements s").
+		Select(`s.id, m.nip AS marketing_nip, m.nama AS marketing_name, s.kantor_cabang_id, kc.nama AS branch_name,
+			s.tahun, s.bulan, s.total_amount, s.status, s.calculated_at,
+			rv.nip AS reviewed_by_nip, s.reviewed_at, s.review_note`).
+		Joins("JOIN users m ON m.id = s.marketing_id").
+		Joins("LEFT JOIN kantor_cabang kc ON kc.id = s.kantor_cabang_id").
+		Joins("LEFT JOIN users rv ON rv.id = s.reviewed_by")
+
+	if filter.Tahun != 0 {
+		query = query.Where("s.tahun = ?", filter.Tahun)
+	}
+	if filter.Bulan != 0 {
+		query = query.Where("s.bulan = ?", filter.Bulan)
+	}
+	if filter.Status != "" {
+		query = query.Where("s.status = ?", filter.Status)
+	}
+	if filter.KantorCabangID != nil {
+		query = query.Where("s.kantor_cabang_id = ?", *filter.KantorCabangID)
+	}
+	if filter.MarketingID != nil {
+		query = query.Where("s.marketing_id = ?", *filter.MarketingID)
+	}
+	return query
+}
+
+func (r *incentiveRepository) GetStatements(filter dto.IncentiveStatementFilter, page, limit int) ([]dto.IncentiveStatementResponse, *dto.Pagination, int64, error) {
+	var totals struct {
+		Count  int64 `gorm:"column:count"`
+		Amount int64 `gorm:"column:amount"`
+	}
+	if err := r.statementsQuery(filter).
+		Select("COUNT(*) AS count, COALESCE(SUM(s.total_amount), 0) AS amount").
+		Scan(&totals).Error; err != nil {
+		return nil, nil, 0, fmt.Errorf("error counting incentive statements: %v", err)
+	}
+
+	var statements []dto.IncentiveStatementResponse
+	if err := r.statementsQuery(filter).
+		Order("s.tahun DESC, s.bulan DESC, m.nama ASC, s.id ASC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Scan(&statements).Error; err != nil {
+		return nil, nil, 0, fmt.Errorf("error getting incentive statements: %v", err)
+	}
+
+	meta := &dto.Pagination{
+		CurrentPage: page,
+		PerPage:     limit,
+		TotalItems:  totals.Count,
+		TotalPages:  int64(math.Ceil(float64(totals.Count) / float64(limit))),
+	}
+	return statements, meta, totals.Amount, nil
+}
+
+func (r *incentiveRepository) GetAllStatements(filter dto.IncentiveStatementFilter) ([]dto.IncentiveStatementResponse, error) {
+	var statements []dto.IncentiveStatementResponse
+	if err := r.statementsQuery(filter).
+		Order("kc.nama ASC, m.nip ASC").
+		Scan(&statements).Error; err != nil {
+		return nil, fmt.Errorf("error getting incentive statements: %v", err)
+	}
+	return statements, nil
+}
+
+func (r *incentiveRepository) GetStatement(id uint64) (*dto.IncentiveStatementResponse, error) {
+	var statement dto.IncentiveStatementResponse
+	if err := r.statementsQuery(dto.IncentiveStatementFilter{}).
+		Where("s.id = ?", id).
+		Take(&statement).Error; err != nil {
+		return nil, err
+	}
+	return &statement, nil
+}
+
+func (r *incentiveRepository) GetStatementLines(id uint64) ([]dto.IncentiveStatementLineResponse, error) {
+	var lines []dto.IncentiveStatementLineResponse
+	if err := r.db.Table("incentive_statement_lines l").
+		Select(`l.id, l.line_type, l.scheme_id, l.product_id, p.nama AS product_name, l.description, l.closing_id,
+			l.target_amount, l.achieved_amount, l.attainment, l.amount`).
+		Joins("LEFT JOIN products p ON p.id = l.product_id").
+		Where("l.statement_id = ?", id).
+		Order("l.id ASC").
+		Scan(&lines).Error; err != nil {
+		return nil, fmt.Errorf("error getting incentive statement lines: %v", err)
+	}
+	return lines, nil
+}
+
+func (r *incentiveRepository) FindStatementByIDWithTx(tx *gorm.DB, id uint64) (*model.IncentiveStatement, error) {
+	var statement model.IncentiveStatement
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).
+		First(&statement).Error; err != nil {
+		return nil, err
+	}
+	return &statement, nil
+}
+
+func (r *incentiveRepository) ReviewStatementWithTx(tx *gorm.DB, statement *model.IncentiveStatement) error {
+	if err := tx.Model(statement).Updates(map[string]interface{}{
+		"status":      statement.Status,
+		"reviewed_by": statement.ReviewedBy,
+		"reviewed_at": statement.ReviewedAt,
+		"review_note": statement.ReviewNote,
+	}).Error; err != nil {
+		return fmt.Errorf("Gagal menyimpan persetujuan insentif: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"fmt"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/model"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type LeaderboardRepository interface {
+	GetStandings(kantorCabangID *uint, from, to time.Time) ([]dto.LeaderboardStanding, error)
+	GetMonthlyResults(kantorCabangID *uint, marketingID *uint, from, to time.Time) ([]dto.MonthlyResult, error)
+}
+
+type leaderboardRepository struct {
+	db  *gorm.DB
+	log *zap.Logger
+}
+
+func NewLeaderboardRepository(db *gorm.DB, log *zap.Logger) LeaderboardRepository {
+	return &leaderboardRepository{db: db, log: log}
+}
+
+// monthIndex numbers months consecutively so a range of months spanning a
+// year boundary can be compared on the target tables' tahun and bulan.
+func monthIndex(t time.Time) int {
+	return t.Year()*12 + int(t.Month()) - 1
+}
+
+// GetStandings returns every active marketer, of one branch or nationally,
+// with their targets, verified closings and decided leads between from and
+// to. Both bounds are first days of a month; to is exclusive.
+func (r *leaderboardRepository) GetStandings(kantorCabangID *uint, from, to time.Time) ([]dto.LeaderboardStanding, error) {
+	query := `
+		SELECT u.id AS marketing_id, u.nip AS marketing_nip, u.nama AS marketing_name,
+			u.kantor_cabang_id, kc.nama AS branch_name,
+			COALESCE(t.target, 0) AS target,
+			COALESCE(cl.achieved, 0) AS achieved,
+			COALESCE(cl.closings, 0) AS closings,
+			COALESCE(d.closed_leads, 0) AS closed_leads,
+			COALESCE(d.decided_leads, 0) AS decided_leads
+		FROM users u
+		LEFT JOIN kantor_cabang kc ON kc.id = u.kantor_cabang_id
+		LEFT JOIN (
+			SELECT marketing_id, SUM(target_amount) AS target
+			FROM marketing_target_bulanan
+			WHERE tahun * 12 + bulan - 1 >= ? AND tahun * 12 + bulan - 1 < ?
+			AND deleted_at IS NULL
+			GROUP BY marketing_id
+		) t ON t.marketing_id = u.id
+		LEFT JOIN (
+			SELECT marketing_id, SUM(amount) AS achieved, COUNT(*) AS closings
+			FROM closings
+			WHERE closed_at >= ?::date AND closed_at < ?::date
+			AND verification_status = ?
+			AND deleted_at IS NULL
+			GROUP BY marketing_id
+		) cl ON cl.marketing_id = u.id
+		LEFT JOIN (
+			SELECT mc.marketing_id,
+				COUNT(DISTINCT e.marketing_customer_id) FILTER (WHERE e.new_status = ?) AS closed_leads,
+				COUNT(DISTINCT e.marketing_customer_id) AS decided_leads
+			FROM marketing_customer_events e
+			JOIN marketing_customers mc ON mc.id = e.marketing_customer_id
+			WHERE e.event_type = ? AND e.new_status IN (?, ?)
+			AND e.created_at >= ? AND e.created_at < ?
+			GROUP BY mc.marketing_id
+		) d ON d.marketing_id = u.id
+		WHERE u.role = 'marketing' AND u.deleted_at IS NULL`
+	args := []interface{}{
+		monthIndex(from), monthIndex(to),
+		from.Format("2006-01-02"), to.Format("2006-01-02"), model.ClosingVerified,
+		string(model.CustomerStatusClosed), model.LeadEventStatusChanged,
+		string(model.CustomerStatusClosed), string(model.CustomerStatusRejected), from, to,
+	}
+	if kantorCabangID != nil {
+		query += " AND u.kantor_cabang_id = ?"
+		args = append(args, *kantorCabangID)
+	}
+
+	var standings []dto.LeaderboardStanding
+	if err := r.db.Raw(query+" ORDER BY u.nip", args...).Scan(&standings).Error; err != nil {
+		return nil, fmt.Errorf("error getting leaderboard standings: %v", err)
+	}
+	return standings, nil
+}
+
+// GetMonthlyResults returns, per marketer and month between from and to,
+// the target and verified achievement. Months without either are left out.
+func (r *leaderboardRepository) GetMonthlyResults(kantorCabangID *uint, marketingID *uint, from, to time.Time) ([]dto.MonthlyResult, error) {
+	query := `
+		SELECT u.id AS marketing_id,
+			COALESCE(t.tahun, cl.tahun) AS tahun,
+			COALESCE(t.bulan, cl.bulan) AS bulan,
+			COALESCE(t.target, 0) AS target,
+			COALESCE(cl.achieved, 0) AS achieved,
+			COALESCE(cl.closings, 0) AS closings
+		FROM (
+			SELECT marketing_id, tahun, bulan, SUM(target_amount) AS target
+			FROM marketing_target_bulanan
+			WHERE tahun * 12 + bulan - 1 >= ? AND tahun * 12 + bulan - 1 < ?
+			AND deleted_at IS NULL
+			GROUP BY marketing_id, tahun, bulan
+		) t
+		FULL OUTER JOIN (
+			SELECT marketing_id,
+				EXTRACT(YEAR FROM closed_at)::int AS tahun,
+				EXTRACT(MONTH FROM closed_at)::int AS bulan,
+				SUM(amount) AS achieved, COUNT(*) AS closings
+			FROM closings
+			WHERE closed_at >= ?::date AND closed_at < ?::date
+			AND verification_status = ?
+			AND deleted_at IS NULL
+			GROUP BY 1, 2, 3
+		) cl ON cl.marketing_id = t.marketing_id AND cl.tahun = t.tahun AND cl.bulan = t.bulan
+		JOIN users u ON u.id = COALESCE(t.marketing_id, cl.marketing_id)
+		WHERE u.role = 'marketing' AND u.deleted_at IS NULL`
+	args := []interface{}{
+		monthIndex(from), monthIndex(to),
+		from.Format("2006-01-02"), to.Format("2006-01-02"), model.ClosingVerified,
+	}
+	if kantorCabangID != nil {
+		query += " AND u.kantor_cabang_id = ?"
+		args = append(args, *kantorCabangID)
+	}
+	if marketingID != nil {
+		query += " AND u.id = ?"
+		args = append(args, *marketingID)
+	}
+
+	var results []dto.MonthlyResult
+	if err := r.db.Raw(query+" ORDER BY 1, 2, 3", args...).Scan(&results).Error; err != nil {
+		return nil, fmt.Errorf("error getting monthly results: %v", err)
+	}
+	return results, nil
+}
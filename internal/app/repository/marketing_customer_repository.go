@@ -7,9 +7,11 @@ import (
 	"ml-prediction/internal/app/model"
 	"slices"
 	"strconv"
+	"time"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type MarketingCustomerRepository interface {
@@ -18,10 +20,18 @@ type MarketingCustomerRepository interface {
 	FindByCIFWithTx(tx *gorm.DB, cif string) (*model.Customer, error)
 	CheckCustomerAssignmentExists(tx *gorm.DB, customerID uint64) (bool, error)
 	CreateWithTx(tx *gorm.DB, mc *model.MarketingCustomer) error
-	CheckAndCreateAssignment(tx *gorm.DB, customerID uint64, marketingID uint) (*model.MarketingCustomer, error)
-	GetMonthlyMonitoring(month, year int) ([]dto.MarketingMonitoringResponse, error)
+	CheckAndCreateAssignment(tx *gorm.DB, customerID uint64, marketingID uint, assignedBy *uint, reservedUntil *time.Time) (*model.MarketingCustomer, error)
+	ExtendReservationWithTx(tx *gorm.DB, id uint, reservedUntil time.Time) error
+	FindOpenByMarketingWithTx(tx *gorm.DB, marketingID uint, finalStates []string) ([]model.MarketingCustomer, error)
+	TransferWithTx(tx *gorm.DB, mc *model.MarketingCustomer, toMarketingID uint, actorID uint, reservedUntil *time.Time, reason string) (*model.MarketingCustomer, error)
+	FindExpiredReservations(now time.Time, limit int) ([]model.MarketingCustomer, error)
+	ReleaseWithTx(tx *gorm.DB, mc *model.MarketingCustomer, now time.Time, note string) (bool, error)
+	GetMonthlyMonitoring(month, year int, kantorCabangID *uint) ([]dto.MarketingMonitoringResponse, error)
 	// GetMarketingTargets(tx *gorm.DB, req *dto.MonitoringRequest) ([]dto.MarketingTargetDetail, error)
 	GetProductPerformance(tx *gorm.DB, req *dto.ProductPerformanceRequest) (*dto.ProductPerformanceResponse, error)
+	FindActiveByCustomerIDWithTx(tx *gorm.DB, customerID uint64) (*model.MarketingCustomer, error)
+	CreateEventWithTx(tx *gorm.DB, event *model.MarketingCustomerEvent) error
+	GetLeadEvents(cif string, scope dto.LeadEventScope) ([]dto.LeadEventResponse, error)
 }
 
 type marketingCustomerRepository struct {
@@ -34,9 +44,13 @@ func NewMarketingCustomerRepository(db *gorm.DB,
 	return &marketingCustomerRepository{db: db, log: log}
 }
 
+// FindByCifAndMarketingNIP locks the marketer's active lead on the customer,
+// so a reservation release cannot retire it while the caller's transaction
+// works on it.
 func (r *marketingCustomerRepository) FindByCifAndMarketingNIP(tx *gorm.DB, CIF string, NIP string) (*model.MarketingCustomer, error) {
 	var mc model.MarketingCustomer
 	err := tx.Table("marketing_customers").
+		Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "marketing_customers"}}).
 		Joins("JOIN customers ON customers.id = marketing_customers.customer_id").
 		Joins("JOIN users ON users.id = marketing_customers.marketing_id").
 		Where("customers.cif = ? AND users.nip = ? AND marketing_customers.deleted_at IS NULL",
@@ -51,8 +65,20 @@ func (r *marketingCustomerRepository) FindByCifAndMarketingNIP(tx *gorm.DB, CIF
 	return &mc, nil
 }
 
+// UpdateStatusWithTx writes only the columns a status change touches, so the
+// rest of the row, such as deleted_at, is never overwritten from a stale copy.
 func (r *marketingCustomerRepository) UpdateStatusWithTx(tx *gorm.DB, mc *model.MarketingCustomer) error {
-	return tx.Save(mc).Error
+	result := tx.Model(mc).
+		Select("status", "status_changed_at", "product_id", "amount", "rejection_reason_id", "notes",
+			"next_follow_up_at", "follow_up_reason", "follow_up_overdue_at", "reserved_until", "updated_at").
+		Updates(mc)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
 }
 
 func (r *marketingCustomerRepository) FindByCIFWithTx(tx *gorm.DB, cif string) (*model.Customer, error) {
@@ -75,35 +101,235 @@ func (r *marketingCustomerRepository) CheckCustomerAssignmentExists(tx *gorm.DB,
 func (r *marketingCustomerRepository) CreateWithTx(tx *gorm.DB, mc *model.MarketingCustomer) error {
 	return tx.Create(mc).Error
 }
-func (r *marketingCustomerRepository) CheckAndCreateAssignment(tx *gorm.DB, customerID uint64, marketingID uint) (*model.MarketingCustomer, error) {
-	// Use a single query with FOR UPDATE to prevent race conditions
-	var count int64
-	err := tx.Model(&model.MarketingCustomer{}).
-		Where("customer_id = ? AND deleted_at IS NULL", customerID).
-		Count(&count).Error
 
-	if err != nil {
-		return nil, fmt.Errorf("Gagal mengecek assignment : %v", err)
+// CheckAndCreateAssignment gives an unassigned customer to a marketer.
+// assignedBy is the marketer itself for self-claimed leads, the BM who
+// directed the work, or nil for leads handed out by the distribution engine.
+// The customer row is locked so concurrent claims queue up; every claim but
+// the first gets a *dto.LeadHeldError naming the holder.
+func (r *marketingCustomerRepository) CheckAndCreateAssignment(tx *gorm.DB, customerID uint64, marketingID uint, assignedBy *uint, reservedUntil *time.Time) (*model.MarketingCustomer, error) {
+	var customer model.Customer
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").
+		Where("id = ?", customerID).
+		First(&customer).Error; err != nil {
+		return nil, fmt.Errorf("Gagal mengunci customer: %v", err)
 	}
 
-	if count > 0 {
-		return nil, fmt.Errorf("Customer telah memiliki assignment lain")
+	var holder model.MarketingCustomer
+	err := tx.Preload("Marketing", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("customer_id = ?", customerID).
+		First(&holder).Error
+	if err == nil {
+		return nil, &dto.LeadHeldError{
+			CustomerID:    customerID,
+			HolderID:      holder.MarketingID,
+			HolderNIP:     holder.Marketing.NIP,
+			HolderName:    holder.Marketing.Nama,
+			Status:        holder.Status,
+			ReservedUntil: holder.ReservedUntil,
+		}
+	}
+	if err != gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("Gagal mengecek assignment : %v", err)
 	}
 
-	// Create new assignment since none exists
 	newAssignment := &model.MarketingCustomer{
-		CustomerID:  customerID,
-		MarketingID: marketingID,
-		Status:      string(model.CustomerStatusNew),
+		CustomerID:    customerID,
+		MarketingID:   marketingID,
+		Status:        string(model.CustomerStatusNew),
+		AssignedBy:    assignedBy,
+		ReservedUntil: reservedUntil,
 	}
 
 	if err := tx.Create(newAssignment).Error; err != nil {
 		return nil, fmt.Errorf("Gagal membuat assignment: %v", err)
 	}
 
+	if err := r.CreateEventWithTx(tx, &model.MarketingCustomerEvent{
+		MarketingCustomerID: newAssignment.ID,
+		EventType:           model.LeadEventAssigned,
+		NewStatus:           newAssignment.Status,
+		ActorID:             assignedBy,
+	}); err != nil {
+		return nil, err
+	}
+
 	return newAssignment, nil
 }
 
+// FindActiveByCustomerIDWithTx locks the active assignment of a customer and
+// loads the marketer holding it, who may have resigned and been deleted.
+func (r *marketingCustomerRepository) FindActiveByCustomerIDWithTx(tx *gorm.DB, customerID uint64) (*model.MarketingCustomer, error) {
+	var mc model.MarketingCustomer
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: clause.CurrentTable}}).
+		Preload("Marketing", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("customer_id = ? AND deleted_at IS NULL", customerID).
+		First(&mc).Error; err != nil {
+		return nil, err
+	}
+	return &mc, nil
+}
+
+// FindOpenByMarketingWithTx locks the open leads of a marketer and loads
+// their customers.
+func (r *marketingCustomerRepository) FindOpenByMarketingWithTx(tx *gorm.DB, marketingID uint, finalStates []string) ([]model.MarketingCustomer, error) {
+	var leads []model.MarketingCustomer
+	query := tx.Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: clause.CurrentTable}}).
+		Preload("Customer").
+		Where("marketing_id = ?", marketingID)
+	if len(finalStates) > 0 {
+		query = query.Where("status NOT IN ?", finalStates)
+	}
+	if err := query.Order("id ASC").Find(&leads).Error; err != nil {
+		return nil, fmt.Errorf("error finding open leads: %v", err)
+	}
+	return leads, nil
+}
+
+// TransferWithTx hands a lead to another marketer. The current row is closed
+// and a new row continues the lead with the same status, notes and
+// follow-up, so history and activities stay with the marketer who did the
+// work. Both rows get a history entry with the reason.
+func (r *marketingCustomerRepository) TransferWithTx(tx *gorm.DB, mc *model.MarketingCustomer, toMarketingID uint, actorID uint, reservedUntil *time.Time, reason string) (*model.MarketingCustomer, error) {
+	if err := tx.Model(&model.MarketingCustomer{}).
+		Where("id = ?", mc.ID).
+		UpdateColumns(map[string]interface{}{
+			"deleted_at":           time.Now(),
+			"next_follow_up_at":    nil,
+			"follow_up_overdue_at": nil,
+		}).Error; err != nil {
+		return nil, fmt.Errorf("Gagal menutup lead lama: %v", err)
+	}
+
+	next := &model.MarketingCustomer{
+		CustomerID:        mc.CustomerID,
+		MarketingID:       toMarketingID,
+		Status:            mc.Status,
+		ProductID:         mc.ProductID,
+		Amount:            mc.Amount,
+		Notes:             mc.Notes,
+		AssignedBy:        &actorID,
+		TransferredFromID: &mc.ID,
+		StatusChangedAt:   mc.StatusChangedAt,
+		ReservedUntil:     reservedUntil,
+		NextFollowUpAt:    mc.NextFollowUpAt,
+		FollowUpReason:    mc.FollowUpReason,
+		FollowUpOverdueAt: mc.FollowUpOverdueAt,
+	}
+	if err := tx.Omit(clause.Associations).Create(next).Error; err != nil {
+		return nil, fmt.Errorf("Gagal membuat lead baru: %v", err)
+	}
+
+	status := mc.Status
+	for _, event := range []*model.MarketingCustomerEvent{
+		{MarketingCustomerID: mc.ID, EventType: model.LeadEventTransferOut},
+		{MarketingCustomerID: next.ID, EventType: model.LeadEventTransferIn},
+	} {
+		event.OldStatus = &status
+		event.NewStatus = status
+		event.ActorID = &actorID
+		event.Note = reason
+		if err := r.CreateEventWithTx(tx, event); err != nil {
+			return nil, err
+		}
+	}
+	return next, nil
+}
+
+// ExtendReservationWithTx pushes back the expiry of an open lead. Finished
+// leads carry no reservation and are left alone.
+func (r *marketingCustomerRepository) ExtendReservationWithTx(tx *gorm.DB, id uint, reservedUntil time.Time) error {
+	if err := tx.Model(&model.MarketingCustomer{}).
+		Where("id = ? AND reserved_until IS NOT NULL AND reserved_until < ?", id, reservedUntil).
+		UpdateColumn("reserved_until", reservedUntil).Error; err != nil {
+		return fmt.Errorf("Gagal memperpanjang reservasi lead: %v", err)
+	}
+	return nil
+}
+
+// FindExpiredReservations returns active leads whose reservation ran out,
+// oldest first.
+func (r *marketingCustomerRepository) FindExpiredReservations(now time.Time, limit int) ([]model.MarketingCustomer, error) {
+	var leads []model.MarketingCustomer
+	if err := r.db.Where("reserved_until < ?", now).
+		Order("reserved_until ASC, id ASC").
+		Limit(limit).
+		Find(&leads).Error; err != nil {
+		return nil, fmt.Errorf("error finding expired reservations: %v", err)
+	}
+	return leads, nil
+}
+
+// ReleaseWithTx returns an expired lead to the pool and notes it in the lead
+// history. It reports false when the reservation was extended in the
+// meantime.
+func (r *marketingCustomerRepository) ReleaseWithTx(tx *gorm.DB, mc *model.MarketingCustomer, now time.Time, note string) (bool, error) {
+	result := tx.Model(&model.MarketingCustomer{}).
+		Where("id = ? AND reserved_until < ?", mc.ID, now).
+		UpdateColumns(map[string]interface{}{
+			"deleted_at":           now,
+			"next_follow_up_at":    nil,
+			"follow_up_overdue_at": nil,
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("Gagal melepas lead: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	status := mc.Status
+	if err := r.CreateEventWithTx(tx, &model.MarketingCustomerEvent{
+		MarketingCustomerID: mc.ID,
+		EventType:           model.LeadEventReleased,
+		OldStatus:           &status,
+		NewStatus:           mc.Status,
+		Note:                note,
+	}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *marketingCustomerRepository) CreateEventWithTx(tx *gorm.DB, event *model.MarketingCustomerEvent) error {
+	if err := tx.Create(event).Error; err != nil {
+		return fmt.Errorf("Gagal mencatat riwayat lead: %v", err)
+	}
+	return nil
+}
+
+// GetLeadEvents returns the history of every assignment of the customer,
+// including earlier assignments, limited to the given scope.
+func (r *marketingCustomerRepository) GetLeadEvents(cif string, scope dto.LeadEventScope) ([]dto.LeadEventResponse, error) {
+	var events []dto.LeadEventResponse
+
+	query := r.db.Table("marketing_customer_events e").
+		Select(`e.id, e.marketing_customer_id, mc.marketing_id, m.nama AS marketing_name,
+			e.event_type, e.old_status, e.new_status, e.actor_id, a.nama AS actor_name, a.nip AS actor_nip, a.role AS actor_role,
+			COALESCE(e.note, '') AS note, e.product_id, p.nama AS product_name, e.amount, e.channel,
+			rr.code AS rejection_reason, rr.label AS rejection_label, e.created_at`).
+		Joins("JOIN marketing_customers mc ON mc.id = e.marketing_customer_id").
+		Joins("JOIN customers c ON c.id = mc.customer_id").
+		Joins("JOIN users m ON m.id = mc.marketing_id").
+		Joins("LEFT JOIN users a ON a.id = e.actor_id").
+		Joins("LEFT JOIN products p ON p.id = e.product_id").
+		Joins("LEFT JOIN rejection_reasons rr ON rr.id = e.rejection_reason_id").
+		Where("c.cif = ?", cif)
+
+	if scope.MarketingID != nil {
+		query = query.Where("mc.marketing_id = ?", *scope.MarketingID)
+	}
+	if scope.KantorCabangID != nil {
+		query = query.Where("m.kantor_cabang_id = ?", *scope.KantorCabangID)
+	}
+
+	if err := query.Order("e.created_at ASC, e.id ASC").Scan(&events).Error; err != nil {
+		return nil, fmt.Errorf("error getting lead history: %v", err)
+	}
+	return events, nil
+}
+
 // func (r *marketingCustomerRepository) GetMonthlyMonitoring(tx *gorm.DB, month, year int) ([]dto.MarketingMonitoringResponse, error) {
 // 	var result []dto.MarketingMonitoringResponse
 
@@ -188,27 +414,40 @@ func (r *marketingCustomerRepository) CheckAndCreateAssignment(tx *gorm.DB, cust
 // 	return result, nil
 // }
 
-func (r *marketingCustomerRepository) GetMonthlyMonitoring(month, year int) ([]dto.MarketingMonitoringResponse, error) {
+// GetMonthlyMonitoring returns the month's target and achievement of every
+// marketer, or only of the marketers of a branch when kantorCabangID is set.
+func (r *marketingCustomerRepository) GetMonthlyMonitoring(month, year int, kantorCabangID *uint) ([]dto.MarketingMonitoringResponse, error) {
 	var tempResult []struct {
 		MarketingNIP   string  `gorm:"column:marketing_nip"`
 		MarketingName  string  `gorm:"column:marketing_name"`
 		MonthlyTarget  float64 `gorm:"column:monthly_target"`
 		MonthlyClosing float64 `gorm:"column:monthly_closing"`
 		CarryOver      float64 `gorm:"column:carry_over"`
+		MonthlyPending float64 `gorm:"column:monthly_pending"`
 	}
 
-	// Main query remains the same until the SELECT part
+	// Achievement is counted from the closing lines booked in the month.
+	prevMonth, prevYear := month-1, year
+	if prevMonth < 1 {
+		prevMonth, prevYear = 12, year-1
+	}
+	args := []interface{}{month, year, month, year, prevMonth, prevYear, prevMonth, prevYear, month, year}
+	branchFilter := ""
+	if kantorCabangID != nil {
+		branchFilter = "AND u.kantor_cabang_id = ?"
+		args = append(args, *kantorCabangID)
+	}
 	err := r.db.Raw(`
       WITH monthly_closings AS (
         SELECT 
             u.id,
-            COALESCE(SUM(mc.amount), 0) as closing_amount
+            COALESCE(SUM(cl.amount), 0) as closing_amount
         FROM users u
-        LEFT JOIN marketing_customers mc ON mc.marketing_id = u.id 
-            AND mc.status = 'closed'
-            AND mc.deleted_at IS NULL
-            AND EXTRACT(MONTH FROM mc.updated_at) = ?
-            AND EXTRACT(YEAR FROM mc.updated_at) = ?
+        LEFT JOIN closings cl ON cl.marketing_id = u.id 
+            AND cl.verification_status = 'verified'
+            AND cl.deleted_at IS NULL
+            AND EXTRACT(MONTH FROM cl.closed_at) = ?
+            AND EXTRACT(YEAR FROM cl.closed_at) = ?
         WHERE u.role = 'marketing'
         AND u.deleted_at IS NULL
         GROUP BY u.id
@@ -230,7 +469,7 @@ func (r *marketingCustomerRepository) GetMonthlyMonitoring(month, year int) ([]d
         SELECT 
             u.id,
             GREATEST(
-                COALESCE(SUM(mc.amount), 0) - 
+                COALESCE(SUM(cl.amount), 0) - 
                 COALESCE((
                     SELECT SUM(mt.target_amount) 
                     FROM marketing_target_bulanan mt 
@@ -242,29 +481,43 @@ func (r *marketingCustomerRepository) GetMonthlyMonitoring(month, year int) ([]d
                 0
             ) as carry_over
         FROM users u
-        LEFT JOIN marketing_customers mc ON mc.marketing_id = u.id
-            AND mc.status = 'closed'
-            AND mc.deleted_at IS NULL
-            AND EXTRACT(MONTH FROM mc.updated_at) = ?
-            AND EXTRACT(YEAR FROM mc.updated_at) = ?
+        LEFT JOIN closings cl ON cl.marketing_id = u.id
+            AND cl.verification_status = 'verified'
+            AND cl.deleted_at IS NULL
+            AND EXTRACT(MONTH FROM cl.closed_at) = ?
+            AND EXTRACT(YEAR FROM cl.closed_at) = ?
         WHERE u.role = 'marketing'
         AND u.deleted_at IS NULL
         GROUP BY u.id
+    ),
+    monthly_pending AS (
+        SELECT 
+            cl.marketing_id as id,
+            SUM(cl.amount) as pending_amount
+        FROM closings cl
+        WHERE cl.verification_status = 'pending_verification'
+        AND cl.deleted_at IS NULL
+        AND EXTRACT(MONTH FROM cl.closed_at) = ?
+        AND EXTRACT(YEAR FROM cl.closed_at) = ?
+        GROUP BY cl.marketing_id
     )
         SELECT 
             u.nip as marketing_nip,
             u.nama as marketing_name,
             COALESCE(mt.target_amount, 0) as monthly_target,
             COALESCE(mc.closing_amount, 0) as monthly_closing,
-            COALESCE(pe.carry_over, 0) as carry_over
+            COALESCE(pe.carry_over, 0) as carry_over,
+            COALESCE(mp.pending_amount, 0) as monthly_pending
         FROM users u
         LEFT JOIN monthly_closings mc ON mc.id = u.id
         LEFT JOIN monthly_targets mt ON mt.id = u.id
         LEFT JOIN previous_excess pe ON pe.id = u.id
+        LEFT JOIN monthly_pending mp ON mp.id = u.id
         WHERE u.role = 'marketing'
         AND u.deleted_at IS NULL
+        `+branchFilter+`
         ORDER BY monthly_closing DESC
-    `, month, year, month, year, month-1, year, month-1, year).
+    `, args...).
 		Scan(&tempResult).Error
 
 	if err != nil {
@@ -282,6 +535,7 @@ func (r *marketingCustomerRepository) GetMonthlyMonitoring(month, year int) ([]d
 			MarketingName:   temp.MarketingName,
 			MonthlyAchieved: monthlyAchieved,
 			MonthlyTarget:   temp.MonthlyTarget,
+			MonthlyPending:  temp.MonthlyPending,
 			Labels:          []string{"Tercapai", "Belum"},
 			Datasets: []dto.ChartDataset{
 				{
@@ -300,58 +554,64 @@ func (r *marketingCustomerRepository) GetMonthlyMonitoring(month, year int) ([]d
 		}
 
 		err := r.db.Raw(`
-			WITH product_data AS (
+			WITH marketing AS (
+				SELECT id FROM users WHERE nip = ? AND deleted_at IS NULL
+			),
+			product_data AS (
 				SELECT 
 					p.id as product_id,
 					p.nama as product_name,
-					COALESCE(mt.target_amount, 0) as monthly_target,
-					(COALESCE(SUM(mc.amount), 0) + 
-					COALESCE(
-						GREATEST(
-							COALESCE((
-								SELECT SUM(pmc.amount)
-								FROM marketing_customers pmc
-								WHERE pmc.marketing_id = (SELECT id FROM users WHERE nip = ? AND deleted_at IS NULL)
-								AND pmc.product_id = p.id
-								AND pmc.status = 'closed'
-								AND EXTRACT(MONTH FROM pmc.updated_at) = ?
-								AND EXTRACT(YEAR FROM pmc.updated_at) = ?
-								AND pmc.deleted_at IS NULL
-							), 0) - 
-							COALESCE((
-								SELECT target_amount
-								FROM marketing_target_bulanan
-								WHERE marketing_id = (SELECT id FROM users WHERE nip = ? AND deleted_at IS NULL)
-								AND product_id = p.id
-								AND bulan = ?
-								AND tahun = ?
-								AND deleted_at IS NULL
-							), 0),
-							0
-						),
+					COALESCE((
+						SELECT SUM(mt.target_amount)
+						FROM marketing_target_bulanan mt
+						WHERE mt.marketing_id = (SELECT id FROM marketing)
+						AND mt.product_id = p.id
+						AND mt.bulan = ?
+						AND mt.tahun = ?
+						AND mt.deleted_at IS NULL
+					), 0) as monthly_target,
+					(COALESCE((
+						SELECT SUM(cl.amount)
+						FROM closings cl
+						WHERE cl.marketing_id = (SELECT id FROM marketing)
+						AND cl.product_id = p.id
+						AND EXTRACT(MONTH FROM cl.closed_at) = ?
+						AND EXTRACT(YEAR FROM cl.closed_at) = ?
+						AND cl.verification_status = 'verified'
+						AND cl.deleted_at IS NULL
+					), 0) + 
+					GREATEST(
+						COALESCE((
+							SELECT SUM(pcl.amount)
+							FROM closings pcl
+							WHERE pcl.marketing_id = (SELECT id FROM marketing)
+							AND pcl.product_id = p.id
+							AND EXTRACT(MONTH FROM pcl.closed_at) = ?
+							AND EXTRACT(YEAR FROM pcl.closed_at) = ?
+							AND pcl.verification_status = 'verified'
+							AND pcl.deleted_at IS NULL
+						), 0) - 
+						COALESCE((
+							SELECT SUM(target_amount)
+							FROM marketing_target_bulanan
+							WHERE marketing_id = (SELECT id FROM marketing)
+							AND product_id = p.id
+							AND bulan = ?
+							AND tahun = ?
+							AND deleted_at IS NULL
+						), 0),
 						0
 					)) as monthly_achieved
 				FROM products p
-				LEFT JOIN marketing_target_bulanan mt ON mt.product_id = p.id
-					AND mt.marketing_id = (SELECT id FROM users WHERE nip = ? AND deleted_at IS NULL)
-					AND mt.bulan = ? 
-					AND mt.tahun = ?
-					AND mt.deleted_at IS NULL
-				LEFT JOIN marketing_customers mc ON mc.product_id = p.id
-					AND mc.marketing_id = mt.marketing_id
-					AND mc.status = 'closed'
-					AND EXTRACT(MONTH FROM mc.updated_at) = ?
-					AND EXTRACT(YEAR FROM mc.updated_at) = ?
-					AND mc.deleted_at IS NULL
 				WHERE p.deleted_at IS NULL
-				GROUP BY p.id, p.nama, mt.target_amount
 				ORDER BY p.id
 			)
 			SELECT * FROM product_data
-		`, temp.MarketingNIP, month-1, year,
-			temp.MarketingNIP, month-1, year,
-			temp.MarketingNIP, month, year,
-			month, year).Scan(&productDetails).Error
+		`, temp.MarketingNIP,
+			month, year,
+			month, year,
+			prevMonth, prevYear,
+			prevMonth, prevYear).Scan(&productDetails).Error
 
 		if err != nil {
 			return nil, fmt.Errorf("error getting product details: %v", err)
@@ -416,6 +676,12 @@ func (r *marketingCustomerRepository) GetProductPerformance(tx *gorm.DB, req *dt
 	}
 
 	// Query with branch_total (sum of all marketing achievements)
+	args := []interface{}{timeFormat, req.StartDate, req.EndDate, req.GroupBy, req.ProductID, timeFormat}
+	branchFilter := ""
+	if req.KantorCabangID != nil {
+		branchFilter = "AND u.kantor_cabang_id = ?"
+		args = append(args, *req.KantorCabangID)
+	}
 	err = tx.Raw(`
     WITH RECURSIVE time_periods AS (
         SELECT 
@@ -435,16 +701,17 @@ func (r *marketingCustomerRepository) GetProductPerformance(tx *gorm.DB, req *dt
             tp.time_label,
             u.nip as marketing_nip,
             u.nama as marketing_name,
-            COALESCE(SUM(mc.amount), 0) as achievement
+            COALESCE(SUM(cl.amount), 0) as achievement
         FROM time_periods tp
         CROSS JOIN users u
-        LEFT JOIN marketing_customers mc ON mc.marketing_id = u.id
-            AND mc.product_id = ?
-            AND mc.status = 'closed'
-            AND TO_CHAR(mc.updated_at, ?) = tp.time_label
-            AND mc.deleted_at IS NULL
+        LEFT JOIN closings cl ON cl.marketing_id = u.id
+            AND cl.product_id = ?
+            AND TO_CHAR(cl.closed_at, ?) = tp.time_label
+            AND cl.verification_status = 'verified'
+            AND cl.deleted_at IS NULL
         WHERE u.role = 'marketing'
         AND u.deleted_at IS NULL
+        `+branchFilter+`
         GROUP BY tp.time_label, u.id, u.nip, u.nama
     ),
     branch_total AS (
@@ -461,8 +728,7 @@ func (r *marketingCustomerRepository) GetProductPerformance(tx *gorm.DB, req *dt
     UNION ALL
     SELECT * FROM marketing_achievements
     ORDER BY time_label, marketing_nip
-    `, timeFormat, req.StartDate, req.EndDate, req.GroupBy,
-		req.ProductID, timeFormat).Scan(&timeSeries).Error
+    `, args...).Scan(&timeSeries).Error
 
 	if err != nil {
 		return nil, fmt.Errorf("error getting time series data: %v", err)
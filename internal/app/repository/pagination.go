@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	dto "ml-prediction/internal/app/domain"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultCursorLimit = 10
+	maxCursorLimit     = 100
+)
+
+func normalizeCursorLimit(limit int) int {
+	if limit <= 0 {
+		return defaultCursorLimit
+	}
+	if limit > maxCursorLimit {
+		return maxCursorLimit
+	}
+	return limit
+}
+
+// countForCursor returns the total row count for a keyset listing according to
+// the requested mode. The query must not carry ordering, limit or keyset
+// conditions yet.
+func countForCursor(db *gorm.DB, query *gorm.DB, mode string) (*int64, bool, error) {
+	switch mode {
+	case dto.TotalExact:
+		var count int64
+		if err := query.Session(&gorm.Session{}).Count(&count).Error; err != nil {
+			return nil, false, fmt.Errorf("error counting rows: %v", err)
+		}
+		return &count, false, nil
+	case dto.TotalEstimate:
+		estimate, err := estimateRows(db, query)
+		if err != nil {
+			return nil, false, err
+		}
+		return &estimate, true, nil
+	default:
+		return nil, false, nil
+	}
+}
+
+// estimateRows asks the PostgreSQL planner how many rows the query would
+// return, which is far cheaper than COUNT(*) on large tables.
+func estimateRows(db *gorm.DB, query *gorm.DB) (int64, error) {
+	var rows []map[string]interface{}
+	stmt := query.Session(&gorm.Session{DryRun: true}).Find(&rows).Statement
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return 0, fmt.Errorf("error estimating rows: %v", err)
+	}
+
+	var plan string
+	if err := sqlDB.QueryRow("EXPLAIN (FORMAT JSON) "+stmt.SQL.String(), stmt.Vars...).Scan(&plan); err != nil {
+		return 0, fmt.Errorf("error estimating rows: %v", err)
+	}
+
+	var parsed []struct {
+		Plan struct {
+			Rows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal([]byte(plan), &parsed); err != nil || len(parsed) == 0 {
+		return 0, fmt.Errorf("error parsing query plan: %v", err)
+	}
+	return int64(parsed[0].Plan.Rows), nil
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"fmt"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/model"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type PIIAccessRepository interface {
+	FindOwners(customerIDs []uint64) (map[uint64]dto.CustomerOwner, error)
+	LogAccess(entries []model.PIIAccessLog) error
+}
+
+type piiAccessRepository struct {
+	db  *gorm.DB
+	log *zap.Logger
+}
+
+func NewPIIAccessRepository(db *gorm.DB, log *zap.Logger) PIIAccessRepository {
+	return &piiAccessRepository{db: db, log: log}
+}
+
+// FindOwners returns the active assignment of each customer together with the
+// branch of the assigned marketer. Unassigned customers are absent from the map.
+func (r *piiAccessRepository) FindOwners(customerIDs []uint64) (map[uint64]dto.CustomerOwner, error) {
+	owners := make(map[uint64]dto.CustomerOwner, len(customerIDs))
+	if len(customerIDs) == 0 {
+		return owners, nil
+	}
+
+	var rows []dto.CustomerOwner
+	if err := r.db.Table("marketing_customers mc").
+		Select("mc.customer_id, mc.marketing_id, u.kantor_cabang_id").
+		Joins("JOIN users u ON u.id = mc.marketing_id").
+		Where("mc.customer_id IN ? AND mc.deleted_at IS NULL", customerIDs).
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("error finding customer owners: %v", err)
+	}
+	for _, row := range rows {
+		owners[row.CustomerID] = row
+	}
+	return owners, nil
+}
+
+func (r *piiAccessRepository) LogAccess(entries []model.PIIAccessLog) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if err := r.db.Create(&entries).Error; err != nil {
+		return fmt.Errorf("error logging PII access: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"fmt"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/model"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type RejectionRepository interface {
+	FindAll(activeOnly bool) ([]model.RejectionReason, error)
+	FindByID(id uint) (*model.RejectionReason, error)
+	FindActiveByCode(code string) (*model.RejectionReason, error)
+	Create(reason *model.RejectionReason) error
+	Update(reason *model.RejectionReason) error
+	GetCounts(from, to time.Time, kantorCabangID *uint) ([]dto.RejectionCount, error)
+}
+
+type rejectionRepository struct {
+	db  *gorm.DB
+	log *zap.Logger
+}
+
+func NewRejectionRepository(db *gorm.DB, log *zap.Logger) RejectionRepository {
+	return &rejectionRepository{db: db, log: log}
+}
+
+func (r *rejectionRepository) FindAll(activeOnly bool) ([]model.RejectionReason, error) {
+	var reasons []model.RejectionReason
+	query := r.db.Order("sort_order ASC, id ASC")
+	if activeOnly {
+		query = query.Where("active")
+	}
+	if err := query.Find(&reasons).Error; err != nil {
+		return nil, fmt.Errorf("error finding rejection reasons: %v", err)
+	}
+	return reasons, nil
+}
+
+func (r *rejectionRepository) FindByID(id uint) (*model.RejectionReason, error) {
+	var reason model.RejectionReason
+	if err := r.db.Where("id = ?", id).First(&reason).Error; err != nil {
+		return nil, err
+	}
+	return &reason, nil
+}
+
+func (r *rejectionRepository) FindActiveByCode(code string) (*model.RejectionReason, error) {
+	var reason model.RejectionReason
+	if err := r.db.Where("code = ? AND active", code).First(&reason).Error; err != nil {
+		return nil, err
+	}
+	return &reason, nil
+}
+
+func (r *rejectionRepository) Create(reason *model.RejectionReason) error {
+	return r.db.Create(reason).Error
+}
+
+func (r *rejectionRepository) Update(reason *model.RejectionReason) error {
+	return r.db.Save(reason).Error
+}
+
+// GetCounts counts the rejections recorded in the period by reason, product,
+// segment, marketer and predicted rank. Rejections are read from the lead
+// history so reopened leads still count.
+func (r *rejectionRepository) GetCounts(from, to time.Time, kantorCabangID *uint) ([]dto.RejectionCount, error) {
+	var counts []dto.RejectionCount
+
+	query := r.db.Table("marketing_customer_events e").
+		Select(`rr.code AS reason_code, rr.label AS reason_label,
+			p.id AS product_id, p.nama AS product_name,
+			COALESCE(NULLIF(c.segmen, ''), '-') AS segment,
+			m.nip AS marketing_nip, m.nama AS marketing_name,
+			cp."order" AS predicted_rank,
+			COUNT(*) AS total`).
+		Joins("JOIN rejection_reasons rr ON rr.id = e.rejection_reason_id").
+		Joins("JOIN marketing_customers mc ON mc.id = e.marketing_customer_id").
+		Joins("JOIN customers c ON c.id = mc.customer_id").
+		Joins("JOIN users m ON m.id = mc.marketing_id").
+		Joins(`LEFT JOIN LATERAL (SELECT top.product_id FROM customer_products top
+			WHERE top.customer_id = c.id ORDER BY top."order" ASC LIMIT 1) top ON TRUE`).
+		Joins("LEFT JOIN products p ON p.id = COALESCE(e.product_id, top.product_id)").
+		Joins("LEFT JOIN customer_products cp ON cp.customer_id = c.id AND cp.product_id = p.id").
+		Where("e.created_at >= ? AND e.created_at < ?", from, to)
+	if kantorCabangID != nil {
+		query = query.Where("m.kantor_cabang_id = ?", *kantorCabangID)
+	}
+
+	if err := query.
+		Group(`rr.code, rr.label, p.id, p.nama, COALESCE(NULLIF(c.segmen, ''), '-'), m.nip, m.nama, cp."order"`).
+		Scan(&counts).Error; err != nil {
+		return nil, fmt.Errorf("error counting rejections: %v", err)
+	}
+	return counts, nil
+}
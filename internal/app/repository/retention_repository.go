@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"fmt"
+	"math"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/model"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type RetentionRepository interface {
+	CreateRun(run *model.RetentionRun) error
+	UpdateRun(run *model.RetentionRun) error
+	GetRuns(req *dto.RetentionRunListRequest) ([]model.RetentionRun, *dto.Pagination, error)
+	GetRun(id uint64) (*model.RetentionRun, error)
+
+	FindRejectedLeads(cutoff time.Time, afterID uint64, limit int) ([]uint64, error)
+	FindStaleProspects(cutoff time.Time, afterID uint64, limit int) ([]uint64, error)
+	FindDeletedWithHistory(cutoff time.Time, afterID uint64, limit int) ([]uint64, error)
+	FindDeletedWithoutHistory(cutoff time.Time, afterID uint64, limit int) ([]uint64, error)
+
+	FindDocumentKeysWithTx(tx *gorm.DB, customerIDs []uint64) ([]string, error)
+	AnonymizeWithTx(tx *gorm.DB, customerIDs []uint64) error
+	PurgeWithTx(tx *gorm.DB, customerIDs []uint64) error
+	AddItemsWithTx(tx *gorm.DB, items []model.RetentionRunItem) error
+}
+
+type retentionRepository struct {
+	db  *gorm.DB
+	log *zap.Logger
+}
+
+func NewRetentionRepository(db *gorm.DB, log *zap.Logger) RetentionRepository {
+	return &retentionRepository{db: db, log: log}
+}
+
+func (r *retentionRepository) CreateRun(run *model.RetentionRun) error {
+	return r.db.Create(run).Error
+}
+
+func (r *retentionRepository) UpdateRun(run *model.RetentionRun) error {
+	return r.db.Omit("Items").Save(run).Error
+}
+
+func (r *retentionRepository) GetRuns(req *dto.RetentionRunListRequest) ([]model.RetentionRun, *dto.Pagination, error) {
+	var runs []model.RetentionRun
+	var count int64
+
+	if err := r.db.Model(&model.RetentionRun{}).Count(&count).Error; err != nil {
+		return nil, nil, fmt.Errorf("error counting retention runs: %v", err)
+	}
+	if err := r.db.Order("started_at DESC, id DESC").
+		Offset((req.Page - 1) * req.Limit).
+		Limit(req.Limit).
+		Find(&runs).Error; err != nil {
+		return nil, nil, fmt.Errorf("error finding retention runs: %v", err)
+	}
+
+	meta := &dto.Pagination{
+		CurrentPage: req.Page,
+		PerPage:     req.Limit,
+		TotalItems:  count,
+		TotalPages:  int64(math.Ceil(float64(count) / float64(req.Limit))),
+	}
+	return runs, meta, nil
+}
+
+func (r *retentionRepository) GetRun(id uint64) (*model.RetentionRun, error) {
+	var run model.RetentionRun
+	if err := r.db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).Where("id = ?", id).First(&run).Error; err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// FindRejectedLeads returns customers whose active lead was rejected before
+// the cutoff. The rejection time is the status change, since later edits such
+// as notes also touch updated_at.
+func (r *retentionRepository) FindRejectedLeads(cutoff time.Time, afterID uint64, limit int) ([]uint64, error) {
+	var ids []uint64
+	err := r.db.Table("customers c").
+		Joins("JOIN marketing_customers mc ON mc.customer_id = c.id AND mc.deleted_at IS NULL").
+		Where("mc.status = ? AND COALESCE(mc.status_changed_at, mc.updated_at) < ?", model.CustomerStatusRejected, cutoff).
+		Where("c.anonymized_at IS NULL AND c.deleted_at IS NULL AND c.id > ?", afterID).
+		Order("c.id ASC").
+		Limit(limit).
+		Pluck("c.id", &ids).Error
+	return ids, err
+}
+
+// FindStaleProspects returns customers created before the cutoff that were
+// never worked by any marketer.
+func (r *retentionRepository) FindStaleProspects(cutoff time.Time, afterID uint64, limit int) ([]uint64, error) {
+	var ids []uint64
+	err := r.db.Table("customers c").
+		Where("c.created_at < ? AND c.anonymized_at IS NULL AND c.deleted_at IS NULL AND c.id > ?", cutoff, afterID).
+		Where("NOT EXISTS (SELECT 1 FROM marketing_customers mc WHERE mc.customer_id = c.id)").
+		Order("c.id ASC").
+		Limit(limit).
+		Pluck("c.id", &ids).Error
+	return ids, err
+}
+
+// FindDeletedWithHistory returns soft-deleted customers that still carry lead
+// history. They cannot be purged without changing achievement reports, so
+// they are anonymised instead.
+func (r *retentionRepository) FindDeletedWithHistory(cutoff time.Time, afterID uint64, limit int) ([]uint64, error) {
+	var ids []uint64
+	err := r.db.Table("customers c").
+		Where("c.deleted_at < ? AND c.anonymized_at IS NULL AND c.id > ?", cutoff, afterID).
+		Where("EXISTS (SELECT 1 FROM marketing_customers mc WHERE mc.customer_id = c.id)").
+		Order("c.id ASC").
+		Limit(limit).
+		Pluck("c.id", &ids).Error
+	return ids, err
+}
+
+func (r *retentionRepository) FindDeletedWithoutHistory(cutoff time.Time, afterID uint64, limit int) ([]uint64, error) {
+	var ids []uint64
+	err := r.db.Table("customers c").
+		Where("c.deleted_at < ? AND c.id > ?", cutoff, afterID).
+		Where("NOT EXISTS (SELECT 1 FROM marketing_customers mc WHERE mc.customer_id = c.id)").
+		Order("c.id ASC").
+		Limit(limit).
+		Pluck("c.id", &ids).Error
+	return ids, err
+}
+
+// FindDocumentKeysWithTx returns the storage keys of every document of the
+// customers, including documents already removed from their lead.
+func (r *retentionRepository) FindDocumentKeysWithTx(tx *gorm.DB, customerIDs []uint64) ([]string, error) {
+	var keys []string
+	if len(customerIDs) == 0 {
+		return keys, nil
+	}
+	if err := tx.Table("lead_documents").
+		Where("customer_id IN ?", customerIDs).
+		Order("id ASC").
+		Pluck("storage_key", &keys).Error; err != nil {
+		return nil, fmt.Errorf("error finding lead documents: %v", err)
+	}
+	return keys, nil
+}
+
+// AnonymizeWithTx replaces identifying columns with random tokens that cannot
+// be traced back to the customer. Aggregate attributes such as age, income,
+// segment and lead outcomes are kept so reports stay correct. Lead documents
+// are removed entirely; the caller deletes their stored files.
+func (r *retentionRepository) AnonymizeWithTx(tx *gorm.DB, customerIDs []uint64) error {
+	if len(customerIDs) == 0 {
+		return nil
+	}
+
+	if err := tx.Exec(`UPDATE customers c SET
+			cif = 'ANON-' || t.token,
+			nama = 'ANON-' || t.token,
+			nama_perusahaan = 'ANONYMIZED',
+			nomor_rekening = '',
+			nomor_hp = '',
+			email = '',
+			address = '',
+			nomor_rekening_bidx = NULL,
+			nomor_hp_bidx = NULL,
+			email_bidx = NULL,
+			anonymized_at = NOW()
+		FROM (SELECT id, substr(md5(gen_random_uuid()::text), 1, 24) AS token FROM customers WHERE id IN ?) t
+		WHERE c.id = t.id AND c.anonymized_at IS NULL`, customerIDs).Error; err != nil {
+		return fmt.Errorf("error anonymizing customers: %v", err)
+	}
+	if err := tx.Exec("UPDATE marketing_customers SET notes = '', follow_up_reason = NULL WHERE customer_id IN ?", customerIDs).Error; err != nil {
+		return fmt.Errorf("error clearing lead notes: %v", err)
+	}
+	if err := tx.Exec(`UPDATE marketing_customer_events SET note = NULL
+		WHERE marketing_customer_id IN (SELECT id FROM marketing_customers WHERE customer_id IN ?)`, customerIDs).Error; err != nil {
+		return fmt.Errorf("error clearing lead history notes: %v", err)
+	}
+	if err := tx.Exec(`UPDATE lead_activities SET notes = NULL, next_step = NULL, latitude = NULL, longitude = NULL, location_name = NULL
+		WHERE marketing_customer_id IN (SELECT id FROM marketing_customers WHERE customer_id IN ?)`, customerIDs).Error; err != nil {
+		return fmt.Errorf("error clearing lead activity details: %v", err)
+	}
+	if err := tx.Exec(`UPDATE follow_up_reminders SET reason = NULL
+		WHERE marketing_customer_id IN (SELECT id FROM marketing_customers WHERE customer_id IN ?)`, customerIDs).Error; err != nil {
+		return fmt.Errorf("error clearing follow-up reminders: %v", err)
+	}
+	if err := tx.Exec("UPDATE customer_consents SET notes = '' WHERE customer_id IN ?", customerIDs).Error; err != nil {
+		return fmt.Errorf("error clearing consent notes: %v", err)
+	}
+	if err := tx.Exec("UPDATE closings SET account_reference = NULL WHERE customer_id IN ?", customerIDs).Error; err != nil {
+		return fmt.Errorf("error clearing closing account references: %v", err)
+	}
+	if err := tx.Exec("DELETE FROM lead_documents WHERE customer_id IN ?", customerIDs).Error; err != nil {
+		return fmt.Errorf("error removing lead documents: %v", err)
+	}
+	return nil
+}
+
+// PurgeWithTx deletes the customers together with their lead documents; the
+// caller deletes the stored files.
+func (r *retentionRepository) PurgeWithTx(tx *gorm.DB, customerIDs []uint64) error {
+	if len(customerIDs) == 0 {
+		return nil
+	}
+	if err := tx.Exec(`DELETE FROM lead_documents WHERE customer_id IN
+		(SELECT id FROM customers WHERE id IN ? AND deleted_at IS NOT NULL)`, customerIDs).Error; err != nil {
+		return fmt.Errorf("error removing lead documents: %v", err)
+	}
+	if err := tx.Exec("DELETE FROM customers WHERE id IN ? AND deleted_at IS NOT NULL", customerIDs).Error; err != nil {
+		return fmt.Errorf("error purging customers: %v", err)
+	}
+	return nil
+}
+
+func (r *retentionRepository) AddItemsWithTx(tx *gorm.DB, items []model.RetentionRunItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	return tx.CreateInBatches(&items, 500).Error
+}
@@ -5,6 +5,7 @@ import (
 	"math"
 	dto "ml-prediction/internal/app/domain"
 	"ml-prediction/internal/app/model"
+	"ml-prediction/pkg/helper"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -29,6 +30,7 @@ type TargetRepository interface {
 	UpdateTargetBulananWithTx(tx *gorm.DB, target *model.TargetProdukBulanan) error
 	CreateTargetBulananWithTx(tx *gorm.DB, target *model.TargetProdukBulanan) error
 	GetMarketingTargets(req *dto.MonitoringRequest) ([]dto.MarketingTargetDetail, error)
+	GetMarketingTargetsCursor(req *dto.MonitoringRequest) ([]dto.MarketingTargetDetail, *dto.CursorPagination, error)
 	GetTargetSummary(tx *gorm.DB, userID uint, role string, month, year int) (*dto.TargetSummaryResponse, error)
 	GetBranchTargets(branchID uint, month, year int) ([]struct {
 		ProductID    uint    `gorm:"column:product_id"`
@@ -153,16 +155,14 @@ func (r *targetRepository) CreateTargetBulananWithTx(tx *gorm.DB, target *model.
 	return tx.Create(target).Error
 }
 
-func (r *targetRepository) GetMarketingTargets(req *dto.MonitoringRequest) ([]dto.MarketingTargetDetail, error) {
-	type marketingTargetTemp struct {
-		MarketingNIP  string  `gorm:"column:marketing_nip"`
-		MarketingName string  `gorm:"column:marketing_name"`
-		HasTarget     bool    `gorm:"column:has_target"`
-		TotalTarget   float64 `gorm:"column:total_target"`
-	}
-
-	var tempDetails []marketingTargetTemp
+type marketingTargetTemp struct {
+	MarketingNIP  string  `gorm:"column:marketing_nip"`
+	MarketingName string  `gorm:"column:marketing_name"`
+	HasTarget     bool    `gorm:"column:has_target"`
+	TotalTarget   float64 `gorm:"column:total_target"`
+}
 
+func marketingTargetsQuery(req *dto.MonitoringRequest) (string, []interface{}) {
 	query := `
         WITH marketing_targets AS (
             SELECT 
@@ -178,6 +178,7 @@ func (r *targetRepository) GetMarketingTargets(req *dto.MonitoringRequest) ([]dt
                 AND mt.deleted_at IS NULL
             WHERE u.role = 'marketing'
                 AND u.deleted_at IS NULL
+                %s
             GROUP BY u.id, u.nip, u.nama
         )
         SELECT * FROM marketing_targets mt
@@ -185,6 +186,13 @@ func (r *targetRepository) GetMarketingTargets(req *dto.MonitoringRequest) ([]dt
     `
 	args := []interface{}{req.Month, req.Year}
 
+	branchFilter := ""
+	if req.KantorCabangID != nil {
+		branchFilter = "AND u.kantor_cabang_id = ?"
+		args = append(args, *req.KantorCabangID)
+	}
+	query = fmt.Sprintf(query, branchFilter)
+
 	if req.HasTarget != nil {
 		query += " AND mt.has_target = ?"
 		args = append(args, *req.HasTarget)
@@ -201,6 +209,13 @@ func (r *targetRepository) GetMarketingTargets(req *dto.MonitoringRequest) ([]dt
 		args = append(args, req.NIP)
 	}
 
+	return query, args
+}
+
+func (r *targetRepository) GetMarketingTargets(req *dto.MonitoringRequest) ([]dto.MarketingTargetDetail, error) {
+	var tempDetails []marketingTargetTemp
+
+	query, args := marketingTargetsQuery(req)
 	query += " ORDER BY mt.marketing_name"
 
 	err := r.db.Raw(query, args...).Scan(&tempDetails).Error
@@ -208,6 +223,68 @@ func (r *targetRepository) GetMarketingTargets(req *dto.MonitoringRequest) ([]dt
 		return nil, fmt.Errorf("error getting target data: %v", err)
 	}
 
+	return r.withProductTargets(tempDetails, req)
+}
+
+// marketingTargetCursor is the keyset position for the monitoring list, ordered
+// by marketing name with the NIP as a unique tie-breaker.
+type marketingTargetCursor struct {
+	Name string `json:"name"`
+	NIP  string `json:"nip"`
+}
+
+func (r *targetRepository) GetMarketingTargetsCursor(req *dto.MonitoringRequest) ([]dto.MarketingTargetDetail, *dto.CursorPagination, error) {
+	limit := normalizeCursorLimit(req.Limit)
+	query, args := marketingTargetsQuery(req)
+
+	meta := &dto.CursorPagination{PerPage: limit}
+	switch req.Total {
+	case dto.TotalExact, dto.TotalEstimate:
+		// The monitoring list is bounded by the number of marketers, so an
+		// exact count is always cheap enough.
+		var count int64
+		if err := r.db.Raw("SELECT COUNT(*) FROM ("+query+") counted", args...).Scan(&count).Error; err != nil {
+			return nil, nil, fmt.Errorf("error counting target data: %v", err)
+		}
+		meta.TotalItems = &count
+	}
+
+	if req.Cursor != "" {
+		var position marketingTargetCursor
+		if err := helper.DecodeCursor(req.Cursor, &position); err != nil {
+			return nil, nil, err
+		}
+		query += " AND (mt.marketing_name, mt.marketing_nip) > (?, ?)"
+		args = append(args, position.Name, position.NIP)
+	}
+
+	query += " ORDER BY mt.marketing_name, mt.marketing_nip LIMIT ?"
+	args = append(args, limit+1)
+
+	var tempDetails []marketingTargetTemp
+	if err := r.db.Raw(query, args...).Scan(&tempDetails).Error; err != nil {
+		return nil, nil, fmt.Errorf("error getting target data: %v", err)
+	}
+
+	if len(tempDetails) > limit {
+		tempDetails = tempDetails[:limit]
+		last := tempDetails[limit-1]
+		next, err := helper.EncodeCursor(marketingTargetCursor{Name: last.MarketingName, NIP: last.MarketingNIP})
+		if err != nil {
+			return nil, nil, err
+		}
+		meta.HasMore = true
+		meta.NextCursor = next
+	}
+
+	details, err := r.withProductTargets(tempDetails, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	return details, meta, nil
+}
+
+func (r *targetRepository) withProductTargets(tempDetails []marketingTargetTemp, req *dto.MonitoringRequest) ([]dto.MarketingTargetDetail, error) {
 	// Convert to final response structure
 	details := make([]dto.MarketingTargetDetail, len(tempDetails))
 	for i, temp := range tempDetails {
@@ -273,15 +350,15 @@ func (r *targetRepository) GetTargetSummary(tx *gorm.DB, userID uint, role strin
 		query = `
 			WITH branch_achievements AS (
 				SELECT 
-					mc.product_id,
-					SUM(mc.amount) as amount
-				FROM marketing_customers mc
-				JOIN users u ON mc.marketing_id = u.id AND u.kantor_cabang_id = ?
-				WHERE mc.status = 'closed'
-				AND EXTRACT(MONTH FROM mc.updated_at) = ?
-				AND EXTRACT(YEAR FROM mc.updated_at) = ?
-				AND mc.deleted_at IS NULL
-				GROUP BY mc.product_id
+					cl.product_id,
+					SUM(cl.amount) as amount
+				FROM closings cl
+				JOIN users u ON cl.marketing_id = u.id AND u.kantor_cabang_id = ?
+				WHERE EXTRACT(MONTH FROM cl.closed_at) = ?
+				AND EXTRACT(YEAR FROM cl.closed_at) = ?
+				AND cl.verification_status = 'verified'
+				AND cl.deleted_at IS NULL
+				GROUP BY cl.product_id
 			)
 			SELECT 
 				COALESCE(SUM(tb.target_amount), 0) as target,
@@ -292,28 +369,52 @@ func (r *targetRepository) GetTargetSummary(tx *gorm.DB, userID uint, role strin
 						SELECT product_id FROM target_produk_bulanan 
 						WHERE bulan = ? AND tahun = ? AND kantor_cabang_id = ?
 					)
-				), 0) as achieved
+				), 0) as achieved,
+				COALESCE((
+					SELECT SUM(cl.amount)
+					FROM closings cl
+					JOIN users u ON cl.marketing_id = u.id AND u.kantor_cabang_id = ?
+					WHERE EXTRACT(MONTH FROM cl.closed_at) = ?
+					AND EXTRACT(YEAR FROM cl.closed_at) = ?
+					AND cl.verification_status = 'pending_verification'
+					AND cl.deleted_at IS NULL
+				), 0) as pending
 			FROM target_produk_bulanan tb
 			WHERE tb.bulan = ? AND tb.tahun = ? AND tb.kantor_cabang_id = ?
 			AND tb.deleted_at IS NULL
 			`
-		args = []interface{}{user.BranchID, month, year, month, year, user.BranchID, month, year, user.BranchID}
+		args = []interface{}{user.BranchID, month, year, month, year, user.BranchID, user.BranchID, month, year, month, year, user.BranchID}
 	} else {
 		query = `
 				SELECT 
-					COALESCE(SUM(mt.target_amount), 0) as target,
-					COALESCE(SUM(CASE WHEN mc.status = 'closed' THEN mc.amount ELSE 0 END), 0) as achieved
+					COALESCE((
+						SELECT SUM(mt.target_amount)
+						FROM marketing_target_bulanan mt
+						WHERE mt.marketing_id = params.marketing_id
+						AND mt.bulan = params.month AND mt.tahun = params.year
+						AND mt.deleted_at IS NULL
+					), 0) as target,
+					COALESCE((
+						SELECT SUM(cl.amount)
+						FROM closings cl
+						WHERE cl.marketing_id = params.marketing_id
+						AND EXTRACT(MONTH FROM cl.closed_at) = params.month
+						AND EXTRACT(YEAR FROM cl.closed_at) = params.year
+						AND cl.verification_status = 'verified'
+						AND cl.deleted_at IS NULL
+					), 0) as achieved,
+					COALESCE((
+						SELECT SUM(cl.amount)
+						FROM closings cl
+						WHERE cl.marketing_id = params.marketing_id
+						AND EXTRACT(MONTH FROM cl.closed_at) = params.month
+						AND EXTRACT(YEAR FROM cl.closed_at) = params.year
+						AND cl.verification_status = 'pending_verification'
+						AND cl.deleted_at IS NULL
+					), 0) as pending
 				FROM (
 					SELECT ?::integer as marketing_id, ?::integer as month, ?::integer as year
 				) params
-				LEFT JOIN marketing_target_bulanan mt ON mt.marketing_id = params.marketing_id
-					AND mt.bulan = params.month AND mt.tahun = params.year
-					AND mt.deleted_at IS NULL
-				LEFT JOIN marketing_customers mc ON mc.marketing_id = params.marketing_id
-					AND mc.status = 'closed'
-					AND EXTRACT(MONTH FROM mc.updated_at) = params.month
-					AND EXTRACT(YEAR FROM mc.updated_at) = params.year
-					AND mc.deleted_at IS NULL
 			`
 		args = []interface{}{userID, month, year}
 	}
@@ -323,6 +424,7 @@ func (r *targetRepository) GetTargetSummary(tx *gorm.DB, userID uint, role strin
 		ProductName string  `gorm:"column:product_name"`
 		Target      float64 `gorm:"column:target"`
 		Achieved    float64 `gorm:"column:achieved"`
+		Pending     float64 `gorm:"column:pending"`
 	}
 
 	if err := tx.Raw(query, args...).Scan(&products).Error; err != nil {
@@ -332,6 +434,7 @@ func (r *targetRepository) GetTargetSummary(tx *gorm.DB, userID uint, role strin
 	for _, p := range products {
 		response.TotalTarget += p.Target
 		response.Achieved += p.Achieved
+		response.Pending += p.Pending
 	}
 
 	var branch struct {
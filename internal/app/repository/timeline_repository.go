@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"fmt"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/model"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// TimelineRepository reads the separate histories that make up a customer's
+// activity timeline.
+type TimelineRepository interface {
+	FindCustomerByCIF(cif string) (*model.Customer, error)
+	GetPredictionRuns(customerID uint64) ([]model.CustomerPredictionRun, error)
+	GetRecommendations(customerID uint64) ([]dto.TimelineRecommendation, error)
+	GetAssignments(customerID uint64) ([]dto.TimelineAssignment, error)
+	GetStatusEvents(customerID uint64) ([]dto.LeadEventResponse, error)
+}
+
+type timelineRepository struct {
+	db  *gorm.DB
+	log *zap.Logger
+}
+
+func NewTimelineRepository(db *gorm.DB, log *zap.Logger) TimelineRepository {
+	return &timelineRepository{db: db, log: log}
+}
+
+func (r *timelineRepository) FindCustomerByCIF(cif string) (*model.Customer, error) {
+	var customer model.Customer
+	if err := r.db.Where("cif = ?", cif).First(&customer).Error; err != nil {
+		return nil, err
+	}
+	return &customer, nil
+}
+
+func (r *timelineRepository) GetPredictionRuns(customerID uint64) ([]model.CustomerPredictionRun, error) {
+	var runs []model.CustomerPredictionRun
+	if err := r.db.Where("customer_id = ?", customerID).
+		Order("created_at ASC, id ASC").
+		Find(&runs).Error; err != nil {
+		return nil, fmt.Errorf("error getting prediction runs: %v", err)
+	}
+	return runs, nil
+}
+
+func (r *timelineRepository) GetRecommendations(customerID uint64) ([]dto.TimelineRecommendation, error) {
+	var recommendations []dto.TimelineRecommendation
+	if err := r.db.Table("customer_products cp").
+		Select("cp.product_id, p.nama, cp.order, cp.created_at").
+		Joins("JOIN products p ON cp.product_id = p.id").
+		Where("cp.customer_id = ?", customerID).
+		Order("cp.order ASC").
+		Scan(&recommendations).Error; err != nil {
+		return nil, fmt.Errorf("error getting recommendations: %v", err)
+	}
+	return recommendations, nil
+}
+
+// GetAssignments includes soft-deleted assignments so reassignments remain
+// visible.
+func (r *timelineRepository) GetAssignments(customerID uint64) ([]dto.TimelineAssignment, error) {
+	var assignments []dto.TimelineAssignment
+	if err := r.db.Table("marketing_customers mc").
+		Select(`mc.id, mc.marketing_id, u.nama AS marketing_name, u.nip AS marketing_nip, u.kantor_cabang_id,
+			mc.assigned_by AS assigned_by_id, a.nama AS assigned_by_name, a.nip AS assigned_by_nip, a.role AS assigned_by_role,
+			mc.created_at, mc.deleted_at`).
+		Joins("JOIN users u ON u.id = mc.marketing_id").
+		Joins("LEFT JOIN users a ON a.id = mc.assigned_by").
+		Where("mc.customer_id = ?", customerID).
+		Order("mc.created_at ASC, mc.id ASC").
+		Scan(&assignments).Error; err != nil {
+		return nil, fmt.Errorf("error getting assignments: %v", err)
+	}
+	return assignments, nil
+}
+
+// GetStatusEvents returns the status changes of the customer's leads and the
+// closings booked or voided on them.
+func (r *timelineRepository) GetStatusEvents(customerID uint64) ([]dto.LeadEventResponse, error) {
+	var events []dto.LeadEventResponse
+	if err := r.db.Table("marketing_customer_events e").
+		Select(`e.id, e.marketing_customer_id, mc.marketing_id, m.nama AS marketing_name,
+			e.event_type, e.old_status, e.new_status, e.actor_id, a.nama AS actor_name, a.nip AS actor_nip, a.role AS actor_role,
+			COALESCE(e.note, '') AS note, e.product_id, p.nama AS product_name, e.amount, e.channel,
+			rr.code AS rejection_reason, rr.label AS rejection_label, e.created_at`).
+		Joins("JOIN marketing_customers mc ON mc.id = e.marketing_customer_id").
+		Joins("JOIN users m ON m.id = mc.marketing_id").
+		Joins("LEFT JOIN users a ON a.id = e.actor_id").
+		Joins("LEFT JOIN products p ON p.id = e.product_id").
+		Joins("LEFT JOIN rejection_reasons rr ON rr.id = e.rejection_reason_id").
+		Where("mc.customer_id = ? AND e.event_type IN ?", customerID,
+			[]string{model.LeadEventStatusChanged, model.LeadEventClosingAdded, model.LeadEventClosingVoided}).
+		Order("e.created_at ASC, e.id ASC").
+		Scan(&events).Error; err != nil {
+		return nil, fmt.Errorf("error getting status events: %v", err)
+	}
+	return events, nil
+}
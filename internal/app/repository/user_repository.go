@@ -15,6 +15,7 @@ type UserRepository interface {
 	CreateUser(c *fiber.Ctx, user *model.User) (*model.User, error)
 	ExistsByNama(c *fiber.Ctx, nama string) (bool, error)
 	FindByNIPWithTx(tx *gorm.DB, nip string) (*model.User, error)
+	FindByNIPUnscoped(nip string) (*model.User, error)
 }
 type userRepository struct {
 	db  *gorm.DB
@@ -34,6 +35,14 @@ func (r *userRepository) FindByNIP(nip string) (*model.User, error) {
 	return &user, err
 }
 
+// FindByNIPUnscoped is FindByNIP including deleted users, e.g. a marketer
+// who resigned but still holds leads.
+func (r *userRepository) FindByNIPUnscoped(nip string) (*model.User, error) {
+	var user model.User
+	err := r.db.Unscoped().Preload("KantorCabang").Where("nip = ?", nip).First(&user).Error
+	return &user, err
+}
+
 func (r *userRepository) CreateUser(c *fiber.Ctx, user *model.User) (*model.User, error) {
 
 	for {
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"fmt"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/model"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// worklistScoreColumn reads the recommendation score of the candidate's
+// product from the customer's latest prediction run.
+const worklistScoreColumn = `(SELECT (pr.scores ->> p.prediksi)::float FROM customer_prediction_runs pr
+	WHERE pr.customer_id = c.id ORDER BY pr.created_at DESC, pr.id DESC LIMIT 1) AS score`
+
+type WorklistRepository interface {
+	GetSetting(kantorCabangID uint) (*model.WorklistSetting, error)
+	SaveSetting(setting *model.WorklistSetting) error
+
+	GetOpenLeads(marketingID uint, finalStates []string) ([]dto.WorklistCandidate, error)
+	GetPoolCandidates(kantorCabangID uint, consent *dto.ConsentFilter, limit int) ([]dto.WorklistCandidate, error)
+	GetTargets(marketingID uint, month, year int) ([]dto.WorklistTarget, error)
+}
+
+type worklistRepository struct {
+	db  *gorm.DB
+	log *zap.Logger
+}
+
+func NewWorklistRepository(db *gorm.DB, log *zap.Logger) WorklistRepository {
+	return &worklistRepository{db: db, log: log}
+}
+
+func (r *worklistRepository) GetSetting(kantorCabangID uint) (*model.WorklistSetting, error) {
+	var setting model.WorklistSetting
+	if err := r.db.Where("kantor_cabang_id = ?", kantorCabangID).First(&setting).Error; err != nil {
+		return nil, err
+	}
+	return &setting, nil
+}
+
+func (r *worklistRepository) SaveSetting(setting *model.WorklistSetting) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "kantor_cabang_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"weight_score", "weight_plafond", "weight_follow_up", "weight_target_gap", "updated_by", "updated_at",
+		}),
+	}).Create(setting).Error
+}
+
+// GetOpenLeads returns the marketer's leads that are not closed yet. A lead
+// without a product is ranked on the customer's top recommended product.
+func (r *worklistRepository) GetOpenLeads(marketingID uint, finalStates []string) ([]dto.WorklistCandidate, error) {
+	var candidates []dto.WorklistCandidate
+	if err := r.db.Table("marketing_customers mc").
+		Select(`c.id AS customer_id, c.cif, c.nama, mc.id AS marketing_customer_id, mc.status,
+			p.id AS product_id, p.nama AS product_name, cp.plafon_max,
+			mc.next_follow_up_at, mc.follow_up_overdue_at AS overdue_at, `+worklistScoreColumn).
+		Joins("JOIN customers c ON c.id = mc.customer_id").
+		Joins(`LEFT JOIN LATERAL (SELECT cp.product_id, cp.plafon_max FROM customer_products cp
+			WHERE cp.customer_id = c.id AND (mc.product_id IS NULL OR cp.product_id = mc.product_id)
+			ORDER BY cp."order" ASC LIMIT 1) cp ON TRUE`).
+		Joins("LEFT JOIN products p ON p.id = COALESCE(mc.product_id, cp.product_id)").
+		Where("mc.marketing_id = ? AND mc.deleted_at IS NULL AND mc.status NOT IN ?", marketingID, finalStates).
+		Where("c.deleted_at IS NULL AND c.anonymized_at IS NULL").
+		Order("mc.id ASC").
+		Scan(&candidates).Error; err != nil {
+		return nil, fmt.Errorf("error getting worklist leads: %v", err)
+	}
+	return candidates, nil
+}
+
+// GetPoolCandidates returns the unassigned customers of a branch with the
+// highest recommendation score for their top product.
+func (r *worklistRepository) GetPoolCandidates(kantorCabangID uint, consent *dto.ConsentFilter, limit int) ([]dto.WorklistCandidate, error) {
+	var candidates []dto.WorklistCandidate
+
+	query := r.db.Table("customers c").
+		Select(`c.id AS customer_id, c.cif, c.nama, p.id AS product_id, p.nama AS product_name, cp.plafon_max, `+worklistScoreColumn).
+		Joins(`LEFT JOIN LATERAL (SELECT cp.product_id, cp.plafon_max FROM customer_products cp
+			WHERE cp.customer_id = c.id ORDER BY cp."order" ASC LIMIT 1) cp ON TRUE`).
+		Joins("LEFT JOIN products p ON p.id = cp.product_id").
+		Where("c.deleted_at IS NULL AND c.anonymized_at IS NULL AND c.merged_into_id IS NULL").
+		Where("c.kantor_cabang_id = ?", kantorCabangID).
+		Where("NOT EXISTS (SELECT 1 FROM marketing_customers mc WHERE mc.customer_id = c.id AND mc.deleted_at IS NULL)")
+	if consent != nil {
+		condition, args := contactableCondition("c", consent)
+		query = query.Where(condition, args...)
+	}
+
+	if err := query.Order("score DESC NULLS LAST, c.id ASC").Limit(limit).Scan(&candidates).Error; err != nil {
+		return nil, fmt.Errorf("error getting worklist pool customers: %v", err)
+	}
+	return candidates, nil
+}
+
+// GetTargets returns the marketer's target of each product for the month
+// with the verified closings counted against it.
+func (r *worklistRepository) GetTargets(marketingID uint, month, year int) ([]dto.WorklistTarget, error) {
+	var targets []dto.WorklistTarget
+	if err := r.db.Raw(`
+		SELECT
+			mt.product_id,
+			SUM(mt.target_amount) AS target,
+			COALESCE((
+				SELECT SUM(cl.amount) FROM closings cl
+				WHERE cl.marketing_id = mt.marketing_id AND cl.product_id = mt.product_id
+				AND cl.verification_status = 'verified' AND cl.deleted_at IS NULL
+				AND EXTRACT(MONTH FROM cl.closed_at) = ? AND EXTRACT(YEAR FROM cl.closed_at) = ?
+			), 0) AS achieved
+		FROM marketing_target_bulanan mt
+		WHERE mt.marketing_id = ? AND mt.bulan = ? AND mt.tahun = ? AND mt.deleted_at IS NULL
+		GROUP BY mt.marketing_id, mt.product_id
+	`, month, year, marketingID, month, year).Scan(&targets).Error; err != nil {
+		return nil, fmt.Errorf("error getting worklist targets: %v", err)
+	}
+	return targets, nil
+}
@@ -2,10 +2,13 @@ package routes
 
 import (
 	"ml-prediction/config"
+	dto "ml-prediction/internal/app/domain"
 	"ml-prediction/internal/app/handler"
 	"ml-prediction/internal/app/repository"
 	"ml-prediction/internal/app/usecase"
 	"ml-prediction/internal/middleware"
+	"ml-prediction/pkg/scheduler"
+	"ml-prediction/pkg/storage"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
@@ -13,7 +16,7 @@ import (
 	"gorm.io/gorm"
 )
 
-func Register(api fiber.Router, db *gorm.DB, cfg config.Configuration, log *zap.Logger, val *validator.Validate) {
+func Register(api fiber.Router, db *gorm.DB, cfg config.Configuration, log *zap.Logger, val *validator.Validate, sched *scheduler.Scheduler) {
 
 	kantorCabangRepo := repository.NewKantorCabangRepository(db, log)
 	kantorCabangService := usecase.NewKantorCabangUsecase(kantorCabangRepo)
@@ -25,8 +28,13 @@ func Register(api fiber.Router, db *gorm.DB, cfg config.Configuration, log *zap.
 
 	customerRepo := repository.NewCustomerRepo(db, log)
 	productRepo := repository.NewProductRepo(db, log)
-	customerService := usecase.NewcustomerUsecase(customerRepo, userRepo, productRepo, db)
+	piiAccessRepo := repository.NewPIIAccessRepository(db, log)
+	consentRepo := repository.NewConsentRepository(db, log)
+	customerBranchRepo := repository.NewCustomerBranchRepository(db, log)
+	customerService := usecase.NewcustomerUsecase(customerRepo, userRepo, productRepo, piiAccessRepo, consentRepo, cfg.Consent, customerBranchRepo, db)
 	customerHandler := handler.NewCustomerHandler(customerService, cfg, val)
+	customerBranchUsecase := usecase.NewCustomerBranchUsecase(customerBranchRepo, userRepo)
+	customerBranchHandler := handler.NewCustomerBranchHandler(customerBranchUsecase, cfg, val)
 
 	targetRepo := repository.NewTargetRepository(db, log)
 	TargetUsecase := usecase.NewTargetUsecase(userRepo, targetRepo, db)
@@ -35,10 +43,87 @@ func Register(api fiber.Router, db *gorm.DB, cfg config.Configuration, log *zap.
 	marketingTargetUsecase := usecase.NewMarketingTargetUsecase(targetRepo, userRepo, db)
 	marketingTargetHandler := handler.NewMarketingTargetHandler(marketingTargetUsecase, cfg, val)
 
+	leadStates, err := dto.LoadLeadStateMachine(cfg.Lead.StateMachineFile, cfg.Lead.ReopenCooldown)
+	if err != nil {
+		log.Fatal("failed to load lead state machine", zap.Error(err))
+	}
 	marketingCustomerRepo := repository.NewMarketingCustomerRepository(db, log)
-	marketingCustomerUsecase := usecase.NewMarketingCustomerUsecase(marketingCustomerRepo, userRepo, db)
+	activityRepo := repository.NewActivityRepository(db, log)
+	rejectionRepo := repository.NewRejectionRepository(db, log)
+	closingRepo := repository.NewClosingRepository(db, log)
+	marketingCustomerUsecase := usecase.NewMarketingCustomerUsecase(marketingCustomerRepo, userRepo, activityRepo, rejectionRepo, closingRepo, consentRepo, cfg.Consent, cfg.Lead, leadStates, db)
 	marketingCustomerHandler := handler.NewMarketingCustomerHandler(marketingCustomerUsecase, cfg, val)
 
+	rejectionUsecase := usecase.NewRejectionUsecase(rejectionRepo, userRepo)
+	rejectionHandler := handler.NewRejectionHandler(rejectionUsecase, cfg, val)
+
+	funnelRepo := repository.NewFunnelRepository(db, log)
+	funnelUsecase := usecase.NewFunnelUsecase(funnelRepo, userRepo, leadStates, cfg.Lead)
+	funnelHandler := handler.NewFunnelHandler(funnelUsecase, cfg, val)
+
+	leaderboardRepo := repository.NewLeaderboardRepository(db, log)
+	leaderboardUsecase := usecase.NewLeaderboardUsecase(leaderboardRepo, userRepo, cfg.Leaderboard)
+	leaderboardHandler := handler.NewLeaderboardHandler(leaderboardUsecase, cfg, val)
+
+	incentiveRepo := repository.NewIncentiveRepository(db, log)
+	incentiveUsecase := usecase.NewIncentiveUsecase(incentiveRepo, userRepo, db)
+	incentiveHandler := handler.NewIncentiveHandler(incentiveUsecase, cfg, val)
+
+	closingUsecase := usecase.NewClosingUsecase(closingRepo, marketingCustomerRepo, userRepo, leadStates, cfg.Lead, db)
+	closingHandler := handler.NewClosingHandler(closingUsecase, cfg, val)
+
+	documentStorage, err := storage.New(storage.Options{
+		Driver:      cfg.Storage.Driver,
+		LocalPath:   cfg.Storage.LocalPath,
+		S3Endpoint:  cfg.Storage.S3Endpoint,
+		S3Region:    cfg.Storage.S3Region,
+		S3Bucket:    cfg.Storage.S3Bucket,
+		S3AccessKey: cfg.Storage.S3AccessKey,
+		S3SecretKey: cfg.Storage.S3SecretKey,
+		S3PathStyle: cfg.Storage.S3PathStyle,
+	})
+	if err != nil {
+		log.Fatal("failed to initialize document storage", zap.Error(err))
+	}
+	documentRepo := repository.NewDocumentRepository(db, log)
+	documentUsecase := usecase.NewDocumentUsecase(documentRepo, marketingCustomerRepo, userRepo, documentStorage, storage.NewScanner(cfg.Document.ScanCommand), cfg.Lead, db)
+	documentHandler := handler.NewDocumentHandler(documentUsecase, cfg, val)
+
+	activityUsecase := usecase.NewActivityUsecase(activityRepo, marketingCustomerRepo, userRepo, consentRepo, cfg.Consent, cfg.Lead, db)
+	activityHandler := handler.NewActivityHandler(activityUsecase, cfg, val)
+
+	assignmentUsecase := usecase.NewAssignmentUsecase(customerRepo, marketingCustomerRepo, userRepo, cfg.Consent, cfg.Lead, leadStates, db)
+	assignmentHandler := handler.NewAssignmentHandler(assignmentUsecase, cfg, val)
+
+	reservationUsecase := usecase.NewReservationUsecase(marketingCustomerRepo, cfg.Lead, db)
+
+	followUpRepo := repository.NewFollowUpRepository(db, log)
+	followUpUsecase := usecase.NewFollowUpUsecase(followUpRepo, marketingCustomerRepo, userRepo, leadStates, cfg.FollowUp, db)
+	followUpHandler := handler.NewFollowUpHandler(followUpUsecase, cfg, val)
+
+	distributionRepo := repository.NewDistributionRepository(db, log)
+	distributionUsecase := usecase.NewDistributionUsecase(distributionRepo, marketingCustomerRepo, userRepo, leadStates, cfg.Consent, cfg.Lead, cfg.Distribution, db)
+	distributionHandler := handler.NewDistributionHandler(distributionUsecase, cfg, val)
+
+	worklistRepo := repository.NewWorklistRepository(db, log)
+	worklistUsecase := usecase.NewWorklistUsecase(worklistRepo, userRepo, leadStates, cfg.Consent, cfg.FollowUp, cfg.Worklist)
+	worklistHandler := handler.NewWorklistHandler(worklistUsecase, cfg, val)
+
+	timelineRepo := repository.NewTimelineRepository(db, log)
+	timelineUsecase := usecase.NewTimelineUsecase(timelineRepo, userRepo)
+	timelineHandler := handler.NewTimelineHandler(timelineUsecase, cfg, val)
+
+	consentUsecase := usecase.NewConsentUsecase(consentRepo, piiAccessRepo, userRepo, cfg.Consent)
+	consentHandler := handler.NewConsentHandler(consentUsecase, cfg, val)
+
+	duplicateRepo := repository.NewDuplicateRepository(db, log)
+	duplicateUsecase := usecase.NewDuplicateUsecase(duplicateRepo, userRepo, cfg.Duplicate, db)
+	duplicateHandler := handler.NewDuplicateHandler(duplicateUsecase, cfg, val)
+
+	retentionRepo := repository.NewRetentionRepository(db, log)
+	retentionUsecase := usecase.NewRetentionUsecase(retentionRepo, userRepo, documentStorage, cfg.Retention, db)
+	retentionHandler := handler.NewRetentionHandler(retentionUsecase, cfg, val)
+
 	productUsecase := usecase.NewProductUsecase(productRepo)
 	productHandler := handler.NewProductHandler(productUsecase)
 
@@ -49,23 +134,87 @@ func Register(api fiber.Router, db *gorm.DB, cfg config.Configuration, log *zap.
 	auth.Post("/create", middleware.JWTMiddleware("admin"), authHandler.CreateUser)
 
 	predict := api.Group("/predictions")
-	predict.Post("/", customerHandler.CreateCustomer)
+	predict.Post("/", middleware.OptionalJWTMiddleware(), customerHandler.CreateCustomer)
 
 	targetsRoute := api.Group("/profile", middleware.JWTMiddleware("marketing", "bm"))
 	targetsRoute.Get("/summary", targetHandler.GetTargetSummary)
 
+	customers := api.Group("/customers", middleware.JWTMiddleware("admin", "bm", "marketing"))
+	customers.Get("/:cif/timeline", timelineHandler.GetCustomerTimeline)
+	customers.Get("/:cif/consents", consentHandler.GetConsents)
+	customers.Post("/:cif/consents", consentHandler.Record)
+	customers.Post("/:cif/consents/withdraw", consentHandler.Withdraw)
+
+	api.Get("/lead-states", middleware.JWTMiddleware("bm", "marketing"), marketingCustomerHandler.GetLeadStates)
+	api.Get("/rejection-reasons", middleware.JWTMiddleware("admin", "bm", "marketing"), rejectionHandler.GetActiveReasons)
+	api.Get("/closing-periods", middleware.JWTMiddleware("admin", "bm", "marketing"), closingHandler.GetPeriods)
+
 	marketing := api.Group("/marketing", middleware.JWTMiddleware("marketing"))
 	marketing.Get("/customers", customerHandler.GetNewCustomers)
 	marketing.Get("/customers/me", customerHandler.GetAssignedCustomers)
 	marketing.Post("/customer/:cif", marketingCustomerHandler.UpdateCustomerStatus)
 	marketing.Get("/customers/:cif", customerHandler.GetCustomerDetail)
+	marketing.Get("/customers/:cif/history", marketingCustomerHandler.GetLeadHistory)
+	marketing.Post("/customers/:cif/activities", activityHandler.Log)
+	marketing.Get("/customers/:cif/activities", activityHandler.GetLeadActivities)
+	marketing.Post("/customers/:cif/closings", closingHandler.Add)
+	marketing.Get("/customers/:cif/closings", closingHandler.GetLeadClosings)
+	marketing.Delete("/customers/:cif/closings/:id", closingHandler.Void)
+	marketing.Post("/customers/:cif/documents", documentHandler.Upload)
+	marketing.Get("/customers/:cif/documents", documentHandler.List)
+	marketing.Delete("/customers/:cif/documents/:id", documentHandler.Delete)
+	marketing.Get("/documents/:id/download", documentHandler.Download)
+	marketing.Get("/activities", activityHandler.GetMarketingActivities)
+	marketing.Put("/customers/:cif/follow-up", followUpHandler.Schedule)
+	marketing.Delete("/customers/:cif/follow-up", followUpHandler.Clear)
+	marketing.Get("/follow-ups", followUpHandler.GetAgenda)
+	marketing.Get("/follow-ups/reminders", followUpHandler.GetReminders)
+	marketing.Post("/follow-ups/reminders/:id/read", followUpHandler.MarkReminderRead)
+	marketing.Get("/worklist", worklistHandler.GetWorklist)
 
 	marketing.Get("/monitoring/target", marketingCustomerHandler.GetMonthlyMonitoringMarketing)
+	marketing.Get("/leaderboard", leaderboardHandler.GetLeaderboard)
+	marketing.Get("/achievements", leaderboardHandler.GetAchievements)
+	marketing.Get("/incentives/statements", incentiveHandler.GetStatements)
+	marketing.Get("/incentives/statements/:id", incentiveHandler.GetStatement)
 
 	kc := api.Group("/kantor-cabang", middleware.JWTMiddleware("admin"))
 	kc.Post("/", kcHandler.Create)
 	kc.Get("/", kcHandler.GetAll)
 
+	admin := api.Group("/admin", middleware.JWTMiddleware("admin"))
+	admin.Get("/customer-branch-rules", customerBranchHandler.GetRules)
+	admin.Post("/customer-branch-rules", customerBranchHandler.CreateRule)
+	admin.Delete("/customer-branch-rules/:id", customerBranchHandler.DeleteRule)
+	admin.Post("/customer-branch-rules/apply", customerBranchHandler.ApplyRules)
+	admin.Put("/customers/:cif/kantor-cabang", customerBranchHandler.SetBranch)
+	admin.Post("/customers/duplicates/scan", duplicateHandler.Scan)
+	admin.Get("/customers/duplicates/scans/:id", duplicateHandler.GetScanRun)
+	admin.Get("/customers/duplicates", duplicateHandler.GetCandidates)
+	admin.Post("/customers/duplicates/:id/dismiss", duplicateHandler.Dismiss)
+	admin.Post("/customers/duplicates/:id/merge", duplicateHandler.Merge)
+	admin.Post("/retention/runs", retentionHandler.Trigger)
+	admin.Get("/retention/runs", retentionHandler.GetRuns)
+	admin.Get("/retention/runs/:id", retentionHandler.GetRun)
+	admin.Get("/rejection-reasons", rejectionHandler.GetAllReasons)
+	admin.Post("/rejection-reasons", rejectionHandler.CreateReason)
+	admin.Put("/rejection-reasons/:id", rejectionHandler.UpdateReason)
+	admin.Get("/reports/rejections", rejectionHandler.GetReport)
+	admin.Get("/reports/funnel", funnelHandler.GetReport)
+	admin.Get("/leaderboard", leaderboardHandler.GetLeaderboard)
+	admin.Post("/incentive-schemes", incentiveHandler.CreateScheme)
+	admin.Get("/incentive-schemes", incentiveHandler.GetSchemes)
+	admin.Delete("/incentive-schemes/:id", incentiveHandler.DeleteScheme)
+	admin.Post("/incentives/calculate", incentiveHandler.Calculate)
+	admin.Get("/incentives/statements", incentiveHandler.GetStatements)
+	admin.Get("/incentives/statements/:id", incentiveHandler.GetStatement)
+	admin.Get("/incentives/export", incentiveHandler.Export)
+	admin.Post("/closing-periods/:tahun/:bulan/lock", closingHandler.LockPeriod)
+	admin.Delete("/closing-periods/:tahun/:bulan/lock", closingHandler.UnlockPeriod)
+	admin.Post("/distribution/runs", distributionHandler.Trigger)
+	admin.Get("/distribution/runs", distributionHandler.GetRuns)
+	admin.Get("/distribution/runs/:id", distributionHandler.GetRun)
+
 	bm := api.Group("/bm", middleware.JWTMiddleware("bm"))
 	bm.Post("/kantor_cabang/target", targetHandler.CreateTargetTahunan)
 	bm.Get("/monitoring/target", marketingCustomerHandler.GetMonthlyMonitoring)
@@ -73,6 +222,81 @@ func Register(api fiber.Router, db *gorm.DB, cfg config.Configuration, log *zap.
 	bm.Get("/monitoring/assignment/:nip", marketingTargetHandler.GetMarketingTargetsDetail)
 	bm.Post("/monitoring/assignment/:nip", marketingTargetHandler.AssignMarketingTarget)
 	bm.Get("/monitoring/product-performance", marketingCustomerHandler.GetProductPerformance)
+	bm.Get("/monitoring/rejections", rejectionHandler.GetReport)
+	bm.Get("/monitoring/funnel", funnelHandler.GetReport)
+	bm.Get("/leaderboard", leaderboardHandler.GetLeaderboard)
+	bm.Get("/marketing/:nip/achievements", leaderboardHandler.GetAchievements)
+	bm.Get("/incentives/statements", incentiveHandler.GetStatements)
+	bm.Get("/incentives/statements/:id", incentiveHandler.GetStatement)
+	bm.Post("/incentives/statements/:id/approve", incentiveHandler.Approve)
+	bm.Post("/incentives/statements/:id/reject", incentiveHandler.Reject)
+	bm.Get("/incentives/export", incentiveHandler.Export)
 
 	bm.Get("/branch-targets", targetHandler.GetBranchMonthlyTarget)
+	bm.Get("/customers/:cif/history", marketingCustomerHandler.GetLeadHistory)
+	bm.Post("/customer/:cif", marketingCustomerHandler.UpdateCustomerStatus)
+	bm.Get("/customers/:cif/activities", activityHandler.GetLeadActivities)
+	bm.Get("/customers/:cif/closings", closingHandler.GetLeadClosings)
+	bm.Get("/customers/:cif/documents", documentHandler.List)
+	bm.Get("/documents/:id/download", documentHandler.Download)
+	bm.Get("/closings/pending", closingHandler.GetPending)
+	bm.Post("/closings/:id/verify", closingHandler.Verify)
+	bm.Post("/closings/:id/reject", closingHandler.Reject)
+	bm.Get("/marketing/:nip/activities", activityHandler.GetMarketingActivities)
+	bm.Get("/follow-ups/overdue", followUpHandler.GetBranchOverdue)
+	bm.Post("/assignments", assignmentHandler.AssignSelected)
+	bm.Post("/assignments/filter", assignmentHandler.AssignFiltered)
+	bm.Post("/transfers", assignmentHandler.Transfer)
+	bm.Post("/marketing/:nip/transfer", assignmentHandler.TransferAll)
+	bm.Get("/distribution/settings", distributionHandler.GetSettings)
+	bm.Put("/distribution/settings", distributionHandler.UpdateSettings)
+	bm.Put("/distribution/caps/:nip", distributionHandler.SetCap)
+	bm.Post("/distribution/preview", distributionHandler.Preview)
+	bm.Get("/distribution/decisions", distributionHandler.GetDecisions)
+	bm.Get("/worklist/settings", worklistHandler.GetSettings)
+	bm.Put("/worklist/settings", worklistHandler.UpdateSettings)
+
+	// Register background jobs.
+	if cfg.Retention.Enabled {
+		sched.Add(scheduler.Job{
+			Name:     "retention",
+			Interval: cfg.Retention.Interval,
+			Run:      retentionUsecase.RunScheduled,
+		})
+	}
+	if cfg.Duplicate.ScanEnabled {
+		sched.Add(scheduler.Job{
+			Name:     "duplicate-scan",
+			Interval: cfg.Duplicate.ScanInterval,
+			Run:      duplicateUsecase.RunScheduled,
+		})
+	}
+	if cfg.FollowUp.RemindersEnabled {
+		sched.Add(scheduler.Job{
+			Name:     "follow-up-reminders",
+			Interval: cfg.FollowUp.Interval,
+			Run:      followUpUsecase.RunScheduled,
+		})
+	}
+	if cfg.Lead.ReleaseEnabled {
+		sched.Add(scheduler.Job{
+			Name:     "lead-reservations",
+			Interval: cfg.Lead.ReleaseInterval,
+			Run:      reservationUsecase.RunScheduled,
+		})
+	}
+	if cfg.Distribution.Enabled {
+		sched.Add(scheduler.Job{
+			Name:     "lead-distribution",
+			Interval: cfg.Distribution.Interval,
+			Run:      distributionUsecase.RunScheduled,
+		})
+	}
+	if cfg.Incentive.Enabled {
+		sched.Add(scheduler.Job{
+			Name:     "incentives",
+			Interval: cfg.Incentive.Interval,
+			Run:      incentiveUsecase.RunScheduled,
+		})
+	}
 }
@@ -6,7 +6,10 @@ import (
 	"log"
 	"ml-prediction/config"
 	"ml-prediction/internal/app/routes"
+	"ml-prediction/internal/app/usecase"
 	"ml-prediction/pkg/logger"
+	"ml-prediction/pkg/piicrypto"
+	"ml-prediction/pkg/scheduler"
 	"ml-prediction/pkg/utils"
 	"ml-prediction/pkg/validation"
 	"os"
@@ -27,11 +30,29 @@ func Run() {
 		ExposeHeaders:    "Content-Length, X-Total-Count",
 		MaxAge:           3600,
 	})
-	app := fiber.New()
-	app.Use(cors)
 	cfg := config.NewConfig()
+	// Leave room for the multipart framing around the largest document.
+	app := fiber.New(fiber.Config{BodyLimit: int(cfg.Document.MaxSizeBytes) + 1<<20})
+	app.Use(cors)
 	var validate *validator.Validate
 
+	keyring, err := piicrypto.LoadKeyring(piicrypto.Options{
+		Keys:          cfg.Encryption.PIIKeys,
+		ActiveKeyID:   cfg.Encryption.PIIActiveKeyID,
+		KeyFile:       cfg.Encryption.PIIKeyFile,
+		BlindIndexKey: cfg.Encryption.BlindIndexKey,
+	})
+	if err != nil {
+		log.Fatalf("failed to load PII keys: %v", err)
+	}
+	if !keyring.Enabled() {
+		if cfg.App.Environment == "production" {
+			log.Fatalf("PII encryption keys are required in production")
+		}
+		log.Println("PII encryption keys not configured, customer PII will be stored in plaintext")
+	}
+	piicrypto.SetDefault(keyring)
+
 	db := config.SetupDatabase(cfg)
 	validate = validator.New()
 	if err := validation.RegisterCustomValidation(validate, db); err != nil {
@@ -43,13 +64,17 @@ func Run() {
 		log.Fatalf("failed to initialize logger: %v", err)
 	}
 
-	err = utils.ImportInitialCustomerData(context.Background(), db)
+	err = utils.ImportInitialCustomerData(context.Background(), db, usecase.ResolveCustomerBranch)
 	if err != nil {
 		log.Fatalf("failed to run import data: %v", err)
 	}
 
+	jobs := scheduler.New(logger)
 	api := app.Group("/api/v1")
-	routes.Register(api, db, *cfg, logger, validate)
+	routes.Register(api, db, *cfg, logger, validate, jobs)
+
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	jobs.Start(jobsCtx)
 
 	go func() {
 		fmt.Println("Listen and Serve at port 8080")
@@ -67,5 +92,7 @@ func Run() {
 	if err := app.Shutdown(); err != nil {
 		log.Fatalf("error in Server Shutdown: %s", err)
 	}
+	stopJobs()
+	jobs.Wait()
 	fmt.Println("server stopped")
 }
@@ -0,0 +1,194 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"ml-prediction/config"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/model"
+	"ml-prediction/internal/app/repository"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// activityClockSkew tolerates small differences between the device clock and
+// the server when a marketer logs an activity that just happened.
+const activityClockSkew = 5 * time.Minute
+
+type ActivityUsecase interface {
+	Log(ctx context.Context, NIP string, cif string, req *dto.LogActivityRequest) (*model.LeadActivity, error)
+	GetLeadActivities(ctx context.Context, NIP string, cif string) (*dto.LeadActivitiesResponse, error)
+	GetMarketingActivities(ctx context.Context, NIP string, marketingNIP string, req *dto.ActivityListRequest) (*dto.MarketingActivitiesResponse, error)
+}
+
+type activityUsecase struct {
+	activityRepo          repository.ActivityRepository
+	marketingCustomerRepo repository.MarketingCustomerRepository
+	userRepo              repository.UserRepository
+	consentRepo           repository.ConsentRepository
+	consentCfg            config.ConsentConfig
+	leadCfg               config.LeadConfig
+	db                    *gorm.DB
+}
+
+func NewActivityUsecase(
+	activityRepo repository.ActivityRepository,
+	mcRepo repository.MarketingCustomerRepository,
+	userRepo repository.UserRepository,
+	consentRepo repository.ConsentRepository,
+	consentCfg config.ConsentConfig,
+	leadCfg config.LeadConfig,
+	db *gorm.DB,
+) ActivityUsecase {
+	return &activityUsecase{
+		activityRepo:          activityRepo,
+		marketingCustomerRepo: mcRepo,
+		userRepo:              userRepo,
+		consentRepo:           consentRepo,
+		consentCfg:            consentCfg,
+		leadCfg:               leadCfg,
+		db:                    db,
+	}
+}
+
+// Log records a contact attempt on one of the marketer's own leads and renews
+// the lead's reservation. The customer must have agreed to be contacted
+// through the activity's channel.
+func (u *activityUsecase) Log(ctx context.Context, NIP string, cif string, req *dto.LogActivityRequest) (*model.LeadActivity, error) {
+	mc, err := u.marketingCustomerRepo.FindByCifAndMarketingNIP(u.db, cif, NIP)
+	if err == gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("%w: lead dengan CIF %s tidak ditemukan di daftar Anda", ErrNotFound, cif)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	occurredAt := now
+	if req.OccurredAt != nil {
+		if req.OccurredAt.After(now.Add(activityClockSkew)) {
+			return nil, fmt.Errorf("waktu aktivitas tidak boleh di masa depan")
+		}
+		occurredAt = *req.OccurredAt
+	}
+
+	if err := checkContactChannel(u.consentRepo, u.consentCfg, mc.CustomerID, model.ActivityChannels[req.ActivityType]); err != nil {
+		return nil, err
+	}
+
+	activity := &model.LeadActivity{
+		MarketingCustomerID: mc.ID,
+		MarketingID:         mc.MarketingID,
+		ActivityType:        req.ActivityType,
+		Outcome:             req.Outcome,
+		DurationMinutes:     req.DurationMinutes,
+		NextStep:            req.NextStep,
+		Notes:               req.Notes,
+		Latitude:            req.Latitude,
+		Longitude:           req.Longitude,
+		LocationName:        req.LocationName,
+		OccurredAt:          occurredAt,
+	}
+	err = u.db.Transaction(func(tx *gorm.DB) error {
+		if err := u.activityRepo.CreateWithTx(tx, activity); err != nil {
+			return fmt.Errorf("gagal menyimpan aktivitas: %v", err)
+		}
+		return u.marketingCustomerRepo.ExtendReservationWithTx(tx, mc.ID, *reservationExpiry(u.leadCfg, now))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return activity, nil
+}
+
+// GetLeadActivities lists the activities logged on a customer's leads, scoped
+// like the lead history.
+func (u *activityUsecase) GetLeadActivities(ctx context.Context, NIP string, cif string) (*dto.LeadActivitiesResponse, error) {
+	user, err := u.userRepo.FindByNIP(NIP)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user data: %v", err)
+	}
+	scope, err := leadScope(user)
+	if err != nil {
+		return nil, err
+	}
+
+	activities, err := u.activityRepo.GetByLead(cif, scope)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.LeadActivitiesResponse{CIF: cif, Activities: activities}, nil
+}
+
+// GetMarketingActivities lists the activities of one marketer. Marketers only
+// see their own; BMs see the marketers of their branch.
+func (u *activityUsecase) GetMarketingActivities(ctx context.Context, NIP string, marketingNIP string, req *dto.ActivityListRequest) (*dto.MarketingActivitiesResponse, error) {
+	viewer, err := u.userRepo.FindByNIP(NIP)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user data: %v", err)
+	}
+
+	marketing := viewer
+	switch viewer.Role {
+	case "marketing":
+	case "bm":
+		marketing, err = u.userRepo.FindByNIP(marketingNIP)
+		if err != nil || marketing.Role != "marketing" {
+			return nil, fmt.Errorf("%w: marketing dengan NIP %s", ErrNotFound, marketingNIP)
+		}
+		if !sameBranch(marketing.KantorCabangID, viewer.KantorCabangID) {
+			return nil, fmt.Errorf("%w: marketing bukan bagian dari kantor cabang Anda", ErrForbidden)
+		}
+	default:
+		return nil, fmt.Errorf("%w: role %s", ErrForbidden, viewer.Role)
+	}
+
+	from, to, err := activityPeriod(req)
+	if err != nil {
+		return nil, err
+	}
+
+	activities, pagination, err := u.activityRepo.GetByMarketing(marketing.ID, req, from, to)
+	if err != nil {
+		return nil, err
+	}
+	summaries, err := u.activityRepo.GetSummaries([]string{marketing.NIP}, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.MarketingActivitiesResponse{
+		MarketingNIP:  marketing.NIP,
+		MarketingName: marketing.Nama,
+		Summary:       summaries[marketing.NIP],
+		Activities:    activities,
+		Pagination:    pagination,
+	}, nil
+}
+
+// activityPeriod resolves the summary period of a listing so that it covers
+// the same activities as the list itself.
+func activityPeriod(req *dto.ActivityListRequest) (time.Time, time.Time, error) {
+	now := time.Now()
+	from, to := time.Time{}, now.Add(activityClockSkew)
+
+	if req.From != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", req.From, now.Location())
+		if err != nil {
+			return from, to, fmt.Errorf("format tanggal from tidak valid")
+		}
+		from = parsed
+	}
+	if req.To != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", req.To, now.Location())
+		if err != nil {
+			return from, to, fmt.Errorf("format tanggal to tidak valid")
+		}
+		to = parsed.AddDate(0, 0, 1)
+	}
+	if !from.Before(to) {
+		return from, to, fmt.Errorf("tanggal from harus sebelum tanggal to")
+	}
+	return from, to, nil
+}
@@ -0,0 +1,377 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ml-prediction/config"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/model"
+	"ml-prediction/internal/app/repository"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AssignmentUsecase interface {
+	AssignSelected(ctx context.Context, NIP string, req *dto.BulkAssignRequest) (*dto.BulkAssignResponse, error)
+	AssignFiltered(ctx context.Context, NIP string, req *dto.FilterAssignRequest) (*dto.BulkAssignResponse, error)
+	Transfer(ctx context.Context, NIP string, req *dto.TransferLeadsRequest) (*dto.TransferResponse, error)
+	TransferAll(ctx context.Context, NIP string, fromNIP string, req *dto.TransferAllRequest) (*dto.TransferResponse, error)
+}
+
+type assignmentUsecase struct {
+	customerRepo          repository.CustomerRepository
+	marketingCustomerRepo repository.MarketingCustomerRepository
+	userRepo              repository.UserRepository
+	consentCfg            config.ConsentConfig
+	leadCfg               config.LeadConfig
+	leadStates            *dto.LeadStateMachine
+	db                    *gorm.DB
+}
+
+func NewAssignmentUsecase(
+	customerRepo repository.CustomerRepository,
+	mcRepo repository.MarketingCustomerRepository,
+	userRepo repository.UserRepository,
+	consentCfg config.ConsentConfig,
+	leadCfg config.LeadConfig,
+	leadStates *dto.LeadStateMachine,
+	db *gorm.DB,
+) AssignmentUsecase {
+	return &assignmentUsecase{
+		customerRepo:          customerRepo,
+		marketingCustomerRepo: mcRepo,
+		userRepo:              userRepo,
+		consentCfg:            consentCfg,
+		leadCfg:               leadCfg,
+		leadStates:            leadStates,
+		db:                    db,
+	}
+}
+
+// AssignSelected gives the listed customers of the BM's branch pool to a
+// marketer of the branch. Customers that cannot be assigned are reported per
+// item instead of failing the whole request.
+func (u *assignmentUsecase) AssignSelected(ctx context.Context, NIP string, req *dto.BulkAssignRequest) (*dto.BulkAssignResponse, error) {
+	bm, marketing, err := u.resolve(NIP, req.MarketingNIP)
+	if err != nil {
+		return nil, err
+	}
+
+	found, err := u.customerRepo.FindByCIFs(req.CIFs)
+	if err != nil {
+		return nil, err
+	}
+	byCIF := make(map[string]dto.AssignableCustomer, len(found))
+	for _, customer := range found {
+		byCIF[customer.CIF] = customer
+	}
+
+	var customers []dto.AssignableCustomer
+	var missing []dto.AssignmentResult
+	seen := make(map[string]bool, len(req.CIFs))
+	for _, cif := range req.CIFs {
+		if seen[cif] {
+			continue
+		}
+		seen[cif] = true
+		customer, ok := byCIF[cif]
+		switch {
+		case !ok:
+			missing = append(missing, dto.AssignmentResult{
+				CIF:     cif,
+				Result:  dto.AssignmentNotFound,
+				Message: "customer tidak ditemukan",
+			})
+		case !sameBranch(customer.KantorCabangID, bm.KantorCabangID):
+			missing = append(missing, dto.AssignmentResult{
+				CIF:     cif,
+				Result:  dto.AssignmentOutOfBranch,
+				Message: "customer bukan bagian dari kantor cabang Anda",
+			})
+		default:
+			customers = append(customers, customer)
+		}
+	}
+
+	result, err := u.assign(bm, marketing, customers)
+	if err != nil {
+		return nil, err
+	}
+	result.Rejected += len(missing)
+	result.Results = append(result.Results, missing...)
+	return result, nil
+}
+
+// AssignFiltered gives up to req.Limit customers of the branch's unassigned
+// pool that match the filter to a marketer of the BM's branch.
+func (u *assignmentUsecase) AssignFiltered(ctx context.Context, NIP string, req *dto.FilterAssignRequest) (*dto.BulkAssignResponse, error) {
+	bm, marketing, err := u.resolve(NIP, req.MarketingNIP)
+	if err != nil {
+		return nil, err
+	}
+
+	search := &dto.CustomerSearchRequest{Search: req.Search, SearchBy: req.SearchBy, KantorCabangID: bm.KantorCabangID}
+	if u.consentCfg.HideNonConsenting {
+		search.Consent = &dto.ConsentFilter{
+			Scope:           model.ConsentScopeCrossSelling,
+			RequireExplicit: u.consentCfg.RequireExplicit,
+		}
+	}
+	customers, err := u.customerRepo.FindUnassigned(search, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+	return u.assign(bm, marketing, customers)
+}
+
+// resolve checks that the caller is a BM and that the target marketer works
+// in the same branch.
+func (u *assignmentUsecase) resolve(NIP string, marketingNIP string) (*model.User, *model.User, error) {
+	bm, err := u.userRepo.FindByNIP(NIP)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting user data: %v", err)
+	}
+	if bm.Role != "bm" {
+		return nil, nil, fmt.Errorf("%w: hanya BM yang dapat mengassign lead", ErrForbidden)
+	}
+
+	marketing, err := u.userRepo.FindByNIP(marketingNIP)
+	if err != nil || marketing.Role != "marketing" {
+		return nil, nil, fmt.Errorf("%w: marketing dengan NIP %s", ErrNotFound, marketingNIP)
+	}
+	if !sameBranch(marketing.KantorCabangID, bm.KantorCabangID) {
+		return nil, nil, fmt.Errorf("%w: marketing bukan bagian dari kantor cabang Anda", ErrForbidden)
+	}
+	return bm, marketing, nil
+}
+
+// resolveFormer loads the marketer leads are taken from. Unlike resolve it
+// finds deleted marketers too, since resigned marketers are the usual reason
+// to transfer all of someone's leads.
+func (u *assignmentUsecase) resolveFormer(bm *model.User, marketingNIP string) (*model.User, error) {
+	marketing, err := u.userRepo.FindByNIPUnscoped(marketingNIP)
+	if err != nil || marketing.Role != "marketing" {
+		return nil, fmt.Errorf("%w: marketing dengan NIP %s", ErrNotFound, marketingNIP)
+	}
+	if !sameBranch(marketing.KantorCabangID, bm.KantorCabangID) {
+		return nil, fmt.Errorf("%w: marketing bukan bagian dari kantor cabang Anda", ErrForbidden)
+	}
+	return marketing, nil
+}
+
+// assign creates the assignments in one transaction. Each customer runs in
+// its own savepoint so a conflict, e.g. a marketer claiming the customer at
+// the same moment, only rejects that customer.
+func (u *assignmentUsecase) assign(bm *model.User, marketing *model.User, customers []dto.AssignableCustomer) (*dto.BulkAssignResponse, error) {
+	result := &dto.BulkAssignResponse{
+		MarketingNIP:  marketing.NIP,
+		MarketingName: marketing.Nama,
+		Results:       make([]dto.AssignmentResult, 0, len(customers)),
+	}
+
+	tx := u.db.Begin()
+	if tx.Error != nil {
+		return nil, fmt.Errorf("gagal memulai transaksi: %v", tx.Error)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	for _, customer := range customers {
+		item, err := u.assignOne(tx, customer, marketing, bm)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if item.Result == dto.AssignmentAssigned {
+			result.Assigned++
+		} else {
+			result.Rejected++
+		}
+		result.Results = append(result.Results, item)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("gagal menyimpan assignment: %v", err)
+	}
+	return result, nil
+}
+
+func (u *assignmentUsecase) assignOne(tx *gorm.DB, customer dto.AssignableCustomer, marketing *model.User, bm *model.User) (dto.AssignmentResult, error) {
+	item := dto.AssignmentResult{CIF: customer.CIF, Result: dto.AssignmentAssigned}
+
+	if err := tx.SavePoint("assign").Error; err != nil {
+		return item, fmt.Errorf("gagal membuat savepoint: %v", err)
+	}
+
+	_, err := u.marketingCustomerRepo.CheckAndCreateAssignment(tx, customer.ID, marketing.ID, &bm.ID, reservationExpiry(u.leadCfg, time.Now()))
+	var heldErr *dto.LeadHeldError
+	switch {
+	case errors.As(err, &heldErr):
+		item.Result, item.Message = dto.AssignmentAlreadyAssigned, heldErr.Error()
+	case err != nil:
+		item.Result, item.Message = dto.AssignmentFailed, err.Error()
+	}
+
+	if item.Result != dto.AssignmentAssigned {
+		if err := tx.RollbackTo("assign").Error; err != nil {
+			return item, fmt.Errorf("gagal membatalkan assignment: %v", err)
+		}
+	}
+	return item, nil
+}
+
+// Transfer moves the listed leads to a marketer of the BM's branch. Only open
+// leads held by marketers of the same branch move; closings stay with the
+// marketer who booked them.
+func (u *assignmentUsecase) Transfer(ctx context.Context, NIP string, req *dto.TransferLeadsRequest) (*dto.TransferResponse, error) {
+	bm, to, err := u.resolve(NIP, req.ToMarketingNIP)
+	if err != nil {
+		return nil, err
+	}
+
+	found, err := u.customerRepo.FindByCIFs(req.CIFs)
+	if err != nil {
+		return nil, err
+	}
+	byCIF := make(map[string]dto.AssignableCustomer, len(found))
+	for _, customer := range found {
+		byCIF[customer.CIF] = customer
+	}
+
+	return u.transfer(to, func(tx *gorm.DB, result *dto.TransferResponse) error {
+		seen := make(map[string]bool, len(req.CIFs))
+		for _, cif := range req.CIFs {
+			if seen[cif] {
+				continue
+			}
+			seen[cif] = true
+
+			customer, ok := byCIF[cif]
+			if !ok {
+				addTransferResult(result, dto.TransferResult{CIF: cif, Result: dto.TransferNotFound, Message: "customer tidak ditemukan"})
+				continue
+			}
+			mc, err := u.marketingCustomerRepo.FindActiveByCustomerIDWithTx(tx, customer.ID)
+			if err == gorm.ErrRecordNotFound {
+				addTransferResult(result, dto.TransferResult{CIF: cif, Result: dto.TransferNotFound, Message: "customer belum memiliki assignment"})
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("gagal mencari assignment: %v", err)
+			}
+			if !sameBranch(mc.Marketing.KantorCabangID, bm.KantorCabangID) {
+				addTransferResult(result, dto.TransferResult{CIF: cif, Result: dto.TransferOutOfBranch, Message: "lead dipegang marketing di luar kantor cabang Anda"})
+				continue
+			}
+
+			item, err := u.transferOne(tx, mc, cif, mc.Marketing.NIP, to, bm, req.Reason)
+			if err != nil {
+				return err
+			}
+			addTransferResult(result, item)
+		}
+		return nil
+	})
+}
+
+// TransferAll moves every open lead of a marketer, e.g. one who resigned or
+// went on leave, to another marketer of the BM's branch.
+func (u *assignmentUsecase) TransferAll(ctx context.Context, NIP string, fromNIP string, req *dto.TransferAllRequest) (*dto.TransferResponse, error) {
+	bm, to, err := u.resolve(NIP, req.ToMarketingNIP)
+	if err != nil {
+		return nil, err
+	}
+	from, err := u.resolveFormer(bm, fromNIP)
+	if err != nil {
+		return nil, err
+	}
+	if from.ID == to.ID {
+		return nil, fmt.Errorf("%w: marketing asal dan tujuan tidak boleh sama", ErrConflict)
+	}
+
+	return u.transfer(to, func(tx *gorm.DB, result *dto.TransferResponse) error {
+		leads, err := u.marketingCustomerRepo.FindOpenByMarketingWithTx(tx, from.ID, u.leadStates.FinalStates())
+		if err != nil {
+			return err
+		}
+		for i := range leads {
+			item, err := u.transferOne(tx, &leads[i], leads[i].Customer.CIF, from.NIP, to, bm, req.Reason)
+			if err != nil {
+				return err
+			}
+			addTransferResult(result, item)
+		}
+		return nil
+	})
+}
+
+// transfer runs the given transfers in one transaction and collects the
+// per-lead results.
+func (u *assignmentUsecase) transfer(to *model.User, run func(tx *gorm.DB, result *dto.TransferResponse) error) (*dto.TransferResponse, error) {
+	result := &dto.TransferResponse{
+		ToMarketingNIP:  to.NIP,
+		ToMarketingName: to.Nama,
+		Results:         []dto.TransferResult{},
+	}
+
+	tx := u.db.Begin()
+	if tx.Error != nil {
+		return nil, fmt.Errorf("gagal memulai transaksi: %v", tx.Error)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := run(tx, result); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("gagal menyimpan transfer lead: %v", err)
+	}
+	return result, nil
+}
+
+// transferOne moves a single lead inside its own savepoint so a failure only
+// rejects that lead.
+func (u *assignmentUsecase) transferOne(tx *gorm.DB, mc *model.MarketingCustomer, cif string, fromNIP string, to *model.User, bm *model.User, reason string) (dto.TransferResult, error) {
+	item := dto.TransferResult{CIF: cif, FromMarketingNIP: fromNIP, Result: dto.TransferTransferred}
+	switch {
+	case mc.MarketingID == to.ID:
+		item.Result, item.Message = dto.TransferSameMarketing, "lead sudah dipegang marketing tujuan"
+		return item, nil
+	case u.leadStates.IsFinal(mc.Status):
+		item.Result, item.Message = dto.TransferNotOpen, fmt.Sprintf("lead berstatus %s tidak dapat dipindahkan", mc.Status)
+		return item, nil
+	}
+
+	if err := tx.SavePoint("transfer").Error; err != nil {
+		return item, fmt.Errorf("gagal membuat savepoint: %v", err)
+	}
+	if _, err := u.marketingCustomerRepo.TransferWithTx(tx, mc, to.ID, bm.ID, reservationExpiry(u.leadCfg, time.Now()), reason); err != nil {
+		item.Result, item.Message = dto.TransferFailed, err.Error()
+		if err := tx.RollbackTo("transfer").Error; err != nil {
+			return item, fmt.Errorf("gagal membatalkan transfer: %v", err)
+		}
+	}
+	return item, nil
+}
+
+func addTransferResult(result *dto.TransferResponse, item dto.TransferResult) {
+	if item.Result == dto.TransferTransferred {
+		result.Transferred++
+	} else {
+		result.Rejected++
+	}
+	result.Results = append(result.Results, item)
+}
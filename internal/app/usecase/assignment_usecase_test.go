@@ -0,0 +1,61 @@
+package usecase
+
+import (
+	"context"
+	"ml-prediction/config"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/model"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestTransferLeadOfDeletedMarketer(t *testing.T) {
+	branch := uintPtr(1)
+	resigned := &model.User{ID: 10, NIP: "M001", Nama: "Marketing Resign", Role: "marketing", KantorCabangID: branch,
+		DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true}}
+	users := &fakeUserRepo{users: []*model.User{
+		resigned,
+		{ID: 11, NIP: "M002", Nama: "Marketing Baru", Role: "marketing", KantorCabangID: branch},
+		{ID: 12, NIP: "M003", Nama: "Marketing Lain", Role: "marketing", KantorCabangID: uintPtr(2)},
+		{ID: 20, NIP: "BM01", Nama: "Kepala Cabang", Role: "bm", KantorCabangID: branch},
+	}}
+	leads := &fakeLeadRepo{users: users}
+	leads.add(&model.MarketingCustomer{CustomerID: 100, Customer: model.Customer{Id: 100, CIF: "CIF100"}, MarketingID: 10, Status: "interested"})
+	leads.add(&model.MarketingCustomer{CustomerID: 101, Customer: model.Customer{Id: 101, CIF: "CIF101"}, MarketingID: 12, Status: "interested"})
+	customers := &fakeCustomerRepo{customers: []dto.AssignableCustomer{
+		{ID: 100, CIF: "CIF100", KantorCabangID: branch},
+		{ID: 101, CIF: "CIF101", KantorCabangID: uintPtr(2)},
+	}}
+
+	uc := NewAssignmentUsecase(customers, leads, users, config.ConsentConfig{}, config.LeadConfig{ReservationTTL: time.Hour},
+		dto.DefaultLeadStateMachine(0), newTestDB(t))
+	result, err := uc.Transfer(context.Background(), "BM01", &dto.TransferLeadsRequest{
+		ToMarketingNIP: "M002",
+		CIFs:           []string{"CIF100", "CIF101"},
+		Reason:         "marketing resign",
+	})
+	if err != nil {
+		t.Fatalf("Transfer: %v", err)
+	}
+
+	if result.Transferred != 1 || result.Rejected != 1 {
+		t.Fatalf("transferred %d, rejected %d; want 1 and 1: %+v", result.Transferred, result.Rejected, result.Results)
+	}
+	byCIF := map[string]dto.TransferResult{}
+	for _, item := range result.Results {
+		byCIF[item.CIF] = item
+	}
+	if got := byCIF["CIF100"]; got.Result != dto.TransferTransferred || got.FromMarketingNIP != "M001" {
+		t.Errorf("lead of the deleted marketer: %+v, want transferred from M001", got)
+	}
+	if got := byCIF["CIF101"]; got.Result != dto.TransferOutOfBranch {
+		t.Errorf("lead of another branch: %+v, want %s", got, dto.TransferOutOfBranch)
+	}
+
+	active, err := leads.FindActiveByCustomerIDWithTx(nil, 100)
+	if err != nil || active.MarketingID != 11 {
+		t.Fatalf("active lead after transfer = %+v, %v; want held by M002", active, err)
+	}
+}
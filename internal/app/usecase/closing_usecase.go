@@ -0,0 +1,409 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"ml-prediction/config"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/model"
+	"ml-prediction/internal/app/repository"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ClosingUsecase interface {
+	Add(ctx context.Context, NIP string, cif string, req *dto.AddClosingRequest) (*model.Closing, error)
+	Void(ctx context.Context, NIP string, cif string, id uint64) error
+	GetLeadClosings(ctx context.Context, NIP string, cif string) (*dto.LeadClosingsResponse, error)
+	GetPending(ctx context.Context, NIP string, req *dto.PendingClosingsRequest) (*dto.PendingClosingsResponse, error)
+	Verify(ctx context.Context, NIP string, id uint64) (*model.Closing, error)
+	Reject(ctx context.Context, NIP string, id uint64, req *dto.RejectClosingRequest) (*model.Closing, error)
+	GetPeriods(ctx context.Context, year int) (*dto.ClosingPeriodsResponse, error)
+	LockPeriod(ctx context.Context, NIP string, year, month int) (*model.ClosingPeriod, error)
+	UnlockPeriod(ctx context.Context, year, month int) error
+}
+
+type closingUsecase struct {
+	closingRepo           repository.ClosingRepository
+	marketingCustomerRepo repository.MarketingCustomerRepository
+	userRepo              repository.UserRepository
+	leadStates            *dto.LeadStateMachine
+	leadCfg               config.LeadConfig
+	db                    *gorm.DB
+}
+
+func NewClosingUsecase(
+	closingRepo repository.ClosingRepository,
+	mcRepo repository.MarketingCustomerRepository,
+	userRepo repository.UserRepository,
+	leadStates *dto.LeadStateMachine,
+	leadCfg config.LeadConfig,
+	db *gorm.DB,
+) ClosingUsecase {
+	return &closingUsecase{
+		closingRepo:           closingRepo,
+		marketingCustomerRepo: mcRepo,
+		userRepo:              userRepo,
+		leadStates:            leadStates,
+		leadCfg:               leadCfg,
+		db:                    db,
+	}
+}
+
+// Add books a product the customer took up on one of the marketer's own
+// leads. The lead keeps its status, so the other recommended products stay
+// open; a closed lead can still take further products. The closing counts
+// toward achievement once the BM has verified it.
+func (u *closingUsecase) Add(ctx context.Context, NIP string, cif string, req *dto.AddClosingRequest) (*model.Closing, error) {
+	now := time.Now()
+	closedAt, err := closingDate(req.ClosedAt, now)
+	if err != nil {
+		return nil, err
+	}
+	evidence, err := closingEvidence(u.leadCfg, req.EvidenceReference)
+	if err != nil {
+		return nil, err
+	}
+
+	var closing *model.Closing
+	err = u.db.Transaction(func(tx *gorm.DB) error {
+		mc, err := u.marketingCustomerRepo.FindByCifAndMarketingNIP(tx, cif, NIP)
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("%w: lead dengan CIF %s tidak ditemukan di daftar Anda", ErrNotFound, cif)
+		}
+		if err != nil {
+			return err
+		}
+		if u.leadStates.IsFinal(mc.Status) && mc.Status != string(model.CustomerStatusClosed) {
+			return fmt.Errorf("%w: closing tidak dapat dicatat pada lead berstatus %s", ErrConflict, mc.Status)
+		}
+		if err := checkClosingPeriod(tx, u.closingRepo, u.leadCfg, closedAt, now); err != nil {
+			return err
+		}
+
+		closing = newClosing(mc, req.ProductID, req.Amount, closedAt, mc.MarketingID)
+		closing.TenorMonths = req.TenorMonths
+		closing.Notes = req.Notes
+		closing.EvidenceReference = evidence
+		if req.AccountReference != "" {
+			booked, err := u.closingRepo.AccountBookedWithTx(tx, req.ProductID, req.AccountReference)
+			if err != nil {
+				return err
+			}
+			if booked {
+				return fmt.Errorf("%w: nomor rekening %s sudah tercatat untuk produk ini", ErrConflict, req.AccountReference)
+			}
+			closing.AccountReference = &req.AccountReference
+		}
+
+		if err := u.closingRepo.CreateWithTx(tx, closing); err != nil {
+			return err
+		}
+		if err := u.recordEvent(tx, mc, closing, model.LeadEventClosingAdded, req.Notes); err != nil {
+			return err
+		}
+		return u.marketingCustomerRepo.ExtendReservationWithTx(tx, mc.ID, *reservationExpiry(u.leadCfg, now))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return closing, nil
+}
+
+// Void cancels a closing booked by mistake on one of the marketer's own
+// leads, as long as the BM has not verified it yet. The lead history keeps
+// both entries.
+func (u *closingUsecase) Void(ctx context.Context, NIP string, cif string, id uint64) error {
+	return u.db.Transaction(func(tx *gorm.DB) error {
+		mc, err := u.marketingCustomerRepo.FindByCifAndMarketingNIP(tx, cif, NIP)
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("%w: lead dengan CIF %s tidak ditemukan di daftar Anda", ErrNotFound, cif)
+		}
+		if err != nil {
+			return err
+		}
+
+		closing, err := u.closingRepo.FindByIDWithTx(tx, id)
+		if err == gorm.ErrRecordNotFound || (err == nil && closing.MarketingCustomerID != mc.ID) {
+			return fmt.Errorf("%w: closing %d pada lead %s", ErrNotFound, id, cif)
+		}
+		if err != nil {
+			return fmt.Errorf("gagal mengambil closing: %v", err)
+		}
+		if closing.VerificationStatus == model.ClosingVerified {
+			return fmt.Errorf("%w: closing yang sudah diverifikasi tidak dapat dibatalkan", ErrConflict)
+		}
+		if err := checkClosingPeriod(tx, u.closingRepo, u.leadCfg, closing.ClosedAt, time.Now()); err != nil {
+			return err
+		}
+
+		if err := u.closingRepo.VoidWithTx(tx, closing); err != nil {
+			return err
+		}
+		return u.recordEvent(tx, mc, closing, model.LeadEventClosingVoided, "")
+	})
+}
+
+// GetLeadClosings lists the closings of a customer, scoped like the lead
+// history.
+func (u *closingUsecase) GetLeadClosings(ctx context.Context, NIP string, cif string) (*dto.LeadClosingsResponse, error) {
+	user, err := u.userRepo.FindByNIP(NIP)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user data: %v", err)
+	}
+	scope, err := leadScope(user)
+	if err != nil {
+		return nil, err
+	}
+
+	closings, err := u.closingRepo.GetByLead(cif, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.LeadClosingsResponse{CIF: cif, Closings: closings}
+	for _, closing := range closings {
+		switch closing.VerificationStatus {
+		case model.ClosingVerified:
+			resp.TotalAmount += closing.Amount
+		case model.ClosingPendingVerification:
+			resp.PendingAmount += closing.Amount
+		}
+	}
+	return resp, nil
+}
+
+// GetPending lists the closings of the BM's branch awaiting verification.
+func (u *closingUsecase) GetPending(ctx context.Context, NIP string, req *dto.PendingClosingsRequest) (*dto.PendingClosingsResponse, error) {
+	bm, err := u.userRepo.FindByNIP(NIP)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user data: %v", err)
+	}
+	if bm.KantorCabangID == nil {
+		return nil, fmt.Errorf("%w: BM belum terdaftar di kantor cabang", ErrForbidden)
+	}
+
+	var marketingID *uint
+	if req.MarketingNIP != "" {
+		marketing, err := u.userRepo.FindByNIP(req.MarketingNIP)
+		if err != nil || marketing.Role != "marketing" {
+			return nil, fmt.Errorf("%w: marketing dengan NIP %s", ErrNotFound, req.MarketingNIP)
+		}
+		if !sameBranch(marketing.KantorCabangID, bm.KantorCabangID) {
+			return nil, fmt.Errorf("%w: marketing bukan bagian dari kantor cabang Anda", ErrForbidden)
+		}
+		marketingID = &marketing.ID
+	}
+
+	closings, pagination, amount, err := u.closingRepo.GetPending(*bm.KantorCabangID, marketingID, req.Page, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.PendingClosingsResponse{PendingAmount: amount, Closings: closings, Pagination: pagination}, nil
+}
+
+// Verify approves a closing so it counts toward achievement.
+func (u *closingUsecase) Verify(ctx context.Context, NIP string, id uint64) (*model.Closing, error) {
+	return u.review(NIP, id, model.ClosingVerified, "")
+}
+
+// Reject refuses a closing; it never counts toward achievement.
+func (u *closingUsecase) Reject(ctx context.Context, NIP string, id uint64, req *dto.RejectClosingRequest) (*model.Closing, error) {
+	return u.review(NIP, id, model.ClosingRejected, req.Reason)
+}
+
+// review records the BM's decision on a pending closing of a marketer in
+// their branch. Decisions change achievement, so closings of locked periods
+// cannot be reviewed.
+func (u *closingUsecase) review(NIP string, id uint64, status string, note string) (*model.Closing, error) {
+	bm, err := u.userRepo.FindByNIP(NIP)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user data: %v", err)
+	}
+
+	var closing *model.Closing
+	err = u.db.Transaction(func(tx *gorm.DB) error {
+		closing, err = u.closingRepo.FindByIDWithTx(tx, id)
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("%w: closing %d", ErrNotFound, id)
+		}
+		if err != nil {
+			return fmt.Errorf("gagal mengambil closing: %v", err)
+		}
+		if closing.Marketing == nil || !sameBranch(closing.Marketing.KantorCabangID, bm.KantorCabangID) {
+			return fmt.Errorf("%w: closing milik marketing di luar kantor cabang Anda", ErrForbidden)
+		}
+		if closing.VerificationStatus != model.ClosingPendingVerification {
+			return fmt.Errorf("%w: closing sudah berstatus %s", ErrConflict, closing.VerificationStatus)
+		}
+		locked, err := u.closingRepo.IsPeriodLockedWithTx(tx, closing.ClosedAt.Year(), int(closing.ClosedAt.Month()))
+		if err != nil {
+			return err
+		}
+		if locked {
+			return fmt.Errorf("%w: periode %02d/%d sudah dikunci", ErrConflict, closing.ClosedAt.Month(), closing.ClosedAt.Year())
+		}
+
+		now := time.Now()
+		closing.VerificationStatus = status
+		closing.VerifiedBy = &bm.ID
+		closing.VerifiedAt = &now
+		closing.VerificationNote = note
+		return u.closingRepo.ReviewWithTx(tx, closing)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return closing, nil
+}
+
+// GetPeriods lists the months of a year with their lock state and whether
+// closings can currently be dated in them.
+func (u *closingUsecase) GetPeriods(ctx context.Context, year int) (*dto.ClosingPeriodsResponse, error) {
+	now := time.Now()
+	if year == 0 {
+		year = now.Year()
+	}
+
+	locks, err := u.closingRepo.GetPeriodLocks(year)
+	if err != nil {
+		return nil, err
+	}
+	locked := make(map[int]model.ClosingPeriod, len(locks))
+	for _, lock := range locks {
+		locked[lock.Bulan] = lock
+	}
+
+	resp := &dto.ClosingPeriodsResponse{Tahun: year, BackdateMonths: u.leadCfg.ClosingBackdateMonths}
+	for month := 1; month <= 12; month++ {
+		period := dto.ClosingPeriodResponse{Tahun: year, Bulan: month}
+		if lock, ok := locked[month]; ok {
+			period.Locked = true
+			period.LockedAt = &lock.LockedAt
+			period.LockedBy = lock.LockedBy
+		} else {
+			age := monthsBetween(year, month, now)
+			period.AcceptsClosings = age >= 0 && age <= u.leadCfg.ClosingBackdateMonths
+		}
+		resp.Periods = append(resp.Periods, period)
+	}
+	return resp, nil
+}
+
+// LockPeriod freezes the closings of a month that has ended.
+func (u *closingUsecase) LockPeriod(ctx context.Context, NIP string, year, month int) (*model.ClosingPeriod, error) {
+	if month < 1 || month > 12 {
+		return nil, fmt.Errorf("bulan harus antara 1 dan 12")
+	}
+	if monthsBetween(year, month, time.Now()) < 1 {
+		return nil, fmt.Errorf("%w: periode %02d/%d belum berakhir", ErrConflict, month, year)
+	}
+	user, err := u.userRepo.FindByNIP(NIP)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user data: %v", err)
+	}
+
+	period := &model.ClosingPeriod{Tahun: year, Bulan: month, LockedAt: time.Now(), LockedBy: &user.ID}
+	created, err := u.closingRepo.LockPeriod(period)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, fmt.Errorf("%w: periode %02d/%d sudah dikunci", ErrConflict, month, year)
+	}
+	return period, nil
+}
+
+// UnlockPeriod reopens a locked month, e.g. to correct a closing.
+func (u *closingUsecase) UnlockPeriod(ctx context.Context, year, month int) error {
+	removed, err := u.closingRepo.UnlockPeriod(year, month)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return fmt.Errorf("%w: periode %02d/%d tidak dalam keadaan terkunci", ErrNotFound, month, year)
+	}
+	return nil
+}
+
+func (u *closingUsecase) recordEvent(tx *gorm.DB, mc *model.MarketingCustomer, closing *model.Closing, eventType string, note string) error {
+	status := mc.Status
+	return u.marketingCustomerRepo.CreateEventWithTx(tx, &model.MarketingCustomerEvent{
+		MarketingCustomerID: mc.ID,
+		EventType:           eventType,
+		OldStatus:           &status,
+		NewStatus:           status,
+		ActorID:             &mc.MarketingID,
+		Note:                note,
+		ProductID:           &closing.ProductID,
+		Amount:              &closing.Amount,
+	})
+}
+
+// newClosing builds a closing line attributed to the marketer holding the
+// lead.
+func newClosing(mc *model.MarketingCustomer, productID uint, amount int64, closedAt time.Time, createdBy uint) *model.Closing {
+	return &model.Closing{
+		MarketingCustomerID: mc.ID,
+		CustomerID:          mc.CustomerID,
+		MarketingID:         mc.MarketingID,
+		ProductID:           productID,
+		Amount:              amount,
+		ClosedAt:            closedAt,
+		CreatedBy:           &createdBy,
+		VerificationStatus:  model.ClosingPendingVerification,
+	}
+}
+
+// closingEvidence checks the evidence reference of a new closing against the
+// configuration.
+func closingEvidence(cfg config.LeadConfig, value string) (*string, error) {
+	if value == "" {
+		if cfg.ClosingRequireEvidence {
+			return nil, fmt.Errorf("evidence_reference wajib diisi untuk closing")
+		}
+		return nil, nil
+	}
+	return &value, nil
+}
+
+// closingDate parses the business date of a closing, defaulting to today.
+// Closings cannot be booked ahead of time.
+func closingDate(value string, now time.Time) (time.Time, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if value == "" {
+		return today, nil
+	}
+	date, err := time.ParseInLocation("2006-01-02", value, now.Location())
+	if err != nil {
+		return today, fmt.Errorf("format tanggal closed_at tidak valid")
+	}
+	if date.After(today) {
+		return today, fmt.Errorf("tanggal closing tidak boleh di masa depan")
+	}
+	return date, nil
+}
+
+// checkClosingPeriod rejects closing dates outside the open periods: the
+// current month, the configured number of months before it, and only while
+// the month is not locked.
+func checkClosingPeriod(tx *gorm.DB, closingRepo repository.ClosingRepository, cfg config.LeadConfig, date time.Time, now time.Time) error {
+	if monthsBetween(date.Year(), int(date.Month()), now) > cfg.ClosingBackdateMonths {
+		return fmt.Errorf("%w: periode %02d/%d sudah tidak menerima closing", ErrConflict, date.Month(), date.Year())
+	}
+	locked, err := closingRepo.IsPeriodLockedWithTx(tx, date.Year(), int(date.Month()))
+	if err != nil {
+		return err
+	}
+	if locked {
+		return fmt.Errorf("%w: periode %02d/%d sudah dikunci", ErrConflict, date.Month(), date.Year())
+	}
+	return nil
+}
+
+// monthsBetween counts the whole months from the given month to the month of
+// now; it is negative for months after now.
+func monthsBetween(year, month int, now time.Time) int {
+	return (now.Year()*12 + int(now.Month())) - (year*12 + month)
+}
@@ -0,0 +1,161 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"ml-prediction/config"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/model"
+	"ml-prediction/internal/app/repository"
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+type ConsentUsecase interface {
+	GetConsents(ctx context.Context, NIP string, cif string) (*dto.CustomerConsentResponse, error)
+	Record(ctx context.Context, NIP string, cif string, req *dto.RecordConsentRequest) (*dto.CustomerConsentResponse, error)
+	Withdraw(ctx context.Context, NIP string, cif string, req *dto.WithdrawConsentRequest) (*dto.CustomerConsentResponse, error)
+}
+
+type consentUsecase struct {
+	consentRepo   repository.ConsentRepository
+	piiAccessRepo repository.PIIAccessRepository
+	userRepo      repository.UserRepository
+	cfg           config.ConsentConfig
+}
+
+func NewConsentUsecase(consentRepo repository.ConsentRepository, piiAccessRepo repository.PIIAccessRepository, userRepo repository.UserRepository, cfg config.ConsentConfig) ConsentUsecase {
+	return &consentUsecase{
+		consentRepo:   consentRepo,
+		piiAccessRepo: piiAccessRepo,
+		userRepo:      userRepo,
+		cfg:           cfg,
+	}
+}
+
+func (u *consentUsecase) GetConsents(ctx context.Context, NIP string, cif string) (*dto.CustomerConsentResponse, error) {
+	_, customer, err := u.resolve(NIP, cif)
+	if err != nil {
+		return nil, err
+	}
+	return u.buildResponse(customer)
+}
+
+func (u *consentUsecase) Record(ctx context.Context, NIP string, cif string, req *dto.RecordConsentRequest) (*dto.CustomerConsentResponse, error) {
+	viewer, customer, err := u.resolve(NIP, cif)
+	if err != nil {
+		return nil, err
+	}
+
+	record := model.CustomerConsent{
+		CustomerID: customer.Id,
+		Channel:    req.Channel,
+		Scope:      req.Scope,
+		Granted:    *req.Granted,
+		Source:     req.Source,
+		ExpiresAt:  req.ExpiresAt,
+		RecordedBy: &viewer.ID,
+		Notes:      req.Notes,
+	}
+	if err := u.consentRepo.Create([]model.CustomerConsent{record}); err != nil {
+		return nil, fmt.Errorf("gagal menyimpan persetujuan: %v", err)
+	}
+	return u.buildResponse(customer)
+}
+
+// Withdraw appends refusal records rather than editing earlier grants, so the
+// consent history stays complete.
+func (u *consentUsecase) Withdraw(ctx context.Context, NIP string, cif string, req *dto.WithdrawConsentRequest) (*dto.CustomerConsentResponse, error) {
+	viewer, customer, err := u.resolve(NIP, cif)
+	if err != nil {
+		return nil, err
+	}
+
+	channels := req.Channels
+	if len(channels) == 0 {
+		channels = model.ConsentChannels
+	}
+	records := make([]model.CustomerConsent, 0, len(channels))
+	for _, channel := range channels {
+		records = append(records, model.CustomerConsent{
+			CustomerID: customer.Id,
+			Channel:    channel,
+			Scope:      req.Scope,
+			Granted:    false,
+			Source:     req.Source,
+			RecordedBy: &viewer.ID,
+			Notes:      req.Notes,
+		})
+	}
+	if err := u.consentRepo.Create(records); err != nil {
+		return nil, fmt.Errorf("gagal mencabut persetujuan: %v", err)
+	}
+	return u.buildResponse(customer)
+}
+
+func (u *consentUsecase) resolve(NIP string, cif string) (*model.User, *model.Customer, error) {
+	viewer, err := u.userRepo.FindByNIP(NIP)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting user data: %v", err)
+	}
+
+	customer, err := u.consentRepo.FindCustomerByCIF(cif)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil, fmt.Errorf("%w: customer dengan CIF %s", ErrNotFound, cif)
+		}
+		return nil, nil, fmt.Errorf("error getting customer: %v", err)
+	}
+
+	owners, err := u.piiAccessRepo.FindOwners([]uint64{customer.Id})
+	if err != nil {
+		return nil, nil, err
+	}
+	owner, assigned := owners[customer.Id]
+	if !assigned {
+		owner.KantorCabangID = customer.KantorCabangID
+	}
+	if !customerInScope(viewer, owner, assigned) {
+		return nil, nil, fmt.Errorf("%w: customer ini bukan bagian dari cakupan Anda", ErrForbidden)
+	}
+	return viewer, customer, nil
+}
+
+func (u *consentUsecase) buildResponse(customer *model.Customer) (*dto.CustomerConsentResponse, error) {
+	effective, err := u.consentRepo.GetEffective([]uint64{customer.Id}, "")
+	if err != nil {
+		return nil, err
+	}
+	history, err := u.consentRepo.GetHistory(customer.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	var crossSelling []dto.ConsentStatus
+	for _, status := range effective[customer.Id] {
+		if status.Scope == model.ConsentScopeCrossSelling {
+			crossSelling = append(crossSelling, status)
+		}
+	}
+
+	return &dto.CustomerConsentResponse{
+		CIF:             customer.CIF,
+		ContactChannels: dto.AllowedChannels(crossSelling, u.cfg.RequireExplicit),
+		Effective:       effective[customer.Id],
+		History:         history,
+	}, nil
+}
+
+// checkContactChannel refuses contact through a channel the customer has not
+// agreed to for cross-selling.
+func checkContactChannel(consentRepo repository.ConsentRepository, cfg config.ConsentConfig, customerID uint64, channel string) error {
+	effective, err := consentRepo.GetEffective([]uint64{customerID}, model.ConsentScopeCrossSelling)
+	if err != nil {
+		return fmt.Errorf("gagal memeriksa persetujuan customer: %v", err)
+	}
+	if !slices.Contains(dto.AllowedChannels(effective[customerID], cfg.RequireExplicit), channel) {
+		return fmt.Errorf("%w: customer tidak mengizinkan dihubungi melalui %s", ErrForbidden, channel)
+	}
+	return nil
+}
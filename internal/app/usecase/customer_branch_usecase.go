@@ -0,0 +1,164 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/model"
+	"ml-prediction/internal/app/repository"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// customerRoutingBatch is how many unrouted customers ApplyRules loads at a
+// time; their addresses are decrypted in memory to match them.
+const customerRoutingBatch = 500
+
+type CustomerBranchUsecase interface {
+	CreateRule(ctx context.Context, NIP string, req *dto.CreateCustomerBranchRuleRequest) (*model.CustomerBranchRule, error)
+	GetRules(ctx context.Context) ([]model.CustomerBranchRule, error)
+	DeleteRule(ctx context.Context, id uint) error
+	ApplyRules(ctx context.Context) (*dto.ApplyBranchRulesResponse, error)
+	SetBranch(ctx context.Context, cif string, req *dto.SetCustomerBranchRequest) error
+}
+
+type customerBranchUsecase struct {
+	branchRepo repository.CustomerBranchRepository
+	userRepo   repository.UserRepository
+}
+
+func NewCustomerBranchUsecase(branchRepo repository.CustomerBranchRepository, userRepo repository.UserRepository) CustomerBranchUsecase {
+	return &customerBranchUsecase{branchRepo: branchRepo, userRepo: userRepo}
+}
+
+func (u *customerBranchUsecase) CreateRule(ctx context.Context, NIP string, req *dto.CreateCustomerBranchRuleRequest) (*model.CustomerBranchRule, error) {
+	admin, err := u.userRepo.FindByNIP(NIP)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user data: %v", err)
+	}
+
+	pattern := strings.TrimSpace(req.Pattern)
+	if pattern == "" {
+		return nil, fmt.Errorf("pola aturan tidak boleh kosong")
+	}
+	exists, err := u.branchRepo.RuleExists(req.MatchField, pattern)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("%w: aturan %s dengan pola %q sudah ada", ErrConflict, req.MatchField, pattern)
+	}
+
+	rule := &model.CustomerBranchRule{
+		KantorCabangID: req.KantorCabangID,
+		MatchField:     req.MatchField,
+		Pattern:        pattern,
+		CreatedBy:      &admin.ID,
+	}
+	if err := u.branchRepo.CreateRule(rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (u *customerBranchUsecase) GetRules(ctx context.Context) ([]model.CustomerBranchRule, error) {
+	return u.branchRepo.GetRules()
+}
+
+// DeleteRule removes a rule. Customers it already routed keep their branch.
+func (u *customerBranchUsecase) DeleteRule(ctx context.Context, id uint) error {
+	rule, err := u.branchRepo.FindRule(id)
+	if err == gorm.ErrRecordNotFound {
+		return fmt.Errorf("%w: aturan kantor cabang %d", ErrNotFound, id)
+	}
+	if err != nil {
+		return fmt.Errorf("gagal mengambil aturan kantor cabang: %v", err)
+	}
+	return u.branchRepo.DeleteRule(rule)
+}
+
+// ApplyRules routes the customers that have no branch yet, e.g. after new
+// rules were added. Customers that already have a branch are left alone.
+func (u *customerBranchUsecase) ApplyRules(ctx context.Context) (*dto.ApplyBranchRulesResponse, error) {
+	rules, err := u.branchRepo.GetRules()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &dto.ApplyBranchRulesResponse{}
+	var afterID uint64
+	for {
+		customers, err := u.branchRepo.FindUnrouted(afterID, customerRoutingBatch)
+		if err != nil {
+			return nil, err
+		}
+		for i := range customers {
+			result.Checked++
+			kantorCabangID := ResolveCustomerBranch(rules, &customers[i])
+			if kantorCabangID == nil {
+				result.Unrouted++
+				continue
+			}
+			if err := u.branchRepo.SetBranch(customers[i].Id, *kantorCabangID); err != nil {
+				return nil, err
+			}
+			result.Routed++
+		}
+		if len(customers) < customerRoutingBatch {
+			return result, nil
+		}
+		afterID = customers[len(customers)-1].Id
+	}
+}
+
+// SetBranch moves a customer to another branch's pool. An open lead stays
+// with the marketer holding it; its BM can transfer it if needed.
+func (u *customerBranchUsecase) SetBranch(ctx context.Context, cif string, req *dto.SetCustomerBranchRequest) error {
+	customer, err := u.branchRepo.FindCustomerByCIF(cif)
+	if err == gorm.ErrRecordNotFound {
+		return fmt.Errorf("%w: customer dengan CIF %s", ErrNotFound, cif)
+	}
+	if err != nil {
+		return fmt.Errorf("error getting customer: %v", err)
+	}
+	return u.branchRepo.SetBranch(customer.Id, req.KantorCabangID)
+}
+
+// ResolveCustomerBranch returns the branch the rules route a customer to, or
+// nil when none matches. Company rules win over address rules, and a longer
+// pattern over a shorter one, so "Jl. Sudirman, Bandung" can be carved out
+// of "Bandung".
+func ResolveCustomerBranch(rules []model.CustomerBranchRule, customer *model.Customer) *uint {
+	ordered := make([]model.CustomerBranchRule, len(rules))
+	copy(ordered, rules)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].MatchField != ordered[j].MatchField {
+			return ordered[i].MatchField == model.BranchRuleCompany
+		}
+		return len(ordered[i].Pattern) > len(ordered[j].Pattern)
+	})
+
+	company := strings.ToLower(strings.TrimSpace(customer.NamaPerusahaan))
+	address := strings.ToLower(customer.Address)
+	for _, rule := range ordered {
+		pattern := strings.ToLower(strings.TrimSpace(rule.Pattern))
+		if pattern == "" {
+			continue
+		}
+		switch rule.MatchField {
+		case model.BranchRuleCompany:
+			if customer.Payroll && company == pattern {
+				id := rule.KantorCabangID
+				return &id
+			}
+		case model.BranchRuleAddress:
+			if strings.Contains(address, pattern) {
+				id := rule.KantorCabangID
+				return &id
+			}
+		}
+	}
+	return nil
+}
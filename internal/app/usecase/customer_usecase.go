@@ -6,10 +6,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"ml-prediction/config"
 	dto "ml-prediction/internal/app/domain"
 	"ml-prediction/internal/app/model"
 	"ml-prediction/internal/app/repository"
 	"ml-prediction/pkg/helper"
+	"ml-prediction/pkg/piicrypto"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -23,17 +25,23 @@ type CustomerUsecase interface {
 	Create(c *fiber.Ctx, req dto.PredictionRequest) (*model.Customer, error)
 	GetNewCustomers(ctx context.Context, NIP string, req *dto.CustomerSearchRequest) ([]dto.Customer, *dto.Pagination, error)
 	GetAssignedCustomers(ctx context.Context, NIP string, req *dto.AssignedCustomerRequest) ([]dto.Customer, *dto.Pagination, error)
+	GetNewCustomersCursor(ctx context.Context, NIP string, req *dto.CustomerSearchRequest) ([]dto.Customer, *dto.CursorPagination, error)
+	GetAssignedCustomersCursor(ctx context.Context, NIP string, req *dto.AssignedCustomerRequest) ([]dto.Customer, *dto.CursorPagination, error)
 	GetCustomerDetail(ctx context.Context, NIP string, customerID string) (*dto.Customer, error)
 }
 type customerUsecase struct {
 	custPredRepo repository.CustomerRepository
 	userRepo     repository.UserRepository
 	produkRepo   repository.ProductRepository
+	pii          *piiPolicy
+	consentRepo  repository.ConsentRepository
+	consentCfg   config.ConsentConfig
+	branchRepo   repository.CustomerBranchRepository
 	db           *gorm.DB
 }
 
-func NewcustomerUsecase(custPredRepo repository.CustomerRepository, userRepo repository.UserRepository, produkRepo repository.ProductRepository, db *gorm.DB) CustomerUsecase {
-	return &customerUsecase{custPredRepo, userRepo, produkRepo, db}
+func NewcustomerUsecase(custPredRepo repository.CustomerRepository, userRepo repository.UserRepository, produkRepo repository.ProductRepository, piiRepo repository.PIIAccessRepository, consentRepo repository.ConsentRepository, consentCfg config.ConsentConfig, branchRepo repository.CustomerBranchRepository, db *gorm.DB) CustomerUsecase {
+	return &customerUsecase{custPredRepo, userRepo, produkRepo, newPIIPolicy(piiRepo), consentRepo, consentCfg, branchRepo, db}
 }
 func (s *customerUsecase) Create(c *fiber.Ctx, req dto.PredictionRequest) (*model.Customer, error) {
 	// Validate unique fields
@@ -41,8 +49,15 @@ func (s *customerUsecase) Create(c *fiber.Ctx, req dto.PredictionRequest) (*mode
 		return nil, err
 	}
 
+	kantorCabangID, err := s.customerBranch(c, req)
+	if err != nil {
+		return nil, err
+	}
+
 	// Continue with existing code for prediction and customer creation
-	inputJSON, err := json.Marshal(req)
+	input := req
+	input.KantorCabangID = nil
+	inputJSON, err := json.Marshal(input)
 	if err != nil {
 		return nil, errors.New("Gagal memproses data input!")
 	}
@@ -106,6 +121,8 @@ func (s *customerUsecase) Create(c *fiber.Ctx, req dto.PredictionRequest) (*mode
 		Email:              req.Email,
 		Address:            req.Alamat,
 		Job:                req.Pekerjaan,
+		Source:             model.CustomerSourceAPI,
+		KantorCabangID:     kantorCabangID,
 	}
 
 	tx := s.db.WithContext(c.Context()).Begin()
@@ -152,6 +169,21 @@ func (s *customerUsecase) Create(c *fiber.Ctx, req dto.PredictionRequest) (*mode
 		customerProduct = append(customerProduct, customerProd)
 	}
 
+	scores, err := json.Marshal(predictions)
+	if err != nil {
+		tx.Rollback()
+		return nil, errors.New(fmt.Sprintf("Gagal memproses hasil prediksi: %v", err))
+	}
+	run := &model.CustomerPredictionRun{
+		CustomerID: data.Id,
+		Source:     model.CustomerSourceAPI,
+		Scores:     string(scores),
+	}
+	if err := tx.Create(run).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.New(fmt.Sprintf("Gagal menyimpan riwayat prediksi: %v", err))
+	}
+
 	var fullCustomer model.Customer
 	if err := tx.Preload("CustomerProduk").Where("id = ?", data.Id).First(&fullCustomer).Error; err != nil {
 		tx.Rollback()
@@ -162,7 +194,55 @@ func (s *customerUsecase) Create(c *fiber.Ctx, req dto.PredictionRequest) (*mode
 	return &fullCustomer, nil
 }
 
-// Add this new method for validation
+// customerBranch decides whose pool a new customer lands in: the caller's
+// own branch for BMs and marketers, the requested branch for admins, and
+// otherwise the one the routing rules point to. Anonymous callers cannot pick
+// a branch. A customer no rule matches stays unrouted until an admin places
+// it.
+func (s *customerUsecase) customerBranch(c *fiber.Ctx, req dto.PredictionRequest) (*uint, error) {
+	NIP, _ := c.Locals("nip").(string)
+	if NIP == "" {
+		if req.KantorCabangID != nil {
+			return nil, errors.New("kantor cabang hanya dapat dipilih oleh admin")
+		}
+		return s.routeCustomer(req)
+	}
+
+	user, err := s.userRepo.FindByNIP(NIP)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user data: %v", err)
+	}
+	if user.Role != "admin" {
+		if user.KantorCabangID == nil {
+			return nil, errors.New("pengguna belum terdaftar di kantor cabang")
+		}
+		if req.KantorCabangID != nil && *req.KantorCabangID != *user.KantorCabangID {
+			return nil, errors.New("customer hanya dapat ditambahkan ke kantor cabang Anda")
+		}
+		return user.KantorCabangID, nil
+	}
+	if req.KantorCabangID != nil {
+		return req.KantorCabangID, nil
+	}
+	return s.routeCustomer(req)
+}
+
+// routeCustomer applies the branch routing rules to a new customer.
+func (s *customerUsecase) routeCustomer(req dto.PredictionRequest) (*uint, error) {
+
+	rules, err := s.branchRepo.GetRules()
+	if err != nil {
+		return nil, err
+	}
+	return ResolveCustomerBranch(rules, &model.Customer{
+		NamaPerusahaan: req.NamaPerusahaan,
+		Payroll:        req.Payroll,
+		Address:        req.Alamat,
+	}), nil
+}
+
+// validateUniqueCustomerFields checks uniqueness through the blind indexes,
+// since the contact columns themselves are encrypted.
 func (s *customerUsecase) validateUniqueCustomerFields(ctx context.Context, req dto.PredictionRequest) error {
 	// Check CIF uniqueness
 	var cifCount int64
@@ -179,7 +259,7 @@ func (s *customerUsecase) validateUniqueCustomerFields(ctx context.Context, req
 	if req.NomorRekening != "" {
 		var rekCount int64
 		if err := s.db.WithContext(ctx).Model(&model.Customer{}).
-			Where("nomor_rekening = ? AND deleted_at IS NULL", req.NomorRekening).
+			Where("nomor_rekening_bidx = ? AND deleted_at IS NULL", piicrypto.BlindIndex(piicrypto.FieldAccount, req.NomorRekening)).
 			Count(&rekCount).Error; err != nil {
 			return fmt.Errorf("error checking Nomor Rekening uniqueness: %v", err)
 		}
@@ -192,7 +272,7 @@ func (s *customerUsecase) validateUniqueCustomerFields(ctx context.Context, req
 	if req.Email != "" {
 		var emailCount int64
 		if err := s.db.WithContext(ctx).Model(&model.Customer{}).
-			Where("email = ? AND deleted_at IS NULL", req.Email).
+			Where("email_bidx = ? AND deleted_at IS NULL", piicrypto.BlindIndex(piicrypto.FieldEmail, req.Email)).
 			Count(&emailCount).Error; err != nil {
 			return fmt.Errorf("error checking Email uniqueness: %v", err)
 		}
@@ -205,7 +285,7 @@ func (s *customerUsecase) validateUniqueCustomerFields(ctx context.Context, req
 	if req.NomorHp != "" {
 		var phoneCount int64
 		if err := s.db.WithContext(ctx).Model(&model.Customer{}).
-			Where("nomor_hp = ? AND deleted_at IS NULL", req.NomorHp).
+			Where("nomor_hp_bidx = ? AND deleted_at IS NULL", piicrypto.BlindIndex(piicrypto.FieldPhone, req.NomorHp)).
 			Count(&phoneCount).Error; err != nil {
 			return fmt.Errorf("error checking Nomor HP uniqueness: %v", err)
 		}
@@ -227,8 +307,29 @@ func (u *customerUsecase) GetNewCustomers(ctx context.Context, NIP string, req *
 	if user.Role != "marketing" {
 		return nil, nil, errors.New("unauthorized access")
 	}
+	if user.KantorCabangID == nil {
+		return nil, nil, fmt.Errorf("%w: marketing belum terdaftar di kantor cabang", ErrForbidden)
+	}
+	req.KantorCabangID = user.KantorCabangID
+
+	if u.consentCfg.HideNonConsenting {
+		req.Consent = &dto.ConsentFilter{
+			Scope:           model.ConsentScopeCrossSelling,
+			RequireExplicit: u.consentCfg.RequireExplicit,
+		}
+	}
 
-	return u.custPredRepo.GetNewCustomers(req)
+	customers, pagination, err := u.custPredRepo.GetNewCustomers(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := u.flagContactable(customers); err != nil {
+		return nil, nil, err
+	}
+	if err := u.pii.apply(user, "GET /marketing/customers", customers); err != nil {
+		return nil, nil, err
+	}
+	return customers, pagination, nil
 }
 
 func (u *customerUsecase) GetAssignedCustomers(ctx context.Context, NIP string, req *dto.AssignedCustomerRequest) ([]dto.Customer, *dto.Pagination, error) {
@@ -242,7 +343,70 @@ func (u *customerUsecase) GetAssignedCustomers(ctx context.Context, NIP string,
 		return nil, nil, errors.New("unauthorized access")
 	}
 
-	return u.custPredRepo.GetAssignedCustomers(user.ID, req)
+	customers, pagination, err := u.custPredRepo.GetAssignedCustomers(user.ID, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := u.pii.apply(user, "GET /marketing/customers/me", customers); err != nil {
+		return nil, nil, err
+	}
+	return customers, pagination, nil
+}
+
+func (u *customerUsecase) GetNewCustomersCursor(ctx context.Context, NIP string, req *dto.CustomerSearchRequest) ([]dto.Customer, *dto.CursorPagination, error) {
+
+	user, err := u.userRepo.FindByNIP(NIP)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting user data: %v", err)
+	}
+
+	if user.Role != "marketing" {
+		return nil, nil, errors.New("unauthorized access")
+	}
+	if user.KantorCabangID == nil {
+		return nil, nil, fmt.Errorf("%w: marketing belum terdaftar di kantor cabang", ErrForbidden)
+	}
+	req.KantorCabangID = user.KantorCabangID
+
+	if u.consentCfg.HideNonConsenting {
+		req.Consent = &dto.ConsentFilter{
+			Scope:           model.ConsentScopeCrossSelling,
+			RequireExplicit: u.consentCfg.RequireExplicit,
+		}
+	}
+
+	customers, pagination, err := u.custPredRepo.GetNewCustomersCursor(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := u.flagContactable(customers); err != nil {
+		return nil, nil, err
+	}
+	if err := u.pii.apply(user, "GET /marketing/customers", customers); err != nil {
+		return nil, nil, err
+	}
+	return customers, pagination, nil
+}
+
+func (u *customerUsecase) GetAssignedCustomersCursor(ctx context.Context, NIP string, req *dto.AssignedCustomerRequest) ([]dto.Customer, *dto.CursorPagination, error) {
+
+	user, err := u.userRepo.FindByNIP(NIP)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting user data: %v", err)
+	}
+
+	if user.Role != "marketing" {
+		return nil, nil, errors.New("unauthorized access")
+	}
+
+	customers, pagination, err := u.custPredRepo.GetAssignedCustomersCursor(user.ID, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := u.pii.apply(user, "GET /marketing/customers/me", customers); err != nil {
+		return nil, nil, err
+	}
+	return customers, pagination, nil
 }
 
 func (u *customerUsecase) GetCustomerDetail(ctx context.Context, NIP string, customerID string) (*dto.Customer, error) {
@@ -256,5 +420,41 @@ func (u *customerUsecase) GetCustomerDetail(ctx context.Context, NIP string, cus
 		return nil, errors.New("unauthorized access")
 	}
 
-	return u.custPredRepo.GetCustomerDetail(user.ID, customerID)
+	customer, err := u.custPredRepo.GetCustomerDetail(user.ID, user.KantorCabangID, customerID)
+	if err != nil {
+		return nil, err
+	}
+	customers := []dto.Customer{*customer}
+	if err := u.flagContactable(customers); err != nil {
+		return nil, err
+	}
+	if err := u.pii.apply(user, "GET /marketing/customers/:cif", customers); err != nil {
+		return nil, err
+	}
+	return &customers[0], nil
+}
+
+// flagContactable marks each customer with the channels their cross-selling
+// consent allows, so clients can flag customers that must not be approached.
+func (u *customerUsecase) flagContactable(customers []dto.Customer) error {
+	if len(customers) == 0 {
+		return nil
+	}
+
+	ids := make([]uint64, 0, len(customers))
+	for _, customer := range customers {
+		ids = append(ids, customer.Id)
+	}
+	effective, err := u.consentRepo.GetEffective(ids, model.ConsentScopeCrossSelling)
+	if err != nil {
+		return err
+	}
+
+	for i := range customers {
+		channels := dto.AllowedChannels(effective[customers[i].Id], u.consentCfg.RequireExplicit)
+		contactable := len(channels) > 0
+		customers[i].Contactable = &contactable
+		customers[i].ContactChannels = channels
+	}
+	return nil
 }
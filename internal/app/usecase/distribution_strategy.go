@@ -0,0 +1,126 @@
+package usecase
+
+import (
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/model"
+)
+
+// distributionBranch is the in-memory state of one branch during a run. Open
+// lead counts and last assignment times are updated as leads are handed out
+// so later decisions in the same run see earlier ones.
+type distributionBranch struct {
+	setting    model.LeadDistributionSetting
+	candidates []dto.DistributionCandidate
+	// targetGap returns each marketer's gap to target for a product.
+	targetGap func(productID uint) (map[uint]float64, error)
+}
+
+// capacity returns the most open leads a marketer may hold, or nil when the
+// marketer is uncapped.
+func (b *distributionBranch) capacity(c *dto.DistributionCandidate) *int {
+	if c.CapOverride != nil {
+		return c.CapOverride
+	}
+	return b.setting.DefaultCapacity
+}
+
+func (b *distributionBranch) eligible(c *dto.DistributionCandidate) bool {
+	capacity := b.capacity(c)
+	return capacity == nil || c.OpenLeads < *capacity
+}
+
+func (b *distributionBranch) hasCapacity() bool {
+	for i := range b.candidates {
+		if b.eligible(&b.candidates[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// distributionStrategy scores the eligible marketers of a branch for a
+// customer. The highest score wins.
+type distributionStrategy interface {
+	Score(branch *distributionBranch, customer dto.DistributionCustomer, eligible []*dto.DistributionCandidate) (map[uint]float64, error)
+}
+
+var distributionStrategies = map[string]distributionStrategy{
+	model.DistributionRoundRobin:     roundRobinStrategy{},
+	model.DistributionLeastOpenLeads: leastOpenLeadsStrategy{},
+	model.DistributionCapacity:       capacityStrategy{},
+	model.DistributionTargetGap:      targetGapStrategy{},
+}
+
+// roundRobinStrategy favours the marketer who waited longest for a lead.
+// Marketers who never received one come first.
+type roundRobinStrategy struct{}
+
+func (roundRobinStrategy) Score(branch *distributionBranch, customer dto.DistributionCustomer, eligible []*dto.DistributionCandidate) (map[uint]float64, error) {
+	scores := make(map[uint]float64, len(eligible))
+	for _, c := range eligible {
+		if c.LastAssignedAt == nil {
+			scores[c.MarketingID] = 0
+			continue
+		}
+		scores[c.MarketingID] = -float64(c.LastAssignedAt.UnixMicro())
+	}
+	return scores, nil
+}
+
+type leastOpenLeadsStrategy struct{}
+
+func (leastOpenLeadsStrategy) Score(branch *distributionBranch, customer dto.DistributionCustomer, eligible []*dto.DistributionCandidate) (map[uint]float64, error) {
+	scores := make(map[uint]float64, len(eligible))
+	for _, c := range eligible {
+		scores[c.MarketingID] = -float64(c.OpenLeads)
+	}
+	return scores, nil
+}
+
+// capacityStrategy favours the marketer with the most free slots. Uncapped
+// marketers count as having the largest cap in the branch; when nobody is
+// capped it behaves like least open leads.
+type capacityStrategy struct{}
+
+func (capacityStrategy) Score(branch *distributionBranch, customer dto.DistributionCustomer, eligible []*dto.DistributionCandidate) (map[uint]float64, error) {
+	largest := -1
+	for _, c := range eligible {
+		if capacity := branch.capacity(c); capacity != nil && *capacity > largest {
+			largest = *capacity
+		}
+	}
+	if largest < 0 {
+		return leastOpenLeadsStrategy{}.Score(branch, customer, eligible)
+	}
+
+	scores := make(map[uint]float64, len(eligible))
+	for _, c := range eligible {
+		capacity := largest
+		if own := branch.capacity(c); own != nil {
+			capacity = *own
+		}
+		scores[c.MarketingID] = float64(capacity - c.OpenLeads)
+	}
+	return scores, nil
+}
+
+// targetGapStrategy favours the marketer furthest behind this month's target
+// for the customer's top recommended product. Customers without a
+// recommendation fall back to least open leads.
+type targetGapStrategy struct{}
+
+func (targetGapStrategy) Score(branch *distributionBranch, customer dto.DistributionCustomer, eligible []*dto.DistributionCandidate) (map[uint]float64, error) {
+	if customer.TopProductID == nil {
+		return leastOpenLeadsStrategy{}.Score(branch, customer, eligible)
+	}
+	gaps, err := branch.targetGap(*customer.TopProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make(map[uint]float64, len(eligible))
+	for _, c := range eligible {
+		scores[c.MarketingID] = gaps[c.MarketingID]
+	}
+	return scores, nil
+}
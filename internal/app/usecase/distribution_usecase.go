@@ -0,0 +1,501 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"ml-prediction/config"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/model"
+	"ml-prediction/internal/app/repository"
+	"ml-prediction/pkg/scheduler"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const distributionLockKey = "lead-distribution"
+
+// Reasons recorded in a decision's detail.
+const (
+	distributionReasonHighestScore = "highest_score"
+	distributionReasonNoCapacity   = "all_marketers_at_capacity"
+)
+
+type DistributionUsecase interface {
+	RunScheduled(ctx context.Context) error
+	Trigger(ctx context.Context, NIP string, dryRun bool) (*model.LeadDistributionRun, error)
+	Preview(ctx context.Context, NIP string, limit int) (*model.LeadDistributionRun, error)
+	GetRuns(ctx context.Context, req *dto.DistributionListRequest) ([]model.LeadDistributionRun, *dto.Pagination, error)
+	GetRun(ctx context.Context, id uint64) (*model.LeadDistributionRun, error)
+
+	GetSettings(ctx context.Context, NIP string) (*dto.DistributionSettingsResponse, error)
+	UpdateSettings(ctx context.Context, NIP string, req *dto.UpdateDistributionSettingsRequest) (*model.LeadDistributionSetting, error)
+	SetCap(ctx context.Context, NIP string, marketingNIP string, req *dto.SetDistributionCapRequest) error
+	GetDecisions(ctx context.Context, NIP string, req *dto.DistributionListRequest) ([]dto.DistributionDecisionResponse, *dto.Pagination, error)
+}
+
+type distributionUsecase struct {
+	distributionRepo      repository.DistributionRepository
+	marketingCustomerRepo repository.MarketingCustomerRepository
+	userRepo              repository.UserRepository
+	leadStates            *dto.LeadStateMachine
+	consentCfg            config.ConsentConfig
+	leadCfg               config.LeadConfig
+	cfg                   config.DistributionConfig
+	db                    *gorm.DB
+}
+
+func NewDistributionUsecase(
+	distributionRepo repository.DistributionRepository,
+	mcRepo repository.MarketingCustomerRepository,
+	userRepo repository.UserRepository,
+	leadStates *dto.LeadStateMachine,
+	consentCfg config.ConsentConfig,
+	leadCfg config.LeadConfig,
+	cfg config.DistributionConfig,
+	db *gorm.DB,
+) DistributionUsecase {
+	return &distributionUsecase{
+		distributionRepo:      distributionRepo,
+		marketingCustomerRepo: mcRepo,
+		userRepo:              userRepo,
+		leadStates:            leadStates,
+		consentCfg:            consentCfg,
+		leadCfg:               leadCfg,
+		cfg:                   cfg,
+		db:                    db,
+	}
+}
+
+func (u *distributionUsecase) RunScheduled(ctx context.Context) error {
+	_, err := u.locked(ctx, model.DistributionTriggerScheduled, nil, false)
+	if errors.Is(err, scheduler.ErrLocked) {
+		return nil
+	}
+	return err
+}
+
+// Trigger runs the engine once over every enabled branch for an admin.
+func (u *distributionUsecase) Trigger(ctx context.Context, NIP string, dryRun bool) (*model.LeadDistributionRun, error) {
+	admin, err := u.userRepo.FindByNIP(NIP)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user data: %v", err)
+	}
+
+	if dryRun {
+		settings, err := u.distributionRepo.GetEnabledSettings()
+		if err != nil {
+			return nil, err
+		}
+		return u.execute(model.DistributionTriggerManual, &admin.ID, true, nil, settings, u.cfg.BatchSize)
+	}
+
+	run, err := u.locked(ctx, model.DistributionTriggerManual, &admin.ID, false)
+	if errors.Is(err, scheduler.ErrLocked) {
+		return nil, fmt.Errorf("%w: %v", ErrConflict, err)
+	}
+	return run, err
+}
+
+// Preview shows how the BM's branch setting would place the waiting
+// customers, as if the branch were the only one taking leads. Nothing is
+// assigned but the decisions are kept for review.
+func (u *distributionUsecase) Preview(ctx context.Context, NIP string, limit int) (*model.LeadDistributionRun, error) {
+	bm, err := u.branchManager(NIP)
+	if err != nil {
+		return nil, err
+	}
+	setting, err := u.setting(*bm.KantorCabangID)
+	if err != nil {
+		return nil, err
+	}
+	if limit == 0 {
+		limit = 20
+	}
+	return u.execute(model.DistributionTriggerManual, &bm.ID, true, bm.KantorCabangID, []model.LeadDistributionSetting{*setting}, limit)
+}
+
+func (u *distributionUsecase) GetRuns(ctx context.Context, req *dto.DistributionListRequest) ([]model.LeadDistributionRun, *dto.Pagination, error) {
+	return u.distributionRepo.GetRuns(req)
+}
+
+func (u *distributionUsecase) GetRun(ctx context.Context, id uint64) (*model.LeadDistributionRun, error) {
+	run, err := u.distributionRepo.GetRun(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("%w: laporan distribusi %d", ErrNotFound, id)
+		}
+		return nil, fmt.Errorf("gagal mengambil laporan distribusi: %v", err)
+	}
+	return run, nil
+}
+
+func (u *distributionUsecase) GetSettings(ctx context.Context, NIP string) (*dto.DistributionSettingsResponse, error) {
+	bm, err := u.branchManager(NIP)
+	if err != nil {
+		return nil, err
+	}
+	setting, err := u.setting(*bm.KantorCabangID)
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := u.distributionRepo.GetCandidates(*bm.KantorCabangID, u.leadStates.FinalStates())
+	if err != nil {
+		return nil, err
+	}
+
+	branch := &distributionBranch{setting: *setting}
+	marketers := make([]dto.DistributionMarketer, len(candidates))
+	for i := range candidates {
+		marketers[i] = dto.DistributionMarketer{
+			DistributionCandidate: candidates[i],
+			Capacity:              branch.capacity(&candidates[i]),
+		}
+	}
+
+	strategies := make([]string, 0, len(distributionStrategies))
+	for name := range distributionStrategies {
+		strategies = append(strategies, name)
+	}
+	sort.Strings(strategies)
+
+	return &dto.DistributionSettingsResponse{
+		Setting:    *setting,
+		Marketers:  marketers,
+		Strategies: strategies,
+	}, nil
+}
+
+func (u *distributionUsecase) UpdateSettings(ctx context.Context, NIP string, req *dto.UpdateDistributionSettingsRequest) (*model.LeadDistributionSetting, error) {
+	bm, err := u.branchManager(NIP)
+	if err != nil {
+		return nil, err
+	}
+
+	setting := &model.LeadDistributionSetting{
+		KantorCabangID:  *bm.KantorCabangID,
+		Enabled:         *req.Enabled,
+		Strategy:        req.Strategy,
+		DefaultCapacity: req.DefaultCapacity,
+		UpdatedBy:       &bm.ID,
+	}
+	if err := u.distributionRepo.SaveSetting(setting); err != nil {
+		return nil, fmt.Errorf("gagal menyimpan pengaturan distribusi: %v", err)
+	}
+	return u.setting(*bm.KantorCabangID)
+}
+
+// SetCap overrides the branch's default cap for one marketer of the BM's
+// branch, or removes the override.
+func (u *distributionUsecase) SetCap(ctx context.Context, NIP string, marketingNIP string, req *dto.SetDistributionCapRequest) error {
+	bm, err := u.branchManager(NIP)
+	if err != nil {
+		return err
+	}
+	marketing, err := u.userRepo.FindByNIP(marketingNIP)
+	if err != nil || marketing.Role != "marketing" {
+		return fmt.Errorf("%w: marketing dengan NIP %s", ErrNotFound, marketingNIP)
+	}
+	if !sameBranch(marketing.KantorCabangID, bm.KantorCabangID) {
+		return fmt.Errorf("%w: marketing %s bukan bagian dari kantor cabang Anda", ErrForbidden, marketingNIP)
+	}
+
+	if req.MaxOpenLeads == nil {
+		if err := u.distributionRepo.DeleteCap(marketing.ID); err != nil {
+			return fmt.Errorf("gagal menghapus batas lead: %v", err)
+		}
+		return nil
+	}
+	if err := u.distributionRepo.SaveCap(&model.LeadDistributionCap{
+		MarketingID:  marketing.ID,
+		MaxOpenLeads: *req.MaxOpenLeads,
+		UpdatedBy:    &bm.ID,
+	}); err != nil {
+		return fmt.Errorf("gagal menyimpan batas lead: %v", err)
+	}
+	return nil
+}
+
+func (u *distributionUsecase) GetDecisions(ctx context.Context, NIP string, req *dto.DistributionListRequest) ([]dto.DistributionDecisionResponse, *dto.Pagination, error) {
+	bm, err := u.branchManager(NIP)
+	if err != nil {
+		return nil, nil, err
+	}
+	return u.distributionRepo.GetDecisions(*bm.KantorCabangID, req)
+}
+
+func (u *distributionUsecase) branchManager(NIP string) (*model.User, error) {
+	bm, err := u.userRepo.FindByNIP(NIP)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user data: %v", err)
+	}
+	if bm.KantorCabangID == nil {
+		return nil, fmt.Errorf("%w: BM tidak terdaftar pada kantor cabang", ErrForbidden)
+	}
+	return bm, nil
+}
+
+// setting returns the branch's distribution setting, or the disabled default
+// when the branch never configured one.
+func (u *distributionUsecase) setting(kantorCabangID uint) (*model.LeadDistributionSetting, error) {
+	setting, err := u.distributionRepo.GetSetting(kantorCabangID)
+	if err == gorm.ErrRecordNotFound {
+		return &model.LeadDistributionSetting{
+			KantorCabangID: kantorCabangID,
+			Strategy:       model.DistributionRoundRobin,
+		}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil pengaturan distribusi: %v", err)
+	}
+	return setting, nil
+}
+
+func (u *distributionUsecase) locked(ctx context.Context, trigger string, triggeredBy *uint, dryRun bool) (*model.LeadDistributionRun, error) {
+	var run *model.LeadDistributionRun
+	err := scheduler.WithAdvisoryLock(ctx, u.db, distributionLockKey, func() error {
+		settings, err := u.distributionRepo.GetEnabledSettings()
+		if err != nil {
+			return err
+		}
+		run, err = u.execute(trigger, triggeredBy, dryRun, nil, settings, u.cfg.BatchSize)
+		return err
+	})
+	return run, err
+}
+
+// execute hands waiting customers of the enabled branches to the marketer of
+// their own branch that the branch's strategy scores highest.
+func (u *distributionUsecase) execute(trigger string, triggeredBy *uint, dryRun bool, kantorCabangID *uint, settings []model.LeadDistributionSetting, limit int) (*model.LeadDistributionRun, error) {
+	run := &model.LeadDistributionRun{
+		Trigger:        trigger,
+		TriggeredBy:    triggeredBy,
+		DryRun:         dryRun,
+		KantorCabangID: kantorCabangID,
+		Status:         model.DistributionStatusRunning,
+		StartedAt:      time.Now(),
+	}
+	if err := u.distributionRepo.CreateRun(run); err != nil {
+		return nil, fmt.Errorf("gagal membuat laporan distribusi: %v", err)
+	}
+
+	runErr := u.distribute(run, settings, limit)
+
+	finished := time.Now()
+	run.FinishedAt = &finished
+	run.Status = model.DistributionStatusSucceeded
+	if runErr != nil {
+		message := runErr.Error()
+		run.Status = model.DistributionStatusFailed
+		run.Error = &message
+	}
+	if err := u.distributionRepo.UpdateRun(run); err != nil {
+		return nil, fmt.Errorf("gagal memperbarui laporan distribusi: %v", err)
+	}
+	if runErr != nil {
+		return nil, runErr
+	}
+	return u.GetRun(context.Background(), run.ID)
+}
+
+func (u *distributionUsecase) distribute(run *model.LeadDistributionRun, settings []model.LeadDistributionSetting, limit int) error {
+	if len(settings) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	branches := make(map[uint]*distributionBranch, len(settings))
+	ids := make([]uint, 0, len(settings))
+	for _, setting := range settings {
+		branch, err := u.loadBranch(setting, now)
+		if err != nil {
+			return err
+		}
+		branches[setting.KantorCabangID] = branch
+		ids = append(ids, setting.KantorCabangID)
+	}
+
+	var consent *dto.ConsentFilter
+	if u.consentCfg.HideNonConsenting {
+		consent = &dto.ConsentFilter{
+			Scope:           model.ConsentScopeCrossSelling,
+			RequireExplicit: u.consentCfg.RequireExplicit,
+		}
+	}
+	customers, err := u.distributionRepo.FindPendingCustomers(ids, now.Add(-u.cfg.MaxAge), consent, limit)
+	if err != nil {
+		return err
+	}
+
+	full := make(map[uint]bool)
+	for _, customer := range customers {
+		branch := branches[customer.KantorCabangID]
+		if !branch.hasCapacity() {
+			// Capacity does not depend on the customer, so the decision is
+			// recorded once per branch and its other customers just wait.
+			if !full[customer.KantorCabangID] {
+				if err := u.recordNoCapacity(run, customer, branch); err != nil {
+					return err
+				}
+				full[customer.KantorCabangID] = true
+			}
+			run.Skipped++
+			continue
+		}
+
+		placed, err := u.place(run, branch, customer)
+		if err != nil {
+			return err
+		}
+		if placed {
+			run.Assigned++
+		} else {
+			run.Skipped++
+		}
+	}
+	return nil
+}
+
+func (u *distributionUsecase) loadBranch(setting model.LeadDistributionSetting, now time.Time) (*distributionBranch, error) {
+	candidates, err := u.distributionRepo.GetCandidates(setting.KantorCabangID, u.leadStates.FinalStates())
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, len(candidates))
+	for i, c := range candidates {
+		ids[i] = c.MarketingID
+	}
+	gaps := make(map[uint]map[uint]float64)
+
+	return &distributionBranch{
+		setting:    setting,
+		candidates: candidates,
+		targetGap: func(productID uint) (map[uint]float64, error) {
+			if gap, ok := gaps[productID]; ok {
+				return gap, nil
+			}
+			gap, err := u.distributionRepo.GetTargetGaps(ids, productID, int(now.Month()), now.Year())
+			if err != nil {
+				return nil, err
+			}
+			gaps[productID] = gap
+			return gap, nil
+		},
+	}, nil
+}
+
+// place scores the branch's marketers for the customer and assigns the lead
+// to the winner. It returns false when the customer was claimed by someone
+// else in the meantime.
+func (u *distributionUsecase) place(run *model.LeadDistributionRun, branch *distributionBranch, customer dto.DistributionCustomer) (bool, error) {
+	strategy, ok := distributionStrategies[branch.setting.Strategy]
+	if !ok {
+		return false, fmt.Errorf("strategi distribusi %s tidak dikenal", branch.setting.Strategy)
+	}
+
+	var eligible []*dto.DistributionCandidate
+	for i := range branch.candidates {
+		if branch.eligible(&branch.candidates[i]) {
+			eligible = append(eligible, &branch.candidates[i])
+		}
+	}
+	scores, err := strategy.Score(branch, customer, eligible)
+	if err != nil {
+		return false, fmt.Errorf("gagal menilai marketing: %v", err)
+	}
+
+	var winner *dto.DistributionCandidate
+	for _, c := range eligible {
+		if winner == nil || scores[c.MarketingID] > scores[winner.MarketingID] ||
+			(scores[c.MarketingID] == scores[winner.MarketingID] && c.OpenLeads < winner.OpenLeads) {
+			winner = c
+		}
+	}
+
+	detail, err := json.Marshal(dto.DistributionDecisionDetail{
+		Reason:     distributionReasonHighestScore,
+		Candidates: branch.scores(scores),
+	})
+	if err != nil {
+		return false, fmt.Errorf("gagal menyimpan detail keputusan: %v", err)
+	}
+
+	decision := &model.LeadDistributionDecision{
+		RunID:          run.ID,
+		CustomerID:     customer.ID,
+		KantorCabangID: &branch.setting.KantorCabangID,
+		MarketingID:    &winner.MarketingID,
+		Strategy:       branch.setting.Strategy,
+		Outcome:        model.DistributionOutcomeAssigned,
+		ProductID:      customer.TopProductID,
+		Detail:         string(detail),
+	}
+
+	err = u.db.Transaction(func(tx *gorm.DB) error {
+		if !run.DryRun {
+			mc, err := u.marketingCustomerRepo.CheckAndCreateAssignment(tx, customer.ID, winner.MarketingID, nil, reservationExpiry(u.leadCfg, time.Now()))
+			if err != nil {
+				return err
+			}
+			decision.MarketingCustomerID = &mc.ID
+		}
+		return u.distributionRepo.AddDecisionWithTx(tx, decision)
+	})
+	if err != nil {
+		if !run.DryRun {
+			// Most likely a marketer claimed the customer first.
+			return false, nil
+		}
+		return false, err
+	}
+
+	assignedAt := time.Now()
+	winner.OpenLeads++
+	winner.LastAssignedAt = &assignedAt
+	return true, nil
+}
+
+// scores lists every marketer of the branch with the figures the decision
+// was based on. Marketers at capacity are listed without a score.
+func (b *distributionBranch) scores(scores map[uint]float64) []dto.DistributionScore {
+	result := make([]dto.DistributionScore, len(b.candidates))
+	for i := range b.candidates {
+		c := &b.candidates[i]
+		result[i] = dto.DistributionScore{
+			MarketingID:  c.MarketingID,
+			MarketingNIP: c.MarketingNIP,
+			OpenLeads:    c.OpenLeads,
+			Capacity:     b.capacity(c),
+			Eligible:     b.eligible(c),
+		}
+		if score, ok := scores[c.MarketingID]; ok {
+			result[i].Score = &score
+		}
+	}
+	return result
+}
+
+func (u *distributionUsecase) recordNoCapacity(run *model.LeadDistributionRun, customer dto.DistributionCustomer, branch *distributionBranch) error {
+	detail, err := json.Marshal(dto.DistributionDecisionDetail{
+		Reason:     distributionReasonNoCapacity,
+		Candidates: branch.scores(nil),
+	})
+	if err != nil {
+		return fmt.Errorf("gagal menyimpan detail keputusan: %v", err)
+	}
+
+	decision := &model.LeadDistributionDecision{
+		RunID:          run.ID,
+		CustomerID:     customer.ID,
+		KantorCabangID: &branch.setting.KantorCabangID,
+		Strategy:       branch.setting.Strategy,
+		Outcome:        model.DistributionOutcomeNoCapacity,
+		ProductID:      customer.TopProductID,
+		Detail:         string(detail),
+	}
+	return u.distributionRepo.AddDecisionWithTx(u.db, decision)
+}
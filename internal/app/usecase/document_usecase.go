@@ -0,0 +1,226 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"ml-prediction/config"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/model"
+	"ml-prediction/internal/app/repository"
+	"ml-prediction/pkg/storage"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type DocumentUsecase interface {
+	Upload(ctx context.Context, NIP string, cif string, documentType string, contentType string, fileHeader *multipart.FileHeader) (*model.LeadDocument, error)
+	List(ctx context.Context, NIP string, cif string) (*dto.LeadDocumentsResponse, error)
+	Download(ctx context.Context, NIP string, id uint64) (*model.LeadDocument, io.ReadCloser, error)
+	Delete(ctx context.Context, NIP string, cif string, id uint64) error
+}
+
+type documentUsecase struct {
+	documentRepo          repository.DocumentRepository
+	marketingCustomerRepo repository.MarketingCustomerRepository
+	userRepo              repository.UserRepository
+	storage               storage.Storage
+	scanner               storage.Scanner
+	leadCfg               config.LeadConfig
+	db                    *gorm.DB
+}
+
+func NewDocumentUsecase(
+	documentRepo repository.DocumentRepository,
+	mcRepo repository.MarketingCustomerRepository,
+	userRepo repository.UserRepository,
+	store storage.Storage,
+	scanner storage.Scanner,
+	leadCfg config.LeadConfig,
+	db *gorm.DB,
+) DocumentUsecase {
+	return &documentUsecase{
+		documentRepo:          documentRepo,
+		marketingCustomerRepo: mcRepo,
+		userRepo:              userRepo,
+		storage:               store,
+		scanner:               scanner,
+		leadCfg:               leadCfg,
+		db:                    db,
+	}
+}
+
+// Upload attaches a document to one of the marketer's own leads. The file is
+// scanned before it reaches the storage; contentType is the type sniffed
+// from its content by the handler.
+func (u *documentUsecase) Upload(ctx context.Context, NIP string, cif string, documentType string, contentType string, fileHeader *multipart.FileHeader) (*model.LeadDocument, error) {
+	mc, err := u.marketingCustomerRepo.FindByCifAndMarketingNIP(u.db, cif, NIP)
+	if err == gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("%w: lead dengan CIF %s tidak ditemukan di daftar Anda", ErrNotFound, cif)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tmp, checksum, err := spoolUpload(fileHeader)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if err := u.scanner.Scan(ctx, tmp.Name()); err != nil {
+		if errors.Is(err, storage.ErrInfected) {
+			return nil, fmt.Errorf("%w: file ditolak oleh pemindai virus", storage.ErrInfected)
+		}
+		return nil, err
+	}
+
+	suffix := make([]byte, 16)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, fmt.Errorf("gagal membuat key dokumen: %v", err)
+	}
+	doc := &model.LeadDocument{
+		MarketingCustomerID: mc.ID,
+		CustomerID:          mc.CustomerID,
+		DocumentType:        documentType,
+		FileName:            filepath.Base(fileHeader.Filename),
+		ContentType:         contentType,
+		SizeBytes:           fileHeader.Size,
+		ChecksumSHA256:      checksum,
+		StorageKey:          fmt.Sprintf("leads/%d/%s", mc.CustomerID, hex.EncodeToString(suffix)),
+		UploadedBy:          mc.MarketingID,
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("gagal membaca file: %v", err)
+	}
+	if err := u.storage.Put(ctx, doc.StorageKey, tmp, doc.SizeBytes, doc.ContentType); err != nil {
+		return nil, err
+	}
+
+	err = u.db.Transaction(func(tx *gorm.DB) error {
+		if err := u.documentRepo.CreateWithTx(tx, doc); err != nil {
+			return err
+		}
+		return u.marketingCustomerRepo.ExtendReservationWithTx(tx, mc.ID, *reservationExpiry(u.leadCfg, time.Now()))
+	})
+	if err != nil {
+		// Without its row the stored file is unreachable.
+		u.storage.Delete(ctx, doc.StorageKey)
+		return nil, err
+	}
+	return doc, nil
+}
+
+// List returns the documents of a customer, scoped like the lead history.
+func (u *documentUsecase) List(ctx context.Context, NIP string, cif string) (*dto.LeadDocumentsResponse, error) {
+	scope, err := u.scope(NIP)
+	if err != nil {
+		return nil, err
+	}
+
+	docs, err := u.documentRepo.GetByLead(cif, scope)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.LeadDocumentsResponse{CIF: cif, Documents: docs}, nil
+}
+
+// Download opens a document the user may see: the marketer owning the lead
+// or the BM of the marketer's branch. The caller closes the reader.
+func (u *documentUsecase) Download(ctx context.Context, NIP string, id uint64) (*model.LeadDocument, io.ReadCloser, error) {
+	scope, err := u.scope(NIP)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	doc, err := u.documentRepo.FindInScope(id, scope)
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil, fmt.Errorf("%w: dokumen %d", ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("gagal mengambil dokumen: %v", err)
+	}
+
+	reader, err := u.storage.Open(ctx, doc.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil, fmt.Errorf("%w: file dokumen %d", ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return doc, reader, nil
+}
+
+// Delete removes a document uploaded by mistake on one of the marketer's own
+// leads.
+func (u *documentUsecase) Delete(ctx context.Context, NIP string, cif string, id uint64) error {
+	mc, err := u.marketingCustomerRepo.FindByCifAndMarketingNIP(u.db, cif, NIP)
+	if err == gorm.ErrRecordNotFound {
+		return fmt.Errorf("%w: lead dengan CIF %s tidak ditemukan di daftar Anda", ErrNotFound, cif)
+	}
+	if err != nil {
+		return err
+	}
+
+	doc, err := u.documentRepo.FindInScope(id, dto.LeadEventScope{MarketingID: &mc.MarketingID})
+	if err == gorm.ErrRecordNotFound || (err == nil && doc.CustomerID != mc.CustomerID) {
+		return fmt.Errorf("%w: dokumen %d pada lead %s", ErrNotFound, id, cif)
+	}
+	if err != nil {
+		return fmt.Errorf("gagal mengambil dokumen: %v", err)
+	}
+	if doc.UploadedBy != mc.MarketingID {
+		return fmt.Errorf("%w: hanya pengunggah yang dapat menghapus dokumen", ErrForbidden)
+	}
+
+	// The row goes first so a storage failure never leaves a document listed
+	// without its file; an orphaned file is only logged.
+	if err := u.documentRepo.Delete(doc); err != nil {
+		return err
+	}
+	if err := u.storage.Delete(ctx, doc.StorageKey); err != nil {
+		log.Printf("gagal menghapus file dokumen %d (%s): %v", doc.ID, doc.StorageKey, err)
+	}
+	return nil
+}
+
+func (u *documentUsecase) scope(NIP string) (dto.LeadEventScope, error) {
+	user, err := u.userRepo.FindByNIP(NIP)
+	if err != nil {
+		return dto.LeadEventScope{}, fmt.Errorf("error getting user data: %v", err)
+	}
+	return leadScope(user)
+}
+
+// spoolUpload copies the upload to a temporary file, which the scanner needs
+// a path for, and computes its checksum on the way.
+func spoolUpload(fileHeader *multipart.FileHeader) (*os.File, string, error) {
+	src, err := fileHeader.Open()
+	if err != nil {
+		return nil, "", fmt.Errorf("gagal membaca file: %v", err)
+	}
+	defer src.Close()
+
+	tmp, err := os.CreateTemp("", "lead-document-*")
+	if err != nil {
+		return nil, "", fmt.Errorf("gagal membuat file sementara: %v", err)
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, hash), src); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, "", fmt.Errorf("gagal membaca file: %v", err)
+	}
+	return tmp, hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,384 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"ml-prediction/config"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/model"
+	"ml-prediction/internal/app/repository"
+	"ml-prediction/pkg/helper"
+	"ml-prediction/pkg/scheduler"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Weights of the signals that make up a duplicate score. A pair becomes a
+// candidate at duplicateThreshold, so a similar name alone is never enough:
+// it needs a matching employer, contact detail or age as well.
+const (
+	duplicateThreshold   = 0.6
+	nameSimilarityFloor  = 0.85
+	weightName           = 0.4
+	weightEmployer       = 0.2
+	weightAge            = 0.1
+	weightPhone          = 0.5
+	weightEmail          = 0.4
+	weightAccount        = 0.6
+	maxNameBlockCustomer = 2000
+)
+
+const duplicateScanLockKey = "duplicate-scan"
+
+type DuplicateUsecase interface {
+	Scan(ctx context.Context, NIP string) (*model.DuplicateScanRun, error)
+	RunScheduled(ctx context.Context) error
+	GetScanRun(ctx context.Context, id uint64) (*model.DuplicateScanRun, error)
+	GetCandidates(ctx context.Context, req *dto.DuplicateListRequest) ([]model.CustomerDuplicateCandidate, *dto.Pagination, error)
+	Dismiss(ctx context.Context, id uint64, adminNIP string) error
+	Merge(ctx context.Context, id uint64, req *dto.MergeDuplicateRequest, adminNIP string) (*dto.MergeResult, error)
+}
+
+type duplicateUsecase struct {
+	duplicateRepo repository.DuplicateRepository
+	userRepo      repository.UserRepository
+	cfg           config.DuplicateConfig
+	db            *gorm.DB
+}
+
+func NewDuplicateUsecase(duplicateRepo repository.DuplicateRepository, userRepo repository.UserRepository, cfg config.DuplicateConfig, db *gorm.DB) DuplicateUsecase {
+	return &duplicateUsecase{duplicateRepo: duplicateRepo, userRepo: userRepo, cfg: cfg, db: db}
+}
+
+// Scan starts a duplicate scan for an admin and returns its run as soon as
+// it is recorded; the scan itself continues in the background and its
+// progress is read with GetScanRun.
+func (u *duplicateUsecase) Scan(ctx context.Context, NIP string) (*model.DuplicateScanRun, error) {
+	admin, err := u.userRepo.FindByNIP(NIP)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user data: %v", err)
+	}
+
+	// The scan outlives the request, so it does not use the request context.
+	started := make(chan *model.DuplicateScanRun, 1)
+	failed := make(chan error, 1)
+	go func() {
+		if err := u.execute(context.Background(), model.DuplicateScanTriggerManual, &admin.ID, started); err != nil {
+			failed <- err
+		}
+	}()
+
+	select {
+	case run := <-started:
+		return run, nil
+	case err := <-failed:
+		if errors.Is(err, scheduler.ErrLocked) {
+			return nil, fmt.Errorf("%w: %v", ErrConflict, err)
+		}
+		return nil, err
+	}
+}
+
+func (u *duplicateUsecase) RunScheduled(ctx context.Context) error {
+	err := u.execute(ctx, model.DuplicateScanTriggerScheduled, nil, nil)
+	if errors.Is(err, scheduler.ErrLocked) {
+		return nil
+	}
+	return err
+}
+
+func (u *duplicateUsecase) GetScanRun(ctx context.Context, id uint64) (*model.DuplicateScanRun, error) {
+	run, err := u.duplicateRepo.GetScanRun(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("%w: pemindaian duplikat %d", ErrNotFound, id)
+		}
+		return nil, fmt.Errorf("gagal mengambil pemindaian duplikat: %v", err)
+	}
+	return run, nil
+}
+
+// execute runs one scan under an advisory lock so scans never overlap. The
+// run is sent on started, when given, once it is recorded.
+func (u *duplicateUsecase) execute(ctx context.Context, trigger string, triggeredBy *uint, started chan<- *model.DuplicateScanRun) error {
+	return scheduler.WithAdvisoryLock(ctx, u.db, duplicateScanLockKey, func() error {
+		run := &model.DuplicateScanRun{
+			Trigger:     trigger,
+			TriggeredBy: triggeredBy,
+			Status:      model.DuplicateScanStatusRunning,
+			StartedAt:   time.Now(),
+		}
+		if err := u.duplicateRepo.CreateScanRun(run); err != nil {
+			return fmt.Errorf("gagal membuat pemindaian duplikat: %v", err)
+		}
+		if started != nil {
+			reported := *run
+			started <- &reported
+		}
+
+		scanErr := u.scan(ctx, run)
+
+		finished := time.Now()
+		run.FinishedAt = &finished
+		run.Status = model.DuplicateScanStatusSucceeded
+		if scanErr != nil {
+			message := scanErr.Error()
+			run.Status = model.DuplicateScanStatusFailed
+			run.Error = &message
+		}
+		if err := u.duplicateRepo.UpdateScanRun(run); err != nil {
+			return fmt.Errorf("gagal memperbarui pemindaian duplikat: %v", err)
+		}
+		return scanErr
+	})
+}
+
+// scan reads the customers in keyset batches and then compares them. Only
+// the fields the detector needs are kept in memory.
+func (u *duplicateUsecase) scan(ctx context.Context, run *model.DuplicateScanRun) error {
+	var rows []dto.DuplicateScanRow
+	var afterID uint64
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		batch, err := u.duplicateRepo.GetScanRows(afterID, u.batchSize())
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		rows = append(rows, batch...)
+		afterID = batch[len(batch)-1].ID
+	}
+	run.Scanned = len(rows)
+
+	candidates := detectDuplicates(rows)
+	run.Candidates = len(candidates)
+	saved, err := u.duplicateRepo.SaveCandidates(candidates)
+	if err != nil {
+		return err
+	}
+	run.Saved = saved
+	return nil
+}
+
+func (u *duplicateUsecase) batchSize() int {
+	if u.cfg.BatchSize <= 0 {
+		return 1000
+	}
+	return u.cfg.BatchSize
+}
+
+func (u *duplicateUsecase) GetCandidates(ctx context.Context, req *dto.DuplicateListRequest) ([]model.CustomerDuplicateCandidate, *dto.Pagination, error) {
+	return u.duplicateRepo.GetCandidates(req)
+}
+
+func (u *duplicateUsecase) Dismiss(ctx context.Context, id uint64, adminNIP string) error {
+	tx := u.db.Begin()
+	if tx.Error != nil {
+		return fmt.Errorf("gagal memulai transaksi: %v", tx.Error)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	admin, candidate, err := u.lockPendingCandidate(tx, id, adminNIP)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	now := time.Now()
+	candidate.Status = model.DuplicateStatusDismissed
+	candidate.ReviewedBy = &admin.ID
+	candidate.ReviewedAt = &now
+	if err := u.duplicateRepo.UpdateCandidateWithTx(tx, candidate); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("gagal memperbarui kandidat duplikat: %v", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("gagal menyimpan perubahan: %v", err)
+	}
+	return nil
+}
+
+func (u *duplicateUsecase) Merge(ctx context.Context, id uint64, req *dto.MergeDuplicateRequest, adminNIP string) (*dto.MergeResult, error) {
+	tx := u.db.Begin()
+	if tx.Error != nil {
+		return nil, fmt.Errorf("gagal memulai transaksi: %v", tx.Error)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	admin, candidate, err := u.lockPendingCandidate(tx, id, adminNIP)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	var duplicateID uint64
+	switch req.SurvivorID {
+	case candidate.CustomerID:
+		duplicateID = candidate.DuplicateID
+	case candidate.DuplicateID:
+		duplicateID = candidate.CustomerID
+	default:
+		tx.Rollback()
+		return nil, fmt.Errorf("survivor_id harus salah satu dari customer %d atau %d", candidate.CustomerID, candidate.DuplicateID)
+	}
+
+	now := time.Now()
+	candidate.Status = model.DuplicateStatusMerged
+	candidate.SurvivorID = &req.SurvivorID
+	candidate.ReviewedBy = &admin.ID
+	candidate.ReviewedAt = &now
+	if err := u.duplicateRepo.UpdateCandidateWithTx(tx, candidate); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("gagal memperbarui kandidat duplikat: %v", err)
+	}
+
+	result, err := u.duplicateRepo.MergeCustomersWithTx(tx, req.SurvivorID, duplicateID)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("gagal menggabungkan customer: %v", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("gagal menyimpan penggabungan: %v", err)
+	}
+	return result, nil
+}
+
+func (u *duplicateUsecase) lockPendingCandidate(tx *gorm.DB, id uint64, adminNIP string) (*model.User, *model.CustomerDuplicateCandidate, error) {
+	admin, err := u.userRepo.FindByNIPWithTx(tx, adminNIP)
+	if err != nil {
+		return nil, nil, fmt.Errorf("admin tidak ditemukan: %v", err)
+	}
+
+	candidate, err := u.duplicateRepo.FindCandidateForUpdate(tx, id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil, fmt.Errorf("%w: kandidat duplikat %d", ErrNotFound, id)
+		}
+		return nil, nil, fmt.Errorf("gagal mencari kandidat duplikat: %v", err)
+	}
+	if candidate.Status != model.DuplicateStatusPending {
+		return nil, nil, fmt.Errorf("kandidat duplikat sudah ditinjau dengan status %s", candidate.Status)
+	}
+	return admin, candidate, nil
+}
+
+// detectDuplicates compares customers that share a blocking key: a blind
+// index, or the same employer and first letter of the name. Comparing every
+// pair in the bank would be quadratic in the number of customers.
+func detectDuplicates(rows []dto.DuplicateScanRow) []model.CustomerDuplicateCandidate {
+	blocks := map[string][]int{}
+	for i, row := range rows {
+		for _, key := range blockingKeys(row) {
+			blocks[key] = append(blocks[key], i)
+		}
+	}
+
+	seen := map[[2]uint64]bool{}
+	var candidates []model.CustomerDuplicateCandidate
+	for key, members := range blocks {
+		if len(members) < 2 || (strings.HasPrefix(key, "name:") && len(members) > maxNameBlockCustomer) {
+			continue
+		}
+		for i := 0; i < len(members); i++ {
+			for j := i + 1; j < len(members); j++ {
+				a, b := rows[members[i]], rows[members[j]]
+				if a.ID > b.ID {
+					a, b = b, a
+				}
+				pair := [2]uint64{a.ID, b.ID}
+				if seen[pair] {
+					continue
+				}
+				seen[pair] = true
+
+				score, reasons := scoreDuplicate(a, b)
+				if score < duplicateThreshold {
+					continue
+				}
+				candidates = append(candidates, model.CustomerDuplicateCandidate{
+					CustomerID:  a.ID,
+					DuplicateID: b.ID,
+					Score:       score,
+					Reasons:     reasons,
+					Status:      model.DuplicateStatusPending,
+				})
+			}
+		}
+	}
+	return candidates
+}
+
+func blockingKeys(row dto.DuplicateScanRow) []string {
+	var keys []string
+	if row.NomorHpBidx != nil {
+		keys = append(keys, "phone:"+*row.NomorHpBidx)
+	}
+	if row.EmailBidx != nil {
+		keys = append(keys, "email:"+*row.EmailBidx)
+	}
+	if row.NomorRekeningBidx != nil {
+		keys = append(keys, "account:"+*row.NomorRekeningBidx)
+	}
+	name := helper.NormalizeName(row.Nama)
+	employer := helper.NormalizeName(row.NamaPerusahaan)
+	if name != "" && employer != "" {
+		keys = append(keys, "name:"+employer+"|"+string([]rune(name)[0]))
+	}
+	return keys
+}
+
+func scoreDuplicate(a, b dto.DuplicateScanRow) (float64, []string) {
+	var score float64
+	var reasons []string
+
+	if sim := helper.NameSimilarity(a.Nama, b.Nama); sim >= nameSimilarityFloor {
+		score += weightName * sim
+		reasons = append(reasons, "name")
+	}
+	if employer := helper.NormalizeName(a.NamaPerusahaan); employer != "" && employer == helper.NormalizeName(b.NamaPerusahaan) {
+		score += weightEmployer
+		reasons = append(reasons, "employer")
+	}
+	if a.Umur > 0 && b.Umur > 0 && math.Abs(float64(a.Umur-b.Umur)) <= 1 {
+		score += weightAge
+		reasons = append(reasons, "age")
+	}
+	if sameIndex(a.NomorHpBidx, b.NomorHpBidx) {
+		score += weightPhone
+		reasons = append(reasons, "phone")
+	}
+	if sameIndex(a.EmailBidx, b.EmailBidx) {
+		score += weightEmail
+		reasons = append(reasons, "email")
+	}
+	if sameIndex(a.NomorRekeningBidx, b.NomorRekeningBidx) {
+		score += weightAccount
+		reasons = append(reasons, "account")
+	}
+
+	return math.Min(1, math.Round(score*10000)/10000), reasons
+}
+
+func sameIndex(a, b *string) bool {
+	return a != nil && b != nil && *a == *b
+}
@@ -0,0 +1,10 @@
+package usecase
+
+import "errors"
+
+// Sentinel errors that handlers map to HTTP status codes with errors.Is.
+var (
+	ErrNotFound  = errors.New("data tidak ditemukan")
+	ErrForbidden = errors.New("akses ditolak")
+	ErrConflict  = errors.New("konflik dengan kondisi data saat ini")
+)
@@ -0,0 +1,302 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/model"
+	"ml-prediction/internal/app/repository"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testDriver is a database/sql driver without a database behind it. The
+// usecases under test talk to faked repositories but still open transactions
+// and savepoints on their own connection; those succeed and do nothing.
+type testDriver struct{}
+
+type testConn struct{}
+
+type testTx struct{}
+
+func (testDriver) Open(string) (driver.Conn, error) { return testConn{}, nil }
+
+func (testConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("unexpected query in usecase test: %s", query)
+}
+
+func (testConn) Close() error { return nil }
+
+func (testConn) Begin() (driver.Tx, error) { return testTx{}, nil }
+
+// ExecContext accepts the SAVEPOINT and ROLLBACK TO statements gorm issues.
+func (testConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(0), nil
+}
+
+func (testTx) Commit() error   { return nil }
+func (testTx) Rollback() error { return nil }
+
+func init() {
+	sql.Register("usecase-test", testDriver{})
+}
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DriverName: "usecase-test", DSN: "test"}), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	return db
+}
+
+func uintPtr(v uint) *uint { return &v }
+
+// fakeUserRepo keeps users in memory. Deleted users are only found by
+// FindByNIPUnscoped, like the soft-delete scope of the real repository.
+type fakeUserRepo struct {
+	repository.UserRepository
+	users []*model.User
+}
+
+func (r *fakeUserRepo) find(nip string, unscoped bool) (*model.User, error) {
+	for _, user := range r.users {
+		if user.NIP == nip && (unscoped || !user.DeletedAt.Valid) {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserRepo) byID(id uint) model.User {
+	for _, user := range r.users {
+		if user.ID == id {
+			return *user
+		}
+	}
+	return model.User{}
+}
+
+func (r *fakeUserRepo) FindByNIP(nip string) (*model.User, error) { return r.find(nip, false) }
+
+func (r *fakeUserRepo) FindByNIPWithTx(tx *gorm.DB, nip string) (*model.User, error) {
+	return r.find(nip, false)
+}
+
+func (r *fakeUserRepo) FindByNIPUnscoped(nip string) (*model.User, error) { return r.find(nip, true) }
+
+// fakeLeadRepo keeps leads and their events in memory. Leads are returned
+// with their marketer loaded, deleted marketers included, the way the real
+// repository preloads them.
+type fakeLeadRepo struct {
+	repository.MarketingCustomerRepository
+	users  *fakeUserRepo
+	leads  []*model.MarketingCustomer
+	events []model.MarketingCustomerEvent
+}
+
+func (r *fakeLeadRepo) add(mc *model.MarketingCustomer) {
+	mc.ID = uint(len(r.leads) + 1)
+	if mc.CreatedAt.IsZero() {
+		mc.CreatedAt = time.Now()
+	}
+	r.leads = append(r.leads, mc)
+}
+
+func (r *fakeLeadRepo) loaded(mc *model.MarketingCustomer) *model.MarketingCustomer {
+	copied := *mc
+	copied.Marketing = r.users.byID(mc.MarketingID)
+	return &copied
+}
+
+func (r *fakeLeadRepo) FindByCifAndMarketingNIP(tx *gorm.DB, CIF string, NIP string) (*model.MarketingCustomer, error) {
+	for _, mc := range r.leads {
+		if mc.DeletedAt.Valid || mc.Customer.CIF != CIF {
+			continue
+		}
+		if r.users.byID(mc.MarketingID).NIP == NIP {
+			return r.loaded(mc), nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeLeadRepo) FindActiveByCustomerIDWithTx(tx *gorm.DB, customerID uint64) (*model.MarketingCustomer, error) {
+	for _, mc := range r.leads {
+		if !mc.DeletedAt.Valid && mc.CustomerID == customerID {
+			return r.loaded(mc), nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeLeadRepo) TransferWithTx(tx *gorm.DB, mc *model.MarketingCustomer, toMarketingID uint, actorID uint, reservedUntil *time.Time, reason string) (*model.MarketingCustomer, error) {
+	for _, lead := range r.leads {
+		if lead.ID == mc.ID {
+			lead.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+		}
+	}
+	next := &model.MarketingCustomer{
+		CustomerID:        mc.CustomerID,
+		Customer:          mc.Customer,
+		MarketingID:       toMarketingID,
+		Status:            mc.Status,
+		AssignedBy:        &actorID,
+		TransferredFromID: &mc.ID,
+		ReservedUntil:     reservedUntil,
+	}
+	r.add(next)
+	return next, nil
+}
+
+func (r *fakeLeadRepo) CreateEventWithTx(tx *gorm.DB, event *model.MarketingCustomerEvent) error {
+	event.ID = uint64(len(r.events) + 1)
+	event.CreatedAt = time.Now()
+	r.events = append(r.events, *event)
+	return nil
+}
+
+func (r *fakeLeadRepo) ExtendReservationWithTx(tx *gorm.DB, id uint, reservedUntil time.Time) error {
+	return nil
+}
+
+type fakeClosingRepo struct {
+	repository.ClosingRepository
+	closings []*model.Closing
+}
+
+func (r *fakeClosingRepo) IsPeriodLockedWithTx(tx *gorm.DB, year, month int) (bool, error) {
+	return false, nil
+}
+
+func (r *fakeClosingRepo) CreateWithTx(tx *gorm.DB, closing *model.Closing) error {
+	closing.ID = uint64(len(r.closings) + 1)
+	r.closings = append(r.closings, closing)
+	return nil
+}
+
+func (r *fakeClosingRepo) FindByIDWithTx(tx *gorm.DB, id uint64) (*model.Closing, error) {
+	for _, closing := range r.closings {
+		if closing.ID == id {
+			return closing, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeClosingRepo) VoidWithTx(tx *gorm.DB, closing *model.Closing) error {
+	for i, c := range r.closings {
+		if c.ID == closing.ID {
+			r.closings = append(r.closings[:i], r.closings[i+1:]...)
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+// fakeTimelineRepo reads the customer's history from the fake lead store.
+type fakeTimelineRepo struct {
+	customer *model.Customer
+	leads    *fakeLeadRepo
+	products map[uint]string
+}
+
+func (r *fakeTimelineRepo) FindCustomerByCIF(cif string) (*model.Customer, error) {
+	if r.customer.CIF != cif {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.customer, nil
+}
+
+func (r *fakeTimelineRepo) GetPredictionRuns(customerID uint64) ([]model.CustomerPredictionRun, error) {
+	return nil, nil
+}
+
+func (r *fakeTimelineRepo) GetRecommendations(customerID uint64) ([]dto.TimelineRecommendation, error) {
+	return nil, nil
+}
+
+func (r *fakeTimelineRepo) GetAssignments(customerID uint64) ([]dto.TimelineAssignment, error) {
+	var assignments []dto.TimelineAssignment
+	for _, mc := range r.leads.leads {
+		if mc.CustomerID != customerID {
+			continue
+		}
+		marketing := r.leads.users.byID(mc.MarketingID)
+		assignment := dto.TimelineAssignment{
+			ID:             mc.ID,
+			MarketingID:    mc.MarketingID,
+			MarketingName:  marketing.Nama,
+			MarketingNIP:   marketing.NIP,
+			KantorCabangID: marketing.KantorCabangID,
+			AssignedByID:   mc.AssignedBy,
+			CreatedAt:      mc.CreatedAt,
+		}
+		if mc.DeletedAt.Valid {
+			deletedAt := mc.DeletedAt.Time
+			assignment.DeletedAt = &deletedAt
+		}
+		assignments = append(assignments, assignment)
+	}
+	return assignments, nil
+}
+
+func (r *fakeTimelineRepo) GetStatusEvents(customerID uint64) ([]dto.LeadEventResponse, error) {
+	var events []dto.LeadEventResponse
+	for _, event := range r.leads.events {
+		var lead *model.MarketingCustomer
+		for _, mc := range r.leads.leads {
+			if mc.ID == event.MarketingCustomerID {
+				lead = mc
+			}
+		}
+		if lead == nil || lead.CustomerID != customerID {
+			continue
+		}
+		response := dto.LeadEventResponse{
+			ID:                  event.ID,
+			MarketingCustomerID: event.MarketingCustomerID,
+			MarketingID:         lead.MarketingID,
+			EventType:           event.EventType,
+			OldStatus:           event.OldStatus,
+			NewStatus:           event.NewStatus,
+			ActorID:             event.ActorID,
+			Note:                event.Note,
+			ProductID:           event.ProductID,
+			Amount:              event.Amount,
+			CreatedAt:           event.CreatedAt,
+		}
+		if event.ProductID != nil {
+			name := r.products[*event.ProductID]
+			response.ProductName = &name
+		}
+		events = append(events, response)
+	}
+	return events, nil
+}
+
+type fakeCustomerRepo struct {
+	repository.CustomerRepository
+	customers []dto.AssignableCustomer
+}
+
+func (r *fakeCustomerRepo) FindByCIFs(cifs []string) ([]dto.AssignableCustomer, error) {
+	var found []dto.AssignableCustomer
+	for _, customer := range r.customers {
+		for _, cif := range cifs {
+			if customer.CIF == cif {
+				found = append(found, customer)
+			}
+		}
+	}
+	return found, nil
+}
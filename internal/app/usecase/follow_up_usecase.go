@@ -0,0 +1,192 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ml-prediction/config"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/repository"
+	"ml-prediction/pkg/scheduler"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	followUpLockKey = "follow-up-reminders"
+
+	defaultAgendaDays = 7
+)
+
+type FollowUpUsecase interface {
+	Schedule(ctx context.Context, NIP string, cif string, req *dto.ScheduleFollowUpRequest) error
+	Clear(ctx context.Context, NIP string, cif string) error
+	GetAgenda(ctx context.Context, NIP string, req *dto.FollowUpAgendaRequest) (*dto.FollowUpAgendaResponse, error)
+	GetBranchOverdue(ctx context.Context, NIP string) ([]dto.FollowUpItem, error)
+	GetReminders(ctx context.Context, NIP string, unreadOnly bool) ([]dto.FollowUpReminderResponse, error)
+	MarkReminderRead(ctx context.Context, NIP string, id uint64) error
+	RunScheduled(ctx context.Context) error
+}
+
+type followUpUsecase struct {
+	followUpRepo          repository.FollowUpRepository
+	marketingCustomerRepo repository.MarketingCustomerRepository
+	userRepo              repository.UserRepository
+	leadStates            *dto.LeadStateMachine
+	cfg                   config.FollowUpConfig
+	db                    *gorm.DB
+}
+
+func NewFollowUpUsecase(
+	followUpRepo repository.FollowUpRepository,
+	mcRepo repository.MarketingCustomerRepository,
+	userRepo repository.UserRepository,
+	leadStates *dto.LeadStateMachine,
+	cfg config.FollowUpConfig,
+	db *gorm.DB,
+) FollowUpUsecase {
+	return &followUpUsecase{
+		followUpRepo:          followUpRepo,
+		marketingCustomerRepo: mcRepo,
+		userRepo:              userRepo,
+		leadStates:            leadStates,
+		cfg:                   cfg,
+		db:                    db,
+	}
+}
+
+// Schedule sets when the marketer will next contact one of their leads.
+func (u *followUpUsecase) Schedule(ctx context.Context, NIP string, cif string, req *dto.ScheduleFollowUpRequest) error {
+	mc, err := u.marketingCustomerRepo.FindByCifAndMarketingNIP(u.db, cif, NIP)
+	if err == gorm.ErrRecordNotFound {
+		return fmt.Errorf("%w: lead dengan CIF %s tidak ditemukan di daftar Anda", ErrNotFound, cif)
+	}
+	if err != nil {
+		return err
+	}
+	if u.leadStates.IsFinal(mc.Status) {
+		return fmt.Errorf("%w: lead dengan status %s tidak dapat dijadwalkan tindak lanjut", ErrConflict, mc.Status)
+	}
+	if !req.NextFollowUpAt.After(time.Now()) {
+		return fmt.Errorf("jadwal tindak lanjut harus di masa depan")
+	}
+
+	if err := u.followUpRepo.Schedule(mc.ID, *req.NextFollowUpAt, req.Reason); err != nil {
+		return fmt.Errorf("gagal menyimpan jadwal tindak lanjut: %v", err)
+	}
+	return nil
+}
+
+// Clear marks the scheduled follow-up as done.
+func (u *followUpUsecase) Clear(ctx context.Context, NIP string, cif string) error {
+	mc, err := u.marketingCustomerRepo.FindByCifAndMarketingNIP(u.db, cif, NIP)
+	if err == gorm.ErrRecordNotFound {
+		return fmt.Errorf("%w: lead dengan CIF %s tidak ditemukan di daftar Anda", ErrNotFound, cif)
+	}
+	if err != nil {
+		return err
+	}
+	if err := u.followUpRepo.Clear(mc.ID); err != nil {
+		return fmt.Errorf("gagal menghapus jadwal tindak lanjut: %v", err)
+	}
+	return nil
+}
+
+// GetAgenda splits the marketer's follow-ups into overdue, due later today
+// and upcoming within the requested number of days.
+func (u *followUpUsecase) GetAgenda(ctx context.Context, NIP string, req *dto.FollowUpAgendaRequest) (*dto.FollowUpAgendaResponse, error) {
+	user, err := u.userRepo.FindByNIP(NIP)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user data: %v", err)
+	}
+
+	days := req.Days
+	if days == 0 {
+		days = defaultAgendaDays
+	}
+	now := time.Now()
+	endOfToday := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, 1)
+	overdueCutoff := now.Add(-u.cfg.OverdueGrace)
+
+	items, err := u.followUpRepo.GetFollowUps(dto.LeadEventScope{MarketingID: &user.ID}, endOfToday.AddDate(0, 0, days))
+	if err != nil {
+		return nil, err
+	}
+	unread, err := u.followUpRepo.CountUnreadReminders(user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error counting reminders: %v", err)
+	}
+
+	agenda := &dto.FollowUpAgendaResponse{
+		Overdue:         []dto.FollowUpItem{},
+		Today:           []dto.FollowUpItem{},
+		Upcoming:        []dto.FollowUpItem{},
+		UnreadReminders: unread,
+	}
+	for _, item := range items {
+		switch {
+		case item.OverdueAt != nil || item.NextFollowUpAt.Before(overdueCutoff):
+			agenda.Overdue = append(agenda.Overdue, item)
+		case item.NextFollowUpAt.Before(endOfToday):
+			agenda.Today = append(agenda.Today, item)
+		default:
+			agenda.Upcoming = append(agenda.Upcoming, item)
+		}
+	}
+	return agenda, nil
+}
+
+// GetBranchOverdue lists overdue follow-ups of every marketer in the BM's
+// branch.
+func (u *followUpUsecase) GetBranchOverdue(ctx context.Context, NIP string) ([]dto.FollowUpItem, error) {
+	user, err := u.userRepo.FindByNIP(NIP)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user data: %v", err)
+	}
+	scope, err := leadScope(user)
+	if err != nil {
+		return nil, err
+	}
+	return u.followUpRepo.GetFollowUps(scope, time.Now().Add(-u.cfg.OverdueGrace))
+}
+
+func (u *followUpUsecase) GetReminders(ctx context.Context, NIP string, unreadOnly bool) ([]dto.FollowUpReminderResponse, error) {
+	user, err := u.userRepo.FindByNIP(NIP)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user data: %v", err)
+	}
+	return u.followUpRepo.GetReminders(user.ID, unreadOnly)
+}
+
+func (u *followUpUsecase) MarkReminderRead(ctx context.Context, NIP string, id uint64) error {
+	user, err := u.userRepo.FindByNIP(NIP)
+	if err != nil {
+		return fmt.Errorf("error getting user data: %v", err)
+	}
+	updated, err := u.followUpRepo.MarkReminderRead(id, user.ID)
+	if err != nil {
+		return fmt.Errorf("gagal memperbarui pengingat: %v", err)
+	}
+	if updated == 0 {
+		return fmt.Errorf("%w: pengingat %d", ErrNotFound, id)
+	}
+	return nil
+}
+
+// RunScheduled creates reminders for follow-ups that have fallen due and marks
+// those past the grace period as overdue.
+func (u *followUpUsecase) RunScheduled(ctx context.Context) error {
+	err := scheduler.WithAdvisoryLock(ctx, u.db, followUpLockKey, func() error {
+		now := time.Now()
+		if _, err := u.followUpRepo.GenerateReminders(now); err != nil {
+			return err
+		}
+		_, err := u.followUpRepo.MarkOverdue(now.Add(-u.cfg.OverdueGrace))
+		return err
+	})
+	if errors.Is(err, scheduler.ErrLocked) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,151 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"ml-prediction/config"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/repository"
+	"time"
+)
+
+type FunnelUsecase interface {
+	GetReport(ctx context.Context, NIP string, req *dto.FunnelReportRequest) (*dto.FunnelReportResponse, error)
+}
+
+type funnelUsecase struct {
+	funnelRepo repository.FunnelRepository
+	userRepo   repository.UserRepository
+	leadStates *dto.LeadStateMachine
+	leadCfg    config.LeadConfig
+}
+
+func NewFunnelUsecase(funnelRepo repository.FunnelRepository, userRepo repository.UserRepository, leadStates *dto.LeadStateMachine, leadCfg config.LeadConfig) FunnelUsecase {
+	return &funnelUsecase{
+		funnelRepo: funnelRepo,
+		userRepo:   userRepo,
+		leadStates: leadStates,
+		leadCfg:    leadCfg,
+	}
+}
+
+// GetReport builds the sales funnel of the leads claimed in a period from
+// their history. BMs see the marketers of their branch; admins see everyone
+// unless they pick a branch. Stale counts cover every open lead, whenever it
+// was claimed.
+func (u *funnelUsecase) GetReport(ctx context.Context, NIP string, req *dto.FunnelReportRequest) (*dto.FunnelReportResponse, error) {
+	user, err := u.userRepo.FindByNIP(NIP)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user data: %v", err)
+	}
+
+	var kantorCabangID *uint
+	switch user.Role {
+	case "admin":
+		if req.KantorCabangID != 0 {
+			kantorCabangID = &req.KantorCabangID
+		}
+	case "bm":
+		if user.KantorCabangID == nil {
+			return nil, fmt.Errorf("%w: BM tidak terdaftar pada kantor cabang", ErrForbidden)
+		}
+		kantorCabangID = user.KantorCabangID
+	default:
+		return nil, fmt.Errorf("%w: role %s tidak dapat melihat laporan funnel", ErrForbidden, user.Role)
+	}
+
+	from, to, err := reportPeriod(req.From, req.To)
+	if err != nil {
+		return nil, err
+	}
+	finalStates := u.leadStates.FinalStates()
+	if len(finalStates) == 0 {
+		// NOT IN () is not valid SQL; no lead can be in an empty state.
+		finalStates = []string{""}
+	}
+	filter := dto.FunnelFilter{
+		From:           from,
+		To:             to,
+		StaleBefore:    time.Now().Add(-u.leadCfg.StaleAfter),
+		FinalStates:    finalStates,
+		KantorCabangID: kantorCabangID,
+	}
+
+	pool, err := u.funnelRepo.CountPoolEntries(from, to, kantorCabangID)
+	if err != nil {
+		return nil, err
+	}
+	report := &dto.FunnelReportResponse{
+		From:       from,
+		To:         to,
+		StaleAfter: u.leadCfg.StaleAfter.String(),
+		Pool:       pool,
+	}
+
+	for _, dimension := range []struct {
+		name string
+		rows *[]dto.FunnelStage
+	}{
+		{repository.FunnelBranch, &report.ByBranch},
+		{repository.FunnelMarketing, &report.ByMarketing},
+		{repository.FunnelProduct, &report.ByProduct},
+		{repository.FunnelMonth, &report.ByMonth},
+	} {
+		counts, err := u.funnelRepo.GetFunnel(filter, dimension.name)
+		if err != nil {
+			return nil, err
+		}
+		*dimension.rows = funnelStages(counts)
+	}
+
+	total, err := u.funnelRepo.GetFunnel(filter, repository.FunnelTotal)
+	if err != nil {
+		return nil, err
+	}
+	if stages := funnelStages(total); len(stages) > 0 {
+		report.Total = stages[0]
+	} else {
+		report.Total = dto.FunnelStage{Key: "total", Label: "Total"}
+	}
+	report.ClaimRate = percentage(report.Total.Claimed, pool)
+	return report, nil
+}
+
+// funnelStages adds the conversion rates of each step to the raw counts.
+func funnelStages(counts []dto.FunnelCounts) []dto.FunnelStage {
+	stages := make([]dto.FunnelStage, 0, len(counts))
+	for _, c := range counts {
+		stages = append(stages, dto.FunnelStage{
+			Key:                  c.Key,
+			Label:                c.Label,
+			Claimed:              c.Claimed,
+			Contacted:            c.Contacted,
+			Closed:               c.Closed,
+			Rejected:             c.Rejected,
+			Stale:                c.Stale,
+			ContactRate:          percentage(c.Contacted, c.Claimed),
+			CloseRate:            percentage(c.Closed, c.Contacted),
+			RejectRate:           percentage(c.Rejected, c.Contacted),
+			MedianHoursToContact: roundHours(c.MedianHoursToContact),
+			MedianHoursToClose:   roundHours(c.MedianHoursToClose),
+		})
+	}
+	return stages
+}
+
+// percentage returns part as a percentage of whole with two decimals.
+func percentage(part, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return math.Round(float64(part)/float64(whole)*10000) / 100
+}
+
+func roundHours(hours *float64) *float64 {
+	if hours == nil {
+		return nil
+	}
+	rounded := math.Round(*hours*10) / 10
+	return &rounded
+}
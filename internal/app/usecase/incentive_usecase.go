@@ -0,0 +1,503 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/model"
+	"ml-prediction/internal/app/repository"
+	"ml-prediction/pkg/scheduler"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const incentiveLockKey = "incentives"
+
+type IncentiveUsecase interface {
+	CreateScheme(ctx context.Context, NIP string, req *dto.CreateIncentiveSchemeRequest) (*model.IncentiveScheme, error)
+	GetSchemes(ctx context.Context, productID uint) ([]model.IncentiveScheme, error)
+	DeleteScheme(ctx context.Context, id uint) error
+	Calculate(ctx context.Context, year, month int) (*dto.IncentiveCalculationResponse, error)
+	RunScheduled(ctx context.Context) error
+	GetStatements(ctx context.Context, NIP string, req *dto.IncentiveStatementsRequest) (*dto.IncentiveStatementsResponse, error)
+	GetStatement(ctx context.Context, NIP string, id uint64) (*dto.IncentiveStatementDetailResponse, error)
+	Approve(ctx context.Context, NIP string, id uint64) (*model.IncentiveStatement, error)
+	Reject(ctx context.Context, NIP string, id uint64, req *dto.RejectIncentiveRequest) (*model.IncentiveStatement, error)
+	GetPayroll(ctx context.Context, NIP string, req *dto.IncentivePeriodRequest, kantorCabangID uint) ([]dto.IncentiveStatementResponse, error)
+}
+
+type incentiveUsecase struct {
+	incentiveRepo repository.IncentiveRepository
+	userRepo      repository.UserRepository
+	db            *gorm.DB
+}
+
+func NewIncentiveUsecase(incentiveRepo repository.IncentiveRepository, userRepo repository.UserRepository, db *gorm.DB) IncentiveUsecase {
+	return &incentiveUsecase{
+		incentiveRepo: incentiveRepo,
+		userRepo:      userRepo,
+		db:            db,
+	}
+}
+
+// CreateScheme adds a scheme for a product. Schemes of one product may not
+// cover the same month, so each month is paid under exactly one scheme.
+func (u *incentiveUsecase) CreateScheme(ctx context.Context, NIP string, req *dto.CreateIncentiveSchemeRequest) (*model.IncentiveScheme, error) {
+	admin, err := u.userRepo.FindByNIP(NIP)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user data: %v", err)
+	}
+
+	from, err := time.Parse("2006-01", req.EffectiveFrom)
+	if err != nil {
+		return nil, fmt.Errorf("format effective_from tidak valid")
+	}
+	var to *time.Time
+	if req.EffectiveTo != "" {
+		parsed, err := time.Parse("2006-01", req.EffectiveTo)
+		if err != nil {
+			return nil, fmt.Errorf("format effective_to tidak valid")
+		}
+		if parsed.Before(from) {
+			return nil, fmt.Errorf("effective_to tidak boleh sebelum effective_from")
+		}
+		to = &parsed
+	}
+	if len(req.Tiers) == 0 && req.PerClosingFee == 0 {
+		return nil, fmt.Errorf("skema insentif harus memiliki tier atau fee per closing")
+	}
+
+	seen := make(map[float64]bool)
+	tiers := make([]model.IncentiveSchemeTier, 0, len(req.Tiers))
+	for _, t := range req.Tiers {
+		if seen[t.MinAttainment] {
+			return nil, fmt.Errorf("tier dengan pencapaian minimum %.2f%% ganda", t.MinAttainment)
+		}
+		seen[t.MinAttainment] = true
+		tiers = append(tiers, model.IncentiveSchemeTier{
+			MinAttainment: t.MinAttainment,
+			FixedAmount:   t.FixedAmount,
+			RatePercent:   t.RatePercent,
+		})
+	}
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].MinAttainment < tiers[j].MinAttainment })
+
+	overlaps, err := u.incentiveRepo.SchemeOverlaps(req.ProductID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if overlaps {
+		return nil, fmt.Errorf("%w: produk sudah memiliki skema insentif pada periode tersebut", ErrConflict)
+	}
+
+	scheme := &model.IncentiveScheme{
+		ProductID:     req.ProductID,
+		Name:          req.Name,
+		EffectiveFrom: from,
+		EffectiveTo:   to,
+		PerClosingFee: req.PerClosingFee,
+		CapAmount:     req.CapAmount,
+		CreatedBy:     &admin.ID,
+		Tiers:         tiers,
+	}
+	if err := u.incentiveRepo.CreateScheme(scheme); err != nil {
+		return nil, err
+	}
+	return scheme, nil
+}
+
+func (u *incentiveUsecase) GetSchemes(ctx context.Context, productID uint) ([]model.IncentiveScheme, error) {
+	return u.incentiveRepo.GetSchemes(productID)
+}
+
+// DeleteScheme withdraws a scheme. Approved statements keep their lines;
+// statements still pending lose its lines on the next calculation.
+func (u *incentiveUsecase) DeleteScheme(ctx context.Context, id uint) error {
+	scheme, err := u.incentiveRepo.FindScheme(id)
+	if err == gorm.ErrRecordNotFound {
+		return fmt.Errorf("%w: skema insentif %d", ErrNotFound, id)
+	}
+	if err != nil {
+		return fmt.Errorf("gagal mengambil skema insentif: %v", err)
+	}
+	return u.incentiveRepo.DeleteScheme(scheme)
+}
+
+// Calculate (re)computes the statements of every marketer for a month.
+// Approved statements are left as they were paid.
+func (u *incentiveUsecase) Calculate(ctx context.Context, year, month int) (*dto.IncentiveCalculationResponse, error) {
+	var result *dto.IncentiveCalculationResponse
+	err := scheduler.WithAdvisoryLock(ctx, u.db, incentiveLockKey, func() error {
+		var err error
+		result, err = u.calculate(year, month)
+		return err
+	})
+	if errors.Is(err, scheduler.ErrLocked) {
+		return nil, fmt.Errorf("%w: %v", ErrConflict, err)
+	}
+	return result, err
+}
+
+// RunScheduled calculates the previous month, whose achievement no longer
+// grows except through late verifications.
+func (u *incentiveUsecase) RunScheduled(ctx context.Context) error {
+	now := time.Now()
+	previous := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).AddDate(0, -1, 0)
+	_, err := u.Calculate(ctx, previous.Year(), int(previous.Month()))
+	if errors.Is(err, ErrConflict) {
+		return nil
+	}
+	return err
+}
+
+// incentiveBasis is what one marketer earned on one product in the month.
+type incentiveBasis struct {
+	target   int64
+	closings []model.Closing
+}
+
+type marketerBasis struct {
+	kantorCabangID *uint
+	products       map[uint]*incentiveBasis
+}
+
+func (u *incentiveUsecase) calculate(year, month int) (*dto.IncentiveCalculationResponse, error) {
+	from := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.Local)
+	to := from.AddDate(0, 1, 0)
+	result := &dto.IncentiveCalculationResponse{Tahun: year, Bulan: month}
+
+	schemes, err := u.incentiveRepo.GetActiveSchemes(from)
+	if err != nil {
+		return nil, err
+	}
+	productIDs := make([]uint, 0, len(schemes))
+	for _, s := range schemes {
+		productIDs = append(productIDs, s.ProductID)
+	}
+
+	marketers := make(map[uint]*marketerBasis)
+	basisOf := func(marketingID uint, kantorCabangID *uint, productID uint) *incentiveBasis {
+		m, ok := marketers[marketingID]
+		if !ok {
+			m = &marketerBasis{kantorCabangID: kantorCabangID, products: make(map[uint]*incentiveBasis)}
+			marketers[marketingID] = m
+		}
+		b, ok := m.products[productID]
+		if !ok {
+			b = &incentiveBasis{}
+			m.products[productID] = b
+		}
+		return b
+	}
+
+	if len(productIDs) > 0 {
+		targets, err := u.incentiveRepo.GetTargets(year, month, productIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range targets {
+			basisOf(t.MarketingID, t.KantorCabangID, t.ProductID).target += t.Target
+		}
+
+		closings, err := u.incentiveRepo.GetVerifiedClosings(from, to, productIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range closings {
+			var kantorCabangID *uint
+			if c.Marketing != nil {
+				kantorCabangID = c.Marketing.KantorCabangID
+			}
+			b := basisOf(c.MarketingID, kantorCabangID, c.ProductID)
+			b.closings = append(b.closings, c)
+		}
+	}
+
+	// Marketers calculated before but without basis now get an empty
+	// statement rather than keeping a stale one.
+	existing, err := u.incentiveRepo.GetStatementMarketers(year, month)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range existing {
+		if _, ok := marketers[id]; !ok {
+			marketers[id] = &marketerBasis{products: make(map[uint]*incentiveBasis)}
+		}
+	}
+
+	ids := make([]uint, 0, len(marketers))
+	for id := range marketers {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for _, id := range ids {
+		basis := marketers[id]
+		lines, total := incentiveLines(schemes, basis.products)
+
+		err := u.db.Transaction(func(tx *gorm.DB) error {
+			statement, err := u.incentiveRepo.FindStatementForUpdateWithTx(tx, id, year, month)
+			if err == gorm.ErrRecordNotFound {
+				statement = &model.IncentiveStatement{MarketingID: id, Tahun: year, Bulan: month}
+			} else if err != nil {
+				return fmt.Errorf("gagal mengambil laporan insentif: %v", err)
+			}
+			if statement.Status == model.IncentiveApproved {
+				result.Skipped++
+				return nil
+			}
+
+			if basis.kantorCabangID != nil {
+				statement.KantorCabangID = basis.kantorCabangID
+			}
+			statement.TotalAmount = total
+			statement.Status = model.IncentivePendingApproval
+			statement.CalculatedAt = time.Now()
+			if err := u.incentiveRepo.SaveStatementWithTx(tx, statement, lines); err != nil {
+				return err
+			}
+			result.Calculated++
+			result.TotalAmount += total
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// incentiveLines applies each scheme to the marketer's basis on its product:
+// the highest tier reached, a fee per verified closing, and a deduction down
+// to the cap. It returns the lines and their total.
+func incentiveLines(schemes []model.IncentiveScheme, products map[uint]*incentiveBasis) ([]model.IncentiveStatementLine, int64) {
+	var lines []model.IncentiveStatementLine
+	var total int64
+
+	for _, scheme := range schemes {
+		basis, ok := products[scheme.ProductID]
+		if !ok {
+			continue
+		}
+		schemeID, productID := scheme.ID, scheme.ProductID
+		productName := fmt.Sprintf("produk %d", productID)
+		if scheme.Product != nil {
+			productName = scheme.Product.Nama
+		}
+
+		var achieved int64
+		for _, c := range basis.closings {
+			achieved += c.Amount
+		}
+		attainment := percentage64(achieved, basis.target)
+
+		var subtotal int64
+		if len(scheme.Tiers) > 0 {
+			line := model.IncentiveStatementLine{
+				SchemeID:       &schemeID,
+				ProductID:      &productID,
+				LineType:       model.IncentiveLineTier,
+				TargetAmount:   basis.target,
+				AchievedAmount: achieved,
+				Attainment:     attainment,
+			}
+			var reached *model.IncentiveSchemeTier
+			if basis.target > 0 {
+				for i := range scheme.Tiers {
+					if attainment >= scheme.Tiers[i].MinAttainment {
+						reached = &scheme.Tiers[i]
+					}
+				}
+			}
+			switch {
+			case basis.target == 0:
+				line.Description = fmt.Sprintf("%s: tidak ada target bulan ini, tier tidak berlaku", productName)
+			case reached == nil:
+				line.Description = fmt.Sprintf("%s: pencapaian %.2f%% (%d dari target %d) di bawah tier terendah %.2f%%",
+					productName, attainment, achieved, basis.target, scheme.Tiers[0].MinAttainment)
+			default:
+				line.Amount = reached.FixedAmount + int64(math.Round(float64(achieved)*reached.RatePercent/100))
+				line.Description = fmt.Sprintf("%s: pencapaian %.2f%% (%d dari target %d) mencapai tier %.2f%%: %d + %.3f%% dari pencapaian",
+					productName, attainment, achieved, basis.target, reached.MinAttainment, reached.FixedAmount, reached.RatePercent)
+			}
+			subtotal += line.Amount
+			lines = append(lines, line)
+		}
+
+		if scheme.PerClosingFee > 0 {
+			for _, c := range basis.closings {
+				closingID := c.ID
+				// The closing is referred to by id only; its account number
+				// must not be copied into statements that are kept for payroll.
+				description := fmt.Sprintf("%s: closing #%d tanggal %s sebesar %d", productName, c.ID, c.ClosedAt.Format("2006-01-02"), c.Amount)
+				lines = append(lines, model.IncentiveStatementLine{
+					SchemeID:       &schemeID,
+					ProductID:      &productID,
+					LineType:       model.IncentiveLineClosingFee,
+					Description:    description,
+					ClosingID:      &closingID,
+					AchievedAmount: c.Amount,
+					Amount:         scheme.PerClosingFee,
+				})
+				subtotal += scheme.PerClosingFee
+			}
+		}
+
+		if scheme.CapAmount != nil && subtotal > *scheme.CapAmount {
+			lines = append(lines, model.IncentiveStatementLine{
+				SchemeID:       &schemeID,
+				ProductID:      &productID,
+				LineType:       model.IncentiveLineCap,
+				Description:    fmt.Sprintf("%s: insentif %d dibatasi maksimum %d", productName, subtotal, *scheme.CapAmount),
+				TargetAmount:   basis.target,
+				AchievedAmount: achieved,
+				Attainment:     attainment,
+				Amount:         *scheme.CapAmount - subtotal,
+			})
+			subtotal = *scheme.CapAmount
+		}
+		total += subtotal
+	}
+	return lines, total
+}
+
+// GetStatements lists statements: a marketer's own, those of the BM's branch,
+// or all of them for admins, optionally narrowed to one branch.
+func (u *incentiveUsecase) GetStatements(ctx context.Context, NIP string, req *dto.IncentiveStatementsRequest) (*dto.IncentiveStatementsResponse, error) {
+	user, err := u.userRepo.FindByNIP(NIP)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user data: %v", err)
+	}
+
+	filter := dto.IncentiveStatementFilter{Tahun: req.Tahun, Bulan: req.Bulan, Status: req.Status}
+	switch user.Role {
+	case "admin":
+		if req.KantorCabangID != 0 {
+			filter.KantorCabangID = &req.KantorCabangID
+		}
+	case "bm":
+		if user.KantorCabangID == nil {
+			return nil, fmt.Errorf("%w: BM belum terdaftar di kantor cabang", ErrForbidden)
+		}
+		filter.KantorCabangID = user.KantorCabangID
+	case "marketing":
+		filter.MarketingID = &user.ID
+	default:
+		return nil, fmt.Errorf("%w: role %s", ErrForbidden, user.Role)
+	}
+
+	statements, pagination, amount, err := u.incentiveRepo.GetStatements(filter, req.Page, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.IncentiveStatementsResponse{TotalAmount: amount, Statements: statements, Pagination: pagination}, nil
+}
+
+// GetStatement returns a statement with its lines if the user may see it.
+func (u *incentiveUsecase) GetStatement(ctx context.Context, NIP string, id uint64) (*dto.IncentiveStatementDetailResponse, error) {
+	user, err := u.userRepo.FindByNIP(NIP)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user data: %v", err)
+	}
+
+	statement, err := u.incentiveRepo.GetStatement(id)
+	if err == gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("%w: laporan insentif %d", ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil laporan insentif: %v", err)
+	}
+	switch user.Role {
+	case "admin":
+	case "bm":
+		if !sameBranch(statement.KantorCabangID, user.KantorCabangID) {
+			return nil, fmt.Errorf("%w: laporan insentif %d", ErrNotFound, id)
+		}
+	default:
+		if statement.MarketingNIP != user.NIP {
+			return nil, fmt.Errorf("%w: laporan insentif %d", ErrNotFound, id)
+		}
+	}
+
+	lines, err := u.incentiveRepo.GetStatementLines(id)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.IncentiveStatementDetailResponse{IncentiveStatementResponse: *statement, Lines: lines}, nil
+}
+
+// Approve fixes a statement for payroll; it is no longer recalculated.
+func (u *incentiveUsecase) Approve(ctx context.Context, NIP string, id uint64) (*model.IncentiveStatement, error) {
+	return u.review(NIP, id, model.IncentiveApproved, nil)
+}
+
+// Reject sends a statement back; the next calculation replaces it.
+func (u *incentiveUsecase) Reject(ctx context.Context, NIP string, id uint64, req *dto.RejectIncentiveRequest) (*model.IncentiveStatement, error) {
+	return u.review(NIP, id, model.IncentiveRejected, &req.Reason)
+}
+
+// review records the BM's decision on a pending statement of their branch.
+func (u *incentiveUsecase) review(NIP string, id uint64, status string, note *string) (*model.IncentiveStatement, error) {
+	bm, err := u.userRepo.FindByNIP(NIP)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user data: %v", err)
+	}
+
+	var statement *model.IncentiveStatement
+	err = u.db.Transaction(func(tx *gorm.DB) error {
+		statement, err = u.incentiveRepo.FindStatementByIDWithTx(tx, id)
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("%w: laporan insentif %d", ErrNotFound, id)
+		}
+		if err != nil {
+			return fmt.Errorf("gagal mengambil laporan insentif: %v", err)
+		}
+		if !sameBranch(statement.KantorCabangID, bm.KantorCabangID) {
+			return fmt.Errorf("%w: laporan insentif bukan dari kantor cabang Anda", ErrForbidden)
+		}
+		if statement.Status != model.IncentivePendingApproval {
+			return fmt.Errorf("%w: laporan insentif berstatus %s", ErrConflict, statement.Status)
+		}
+
+		now := time.Now()
+		statement.Status = status
+		statement.ReviewedBy = &bm.ID
+		statement.ReviewedAt = &now
+		statement.ReviewNote = note
+		return u.incentiveRepo.ReviewStatementWithTx(tx, statement)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return statement, nil
+}
+
+// GetPayroll returns the approved statements of a month for the payroll
+// export: of the BM's branch, or for admins of all branches unless one is
+// picked.
+func (u *incentiveUsecase) GetPayroll(ctx context.Context, NIP string, req *dto.IncentivePeriodRequest, kantorCabangID uint) ([]dto.IncentiveStatementResponse, error) {
+	user, err := u.userRepo.FindByNIP(NIP)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user data: %v", err)
+	}
+
+	filter := dto.IncentiveStatementFilter{Tahun: req.Tahun, Bulan: req.Bulan, Status: model.IncentiveApproved}
+	switch user.Role {
+	case "admin":
+		if kantorCabangID != 0 {
+			filter.KantorCabangID = &kantorCabangID
+		}
+	case "bm":
+		if user.KantorCabangID == nil {
+			return nil, fmt.Errorf("%w: BM belum terdaftar di kantor cabang", ErrForbidden)
+		}
+		filter.KantorCabangID = user.KantorCabangID
+	default:
+		return nil, fmt.Errorf("%w: role %s", ErrForbidden, user.Role)
+	}
+	return u.incentiveRepo.GetAllStatements(filter)
+}
@@ -0,0 +1,428 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"ml-prediction/config"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/model"
+	"ml-prediction/internal/app/repository"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Leaderboard visibilities, see config.LeaderboardConfig. Any other value
+// anonymizes.
+const (
+	visibilityFull     = "full"
+	visibilityInitials = "initials"
+)
+
+// streakWindowMonths bounds the history read to compute the streaks shown on
+// the leaderboard.
+const streakWindowMonths = 24
+
+// Badges a marketer can earn, in display order.
+var (
+	badgeFirstClosing = dto.Badge{Code: "first_closing", Label: "Closing Pertama"}
+	badgeTargetHit    = dto.Badge{Code: "target_hit", Label: "Target Tercapai"}
+	badgeStreak3      = dto.Badge{Code: "streak_3", Label: "3 Bulan Beruntun"}
+	badgeStreak6      = dto.Badge{Code: "streak_6", Label: "6 Bulan Beruntun"}
+	badgeStreak12     = dto.Badge{Code: "streak_12", Label: "12 Bulan Beruntun"}
+	badgeClosings100  = dto.Badge{Code: "closings_100", Label: "100 Closing"}
+	badgeBranchTop    = dto.Badge{Code: "branch_top", Label: "Juara Cabang Bulan Lalu"}
+)
+
+type LeaderboardUsecase interface {
+	GetLeaderboard(ctx context.Context, NIP string, req *dto.LeaderboardRequest) (*dto.LeaderboardResponse, error)
+	GetAchievements(ctx context.Context, NIP string, marketingNIP string) (*dto.MarketingAchievementsResponse, error)
+}
+
+type leaderboardUsecase struct {
+	leaderboardRepo repository.LeaderboardRepository
+	userRepo        repository.UserRepository
+	cfg             config.LeaderboardConfig
+}
+
+func NewLeaderboardUsecase(leaderboardRepo repository.LeaderboardRepository, userRepo repository.UserRepository, cfg config.LeaderboardConfig) LeaderboardUsecase {
+	return &leaderboardUsecase{
+		leaderboardRepo: leaderboardRepo,
+		userRepo:        userRepo,
+		cfg:             cfg,
+	}
+}
+
+// GetLeaderboard ranks the marketers of the caller's branch, or of all
+// branches, for a month, quarter or year. Marketers with the same value share
+// a rank.
+func (u *leaderboardUsecase) GetLeaderboard(ctx context.Context, NIP string, req *dto.LeaderboardRequest) (*dto.LeaderboardResponse, error) {
+	user, err := u.userRepo.FindByNIP(NIP)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user data: %v", err)
+	}
+
+	scope := req.Scope
+	if scope == "" {
+		scope = dto.LeaderboardScopeBranch
+	}
+	metric := req.Metric
+	if metric == "" {
+		metric = dto.LeaderboardMetricAchievement
+	}
+
+	var kantorCabangID *uint
+	if scope == dto.LeaderboardScopeBranch {
+		switch user.Role {
+		case "admin":
+			if req.KantorCabangID == 0 {
+				return nil, fmt.Errorf("kantor_cabang_id wajib diisi untuk leaderboard cabang")
+			}
+			kantorCabangID = &req.KantorCabangID
+		case "bm", "marketing":
+			if user.KantorCabangID == nil {
+				return nil, fmt.Errorf("%w: user belum terdaftar di kantor cabang", ErrForbidden)
+			}
+			kantorCabangID = user.KantorCabangID
+		default:
+			return nil, fmt.Errorf("%w: role %s", ErrForbidden, user.Role)
+		}
+	}
+
+	period, from, to, label := leaderboardPeriod(req, time.Now())
+	standings, err := u.leaderboardRepo.GetStandings(kantorCabangID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	streaks, err := u.currentStreaks(kantorCabangID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]dto.LeaderboardEntry, len(standings))
+	values := make([]float64, len(standings))
+	for i, s := range standings {
+		entries[i] = dto.LeaderboardEntry{
+			MarketingNIP:   s.MarketingNIP,
+			MarketingName:  s.MarketingName,
+			Target:         s.Target,
+			Achieved:       s.Achieved,
+			Percentage:     percentage64(s.Achieved, s.Target),
+			Closings:       s.Closings,
+			ConversionRate: percentage(s.ClosedLeads, s.DecidedLeads),
+			CurrentStreak:  streaks[s.MarketingID],
+		}
+		if s.BranchName != nil {
+			entries[i].BranchName = *s.BranchName
+		}
+		values[i] = leaderboardValue(entries[i], metric)
+	}
+
+	order := make([]int, len(entries))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		i, j := order[a], order[b]
+		if values[i] != values[j] {
+			return values[i] > values[j]
+		}
+		if entries[i].Achieved != entries[j].Achieved {
+			return entries[i].Achieved > entries[j].Achieved
+		}
+		return entries[i].MarketingNIP < entries[j].MarketingNIP
+	})
+
+	resp := &dto.LeaderboardResponse{
+		Scope:   scope,
+		Period:  period,
+		Label:   label,
+		From:    from,
+		To:      to,
+		Metric:  metric,
+		Entries: make([]dto.LeaderboardEntry, 0, len(order)),
+	}
+	for pos, i := range order {
+		entry := entries[i]
+		entry.Rank = pos + 1
+		if pos > 0 && values[i] == values[order[pos-1]] {
+			entry.Rank = resp.Entries[pos-1].Rank
+		}
+		standing := standings[i]
+		entry.IsMe = standing.MarketingID == user.ID
+		u.mask(&entry, user, standing.KantorCabangID)
+		resp.Entries = append(resp.Entries, entry)
+		if entry.IsMe {
+			me := entry
+			resp.Me = &me
+		}
+	}
+	if kantorCabangID != nil && len(standings) > 0 && standings[0].BranchName != nil {
+		resp.BranchName = *standings[0].BranchName
+	}
+	if req.Limit > 0 && len(resp.Entries) > req.Limit {
+		resp.Entries = resp.Entries[:req.Limit]
+	}
+	return resp, nil
+}
+
+// GetAchievements returns the streaks and badges of a marketer: the caller
+// when marketingNIP is empty, otherwise a marketer of the BM's branch.
+func (u *leaderboardUsecase) GetAchievements(ctx context.Context, NIP string, marketingNIP string) (*dto.MarketingAchievementsResponse, error) {
+	user, err := u.userRepo.FindByNIP(NIP)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user data: %v", err)
+	}
+
+	marketing := user
+	if marketingNIP != "" {
+		marketing, err = u.userRepo.FindByNIP(marketingNIP)
+		if err != nil || marketing.Role != "marketing" {
+			return nil, fmt.Errorf("%w: marketing dengan NIP %s", ErrNotFound, marketingNIP)
+		}
+		if user.Role == "bm" && !sameBranch(marketing.KantorCabangID, user.KantorCabangID) {
+			return nil, fmt.Errorf("%w: marketing bukan bagian dari kantor cabang Anda", ErrForbidden)
+		}
+	}
+	if marketing.Role != "marketing" {
+		return nil, fmt.Errorf("%w: pencapaian hanya tersedia untuk marketing", ErrForbidden)
+	}
+
+	now := time.Now()
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	since := time.Date(2000, time.January, 1, 0, 0, 0, 0, now.Location())
+	results, err := u.leaderboardRepo.GetMonthlyResults(nil, &marketing.ID, since, thisMonth.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	byMonth := make(map[int]dto.MonthlyResult, len(results))
+	first, totalClosings := monthIndexOf(thisMonth), 0
+	for _, r := range results {
+		idx := r.Tahun*12 + r.Bulan - 1
+		byMonth[idx] = r
+		totalClosings += r.Closings
+		if idx < first {
+			first = idx
+		}
+	}
+	current, longest := monthStreaks(byMonth, first, monthIndexOf(thisMonth))
+
+	resp := &dto.MarketingAchievementsResponse{
+		MarketingNIP:  marketing.NIP,
+		MarketingName: marketing.Nama,
+		CurrentStreak: current,
+		LongestStreak: longest,
+		Badges:        []dto.Badge{},
+	}
+	for i := 11; i >= 0; i-- {
+		month := thisMonth.AddDate(0, -i, 0)
+		r := byMonth[monthIndexOf(month)]
+		resp.History = append(resp.History, dto.AchievementMonth{
+			Tahun:      month.Year(),
+			Bulan:      int(month.Month()),
+			Target:     r.Target,
+			Achieved:   r.Achieved,
+			Percentage: percentage64(r.Achieved, r.Target),
+			HitTarget:  hitTarget(r),
+		})
+	}
+
+	if totalClosings > 0 {
+		resp.Badges = append(resp.Badges, badgeFirstClosing)
+	}
+	if longest > 0 {
+		resp.Badges = append(resp.Badges, badgeTargetHit)
+	}
+	for _, b := range []struct {
+		months int
+		badge  dto.Badge
+	}{{3, badgeStreak3}, {6, badgeStreak6}, {12, badgeStreak12}} {
+		if longest >= b.months {
+			resp.Badges = append(resp.Badges, b.badge)
+		}
+	}
+	if totalClosings >= 100 {
+		resp.Badges = append(resp.Badges, badgeClosings100)
+	}
+	if marketing.KantorCabangID != nil {
+		top, err := u.branchTop(*marketing.KantorCabangID, thisMonth.AddDate(0, -1, 0), thisMonth)
+		if err != nil {
+			return nil, err
+		}
+		if top[marketing.ID] {
+			resp.Badges = append(resp.Badges, badgeBranchTop)
+		}
+	}
+	return resp, nil
+}
+
+// currentStreaks returns the current streak of every marketer in scope,
+// looking back at most streakWindowMonths.
+func (u *leaderboardUsecase) currentStreaks(kantorCabangID *uint) (map[uint]int, error) {
+	now := time.Now()
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	from := thisMonth.AddDate(0, -streakWindowMonths+1, 0)
+	results, err := u.leaderboardRepo.GetMonthlyResults(kantorCabangID, nil, from, thisMonth.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	byMarketing := make(map[uint]map[int]dto.MonthlyResult)
+	for _, r := range results {
+		if byMarketing[r.MarketingID] == nil {
+			byMarketing[r.MarketingID] = make(map[int]dto.MonthlyResult)
+		}
+		byMarketing[r.MarketingID][r.Tahun*12+r.Bulan-1] = r
+	}
+	streaks := make(map[uint]int, len(byMarketing))
+	for id, months := range byMarketing {
+		streaks[id], _ = monthStreaks(months, monthIndexOf(from), monthIndexOf(thisMonth))
+	}
+	return streaks, nil
+}
+
+// branchTop returns the marketers with the best achievement percentage of
+// the branch in the month starting at from.
+func (u *leaderboardUsecase) branchTop(kantorCabangID uint, from, to time.Time) (map[uint]bool, error) {
+	standings, err := u.leaderboardRepo.GetStandings(&kantorCabangID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	best := 0.0
+	for _, s := range standings {
+		if p := percentage64(s.Achieved, s.Target); p > best {
+			best = p
+		}
+	}
+	top := make(map[uint]bool)
+	if best == 0 {
+		return top, nil
+	}
+	for _, s := range standings {
+		if percentage64(s.Achieved, s.Target) == best {
+			top[s.MarketingID] = true
+		}
+	}
+	return top, nil
+}
+
+// mask hides other marketers according to the configured visibility. Admins
+// see everyone and BMs see their own branch in full.
+func (u *leaderboardUsecase) mask(entry *dto.LeaderboardEntry, viewer *model.User, kantorCabangID *uint) {
+	if entry.IsMe || viewer.Role == "admin" {
+		return
+	}
+
+	visibility := u.cfg.NationalVisibility
+	if sameBranch(kantorCabangID, viewer.KantorCabangID) {
+		if viewer.Role == "bm" {
+			return
+		}
+		visibility = u.cfg.BranchVisibility
+	}
+
+	switch visibility {
+	case visibilityFull:
+	case visibilityInitials:
+		entry.MarketingNIP = ""
+		entry.MarketingName = initials(entry.MarketingName)
+	default:
+		entry.MarketingNIP = ""
+		entry.MarketingName = fmt.Sprintf("Marketing #%d", entry.Rank)
+	}
+}
+
+// leaderboardPeriod resolves the requested period to its first day, the
+// first day after it and a label such as "2026-10", "2026-Q4" or "2026".
+func leaderboardPeriod(req *dto.LeaderboardRequest, now time.Time) (string, time.Time, time.Time, string) {
+	year := req.Tahun
+	if year == 0 {
+		year = now.Year()
+	}
+
+	switch req.Period {
+	case dto.LeaderboardPeriodQuarter:
+		quarter := req.Kuartal
+		if quarter == 0 {
+			quarter = (int(now.Month())-1)/3 + 1
+		}
+		from := time.Date(year, time.Month((quarter-1)*3+1), 1, 0, 0, 0, 0, now.Location())
+		return dto.LeaderboardPeriodQuarter, from, from.AddDate(0, 3, 0), fmt.Sprintf("%d-Q%d", year, quarter)
+	case dto.LeaderboardPeriodYear:
+		from := time.Date(year, time.January, 1, 0, 0, 0, 0, now.Location())
+		return dto.LeaderboardPeriodYear, from, from.AddDate(1, 0, 0), fmt.Sprintf("%d", year)
+	}
+
+	month := req.Bulan
+	if month == 0 {
+		month = int(now.Month())
+	}
+	from := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, now.Location())
+	return dto.LeaderboardPeriodMonth, from, from.AddDate(0, 1, 0), from.Format("2006-01")
+}
+
+func leaderboardValue(entry dto.LeaderboardEntry, metric string) float64 {
+	switch metric {
+	case dto.LeaderboardMetricAmount:
+		return float64(entry.Achieved)
+	case dto.LeaderboardMetricClosings:
+		return float64(entry.Closings)
+	case dto.LeaderboardMetricConversion:
+		return entry.ConversionRate
+	}
+	return entry.Percentage
+}
+
+// monthStreaks returns the current and the longest run of consecutive months
+// over target between the month indexes first and current. The current
+// month extends the streak once it is over target but does not break it
+// while it is still running.
+func monthStreaks(months map[int]dto.MonthlyResult, first, current int) (int, int) {
+	streak, longest, run := 0, 0, 0
+	for i := first; i <= current; i++ {
+		if hitTarget(months[i]) {
+			run++
+			if run > longest {
+				longest = run
+			}
+		} else {
+			run = 0
+		}
+	}
+
+	i := current
+	if !hitTarget(months[i]) {
+		i--
+	}
+	for ; i >= first && hitTarget(months[i]); i-- {
+		streak++
+	}
+	return streak, longest
+}
+
+func hitTarget(r dto.MonthlyResult) bool {
+	return r.Target > 0 && r.Achieved >= r.Target
+}
+
+func monthIndexOf(t time.Time) int {
+	return t.Year()*12 + int(t.Month()) - 1
+}
+
+// percentage64 is percentage for amounts.
+func percentage64(part, whole int64) float64 {
+	if whole <= 0 {
+		return 0
+	}
+	return math.Round(float64(part)/float64(whole)*10000) / 100
+}
+
+// initials shortens a name to the first letter of each word, e.g. "B. S.".
+func initials(name string) string {
+	var parts []string
+	for _, word := range strings.Fields(name) {
+		parts = append(parts, strings.ToUpper(string([]rune(word)[0]))+".")
+	}
+	return strings.Join(parts, " ")
+}
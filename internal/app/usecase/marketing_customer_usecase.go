@@ -3,39 +3,68 @@ package usecase
 import (
 	"context"
 	"fmt"
+	"ml-prediction/config"
 	dto "ml-prediction/internal/app/domain"
 	"ml-prediction/internal/app/model"
 	"ml-prediction/internal/app/repository"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
 
 type MarketingCustomerUsecase interface {
-	UpdateCustomerStatus(req *dto.UpdateCustomerStatusRequest, marketingNIP string) error
-	GetMonthlyMonitoring(req *dto.MonitoringRequest) ([]dto.MarketingMonitoringResponse, error)
+	UpdateCustomerStatus(req *dto.UpdateCustomerStatusRequest, NIP string) error
+	GetMonthlyMonitoring(NIP string, req *dto.MonitoringRequest) ([]dto.MarketingMonitoringResponse, error)
 	GetMonthlyMonitoringMarketing(req *dto.MonitoringRequest) (*dto.MarketingMonitoringResponse, error)
-	GetProductPerformance(ctx context.Context, req *dto.ProductPerformanceRequest) (*dto.ProductPerformanceResponse, error)
+	GetProductPerformance(ctx context.Context, NIP string, req *dto.ProductPerformanceRequest) (*dto.ProductPerformanceResponse, error)
+	GetLeadHistory(ctx context.Context, NIP string, cif string) (*dto.LeadHistoryResponse, error)
+	GetLeadStates(ctx context.Context, NIP string) (*dto.LeadStatesResponse, error)
 }
 
 type marketingCustomerUsecase struct {
 	marketingCustomerRepo repository.MarketingCustomerRepository
 	userRepo              repository.UserRepository
+	activityRepo          repository.ActivityRepository
+	rejectionRepo         repository.RejectionRepository
+	closingRepo           repository.ClosingRepository
+	consentRepo           repository.ConsentRepository
+	consentCfg            config.ConsentConfig
+	leadCfg               config.LeadConfig
+	leadStates            *dto.LeadStateMachine
 	db                    *gorm.DB
 }
 
 func NewMarketingCustomerUsecase(
 	mcRepo repository.MarketingCustomerRepository,
 	userRepo repository.UserRepository,
+	activityRepo repository.ActivityRepository,
+	rejectionRepo repository.RejectionRepository,
+	closingRepo repository.ClosingRepository,
+	consentRepo repository.ConsentRepository,
+	consentCfg config.ConsentConfig,
+	leadCfg config.LeadConfig,
+	leadStates *dto.LeadStateMachine,
 	db *gorm.DB,
 ) MarketingCustomerUsecase {
 	return &marketingCustomerUsecase{
 		marketingCustomerRepo: mcRepo,
 		userRepo:              userRepo,
+		activityRepo:          activityRepo,
+		rejectionRepo:         rejectionRepo,
+		closingRepo:           closingRepo,
+		consentRepo:           consentRepo,
+		consentCfg:            consentCfg,
+		leadCfg:               leadCfg,
+		leadStates:            leadStates,
 		db:                    db,
 	}
 }
 
-func (u *marketingCustomerUsecase) UpdateCustomerStatus(req *dto.UpdateCustomerStatusRequest, marketingNIP string) error {
+// UpdateCustomerStatus moves a lead through the state machine. Marketers act
+// on their own leads and claim unassigned customers on first contact; BMs act
+// on leads held by marketers of their branch, e.g. to reopen rejected leads.
+func (u *marketingCustomerUsecase) UpdateCustomerStatus(req *dto.UpdateCustomerStatusRequest, NIP string) error {
 	tx := u.db.Begin()
 	if tx.Error != nil {
 		return fmt.Errorf("gagal memulai transaksi: %v", tx.Error)
@@ -46,11 +75,11 @@ func (u *marketingCustomerUsecase) UpdateCustomerStatus(req *dto.UpdateCustomerS
 		}
 	}()
 
-	// Get marketing user
-	marketing, err := u.userRepo.FindByNIPWithTx(tx, marketingNIP)
+	// Get acting user
+	user, err := u.userRepo.FindByNIPWithTx(tx, NIP)
 	if err != nil {
 		tx.Rollback()
-		return fmt.Errorf("marketing tidak ditemukan: %v", err)
+		return fmt.Errorf("user tidak ditemukan: %v", err)
 	}
 
 	// Find customer
@@ -60,49 +89,149 @@ func (u *marketingCustomerUsecase) UpdateCustomerStatus(req *dto.UpdateCustomerS
 		return fmt.Errorf("customer dengan CIF %s tidak ditemukan: %v", req.CIF, err)
 	}
 
-	// Try to find existing assignment
-	mc, err := u.marketingCustomerRepo.FindByCifAndMarketingNIP(tx, customer.CIF, marketing.NIP)
-	if err != nil && err != gorm.ErrRecordNotFound {
+	var mc *model.MarketingCustomer
+	switch user.Role {
+	case "marketing":
+		mc, err = u.findOrClaimLead(tx, customer, user)
+	case "bm":
+		mc, err = u.findBranchLead(tx, customer, user)
+	default:
+		err = fmt.Errorf("%w: role %s tidak dapat mengubah status lead", ErrForbidden, user.Role)
+	}
+	if err != nil {
 		tx.Rollback()
-		return fmt.Errorf("gagal mencari assignment: %v", err)
+		return err
 	}
 
-	// If no assignment exists, check if customer can be assigned
-	if err == gorm.ErrRecordNotFound {
-		mc, err = u.marketingCustomerRepo.CheckAndCreateAssignment(tx, customer.Id, marketing.ID)
-		if err != nil {
-			tx.Rollback()
-			return fmt.Errorf("gagal membuat assignment: %v", err)
-		}
+	// Validate the transition against the lead state machine
+	statusChangedAt := mc.CreatedAt
+	if mc.StatusChangedAt != nil {
+		statusChangedAt = *mc.StatusChangedAt
 	}
-	// Check if status is final
-	if mc.Status == string(model.CustomerStatusClosed) ||
-		mc.Status == string(model.CustomerStatusRejected) {
+	now := time.Now()
+	if transitionErr := u.leadStates.Validate(dto.LeadTransitionInput{
+		From: mc.Status,
+		To:   req.Status,
+		Role: user.Role,
+		Provided: map[string]bool{
+			dto.LeadFieldProductID:       req.ProductID != nil,
+			dto.LeadFieldAmount:          req.Amount != nil,
+			dto.LeadFieldNotes:           req.Notes != nil && strings.TrimSpace(*req.Notes) != "",
+			dto.LeadFieldChannel:         req.Channel != "",
+			dto.LeadFieldRejectionReason: req.RejectionReason != "",
+		},
+		StatusChangedAt: statusChangedAt,
+		Now:             now,
+	}); transitionErr != nil {
 		tx.Rollback()
-		return fmt.Errorf("tidak dapat mengubah status yang sudah final")
+		return transitionErr
 	}
 
-	// Validate status requirements
-	switch req.Status {
-	case string(model.CustomerStatusClosed):
-		if req.ProductID == nil || req.Amount == nil {
+	// Contact attempts must go through a channel the customer agreed to
+	if req.Channel != "" {
+		if err := checkContactChannel(u.consentRepo, u.consentCfg, customer.Id, req.Channel); err != nil {
 			tx.Rollback()
-			return fmt.Errorf("product dan amount harus diisi untuk status closed")
+			return err
 		}
 	}
 
+	var rejectionReasonID *uint
+	if req.RejectionReason != "" {
+		reason, err := u.rejectionRepo.FindActiveByCode(req.RejectionReason)
+		if err != nil {
+			tx.Rollback()
+			if err == gorm.ErrRecordNotFound {
+				return fmt.Errorf("alasan penolakan %s tidak dikenal atau tidak aktif", req.RejectionReason)
+			}
+			return fmt.Errorf("gagal mengambil alasan penolakan: %v", err)
+		}
+		rejectionReasonID = &reason.ID
+	}
+
 	// Update status and related fields
+	oldStatus := mc.Status
 	mc.Status = req.Status
+	mc.StatusChangedAt = &now
 	if req.Status == string(model.CustomerStatusClosed) {
 		mc.ProductID = req.ProductID
 		mc.Amount = req.Amount
 	}
+	// The reason only describes the state it was given for; a reopened lead
+	// drops it.
+	mc.RejectionReasonID = rejectionReasonID
+	if rejectionReasonID != nil && req.ProductID != nil {
+		mc.ProductID = req.ProductID
+	}
+	if req.Notes != nil {
+		mc.Notes = *req.Notes
+	}
+	// A finished lead needs no further follow-up and is no longer reserved;
+	// progress on an open lead renews the reservation.
+	if u.leadStates.IsFinal(mc.Status) {
+		mc.NextFollowUpAt = nil
+		mc.FollowUpReason = ""
+		mc.FollowUpOverdueAt = nil
+		mc.ReservedUntil = nil
+	} else {
+		mc.ReservedUntil = reservationExpiry(u.leadCfg, now)
+	}
 
 	if err := u.marketingCustomerRepo.UpdateStatusWithTx(tx, mc); err != nil {
 		tx.Rollback()
 		return fmt.Errorf("gagal mengupdate status: %v", err)
 	}
 
+	event := &model.MarketingCustomerEvent{
+		MarketingCustomerID: mc.ID,
+		EventType:           model.LeadEventStatusChanged,
+		OldStatus:           &oldStatus,
+		NewStatus:           mc.Status,
+		ActorID:             &user.ID,
+	}
+	if req.Notes != nil {
+		event.Note = *req.Notes
+	}
+	if req.Status == string(model.CustomerStatusClosed) {
+		event.ProductID = req.ProductID
+		event.Amount = req.Amount
+	}
+	if req.Channel != "" {
+		event.Channel = &req.Channel
+	}
+	if rejectionReasonID != nil {
+		event.RejectionReasonID = rejectionReasonID
+		event.ProductID = req.ProductID
+	}
+	if err := u.marketingCustomerRepo.CreateEventWithTx(tx, event); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Closing the lead books the product it closed on, pending verification by
+	// the BM; further products are added as separate closings.
+	if req.Status == string(model.CustomerStatusClosed) && req.ProductID != nil && req.Amount != nil {
+		closedAt, err := closingDate(req.ClosedAt, now)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := checkClosingPeriod(tx, u.closingRepo, u.leadCfg, closedAt, now); err != nil {
+			tx.Rollback()
+			return err
+		}
+		evidence, err := closingEvidence(u.leadCfg, req.EvidenceReference)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		closing := newClosing(mc, *req.ProductID, *req.Amount, closedAt, user.ID)
+		closing.EvidenceReference = evidence
+		if err := u.closingRepo.CreateWithTx(tx, closing); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
 	if err := tx.Commit().Error; err != nil {
 		tx.Rollback()
 		return fmt.Errorf("gagal menyimpan target: %v", err)
@@ -110,10 +239,101 @@ func (u *marketingCustomerUsecase) UpdateCustomerStatus(req *dto.UpdateCustomerS
 	return nil
 }
 
-func (u *marketingCustomerUsecase) GetMonthlyMonitoring(req *dto.MonitoringRequest) ([]dto.MarketingMonitoringResponse, error) {
+func (u *marketingCustomerUsecase) findOrClaimLead(tx *gorm.DB, customer *model.Customer, marketing *model.User) (*model.MarketingCustomer, error) {
+	// Try to find existing assignment
+	mc, err := u.marketingCustomerRepo.FindByCifAndMarketingNIP(tx, customer.CIF, marketing.NIP)
+	if err == nil {
+		return mc, nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("gagal mencari assignment: %v", err)
+	}
 
-	result, err := u.marketingCustomerRepo.GetMonthlyMonitoring(req.Month, req.Year)
-	return result, err
+	// If no assignment exists, check if customer can be assigned. Marketers
+	// only claim from their own branch's pool.
+	if !sameBranch(customer.KantorCabangID, marketing.KantorCabangID) {
+		return nil, fmt.Errorf("%w: customer bukan bagian dari kantor cabang Anda", ErrForbidden)
+	}
+	mc, err = u.marketingCustomerRepo.CheckAndCreateAssignment(tx, customer.Id, marketing.ID, &marketing.ID, reservationExpiry(u.leadCfg, time.Now()))
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuat assignment: %w", err)
+	}
+	return mc, nil
+}
+
+func (u *marketingCustomerUsecase) findBranchLead(tx *gorm.DB, customer *model.Customer, bm *model.User) (*model.MarketingCustomer, error) {
+	mc, err := u.marketingCustomerRepo.FindActiveByCustomerIDWithTx(tx, customer.Id)
+	if err == gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("%w: customer %s belum memiliki assignment", ErrNotFound, customer.CIF)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal mencari assignment: %v", err)
+	}
+	if !sameBranch(mc.Marketing.KantorCabangID, bm.KantorCabangID) {
+		return nil, fmt.Errorf("%w: lead ini dipegang marketing di luar kantor cabang Anda", ErrForbidden)
+	}
+	return mc, nil
+}
+
+// GetLeadStates returns the configured lead lifecycle and, for the caller's
+// role, the transitions available from each state.
+func (u *marketingCustomerUsecase) GetLeadStates(ctx context.Context, NIP string) (*dto.LeadStatesResponse, error) {
+	user, err := u.userRepo.FindByNIP(NIP)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user data: %v", err)
+	}
+
+	next := make(map[string][]string, len(u.leadStates.States))
+	for _, state := range u.leadStates.States {
+		next[state.Name] = u.leadStates.AllowedNext(state.Name, user.Role)
+	}
+	return &dto.LeadStatesResponse{
+		States:         u.leadStates.States,
+		Transitions:    u.leadStates.Transitions,
+		AllowedNext:    next,
+		ReopenCooldown: u.leadStates.ReopenCooldown.String(),
+	}, nil
+}
+
+// GetMonthlyMonitoring returns the month's achievement of the marketers a BM
+// manages, i.e. those of the BM's branch.
+func (u *marketingCustomerUsecase) GetMonthlyMonitoring(NIP string, req *dto.MonitoringRequest) ([]dto.MarketingMonitoringResponse, error) {
+	user, err := u.userRepo.FindByNIP(NIP)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user data: %v", err)
+	}
+	kantorCabangID, err := monitoringBranch(user)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := u.marketingCustomerRepo.GetMonthlyMonitoring(req.Month, req.Year, kantorCabangID)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.attachActivities(result, req.Month, req.Year); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// attachActivities adds the month's activity counts so effort can be compared
+// with closings.
+func (u *marketingCustomerUsecase) attachActivities(results []dto.MarketingMonitoringResponse, month, year int) error {
+	nips := make([]string, len(results))
+	for i, result := range results {
+		nips[i] = result.MarketingNIP
+	}
+
+	from := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.Local)
+	summaries, err := u.activityRepo.GetSummaries(nips, from, from.AddDate(0, 1, 0))
+	if err != nil {
+		return fmt.Errorf("error fetching activity data: %v", err)
+	}
+	for i := range results {
+		results[i].Activities = summaries[results[i].MarketingNIP]
+	}
+	return nil
 }
 
 func (u *marketingCustomerUsecase) GetMonthlyMonitoringMarketing(req *dto.MonitoringRequest) (*dto.MarketingMonitoringResponse, error) {
@@ -122,20 +342,57 @@ func (u *marketingCustomerUsecase) GetMonthlyMonitoringMarketing(req *dto.Monito
 		return nil, fmt.Errorf("user tidak ditemukan atau bukan marketing: %v", err)
 	}
 
-	results, err := u.marketingCustomerRepo.GetMonthlyMonitoring(req.Month, req.Year)
+	results, err := u.marketingCustomerRepo.GetMonthlyMonitoring(req.Month, req.Year, nil)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching monitoring data: %v", err)
 	}
 
 	for _, result := range results {
 		if result.MarketingNIP == req.NIP {
-			return &result, nil
+			monitoring := []dto.MarketingMonitoringResponse{result}
+			if err := u.attachActivities(monitoring, req.Month, req.Year); err != nil {
+				return nil, err
+			}
+			return &monitoring[0], nil
 		}
 	}
 
 	return nil, fmt.Errorf("tidak ada data monitoring untuk marketing dengan NIP %s", req.NIP)
 }
 
-func (s *marketingCustomerUsecase) GetProductPerformance(ctx context.Context, req *dto.ProductPerformanceRequest) (*dto.ProductPerformanceResponse, error) {
+// GetProductPerformance returns a product's achievement over time for the
+// marketers of the BM's branch.
+func (s *marketingCustomerUsecase) GetProductPerformance(ctx context.Context, NIP string, req *dto.ProductPerformanceRequest) (*dto.ProductPerformanceResponse, error) {
+	user, err := s.userRepo.FindByNIP(NIP)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user data: %v", err)
+	}
+	if req.KantorCabangID, err = monitoringBranch(user); err != nil {
+		return nil, err
+	}
 	return s.marketingCustomerRepo.GetProductPerformance(s.db, req)
 }
+
+// GetLeadHistory returns the status history of a customer's leads. Marketers
+// see only their own assignments and BMs those of marketers in their branch.
+func (u *marketingCustomerUsecase) GetLeadHistory(ctx context.Context, NIP string, cif string) (*dto.LeadHistoryResponse, error) {
+	user, err := u.userRepo.FindByNIP(NIP)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user data: %v", err)
+	}
+
+	scope, err := leadScope(user)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := u.marketingCustomerRepo.GetLeadEvents(cif, scope)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("%w: riwayat lead untuk CIF %s", ErrNotFound, cif)
+	}
+
+	return &dto.LeadHistoryResponse{CIF: cif, Events: events}, nil
+}
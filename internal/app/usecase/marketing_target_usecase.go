@@ -11,7 +11,8 @@ import (
 
 type MarketingTargetUsecase interface {
 	AssignBulkMarketingTarget(req *dto.AssignMarketingTargetRequest, userNIP string) error
-	GetMarketingTargets(req *dto.MonitoringRequest) ([]dto.MarketingTargetDetail, error)
+	GetMarketingTargets(NIP string, req *dto.MonitoringRequest) ([]dto.MarketingTargetDetail, error)
+	GetMarketingTargetsCursor(NIP string, req *dto.MonitoringRequest) ([]dto.MarketingTargetDetail, *dto.CursorPagination, error)
 }
 
 type marketingTargetUsecase struct {
@@ -140,8 +141,27 @@ func (u *marketingTargetUsecase) AssignBulkMarketingTarget(req *dto.AssignMarket
 	return nil
 }
 
-func (u *marketingTargetUsecase) GetMarketingTargets(req *dto.MonitoringRequest) ([]dto.MarketingTargetDetail, error) {
-
+// GetMarketingTargets lists the targets of the marketers of the BM's branch.
+func (u *marketingTargetUsecase) GetMarketingTargets(NIP string, req *dto.MonitoringRequest) ([]dto.MarketingTargetDetail, error) {
+	if err := u.scopeMonitoring(NIP, req); err != nil {
+		return nil, err
+	}
 	result, err := u.targetRepo.GetMarketingTargets(req)
 	return result, err
 }
+
+func (u *marketingTargetUsecase) GetMarketingTargetsCursor(NIP string, req *dto.MonitoringRequest) ([]dto.MarketingTargetDetail, *dto.CursorPagination, error) {
+	if err := u.scopeMonitoring(NIP, req); err != nil {
+		return nil, nil, err
+	}
+	return u.targetRepo.GetMarketingTargetsCursor(req)
+}
+
+func (u *marketingTargetUsecase) scopeMonitoring(NIP string, req *dto.MonitoringRequest) error {
+	user, err := u.userRepo.FindByNIP(NIP)
+	if err != nil {
+		return fmt.Errorf("error getting user data: %v", err)
+	}
+	req.KantorCabangID, err = monitoringBranch(user)
+	return err
+}
@@ -0,0 +1,95 @@
+package usecase
+
+import (
+	"errors"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/model"
+	"ml-prediction/internal/app/repository"
+)
+
+// Reasons recorded in pii_access_logs for an unmasked view.
+const (
+	piiReasonAdmin    = "admin"
+	piiReasonAssigned = "assigned"
+	piiReasonBranch   = "branch"
+)
+
+// piiPolicy decides per customer whether the viewer may see contact data in
+// full. Admins see everything, BMs see customers handled by marketers of their
+// branch, or unassigned customers of their branch, and marketers see the
+// customers assigned to them. Everything else is masked, and every unmasked
+// view is written to the access log.
+type piiPolicy struct {
+	repo repository.PIIAccessRepository
+}
+
+func newPIIPolicy(repo repository.PIIAccessRepository) *piiPolicy {
+	return &piiPolicy{repo: repo}
+}
+
+func (p *piiPolicy) apply(viewer *model.User, endpoint string, customers []dto.Customer) error {
+	if len(customers) == 0 {
+		return nil
+	}
+
+	ids := make([]uint64, 0, len(customers))
+	for _, customer := range customers {
+		ids = append(ids, customer.Id)
+	}
+	owners, err := p.repo.FindOwners(ids)
+	if err != nil {
+		maskAll(customers)
+		return err
+	}
+
+	var entries []model.PIIAccessLog
+	for i := range customers {
+		owner, assigned := owners[customers[i].Id]
+		if !assigned {
+			owner = dto.CustomerOwner{CustomerID: customers[i].Id, KantorCabangID: customers[i].KantorCabangID}
+		}
+		reason := unmaskReason(viewer, owner, assigned)
+		if reason == "" {
+			customers[i].MaskPII()
+			continue
+		}
+		entries = append(entries, model.PIIAccessLog{
+			ViewerID:   viewer.ID,
+			ViewerRole: viewer.Role,
+			CustomerID: customers[i].Id,
+			Endpoint:   endpoint,
+			Reason:     reason,
+		})
+	}
+
+	// Data is only released once the access has been recorded.
+	if err := p.repo.LogAccess(entries); err != nil {
+		maskAll(customers)
+		return errors.New("gagal mencatat akses data pribadi nasabah")
+	}
+	return nil
+}
+
+// unmaskReason expects owner to carry the customer's own branch when the
+// customer is not assigned, as customerInScope does.
+func unmaskReason(viewer *model.User, owner dto.CustomerOwner, assigned bool) string {
+	switch viewer.Role {
+	case "admin":
+		return piiReasonAdmin
+	case "marketing":
+		if assigned && owner.MarketingID == viewer.ID {
+			return piiReasonAssigned
+		}
+	case "bm":
+		if sameBranch(owner.KantorCabangID, viewer.KantorCabangID) {
+			return piiReasonBranch
+		}
+	}
+	return ""
+}
+
+func maskAll(customers []dto.Customer) {
+	for i := range customers {
+		customers[i].MaskPII()
+	}
+}
@@ -0,0 +1,37 @@
+package usecase
+
+import (
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/model"
+	"testing"
+)
+
+func TestUnmaskReason(t *testing.T) {
+	bm := &model.User{ID: 20, Role: "bm", KantorCabangID: uintPtr(1)}
+	marketer := &model.User{ID: 10, Role: "marketing", KantorCabangID: uintPtr(1)}
+
+	tests := []struct {
+		name     string
+		viewer   *model.User
+		owner    dto.CustomerOwner
+		assigned bool
+		want     string
+	}{
+		{"admin", &model.User{ID: 1, Role: "admin"}, dto.CustomerOwner{}, false, piiReasonAdmin},
+		{"bm, assigned in branch", bm, dto.CustomerOwner{MarketingID: 10, KantorCabangID: uintPtr(1)}, true, piiReasonBranch},
+		{"bm, assigned elsewhere", bm, dto.CustomerOwner{MarketingID: 12, KantorCabangID: uintPtr(2)}, true, ""},
+		{"bm, unassigned in branch", bm, dto.CustomerOwner{KantorCabangID: uintPtr(1)}, false, piiReasonBranch},
+		{"bm, unassigned elsewhere", bm, dto.CustomerOwner{KantorCabangID: uintPtr(2)}, false, ""},
+		{"bm, unassigned without branch", bm, dto.CustomerOwner{}, false, ""},
+		{"marketer, own lead", marketer, dto.CustomerOwner{MarketingID: 10, KantorCabangID: uintPtr(1)}, true, piiReasonAssigned},
+		{"marketer, colleague's lead", marketer, dto.CustomerOwner{MarketingID: 11, KantorCabangID: uintPtr(1)}, true, ""},
+		{"marketer, unassigned in branch", marketer, dto.CustomerOwner{KantorCabangID: uintPtr(1)}, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unmaskReason(tt.viewer, tt.owner, tt.assigned); got != tt.want {
+				t.Errorf("unmaskReason = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/model"
+	"ml-prediction/internal/app/repository"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type RejectionUsecase interface {
+	GetReasons(ctx context.Context, activeOnly bool) ([]model.RejectionReason, error)
+	CreateReason(ctx context.Context, req *dto.CreateRejectionReasonRequest) (*model.RejectionReason, error)
+	UpdateReason(ctx context.Context, id uint, req *dto.UpdateRejectionReasonRequest) (*model.RejectionReason, error)
+	GetReport(ctx context.Context, NIP string, req *dto.RejectionReportRequest) (*dto.RejectionReportResponse, error)
+}
+
+type rejectionUsecase struct {
+	rejectionRepo repository.RejectionRepository
+	userRepo      repository.UserRepository
+}
+
+func NewRejectionUsecase(rejectionRepo repository.RejectionRepository, userRepo repository.UserRepository) RejectionUsecase {
+	return &rejectionUsecase{
+		rejectionRepo: rejectionRepo,
+		userRepo:      userRepo,
+	}
+}
+
+func (u *rejectionUsecase) GetReasons(ctx context.Context, activeOnly bool) ([]model.RejectionReason, error) {
+	return u.rejectionRepo.FindAll(activeOnly)
+}
+
+func (u *rejectionUsecase) CreateReason(ctx context.Context, req *dto.CreateRejectionReasonRequest) (*model.RejectionReason, error) {
+	reason := &model.RejectionReason{
+		Code:      req.Code,
+		Label:     req.Label,
+		Active:    true,
+		SortOrder: req.SortOrder,
+	}
+	if err := u.rejectionRepo.Create(reason); err != nil {
+		return nil, fmt.Errorf("%w: kode alasan %s sudah digunakan", ErrConflict, req.Code)
+	}
+	return reason, nil
+}
+
+// UpdateReason relabels, reorders or (de)activates a reason. The code stays
+// fixed because reports and clients key on it.
+func (u *rejectionUsecase) UpdateReason(ctx context.Context, id uint, req *dto.UpdateRejectionReasonRequest) (*model.RejectionReason, error) {
+	reason, err := u.rejectionRepo.FindByID(id)
+	if err == gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("%w: alasan penolakan %d", ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil alasan penolakan: %v", err)
+	}
+
+	if req.Label != nil {
+		reason.Label = *req.Label
+	}
+	if req.Active != nil {
+		reason.Active = *req.Active
+	}
+	if req.SortOrder != nil {
+		reason.SortOrder = *req.SortOrder
+	}
+	if err := u.rejectionRepo.Update(reason); err != nil {
+		return nil, fmt.Errorf("gagal menyimpan alasan penolakan: %v", err)
+	}
+	return reason, nil
+}
+
+// GetReport breaks down the rejections of a period. BMs see the marketers of
+// their branch; admins see everyone unless they pick a branch.
+func (u *rejectionUsecase) GetReport(ctx context.Context, NIP string, req *dto.RejectionReportRequest) (*dto.RejectionReportResponse, error) {
+	user, err := u.userRepo.FindByNIP(NIP)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user data: %v", err)
+	}
+
+	var kantorCabangID *uint
+	switch user.Role {
+	case "admin":
+		if req.KantorCabangID != 0 {
+			kantorCabangID = &req.KantorCabangID
+		}
+	case "bm":
+		if user.KantorCabangID == nil {
+			return nil, fmt.Errorf("%w: BM tidak terdaftar pada kantor cabang", ErrForbidden)
+		}
+		kantorCabangID = user.KantorCabangID
+	default:
+		return nil, fmt.Errorf("%w: role %s tidak dapat melihat laporan penolakan", ErrForbidden, user.Role)
+	}
+
+	from, to, err := reportPeriod(req.From, req.To)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := u.rejectionRepo.GetCounts(from, to, kantorCabangID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &dto.RejectionReportResponse{From: from, To: to}
+	byReason := newRejectionBreakdowns()
+	byProduct := newRejectionBreakdowns()
+	bySegment := newRejectionBreakdowns()
+	byMarketing := newRejectionBreakdowns()
+	byRank := newRejectionBreakdowns()
+	for _, count := range counts {
+		report.Total += count.Total
+		byReason.add(count.ReasonCode, count.ReasonLabel, "", count.Total)
+
+		productKey, productLabel := "-", "Tanpa rekomendasi"
+		if count.ProductID != nil {
+			productKey = strconv.FormatUint(uint64(*count.ProductID), 10)
+			productLabel = *count.ProductName
+		}
+		byProduct.add(productKey, productLabel, count.ReasonCode, count.Total)
+		bySegment.add(count.Segment, count.Segment, count.ReasonCode, count.Total)
+		byMarketing.add(count.MarketingNIP, count.MarketingName, count.ReasonCode, count.Total)
+
+		rankKey, rankLabel := "-", "Di luar rekomendasi"
+		if count.PredictedRank != nil {
+			rankKey = strconv.Itoa(*count.PredictedRank)
+			rankLabel = fmt.Sprintf("Rekomendasi #%d", *count.PredictedRank)
+		}
+		byRank.add(rankKey, rankLabel, count.ReasonCode, count.Total)
+	}
+	report.ByReason = byReason.sorted()
+	report.ByProduct = byProduct.sorted()
+	report.BySegment = bySegment.sorted()
+	report.ByMarketing = byMarketing.sorted()
+	report.ByPredictedRank = byRank.sorted()
+	return report, nil
+}
+
+// reportPeriod parses an inclusive from/to date range. It defaults to the
+// current month.
+func reportPeriod(fromDate, toDate string) (time.Time, time.Time, error) {
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	to := from.AddDate(0, 1, 0)
+
+	if fromDate != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", fromDate, now.Location())
+		if err != nil {
+			return from, to, fmt.Errorf("format tanggal from tidak valid")
+		}
+		from = parsed
+	}
+	if toDate != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", toDate, now.Location())
+		if err != nil {
+			return from, to, fmt.Errorf("format tanggal to tidak valid")
+		}
+		to = parsed.AddDate(0, 0, 1)
+	}
+	if !from.Before(to) {
+		return from, to, fmt.Errorf("tanggal from harus sebelum tanggal to")
+	}
+	return from, to, nil
+}
+
+type rejectionBreakdowns map[string]*dto.RejectionBreakdown
+
+func newRejectionBreakdowns() rejectionBreakdowns {
+	return make(rejectionBreakdowns)
+}
+
+func (b rejectionBreakdowns) add(key, label, reason string, total int) {
+	row, ok := b[key]
+	if !ok {
+		row = &dto.RejectionBreakdown{Key: key, Label: label}
+		if reason != "" {
+			row.Reasons = make(map[string]int)
+		}
+		b[key] = row
+	}
+	row.Total += total
+	if reason != "" {
+		row.Reasons[reason] += total
+	}
+}
+
+// sorted returns the rows with the most rejections first.
+func (b rejectionBreakdowns) sorted() []dto.RejectionBreakdown {
+	rows := make([]dto.RejectionBreakdown, 0, len(b))
+	for _, row := range b {
+		rows = append(rows, *row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Total != rows[j].Total {
+			return rows[i].Total > rows[j].Total
+		}
+		return rows[i].Key < rows[j].Key
+	})
+	return rows
+}
@@ -0,0 +1,84 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ml-prediction/config"
+	"ml-prediction/internal/app/repository"
+	"ml-prediction/pkg/scheduler"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	reservationLockKey   = "lead-reservations"
+	reservationBatchSize = 200
+)
+
+type ReservationUsecase interface {
+	RunScheduled(ctx context.Context) error
+}
+
+type reservationUsecase struct {
+	marketingCustomerRepo repository.MarketingCustomerRepository
+	cfg                   config.LeadConfig
+	db                    *gorm.DB
+}
+
+func NewReservationUsecase(mcRepo repository.MarketingCustomerRepository, cfg config.LeadConfig, db *gorm.DB) ReservationUsecase {
+	return &reservationUsecase{
+		marketingCustomerRepo: mcRepo,
+		cfg:                   cfg,
+		db:                    db,
+	}
+}
+
+// reservationExpiry returns when a lead reserved or worked now expires.
+func reservationExpiry(cfg config.LeadConfig, now time.Time) *time.Time {
+	until := now.Add(cfg.ReservationTTL)
+	return &until
+}
+
+// RunScheduled returns leads whose reservation expired to the unassigned
+// pool. Each release is its own transaction and is skipped when the marketer
+// worked the lead after it was picked up.
+func (u *reservationUsecase) RunScheduled(ctx context.Context) error {
+	err := scheduler.WithAdvisoryLock(ctx, u.db, reservationLockKey, func() error {
+		now := time.Now()
+		note := fmt.Sprintf("Dilepas otomatis: tidak ada aktivitas atau perubahan status selama %s", u.cfg.ReservationTTL)
+		for {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			leads, err := u.marketingCustomerRepo.FindExpiredReservations(now, reservationBatchSize)
+			if err != nil {
+				return err
+			}
+			released := 0
+			for i := range leads {
+				ok := false
+				err := u.db.Transaction(func(tx *gorm.DB) error {
+					var err error
+					ok, err = u.marketingCustomerRepo.ReleaseWithTx(tx, &leads[i], now, note)
+					return err
+				})
+				if err != nil {
+					return fmt.Errorf("gagal melepas lead %d: %v", leads[i].ID, err)
+				}
+				if ok {
+					released++
+				}
+			}
+			if len(leads) < reservationBatchSize || released == 0 {
+				return nil
+			}
+		}
+	})
+	if errors.Is(err, scheduler.ErrLocked) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,246 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"ml-prediction/config"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/model"
+	"ml-prediction/internal/app/repository"
+	"ml-prediction/pkg/scheduler"
+	"ml-prediction/pkg/storage"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const retentionLockKey = "retention"
+
+type RetentionUsecase interface {
+	Run(ctx context.Context, NIP string, dryRun bool) (*model.RetentionRun, error)
+	RunScheduled(ctx context.Context) error
+	GetRuns(ctx context.Context, req *dto.RetentionRunListRequest) ([]model.RetentionRun, *dto.Pagination, error)
+	GetRun(ctx context.Context, id uint64) (*model.RetentionRun, error)
+}
+
+type retentionUsecase struct {
+	retentionRepo repository.RetentionRepository
+	userRepo      repository.UserRepository
+	storage       storage.Storage
+	cfg           config.RetentionConfig
+	db            *gorm.DB
+}
+
+func NewRetentionUsecase(retentionRepo repository.RetentionRepository, userRepo repository.UserRepository, store storage.Storage, cfg config.RetentionConfig, db *gorm.DB) RetentionUsecase {
+	return &retentionUsecase{
+		retentionRepo: retentionRepo,
+		userRepo:      userRepo,
+		storage:       store,
+		cfg:           cfg,
+		db:            db,
+	}
+}
+
+// retentionStep is one policy: how to find the affected customers and what
+// to do with them.
+type retentionStep struct {
+	action  string
+	reason  string
+	find    func(cutoff time.Time, afterID uint64, limit int) ([]uint64, error)
+	cutoff  *time.Time
+	counter *int
+}
+
+// Run executes the retention policies once for an admin. A dry run writes the
+// report without touching any customer.
+func (u *retentionUsecase) Run(ctx context.Context, NIP string, dryRun bool) (*model.RetentionRun, error) {
+	admin, err := u.userRepo.FindByNIP(NIP)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user data: %v", err)
+	}
+
+	run, err := u.execute(ctx, model.RetentionTriggerManual, &admin.ID, dryRun)
+	if errors.Is(err, scheduler.ErrLocked) {
+		return nil, fmt.Errorf("%w: %v", ErrConflict, err)
+	}
+	return run, err
+}
+
+func (u *retentionUsecase) RunScheduled(ctx context.Context) error {
+	_, err := u.execute(ctx, model.RetentionTriggerScheduled, nil, false)
+	if errors.Is(err, scheduler.ErrLocked) {
+		return nil
+	}
+	return err
+}
+
+func (u *retentionUsecase) GetRuns(ctx context.Context, req *dto.RetentionRunListRequest) ([]model.RetentionRun, *dto.Pagination, error) {
+	return u.retentionRepo.GetRuns(req)
+}
+
+func (u *retentionUsecase) GetRun(ctx context.Context, id uint64) (*model.RetentionRun, error) {
+	run, err := u.retentionRepo.GetRun(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("%w: laporan retensi %d", ErrNotFound, id)
+		}
+		return nil, fmt.Errorf("gagal mengambil laporan retensi: %v", err)
+	}
+	return run, nil
+}
+
+func (u *retentionUsecase) execute(ctx context.Context, trigger string, triggeredBy *uint, dryRun bool) (*model.RetentionRun, error) {
+	var run *model.RetentionRun
+	err := scheduler.WithAdvisoryLock(ctx, u.db, retentionLockKey, func() error {
+		policy := u.policy(time.Now())
+		policyJSON, err := json.Marshal(policy)
+		if err != nil {
+			return fmt.Errorf("gagal menyimpan kebijakan retensi: %v", err)
+		}
+
+		run = &model.RetentionRun{
+			Trigger:     trigger,
+			TriggeredBy: triggeredBy,
+			DryRun:      dryRun,
+			Status:      model.RetentionStatusRunning,
+			Policy:      string(policyJSON),
+			StartedAt:   time.Now(),
+		}
+		if err := u.retentionRepo.CreateRun(run); err != nil {
+			return fmt.Errorf("gagal membuat laporan retensi: %v", err)
+		}
+
+		// Purging comes first so customers that are about to disappear are not
+		// anonymised needlessly.
+		steps := []retentionStep{
+			{model.RetentionActionPurge, model.RetentionReasonDeleted, u.retentionRepo.FindDeletedWithoutHistory, policy.DeletedCutoff, &run.Purged},
+			{model.RetentionActionAnonymize, model.RetentionReasonDeleted, u.retentionRepo.FindDeletedWithHistory, policy.DeletedCutoff, &run.AnonymizedDeleted},
+			{model.RetentionActionAnonymize, model.RetentionReasonRejected, u.retentionRepo.FindRejectedLeads, policy.RejectedCutoff, &run.AnonymizedRejected},
+			{model.RetentionActionAnonymize, model.RetentionReasonProspect, u.retentionRepo.FindStaleProspects, policy.ProspectsCutoff, &run.AnonymizedProspects},
+		}
+
+		var runErr error
+		for _, step := range steps {
+			if step.cutoff == nil {
+				continue
+			}
+			if runErr = u.process(ctx, run, step); runErr != nil {
+				break
+			}
+		}
+
+		finished := time.Now()
+		run.FinishedAt = &finished
+		run.Status = model.RetentionStatusSucceeded
+		if runErr != nil {
+			message := runErr.Error()
+			run.Status = model.RetentionStatusFailed
+			run.Error = &message
+		}
+		if err := u.retentionRepo.UpdateRun(run); err != nil {
+			return fmt.Errorf("gagal memperbarui laporan retensi: %v", err)
+		}
+		return runErr
+	})
+	return run, err
+}
+
+// process applies one step in batches, each in its own transaction together
+// with the report items, so an interrupted run leaves a report that matches
+// what was actually changed.
+func (u *retentionUsecase) process(ctx context.Context, run *model.RetentionRun, step retentionStep) error {
+	var afterID uint64
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		ids, err := step.find(*step.cutoff, afterID, u.batchSize())
+		if err != nil {
+			return fmt.Errorf("gagal mencari customer untuk %s: %v", step.reason, err)
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		afterID = ids[len(ids)-1]
+
+		items := make([]model.RetentionRunItem, 0, len(ids))
+		for _, id := range ids {
+			items = append(items, model.RetentionRunItem{
+				RunID:      run.ID,
+				CustomerID: id,
+				Action:     step.action,
+				Reason:     step.reason,
+			})
+		}
+
+		err = u.db.Transaction(func(tx *gorm.DB) error {
+			if !run.DryRun {
+				if err := u.removeDocuments(ctx, tx, ids); err != nil {
+					return err
+				}
+				switch step.action {
+				case model.RetentionActionPurge:
+					if err := u.retentionRepo.PurgeWithTx(tx, ids); err != nil {
+						return err
+					}
+				case model.RetentionActionAnonymize:
+					if err := u.retentionRepo.AnonymizeWithTx(tx, ids); err != nil {
+						return err
+					}
+				}
+			}
+			return u.retentionRepo.AddItemsWithTx(tx, items)
+		})
+		if err != nil {
+			return fmt.Errorf("gagal memproses retensi %s: %v", step.reason, err)
+		}
+		*step.counter += len(ids)
+	}
+}
+
+// removeDocuments deletes the stored files of the customers' lead documents
+// before their rows go. Deleting a file that is already gone succeeds, so a
+// batch that fails afterwards can simply be retried.
+func (u *retentionUsecase) removeDocuments(ctx context.Context, tx *gorm.DB, customerIDs []uint64) error {
+	keys, err := u.retentionRepo.FindDocumentKeysWithTx(tx, customerIDs)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := u.storage.Delete(ctx, key); err != nil {
+			return fmt.Errorf("gagal menghapus dokumen %s: %v", key, err)
+		}
+	}
+	return nil
+}
+
+func (u *retentionUsecase) policy(now time.Time) dto.RetentionPolicy {
+	policy := dto.RetentionPolicy{
+		AnonymizeRejectedMonths:  u.cfg.AnonymizeRejectedMonths,
+		AnonymizeProspectsMonths: u.cfg.AnonymizeProspectsMonths,
+		PurgeDeletedDays:         u.cfg.PurgeDeletedDays,
+	}
+	if u.cfg.AnonymizeRejectedMonths > 0 {
+		cutoff := now.AddDate(0, -u.cfg.AnonymizeRejectedMonths, 0)
+		policy.RejectedCutoff = &cutoff
+	}
+	if u.cfg.AnonymizeProspectsMonths > 0 {
+		cutoff := now.AddDate(0, -u.cfg.AnonymizeProspectsMonths, 0)
+		policy.ProspectsCutoff = &cutoff
+	}
+	if u.cfg.PurgeDeletedDays > 0 {
+		cutoff := now.AddDate(0, 0, -u.cfg.PurgeDeletedDays)
+		policy.DeletedCutoff = &cutoff
+	}
+	return policy
+}
+
+func (u *retentionUsecase) batchSize() int {
+	if u.cfg.BatchSize <= 0 {
+		return 500
+	}
+	return u.cfg.BatchSize
+}
@@ -0,0 +1,63 @@
+package usecase
+
+import (
+	"fmt"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/model"
+)
+
+// customerInScope reports whether the viewer may work with a customer. Admins
+// reach every customer; marketers and BMs reach their branch's unassigned
+// pool plus the customers held by themselves or by a marketer of their
+// branch. For an unassigned customer the owner's branch is the customer's.
+func customerInScope(viewer *model.User, owner dto.CustomerOwner, assigned bool) bool {
+	switch viewer.Role {
+	case "admin":
+		return true
+	case "marketing":
+		if !assigned {
+			return sameBranch(owner.KantorCabangID, viewer.KantorCabangID)
+		}
+		return owner.MarketingID == viewer.ID
+	case "bm":
+		return sameBranch(owner.KantorCabangID, viewer.KantorCabangID)
+	}
+	return false
+}
+
+func sameBranch(a, b *uint) bool {
+	return a != nil && b != nil && *a == *b
+}
+
+// leadScope limits lead records to the marketer's own leads or, for BMs, to
+// the leads held by marketers of their branch.
+func leadScope(user *model.User) (dto.LeadEventScope, error) {
+	var scope dto.LeadEventScope
+	switch user.Role {
+	case "marketing":
+		scope.MarketingID = &user.ID
+	case "bm":
+		if user.KantorCabangID == nil {
+			return scope, fmt.Errorf("%w: BM belum terdaftar di kantor cabang", ErrForbidden)
+		}
+		scope.KantorCabangID = user.KantorCabangID
+	default:
+		return scope, fmt.Errorf("%w: role %s", ErrForbidden, user.Role)
+	}
+	return scope, nil
+}
+
+// monitoringBranch returns the branch a viewer's monitoring is limited to:
+// none for admins and their own branch for BMs.
+func monitoringBranch(user *model.User) (*uint, error) {
+	switch user.Role {
+	case "admin":
+		return nil, nil
+	case "bm":
+		if user.KantorCabangID == nil {
+			return nil, fmt.Errorf("%w: BM tidak terdaftar pada kantor cabang", ErrForbidden)
+		}
+		return user.KantorCabangID, nil
+	}
+	return nil, fmt.Errorf("%w: role %s tidak dapat melihat monitoring", ErrForbidden, user.Role)
+}
@@ -0,0 +1,263 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/model"
+	"ml-prediction/internal/app/repository"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type TimelineUsecase interface {
+	GetCustomerTimeline(ctx context.Context, NIP string, cif string) (*dto.CustomerTimelineResponse, error)
+}
+
+type timelineUsecase struct {
+	timelineRepo repository.TimelineRepository
+	userRepo     repository.UserRepository
+}
+
+func NewTimelineUsecase(timelineRepo repository.TimelineRepository, userRepo repository.UserRepository) TimelineUsecase {
+	return &timelineUsecase{timelineRepo: timelineRepo, userRepo: userRepo}
+}
+
+// GetCustomerTimeline merges the customer's histories into one chronological
+// feed. Admins see every customer. BMs and marketers see the unassigned
+// customers of their branch and those currently held by their branch or by
+// themselves; marketers do not see the notes of other marketers' earlier
+// assignments.
+func (u *timelineUsecase) GetCustomerTimeline(ctx context.Context, NIP string, cif string) (*dto.CustomerTimelineResponse, error) {
+	viewer, err := u.userRepo.FindByNIP(NIP)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user data: %v", err)
+	}
+
+	customer, err := u.timelineRepo.FindCustomerByCIF(cif)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("%w: customer dengan CIF %s", ErrNotFound, cif)
+		}
+		return nil, fmt.Errorf("error getting customer: %v", err)
+	}
+
+	assignments, err := u.timelineRepo.GetAssignments(customer.Id)
+	if err != nil {
+		return nil, err
+	}
+	if !canViewTimeline(viewer, customer, assignments) {
+		return nil, fmt.Errorf("%w: customer ini bukan bagian dari cakupan Anda", ErrForbidden)
+	}
+
+	runs, err := u.timelineRepo.GetPredictionRuns(customer.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []dto.TimelineEntry{{
+		Type:       dto.TimelineCustomerCreated,
+		OccurredAt: customer.CreatedAt,
+		Data:       map[string]interface{}{"source": customer.Source},
+	}}
+
+	if len(runs) == 0 {
+		recommendations, err := u.timelineRepo.GetRecommendations(customer.Id)
+		if err != nil {
+			return nil, err
+		}
+		if len(recommendations) > 0 {
+			entries = append(entries, recommendationEntry(customer, recommendations))
+		}
+	}
+	for i, run := range runs {
+		entries = append(entries, predictionRunEntry(run, i > 0))
+	}
+
+	for i, assignment := range assignments {
+		entries = append(entries, assignmentEntries(assignment, i > 0)...)
+	}
+
+	events, err := u.timelineRepo.GetStatusEvents(customer.Id)
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range events {
+		entries = append(entries, statusEntry(viewer, event))
+	}
+
+	// Customer rows have no edit history yet, so a later updated_at is the
+	// only trace of a profile edit.
+	if customer.UpdatedAt.Sub(customer.CreatedAt) > time.Second {
+		entries = append(entries, dto.TimelineEntry{
+			Type:       dto.TimelineProfileUpdated,
+			OccurredAt: customer.UpdatedAt,
+		})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].OccurredAt.Before(entries[j].OccurredAt)
+	})
+
+	return &dto.CustomerTimelineResponse{
+		CustomerID: customer.Id,
+		CIF:        customer.CIF,
+		Nama:       customer.Nama,
+		Source:     customer.Source,
+		Entries:    entries,
+	}, nil
+}
+
+func canViewTimeline(viewer *model.User, customer *model.Customer, assignments []dto.TimelineAssignment) bool {
+	for _, assignment := range assignments {
+		if assignment.DeletedAt == nil {
+			return customerInScope(viewer, dto.CustomerOwner{
+				MarketingID:    assignment.MarketingID,
+				KantorCabangID: assignment.KantorCabangID,
+			}, true)
+		}
+	}
+	return customerInScope(viewer, dto.CustomerOwner{KantorCabangID: customer.KantorCabangID}, false)
+}
+
+func predictionRunEntry(run model.CustomerPredictionRun, rescore bool) dto.TimelineEntry {
+	var scores map[string]float64
+	_ = json.Unmarshal([]byte(run.Scores), &scores)
+
+	return dto.TimelineEntry{
+		Type:       dto.TimelinePredictionRun,
+		OccurredAt: run.CreatedAt,
+		Data: map[string]interface{}{
+			"run_id":  run.ID,
+			"source":  run.Source,
+			"rescore": rescore,
+			"scores":  scores,
+		},
+	}
+}
+
+func recommendationEntry(customer *model.Customer, recommendations []dto.TimelineRecommendation) dto.TimelineEntry {
+	products := make([]map[string]interface{}, 0, len(recommendations))
+	for _, rec := range recommendations {
+		products = append(products, map[string]interface{}{
+			"product_id": rec.ProductID,
+			"nama":       rec.ProductName,
+			"order":      rec.Order,
+		})
+	}
+	return dto.TimelineEntry{
+		Type:       dto.TimelinePredictionRun,
+		OccurredAt: customer.CreatedAt,
+		Data: map[string]interface{}{
+			"source":   customer.Source,
+			"rescore":  false,
+			"products": products,
+		},
+	}
+}
+
+func assignmentEntries(assignment dto.TimelineAssignment, reassigned bool) []dto.TimelineEntry {
+	actor := &dto.TimelineActor{
+		ID:   assignment.MarketingID,
+		Nama: assignment.MarketingName,
+		NIP:  assignment.MarketingNIP,
+		Role: "marketing",
+	}
+
+	assignedType := dto.TimelineAssigned
+	if reassigned {
+		assignedType = dto.TimelineReassigned
+	}
+	assigned := dto.TimelineEntry{
+		Type:       assignedType,
+		OccurredAt: assignment.CreatedAt,
+		Actor:      actor,
+	}
+	// Leads handed out by a BM name the BM as actor and the marketer as data;
+	// leads handed out by the distribution engine have no actor.
+	if assignment.AssignedByID == nil {
+		assigned.Actor = nil
+		assigned.Data = map[string]interface{}{
+			"marketing":   actor,
+			"assigned_by": "distribution",
+		}
+	} else if *assignment.AssignedByID != assignment.MarketingID {
+		assigned.Actor = &dto.TimelineActor{ID: *assignment.AssignedByID}
+		if assignment.AssignedByName != nil {
+			assigned.Actor.Nama = *assignment.AssignedByName
+		}
+		if assignment.AssignedByNIP != nil {
+			assigned.Actor.NIP = *assignment.AssignedByNIP
+		}
+		if assignment.AssignedByRole != nil {
+			assigned.Actor.Role = *assignment.AssignedByRole
+		}
+		assigned.Data = map[string]interface{}{
+			"marketing": actor,
+		}
+	}
+	entries := []dto.TimelineEntry{assigned}
+
+	if assignment.DeletedAt != nil {
+		entries = append(entries, dto.TimelineEntry{
+			Type:       dto.TimelineUnassigned,
+			OccurredAt: *assignment.DeletedAt,
+			Actor:      actor,
+		})
+	}
+	return entries
+}
+
+// statusEntry turns a lead event into a timeline entry. Closing a lead and
+// booking a closing on it both read as closed; a voided closing gets its own
+// entry. Marketers do not see the notes and amounts recorded on other
+// marketers' leads.
+func statusEntry(viewer *model.User, event dto.LeadEventResponse) dto.TimelineEntry {
+	entry := dto.TimelineEntry{
+		Type:       dto.TimelineStatusChanged,
+		OccurredAt: event.CreatedAt,
+		Data: map[string]interface{}{
+			"old_status": event.OldStatus,
+			"status":     event.NewStatus,
+			"channel":    event.Channel,
+		},
+	}
+	if event.ActorID != nil {
+		entry.Actor = &dto.TimelineActor{ID: *event.ActorID}
+		if event.ActorName != nil {
+			entry.Actor.Nama = *event.ActorName
+		}
+		if event.ActorNIP != nil {
+			entry.Actor.NIP = *event.ActorNIP
+		}
+		if event.ActorRole != nil {
+			entry.Actor.Role = *event.ActorRole
+		}
+	}
+
+	ownEntry := viewer.Role != "marketing" || event.MarketingID == viewer.ID
+	closingType := ""
+	switch {
+	case event.EventType == model.LeadEventClosingAdded:
+		closingType = dto.TimelineClosed
+	case event.EventType == model.LeadEventClosingVoided:
+		closingType = dto.TimelineClosingVoided
+	case event.NewStatus == string(model.CustomerStatusClosed):
+		closingType = dto.TimelineClosed
+	}
+	if closingType != "" {
+		entry.Type = closingType
+		entry.Data["product_id"] = event.ProductID
+		entry.Data["product_name"] = event.ProductName
+		if ownEntry {
+			entry.Data["amount"] = event.Amount
+		}
+	}
+	if ownEntry {
+		entry.Note = event.Note
+	}
+	return entry
+}
@@ -0,0 +1,140 @@
+package usecase
+
+import (
+	"context"
+	"ml-prediction/config"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/model"
+	"testing"
+	"time"
+)
+
+type timelineFixture struct {
+	users    *fakeUserRepo
+	leads    *fakeLeadRepo
+	closings ClosingUsecase
+	timeline TimelineUsecase
+}
+
+func newTimelineFixture(t *testing.T) *timelineFixture {
+	t.Helper()
+	branch := uintPtr(1)
+	users := &fakeUserRepo{users: []*model.User{
+		{ID: 10, NIP: "M001", Nama: "Marketing Satu", Role: "marketing", KantorCabangID: branch},
+		{ID: 11, NIP: "M002", Nama: "Marketing Dua", Role: "marketing", KantorCabangID: branch},
+	}}
+	leads := &fakeLeadRepo{users: users}
+	customer := &model.Customer{Id: 100, CIF: "CIF100", Nama: "Budi", KantorCabangID: branch, CreatedAt: time.Now().Add(-time.Hour)}
+	customer.UpdatedAt = customer.CreatedAt
+	leads.add(&model.MarketingCustomer{CustomerID: customer.Id, Customer: *customer, MarketingID: 10, Status: "interested", AssignedBy: uintPtr(10)})
+
+	leadCfg := config.LeadConfig{ReservationTTL: 24 * time.Hour, ClosingBackdateMonths: 1}
+	return &timelineFixture{
+		users:    users,
+		leads:    leads,
+		closings: NewClosingUsecase(&fakeClosingRepo{}, leads, users, dto.DefaultLeadStateMachine(0), leadCfg, newTestDB(t)),
+		timeline: NewTimelineUsecase(&fakeTimelineRepo{customer: customer, leads: leads, products: map[uint]string{7: "KPR"}}, users),
+	}
+}
+
+func entriesOfType(entries []dto.TimelineEntry, entryType string) []dto.TimelineEntry {
+	var found []dto.TimelineEntry
+	for _, entry := range entries {
+		if entry.Type == entryType {
+			found = append(found, entry)
+		}
+	}
+	return found
+}
+
+func TestTimelineShowsBookedClosing(t *testing.T) {
+	f := newTimelineFixture(t)
+	ctx := context.Background()
+
+	closing, err := f.closings.Add(ctx, "M001", "CIF100", &dto.AddClosingRequest{ProductID: 7, Amount: 250000000, Notes: "akad KPR"})
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	timeline, err := f.timeline.GetCustomerTimeline(ctx, "M001", "CIF100")
+	if err != nil {
+		t.Fatalf("GetCustomerTimeline: %v", err)
+	}
+	closed := entriesOfType(timeline.Entries, dto.TimelineClosed)
+	if len(closed) != 1 {
+		t.Fatalf("closed entries = %d, want 1: %+v", len(closed), timeline.Entries)
+	}
+	entry := closed[0]
+	if productID, _ := entry.Data["product_id"].(*uint); productID == nil || *productID != 7 {
+		t.Errorf("product_id = %v, want 7", entry.Data["product_id"])
+	}
+	if name, _ := entry.Data["product_name"].(*string); name == nil || *name != "KPR" {
+		t.Errorf("product_name = %v, want KPR", entry.Data["product_name"])
+	}
+	if amount, _ := entry.Data["amount"].(*int64); amount == nil || *amount != 250000000 {
+		t.Errorf("amount = %v, want 250000000", entry.Data["amount"])
+	}
+	if entry.Note != "akad KPR" {
+		t.Errorf("note = %q, want the closing note", entry.Note)
+	}
+
+	if err := f.closings.Void(ctx, "M001", "CIF100", closing.ID); err != nil {
+		t.Fatalf("Void: %v", err)
+	}
+	timeline, err = f.timeline.GetCustomerTimeline(ctx, "M001", "CIF100")
+	if err != nil {
+		t.Fatalf("GetCustomerTimeline after Void: %v", err)
+	}
+	if got := len(entriesOfType(timeline.Entries, dto.TimelineClosed)); got != 1 {
+		t.Errorf("closed entries after Void = %d, want the booking to stay", got)
+	}
+	voided := entriesOfType(timeline.Entries, dto.TimelineClosingVoided)
+	if len(voided) != 1 {
+		t.Fatalf("voided entries = %d, want 1", len(voided))
+	}
+	if productID, _ := voided[0].Data["product_id"].(*uint); productID == nil || *productID != 7 {
+		t.Errorf("voided product_id = %v, want 7", voided[0].Data["product_id"])
+	}
+}
+
+func TestTimelineHidesClosingAmountFromOtherMarketers(t *testing.T) {
+	amount := int64(250000000)
+	productID := uint(7)
+	event := dto.LeadEventResponse{
+		MarketingID: 10,
+		EventType:   model.LeadEventClosingAdded,
+		NewStatus:   "interested",
+		Note:        "akad KPR",
+		ProductID:   &productID,
+		Amount:      &amount,
+		CreatedAt:   time.Now(),
+	}
+
+	tests := []struct {
+		name       string
+		viewer     *model.User
+		wantAmount bool
+	}{
+		{"own lead", &model.User{ID: 10, Role: "marketing"}, true},
+		{"other marketer", &model.User{ID: 11, Role: "marketing"}, false},
+		{"bm", &model.User{ID: 20, Role: "bm"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := statusEntry(tt.viewer, event)
+			if entry.Type != dto.TimelineClosed {
+				t.Fatalf("type = %q, want %q", entry.Type, dto.TimelineClosed)
+			}
+			if entry.Data["product_id"] == nil {
+				t.Error("product_id missing")
+			}
+			_, hasAmount := entry.Data["amount"]
+			if hasAmount != tt.wantAmount {
+				t.Errorf("amount shown = %v, want %v", hasAmount, tt.wantAmount)
+			}
+			if (entry.Note != "") != tt.wantAmount {
+				t.Errorf("note = %q", entry.Note)
+			}
+		})
+	}
+}
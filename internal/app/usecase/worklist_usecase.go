@@ -0,0 +1,329 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"ml-prediction/config"
+	dto "ml-prediction/internal/app/domain"
+	"ml-prediction/internal/app/model"
+	"ml-prediction/internal/app/repository"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const defaultWorklistLimit = 20
+
+// Sources of a worklist item.
+const (
+	worklistSourceLead = "lead"
+	worklistSourcePool = "pool"
+)
+
+// A follow-up that is already late outranks one due later today.
+const (
+	worklistFollowUpOverdue = 1.0
+	worklistFollowUpToday   = 0.75
+)
+
+type WorklistUsecase interface {
+	GetWorklist(ctx context.Context, NIP string, req *dto.WorklistRequest) (*dto.WorklistResponse, error)
+	GetSettings(ctx context.Context, NIP string) (*model.WorklistSetting, error)
+	UpdateSettings(ctx context.Context, NIP string, req *dto.UpdateWorklistSettingsRequest) (*model.WorklistSetting, error)
+}
+
+type worklistUsecase struct {
+	worklistRepo repository.WorklistRepository
+	userRepo     repository.UserRepository
+	leadStates   *dto.LeadStateMachine
+	consentCfg   config.ConsentConfig
+	followUpCfg  config.FollowUpConfig
+	cfg          config.WorklistConfig
+}
+
+func NewWorklistUsecase(
+	worklistRepo repository.WorklistRepository,
+	userRepo repository.UserRepository,
+	leadStates *dto.LeadStateMachine,
+	consentCfg config.ConsentConfig,
+	followUpCfg config.FollowUpConfig,
+	cfg config.WorklistConfig,
+) WorklistUsecase {
+	return &worklistUsecase{
+		worklistRepo: worklistRepo,
+		userRepo:     userRepo,
+		leadStates:   leadStates,
+		consentCfg:   consentCfg,
+		followUpCfg:  followUpCfg,
+		cfg:          cfg,
+	}
+}
+
+// GetWorklist ranks the marketer's open leads together with the best
+// customers waiting in the branch pool. Each factor is scaled to 0..1 and
+// weighted by the branch setting, so an item's score runs from 0 to 100.
+func (u *worklistUsecase) GetWorklist(ctx context.Context, NIP string, req *dto.WorklistRequest) (*dto.WorklistResponse, error) {
+	user, err := u.userRepo.FindByNIP(NIP)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user data: %v", err)
+	}
+	if user.KantorCabangID == nil {
+		return nil, fmt.Errorf("%w: marketing belum terdaftar di kantor cabang", ErrForbidden)
+	}
+	setting, err := u.setting(*user.KantorCabangID)
+	if err != nil {
+		return nil, err
+	}
+
+	leads, err := u.worklistRepo.GetOpenLeads(user.ID, u.leadStates.FinalStates())
+	if err != nil {
+		return nil, err
+	}
+	var consent *dto.ConsentFilter
+	if u.consentCfg.HideNonConsenting {
+		consent = &dto.ConsentFilter{
+			Scope:           model.ConsentScopeCrossSelling,
+			RequireExplicit: u.consentCfg.RequireExplicit,
+		}
+	}
+	pool, err := u.worklistRepo.GetPoolCandidates(*user.KantorCabangID, consent, u.cfg.PoolCandidates)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	targetRows, err := u.worklistRepo.GetTargets(user.ID, int(now.Month()), now.Year())
+	if err != nil {
+		return nil, err
+	}
+	targets := make(map[uint]dto.WorklistTarget, len(targetRows))
+	for _, target := range targetRows {
+		targets[target.ProductID] = target
+	}
+
+	var maxPlafon uint64
+	for _, candidates := range [][]dto.WorklistCandidate{leads, pool} {
+		for _, candidate := range candidates {
+			if candidate.PlafonMax != nil && *candidate.PlafonMax > maxPlafon {
+				maxPlafon = *candidate.PlafonMax
+			}
+		}
+	}
+
+	ranker := &worklistRanker{
+		setting:       *setting,
+		targets:       targets,
+		maxPlafon:     maxPlafon,
+		now:           now,
+		endOfToday:    time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, 1),
+		overdueCutoff: now.Add(-u.followUpCfg.OverdueGrace),
+	}
+	items := make([]dto.WorklistItem, 0, len(leads)+len(pool))
+	for i := range leads {
+		items = append(items, ranker.rank(&leads[i], worklistSourceLead))
+	}
+	for i := range pool {
+		items = append(items, ranker.rank(&pool[i], worklistSourcePool))
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Score != items[j].Score {
+			return items[i].Score > items[j].Score
+		}
+		// Leads the marketer already holds come before pool customers.
+		return items[i].Source == worklistSourceLead && items[j].Source != worklistSourceLead
+	})
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultWorklistLimit
+	}
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	for i := range items {
+		items[i].Rank = i + 1
+	}
+
+	return &dto.WorklistResponse{
+		GeneratedAt: now,
+		Setting:     *setting,
+		Items:       items,
+	}, nil
+}
+
+func (u *worklistUsecase) GetSettings(ctx context.Context, NIP string) (*model.WorklistSetting, error) {
+	bm, err := u.branchManager(NIP)
+	if err != nil {
+		return nil, err
+	}
+	return u.setting(*bm.KantorCabangID)
+}
+
+func (u *worklistUsecase) UpdateSettings(ctx context.Context, NIP string, req *dto.UpdateWorklistSettingsRequest) (*model.WorklistSetting, error) {
+	bm, err := u.branchManager(NIP)
+	if err != nil {
+		return nil, err
+	}
+	if *req.WeightScore+*req.WeightPlafond+*req.WeightFollowUp+*req.WeightTargetGap == 0 {
+		return nil, fmt.Errorf("setidaknya satu bobot harus lebih dari 0")
+	}
+
+	setting := &model.WorklistSetting{
+		KantorCabangID:  *bm.KantorCabangID,
+		WeightScore:     *req.WeightScore,
+		WeightPlafond:   *req.WeightPlafond,
+		WeightFollowUp:  *req.WeightFollowUp,
+		WeightTargetGap: *req.WeightTargetGap,
+		UpdatedBy:       &bm.ID,
+	}
+	if err := u.worklistRepo.SaveSetting(setting); err != nil {
+		return nil, fmt.Errorf("gagal menyimpan pengaturan worklist: %v", err)
+	}
+	return u.setting(*bm.KantorCabangID)
+}
+
+func (u *worklistUsecase) branchManager(NIP string) (*model.User, error) {
+	bm, err := u.userRepo.FindByNIP(NIP)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user data: %v", err)
+	}
+	if bm.KantorCabangID == nil {
+		return nil, fmt.Errorf("%w: BM tidak terdaftar pada kantor cabang", ErrForbidden)
+	}
+	return bm, nil
+}
+
+// setting returns the branch's worklist weights, or the configured defaults
+// when its BM never set them.
+func (u *worklistUsecase) setting(kantorCabangID uint) (*model.WorklistSetting, error) {
+	setting, err := u.worklistRepo.GetSetting(kantorCabangID)
+	if err == gorm.ErrRecordNotFound {
+		return &model.WorklistSetting{
+			KantorCabangID:  kantorCabangID,
+			WeightScore:     u.cfg.WeightScore,
+			WeightPlafond:   u.cfg.WeightPlafond,
+			WeightFollowUp:  u.cfg.WeightFollowUp,
+			WeightTargetGap: u.cfg.WeightTargetGap,
+		}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil pengaturan worklist: %v", err)
+	}
+	return setting, nil
+}
+
+// worklistRanker scores candidates against the figures of one worklist.
+type worklistRanker struct {
+	setting       model.WorklistSetting
+	targets       map[uint]dto.WorklistTarget
+	maxPlafon     uint64
+	now           time.Time
+	endOfToday    time.Time
+	overdueCutoff time.Time
+}
+
+type worklistReason struct {
+	points float64
+	text   string
+}
+
+func (r *worklistRanker) rank(candidate *dto.WorklistCandidate, source string) dto.WorklistItem {
+	item := dto.WorklistItem{
+		Source:              source,
+		CIF:                 candidate.CIF,
+		Nama:                candidate.Nama,
+		MarketingCustomerID: candidate.MarketingCustomerID,
+		Status:              candidate.Status,
+		ProductID:           candidate.ProductID,
+		ProductName:         candidate.ProductName,
+		RecommendationScore: candidate.Score,
+		PlafonMax:           candidate.PlafonMax,
+		NextFollowUpAt:      candidate.NextFollowUpAt,
+	}
+	product := "produk rekomendasi"
+	if candidate.ProductName != nil {
+		product = *candidate.ProductName
+	}
+
+	var reasons []worklistReason
+	totalWeight := r.setting.WeightScore + r.setting.WeightPlafond + r.setting.WeightFollowUp + r.setting.WeightTargetGap
+	add := func(factor string, weight int, value float64, text string) {
+		points := 0.0
+		if totalWeight > 0 {
+			points = round2(value * float64(weight) / float64(totalWeight) * 100)
+		}
+		item.Factors = append(item.Factors, dto.WorklistFactor{
+			Factor: factor,
+			Value:  round2(value),
+			Weight: weight,
+			Points: points,
+		})
+		item.Score += points
+		if points > 0 {
+			reasons = append(reasons, worklistReason{points: points, text: text})
+		}
+	}
+
+	score := 0.0
+	if candidate.Score != nil {
+		score = math.Max(0, math.Min(1, *candidate.Score))
+	}
+	add(model.WorklistFactorScore, r.setting.WeightScore, score,
+		fmt.Sprintf("Skor rekomendasi %s %.0f%%", product, score*100))
+
+	plafond := 0.0
+	if candidate.PlafonMax != nil && r.maxPlafon > 0 {
+		plafond = float64(*candidate.PlafonMax) / float64(r.maxPlafon)
+	}
+	plafondText := ""
+	if candidate.PlafonMax != nil {
+		plafondText = fmt.Sprintf("Plafon maksimal %s Rp %d", product, *candidate.PlafonMax)
+	}
+	add(model.WorklistFactorPlafond, r.setting.WeightPlafond, plafond, plafondText)
+
+	followUp, followUpText := 0.0, ""
+	if due := candidate.NextFollowUpAt; due != nil {
+		switch {
+		case candidate.OverdueAt != nil || due.Before(r.overdueCutoff):
+			followUp = worklistFollowUpOverdue
+			followUpText = fmt.Sprintf("Follow-up terlambat sejak %s", due.Format("02-01-2006 15:04"))
+		case due.Before(r.endOfToday):
+			followUp = worklistFollowUpToday
+			followUpText = fmt.Sprintf("Follow-up dijadwalkan hari ini pukul %s", due.Format("15:04"))
+		}
+	}
+	add(model.WorklistFactorFollowUp, r.setting.WeightFollowUp, followUp, followUpText)
+
+	gap, gapText := 0.0, ""
+	if candidate.ProductID != nil {
+		if target, ok := r.targets[*candidate.ProductID]; ok && target.Target > 0 {
+			remaining := math.Max(0, target.Target-target.Achieved)
+			item.TargetGap = &remaining
+			gap = remaining / target.Target
+			gapText = fmt.Sprintf("Sisa target %s bulan ini Rp %.0f (%.0f%% dari target)", product, remaining, gap*100)
+		}
+	}
+	add(model.WorklistFactorTargetGap, r.setting.WeightTargetGap, gap, gapText)
+
+	item.Score = round2(item.Score)
+
+	sort.SliceStable(reasons, func(i, j int) bool { return reasons[i].points > reasons[j].points })
+	item.Reasons = make([]string, 0, len(reasons)+1)
+	for _, reason := range reasons {
+		item.Reasons = append(item.Reasons, reason.text)
+	}
+	if source == worklistSourcePool {
+		item.Reasons = append(item.Reasons, "Customer belum ditangani di pool kantor cabang")
+	}
+	if len(item.Reasons) == 0 {
+		item.Reasons = append(item.Reasons, "Tidak ada faktor prioritas yang menonjol")
+	}
+	return item
+}
+
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -86,3 +86,16 @@ func JWTMiddleware(requiredRoles ...string) fiber.Handler {
 		return c.Next()
 	}
 }
+
+// OptionalJWTMiddleware identifies the caller when a token is sent and lets
+// anonymous requests, e.g. from integrations, through. A token that is sent
+// must be valid.
+func OptionalJWTMiddleware() fiber.Handler {
+	authenticated := JWTMiddleware("admin", "bm", "marketing")
+	return func(c *fiber.Ctx) error {
+		if c.Get("Authorization") == "" {
+			return c.Next()
+		}
+		return authenticated(c)
+	}
+}
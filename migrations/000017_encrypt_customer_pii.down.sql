@@ -0,0 +1,18 @@
+-- Run `go run ./cmd/encryptpii -decrypt` before rolling back, otherwise the
+-- restored columns will hold ciphertext.
+DROP INDEX IF EXISTS idx_customers_email_bidx;
+DROP INDEX IF EXISTS idx_customers_nomor_hp_bidx;
+DROP INDEX IF EXISTS idx_customers_nomor_rekening_bidx;
+
+ALTER TABLE customers
+DROP COLUMN IF EXISTS nomor_rekening_bidx,
+DROP COLUMN IF EXISTS nomor_hp_bidx,
+DROP COLUMN IF EXISTS email_bidx;
+
+ALTER TABLE customers
+ALTER COLUMN nomor_rekening TYPE VARCHAR(50),
+ALTER COLUMN nomor_hp TYPE VARCHAR(20),
+ALTER COLUMN email TYPE VARCHAR(50);
+
+ALTER TABLE customers ADD CONSTRAINT customers_nomor_rekening_key UNIQUE (nomor_rekening);
+ALTER TABLE customers ADD CONSTRAINT customers_nomor_hp_key UNIQUE (nomor_hp);
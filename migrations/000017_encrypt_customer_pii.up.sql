@@ -0,0 +1,21 @@
+ALTER TABLE customers
+ALTER COLUMN nomor_rekening TYPE TEXT,
+ALTER COLUMN nomor_hp TYPE TEXT,
+ALTER COLUMN email TYPE TEXT,
+ADD COLUMN IF NOT EXISTS nomor_rekening_bidx VARCHAR(64) NULL,
+ADD COLUMN IF NOT EXISTS nomor_hp_bidx VARCHAR(64) NULL,
+ADD COLUMN IF NOT EXISTS email_bidx VARCHAR(64) NULL;
+
+-- Ciphertexts are randomised, so uniqueness moves to the blind indexes.
+ALTER TABLE customers DROP CONSTRAINT IF EXISTS customers_nomor_rekening_key;
+ALTER TABLE customers DROP CONSTRAINT IF EXISTS customers_nomor_hp_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_nomor_rekening_bidx ON customers (nomor_rekening_bidx)
+WHERE
+    deleted_at IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_nomor_hp_bidx ON customers (nomor_hp_bidx)
+WHERE
+    deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_customers_email_bidx ON customers (email_bidx);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_pii_access_logs_viewer_id;
+DROP INDEX IF EXISTS idx_pii_access_logs_customer_id;
+DROP TABLE IF EXISTS pii_access_logs;
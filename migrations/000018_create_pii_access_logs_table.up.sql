@@ -0,0 +1,18 @@
+CREATE TABLE
+    pii_access_logs (
+        id BIGSERIAL PRIMARY KEY,
+        viewer_id INT NOT NULL,
+        viewer_role VARCHAR(20) NOT NULL,
+        customer_id BIGINT NOT NULL,
+        endpoint VARCHAR(100) NOT NULL,
+        reason VARCHAR(50) NOT NULL,
+        created_at TIMESTAMP
+        WITH
+            TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            CONSTRAINT fk_pii_access_logs_viewer FOREIGN KEY (viewer_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE,
+            CONSTRAINT fk_pii_access_logs_customer FOREIGN KEY (customer_id) REFERENCES customers (id) ON UPDATE CASCADE ON DELETE CASCADE
+    );
+
+CREATE INDEX idx_pii_access_logs_customer_id ON pii_access_logs (customer_id);
+
+CREATE INDEX idx_pii_access_logs_viewer_id ON pii_access_logs (viewer_id);
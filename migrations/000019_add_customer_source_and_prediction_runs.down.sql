@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_prediction_runs_customer_id;
+DROP TABLE IF EXISTS customer_prediction_runs;
+
+ALTER TABLE customers
+DROP COLUMN IF EXISTS source;
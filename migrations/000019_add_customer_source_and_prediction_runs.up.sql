@@ -0,0 +1,30 @@
+ALTER TABLE customers
+ADD COLUMN source VARCHAR(30);
+
+UPDATE customers
+SET
+    source = 'legacy'
+WHERE
+    source IS NULL;
+
+ALTER TABLE customers
+ALTER COLUMN source
+SET DEFAULT 'api',
+ALTER COLUMN source
+SET NOT NULL;
+
+CREATE TABLE
+    customer_prediction_runs (
+        id BIGSERIAL PRIMARY KEY,
+        customer_id BIGINT NOT NULL,
+        source VARCHAR(30) NOT NULL,
+        triggered_by INT,
+        scores JSONB NOT NULL DEFAULT '{}'::jsonb,
+        created_at TIMESTAMP
+        WITH
+            TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            CONSTRAINT fk_prediction_runs_customer FOREIGN KEY (customer_id) REFERENCES customers (id) ON UPDATE CASCADE ON DELETE CASCADE,
+            CONSTRAINT fk_prediction_runs_user FOREIGN KEY (triggered_by) REFERENCES users (id) ON UPDATE CASCADE ON DELETE SET NULL
+    );
+
+CREATE INDEX idx_prediction_runs_customer_id ON customer_prediction_runs (customer_id, created_at);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_duplicate_candidates_status;
+DROP TABLE IF EXISTS customer_duplicate_candidates;
+
+ALTER TABLE customers
+DROP CONSTRAINT IF EXISTS fk_customers_merged_into,
+DROP COLUMN IF EXISTS merged_into_id;
@@ -0,0 +1,32 @@
+ALTER TABLE customers
+ADD COLUMN merged_into_id BIGINT,
+ADD CONSTRAINT fk_customers_merged_into FOREIGN KEY (merged_into_id) REFERENCES customers (id) ON UPDATE CASCADE ON DELETE SET NULL;
+
+CREATE TABLE
+    customer_duplicate_candidates (
+        id BIGSERIAL PRIMARY KEY,
+        customer_id BIGINT NOT NULL,
+        duplicate_id BIGINT NOT NULL,
+        score NUMERIC(5, 4) NOT NULL,
+        reasons VARCHAR[] NOT NULL DEFAULT '{}',
+        status VARCHAR(20) NOT NULL DEFAULT 'pending',
+        survivor_id BIGINT,
+        reviewed_by INT,
+        reviewed_at TIMESTAMP
+        WITH
+            TIME ZONE,
+            created_at TIMESTAMP
+        WITH
+            TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            updated_at TIMESTAMP
+        WITH
+            TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            CONSTRAINT fk_duplicate_candidates_customer FOREIGN KEY (customer_id) REFERENCES customers (id) ON UPDATE CASCADE ON DELETE CASCADE,
+            CONSTRAINT fk_duplicate_candidates_duplicate FOREIGN KEY (duplicate_id) REFERENCES customers (id) ON UPDATE CASCADE ON DELETE CASCADE,
+            CONSTRAINT fk_duplicate_candidates_reviewer FOREIGN KEY (reviewed_by) REFERENCES users (id) ON UPDATE CASCADE ON DELETE SET NULL,
+            CONSTRAINT chk_duplicate_pair_order CHECK (customer_id < duplicate_id),
+            CONSTRAINT chk_duplicate_status CHECK (status IN ('pending', 'merged', 'dismissed')),
+            CONSTRAINT uq_duplicate_pair UNIQUE (customer_id, duplicate_id)
+    );
+
+CREATE INDEX idx_duplicate_candidates_status ON customer_duplicate_candidates (status);
@@ -0,0 +1,3 @@
+DROP VIEW IF EXISTS customer_consent_status;
+DROP INDEX IF EXISTS idx_customer_consents_lookup;
+DROP TABLE IF EXISTS customer_consents;
@@ -0,0 +1,46 @@
+CREATE TABLE
+    customer_consents (
+        id BIGSERIAL PRIMARY KEY,
+        customer_id BIGINT NOT NULL,
+        channel VARCHAR(20) NOT NULL,
+        scope VARCHAR(30) NOT NULL,
+        granted BOOLEAN NOT NULL,
+        source VARCHAR(30) NOT NULL,
+        expires_at TIMESTAMP
+        WITH
+            TIME ZONE,
+            recorded_by INT,
+            notes TEXT,
+            created_at TIMESTAMP
+        WITH
+            TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            CONSTRAINT fk_customer_consents_customer FOREIGN KEY (customer_id) REFERENCES customers (id) ON UPDATE CASCADE ON DELETE CASCADE,
+            CONSTRAINT fk_customer_consents_user FOREIGN KEY (recorded_by) REFERENCES users (id) ON UPDATE CASCADE ON DELETE SET NULL,
+            CONSTRAINT chk_consent_channel CHECK (channel IN ('phone', 'whatsapp', 'email', 'visit'))
+    );
+
+CREATE INDEX idx_customer_consents_lookup ON customer_consents (customer_id, scope, channel, created_at DESC);
+
+-- The effective preference per channel is the latest record that has not
+-- expired. Records are never updated: a withdrawal is a new refusal record.
+CREATE VIEW
+    customer_consent_status AS
+SELECT DISTINCT
+    ON (customer_id, scope, channel) customer_id,
+    scope,
+    channel,
+    granted,
+    source,
+    expires_at,
+    created_at
+FROM
+    customer_consents
+WHERE
+    expires_at IS NULL
+    OR expires_at > NOW()
+ORDER BY
+    customer_id,
+    scope,
+    channel,
+    created_at DESC,
+    id DESC;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_retention_run_items_run_id;
+DROP TABLE IF EXISTS retention_run_items;
+DROP TABLE IF EXISTS retention_runs;
+
+ALTER TABLE customers
+DROP COLUMN IF EXISTS anonymized_at;
@@ -0,0 +1,44 @@
+ALTER TABLE customers
+ADD COLUMN anonymized_at TIMESTAMP
+WITH
+    TIME ZONE;
+
+CREATE TABLE
+    retention_runs (
+        id BIGSERIAL PRIMARY KEY,
+        trigger VARCHAR(20) NOT NULL,
+        triggered_by INT,
+        dry_run BOOLEAN NOT NULL DEFAULT FALSE,
+        status VARCHAR(20) NOT NULL DEFAULT 'running',
+        policy JSONB NOT NULL DEFAULT '{}'::jsonb,
+        anonymized_rejected INT NOT NULL DEFAULT 0,
+        anonymized_prospects INT NOT NULL DEFAULT 0,
+        anonymized_deleted INT NOT NULL DEFAULT 0,
+        purged INT NOT NULL DEFAULT 0,
+        error TEXT,
+        started_at TIMESTAMP
+        WITH
+            TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+            finished_at TIMESTAMP
+        WITH
+            TIME ZONE,
+            CONSTRAINT fk_retention_runs_user FOREIGN KEY (triggered_by) REFERENCES users (id) ON UPDATE CASCADE ON DELETE SET NULL,
+            CONSTRAINT chk_retention_run_status CHECK (status IN ('running', 'succeeded', 'failed'))
+    );
+
+-- Items keep the customer id without a foreign key so the report survives the
+-- purge of the customer it describes.
+CREATE TABLE
+    retention_run_items (
+        id BIGSERIAL PRIMARY KEY,
+        run_id BIGINT NOT NULL,
+        customer_id BIGINT NOT NULL,
+        action VARCHAR(30) NOT NULL,
+        reason VARCHAR(30) NOT NULL,
+        created_at TIMESTAMP
+        WITH
+            TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            CONSTRAINT fk_retention_run_items_run FOREIGN KEY (run_id) REFERENCES retention_runs (id) ON UPDATE CASCADE ON DELETE CASCADE
+    );
+
+CREATE INDEX idx_retention_run_items_run_id ON retention_run_items (run_id);
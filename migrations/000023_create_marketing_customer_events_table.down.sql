@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS trg_mc_events_append_only ON marketing_customer_events;
+DROP FUNCTION IF EXISTS prevent_mc_event_update ();
+DROP INDEX IF EXISTS idx_mc_events_marketing_customer_id;
+DROP TABLE IF EXISTS marketing_customer_events;
@@ -0,0 +1,87 @@
+CREATE TABLE
+    marketing_customer_events (
+        id BIGSERIAL PRIMARY KEY,
+        marketing_customer_id INT NOT NULL,
+        event_type VARCHAR(30) NOT NULL,
+        old_status VARCHAR(20),
+        new_status VARCHAR(20) NOT NULL,
+        actor_id INT,
+        note TEXT,
+        product_id INT,
+        amount BIGINT,
+        channel VARCHAR(20),
+        created_at TIMESTAMP
+        WITH
+            TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            CONSTRAINT fk_mc_events_marketing_customer FOREIGN KEY (marketing_customer_id) REFERENCES marketing_customers (id) ON UPDATE CASCADE ON DELETE CASCADE,
+            CONSTRAINT fk_mc_events_actor FOREIGN KEY (actor_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE SET NULL,
+            CONSTRAINT fk_mc_events_product FOREIGN KEY (product_id) REFERENCES products (id) ON UPDATE CASCADE ON DELETE SET NULL
+    );
+
+CREATE INDEX idx_mc_events_marketing_customer_id ON marketing_customer_events (marketing_customer_id, created_at);
+
+-- Events are append-only. Only the free-text note may be rewritten, which the
+-- retention job needs to anonymise old leads.
+CREATE FUNCTION prevent_mc_event_update () RETURNS TRIGGER AS $$
+BEGIN
+    IF (NEW.marketing_customer_id, NEW.event_type, NEW.old_status, NEW.new_status, NEW.actor_id,
+        NEW.product_id, NEW.amount, NEW.channel, NEW.created_at)
+       IS DISTINCT FROM
+       (OLD.marketing_customer_id, OLD.event_type, OLD.old_status, OLD.new_status, OLD.actor_id,
+        OLD.product_id, OLD.amount, OLD.channel, OLD.created_at) THEN
+        RAISE EXCEPTION 'marketing_customer_events is append-only';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_mc_events_append_only BEFORE
+UPDATE ON marketing_customer_events FOR EACH ROW
+EXECUTE FUNCTION prevent_mc_event_update ();
+
+-- Backfill the history that can be reconstructed from the current rows.
+INSERT INTO
+    marketing_customer_events (
+        marketing_customer_id,
+        event_type,
+        old_status,
+        new_status,
+        actor_id,
+        created_at
+    )
+SELECT
+    id,
+    'assigned',
+    NULL,
+    'new',
+    marketing_id,
+    created_at
+FROM
+    marketing_customers;
+
+INSERT INTO
+    marketing_customer_events (
+        marketing_customer_id,
+        event_type,
+        old_status,
+        new_status,
+        actor_id,
+        note,
+        product_id,
+        amount,
+        created_at
+    )
+SELECT
+    id,
+    'status_changed',
+    'new',
+    status,
+    marketing_id,
+    NULLIF(notes, ''),
+    product_id,
+    amount,
+    updated_at
+FROM
+    marketing_customers
+WHERE
+    status <> 'new';
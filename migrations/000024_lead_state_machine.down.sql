@@ -0,0 +1,12 @@
+ALTER TABLE marketing_customers
+DROP COLUMN IF EXISTS status_changed_at;
+
+-- Leads in states unknown to the old constraint fall back to contacted.
+UPDATE marketing_customers
+SET
+    status = 'contacted'
+WHERE
+    status NOT IN ('new', 'contacted', 'closed', 'rejected');
+
+ALTER TABLE marketing_customers
+ADD CONSTRAINT chk_status CHECK (status IN ('new', 'contacted', 'closed', 'rejected'));
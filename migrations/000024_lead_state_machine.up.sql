@@ -0,0 +1,12 @@
+-- Lead states are now defined by the application's state machine.
+ALTER TABLE marketing_customers
+DROP CONSTRAINT IF EXISTS chk_status;
+
+ALTER TABLE marketing_customers
+ADD COLUMN status_changed_at TIMESTAMP
+WITH
+    TIME ZONE;
+
+UPDATE marketing_customers
+SET
+    status_changed_at = updated_at;
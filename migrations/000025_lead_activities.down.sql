@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_lead_activities_marketing_id;
+DROP INDEX IF EXISTS idx_lead_activities_marketing_customer_id;
+DROP TABLE IF EXISTS lead_activities;
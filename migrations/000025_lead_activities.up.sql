@@ -0,0 +1,38 @@
+CREATE TABLE
+    lead_activities (
+        id BIGSERIAL PRIMARY KEY,
+        marketing_customer_id INT NOT NULL,
+        marketing_id INT NOT NULL,
+        activity_type VARCHAR(20) NOT NULL,
+        outcome VARCHAR(30) NOT NULL,
+        duration_minutes INT,
+        next_step TEXT,
+        notes TEXT,
+        latitude NUMERIC(9, 6),
+        longitude NUMERIC(9, 6),
+        location_name VARCHAR(255),
+        occurred_at TIMESTAMP
+        WITH
+            TIME ZONE NOT NULL,
+            created_at TIMESTAMP
+        WITH
+            TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            CONSTRAINT fk_lead_activities_marketing_customer FOREIGN KEY (marketing_customer_id) REFERENCES marketing_customers (id) ON UPDATE CASCADE ON DELETE CASCADE,
+            CONSTRAINT fk_lead_activities_marketing FOREIGN KEY (marketing_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE,
+            CONSTRAINT chk_activity_type CHECK (activity_type IN ('call', 'visit', 'whatsapp', 'email')),
+            CONSTRAINT chk_activity_outcome CHECK (
+                outcome IN (
+                    'reached',
+                    'no_answer',
+                    'callback_requested',
+                    'interested',
+                    'not_interested',
+                    'wrong_contact'
+                )
+            ),
+            CONSTRAINT chk_activity_duration CHECK (duration_minutes IS NULL OR duration_minutes >= 0)
+    );
+
+CREATE INDEX idx_lead_activities_marketing_customer_id ON lead_activities (marketing_customer_id, occurred_at);
+
+CREATE INDEX idx_lead_activities_marketing_id ON lead_activities (marketing_id, occurred_at);
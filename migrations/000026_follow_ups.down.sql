@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_follow_up_reminders_marketing_id;
+DROP TABLE IF EXISTS follow_up_reminders;
+DROP INDEX IF EXISTS idx_marketing_customers_next_follow_up_at;
+
+ALTER TABLE marketing_customers
+DROP COLUMN IF EXISTS follow_up_overdue_at,
+DROP COLUMN IF EXISTS follow_up_reason,
+DROP COLUMN IF EXISTS next_follow_up_at;
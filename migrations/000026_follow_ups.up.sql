@@ -0,0 +1,36 @@
+ALTER TABLE marketing_customers
+ADD COLUMN next_follow_up_at TIMESTAMP
+WITH
+    TIME ZONE,
+ADD COLUMN follow_up_reason VARCHAR(255),
+ADD COLUMN follow_up_overdue_at TIMESTAMP
+WITH
+    TIME ZONE;
+
+CREATE INDEX idx_marketing_customers_next_follow_up_at ON marketing_customers (next_follow_up_at)
+WHERE
+    next_follow_up_at IS NOT NULL
+    AND deleted_at IS NULL;
+
+CREATE TABLE
+    follow_up_reminders (
+        id BIGSERIAL PRIMARY KEY,
+        marketing_customer_id INT NOT NULL,
+        marketing_id INT NOT NULL,
+        due_at TIMESTAMP
+        WITH
+            TIME ZONE NOT NULL,
+            reason VARCHAR(255),
+            read_at TIMESTAMP
+        WITH
+            TIME ZONE,
+            created_at TIMESTAMP
+        WITH
+            TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            CONSTRAINT fk_follow_up_reminders_marketing_customer FOREIGN KEY (marketing_customer_id) REFERENCES marketing_customers (id) ON UPDATE CASCADE ON DELETE CASCADE,
+            CONSTRAINT fk_follow_up_reminders_marketing FOREIGN KEY (marketing_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE,
+            -- One reminder per scheduled follow-up, so the job can safely re-run.
+            CONSTRAINT uq_follow_up_reminders_due UNIQUE (marketing_customer_id, due_at)
+    );
+
+CREATE INDEX idx_follow_up_reminders_marketing_id ON follow_up_reminders (marketing_id, read_at);
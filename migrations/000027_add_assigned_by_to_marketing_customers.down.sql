@@ -0,0 +1,3 @@
+ALTER TABLE marketing_customers
+DROP CONSTRAINT IF EXISTS fk_marketing_customers_assigned_by,
+DROP COLUMN IF EXISTS assigned_by;
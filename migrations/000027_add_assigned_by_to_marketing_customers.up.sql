@@ -0,0 +1,8 @@
+ALTER TABLE marketing_customers
+ADD COLUMN assigned_by INT,
+ADD CONSTRAINT fk_marketing_customers_assigned_by FOREIGN KEY (assigned_by) REFERENCES users (id) ON UPDATE CASCADE ON DELETE SET NULL;
+
+-- Until now every lead was claimed by its own marketer.
+UPDATE marketing_customers
+SET
+    assigned_by = marketing_id;
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_lead_distribution_decisions_marketing_id;
+DROP INDEX IF EXISTS idx_lead_distribution_decisions_customer_id;
+DROP INDEX IF EXISTS idx_lead_distribution_decisions_run_id;
+DROP TABLE IF EXISTS lead_distribution_decisions;
+DROP TABLE IF EXISTS lead_distribution_runs;
+DROP TABLE IF EXISTS lead_distribution_caps;
+DROP TABLE IF EXISTS lead_distribution_settings;
@@ -0,0 +1,82 @@
+CREATE TABLE
+    lead_distribution_settings (
+        kantor_cabang_id INT PRIMARY KEY,
+        enabled BOOLEAN NOT NULL DEFAULT FALSE,
+        strategy VARCHAR(30) NOT NULL DEFAULT 'round_robin',
+        default_capacity INT,
+        updated_by INT,
+        created_at TIMESTAMP
+        WITH
+            TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            updated_at TIMESTAMP
+        WITH
+            TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            CONSTRAINT fk_lead_distribution_settings_kantor_cabang FOREIGN KEY (kantor_cabang_id) REFERENCES kantor_cabang (id) ON UPDATE CASCADE ON DELETE CASCADE,
+            CONSTRAINT fk_lead_distribution_settings_user FOREIGN KEY (updated_by) REFERENCES users (id) ON UPDATE CASCADE ON DELETE SET NULL,
+            CONSTRAINT chk_distribution_strategy CHECK (strategy IN ('round_robin', 'least_open_leads', 'capacity', 'target_gap')),
+            CONSTRAINT chk_distribution_default_capacity CHECK (default_capacity IS NULL OR default_capacity >= 0)
+    );
+
+-- Per-marketer caps on open leads override the branch default.
+CREATE TABLE
+    lead_distribution_caps (
+        marketing_id INT PRIMARY KEY,
+        max_open_leads INT NOT NULL,
+        updated_by INT,
+        updated_at TIMESTAMP
+        WITH
+            TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            CONSTRAINT fk_lead_distribution_caps_marketing FOREIGN KEY (marketing_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE,
+            CONSTRAINT fk_lead_distribution_caps_user FOREIGN KEY (updated_by) REFERENCES users (id) ON UPDATE CASCADE ON DELETE SET NULL,
+            CONSTRAINT chk_distribution_max_open_leads CHECK (max_open_leads >= 0)
+    );
+
+CREATE TABLE
+    lead_distribution_runs (
+        id BIGSERIAL PRIMARY KEY,
+        trigger VARCHAR(20) NOT NULL,
+        triggered_by INT,
+        dry_run BOOLEAN NOT NULL DEFAULT FALSE,
+        kantor_cabang_id INT,
+        status VARCHAR(20) NOT NULL,
+        assigned INT NOT NULL DEFAULT 0,
+        skipped INT NOT NULL DEFAULT 0,
+        error TEXT,
+        started_at TIMESTAMP
+        WITH
+            TIME ZONE NOT NULL,
+            finished_at TIMESTAMP
+        WITH
+            TIME ZONE,
+            CONSTRAINT fk_lead_distribution_runs_user FOREIGN KEY (triggered_by) REFERENCES users (id) ON UPDATE CASCADE ON DELETE SET NULL,
+            CONSTRAINT fk_lead_distribution_runs_kantor_cabang FOREIGN KEY (kantor_cabang_id) REFERENCES kantor_cabang (id) ON UPDATE CASCADE ON DELETE SET NULL
+    );
+
+CREATE TABLE
+    lead_distribution_decisions (
+        id BIGSERIAL PRIMARY KEY,
+        run_id BIGINT NOT NULL,
+        customer_id BIGINT NOT NULL,
+        kantor_cabang_id INT,
+        marketing_id INT,
+        marketing_customer_id INT,
+        strategy VARCHAR(30),
+        outcome VARCHAR(20) NOT NULL,
+        product_id INT,
+        detail JSONB,
+        created_at TIMESTAMP
+        WITH
+            TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            CONSTRAINT fk_lead_distribution_decisions_run FOREIGN KEY (run_id) REFERENCES lead_distribution_runs (id) ON UPDATE CASCADE ON DELETE CASCADE,
+            CONSTRAINT fk_lead_distribution_decisions_customer FOREIGN KEY (customer_id) REFERENCES customers (id) ON UPDATE CASCADE ON DELETE CASCADE,
+            CONSTRAINT fk_lead_distribution_decisions_kantor_cabang FOREIGN KEY (kantor_cabang_id) REFERENCES kantor_cabang (id) ON UPDATE CASCADE ON DELETE SET NULL,
+            CONSTRAINT fk_lead_distribution_decisions_marketing FOREIGN KEY (marketing_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE SET NULL,
+            CONSTRAINT fk_lead_distribution_decisions_marketing_customer FOREIGN KEY (marketing_customer_id) REFERENCES marketing_customers (id) ON UPDATE CASCADE ON DELETE SET NULL,
+            CONSTRAINT chk_distribution_outcome CHECK (outcome IN ('assigned', 'no_capacity'))
+    );
+
+CREATE INDEX idx_lead_distribution_decisions_run_id ON lead_distribution_decisions (run_id);
+
+CREATE INDEX idx_lead_distribution_decisions_customer_id ON lead_distribution_decisions (customer_id);
+
+CREATE INDEX idx_lead_distribution_decisions_marketing_id ON lead_distribution_decisions (marketing_id, created_at);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_marketing_customers_reserved_until;
+
+ALTER TABLE marketing_customers
+DROP COLUMN IF EXISTS reserved_until;
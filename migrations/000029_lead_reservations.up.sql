@@ -0,0 +1,19 @@
+-- Open leads are reserved for their marketer until reserved_until. Activity
+-- and status progress extend the reservation; finished leads have none.
+ALTER TABLE marketing_customers
+ADD COLUMN reserved_until TIMESTAMP
+WITH
+    TIME ZONE;
+
+-- Give leads that are already open a fresh week before they can expire.
+UPDATE marketing_customers
+SET
+    reserved_until = NOW() + INTERVAL '7 days'
+WHERE
+    deleted_at IS NULL
+    AND status NOT IN ('closed', 'rejected');
+
+CREATE INDEX idx_marketing_customers_reserved_until ON marketing_customers (reserved_until)
+WHERE
+    deleted_at IS NULL
+    AND reserved_until IS NOT NULL;
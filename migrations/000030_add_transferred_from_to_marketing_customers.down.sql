@@ -0,0 +1,3 @@
+ALTER TABLE marketing_customers
+DROP CONSTRAINT IF EXISTS fk_marketing_customer_transferred_from,
+DROP COLUMN IF EXISTS transferred_from_id;
@@ -0,0 +1,5 @@
+-- A transferred lead continues on a new row for the receiving marketer; the
+-- previous row is soft-deleted and keeps its history and closings.
+ALTER TABLE marketing_customers
+ADD COLUMN transferred_from_id INT,
+ADD CONSTRAINT fk_marketing_customer_transferred_from FOREIGN KEY (transferred_from_id) REFERENCES marketing_customers (id) ON UPDATE CASCADE ON DELETE SET NULL;
@@ -0,0 +1,24 @@
+CREATE OR REPLACE FUNCTION prevent_mc_event_update () RETURNS TRIGGER AS $$
+BEGIN
+    IF (NEW.marketing_customer_id, NEW.event_type, NEW.old_status, NEW.new_status, NEW.actor_id,
+        NEW.product_id, NEW.amount, NEW.channel, NEW.created_at)
+       IS DISTINCT FROM
+       (OLD.marketing_customer_id, OLD.event_type, OLD.old_status, OLD.new_status, OLD.actor_id,
+        OLD.product_id, OLD.amount, OLD.channel, OLD.created_at) THEN
+        RAISE EXCEPTION 'marketing_customer_events is append-only';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP INDEX IF EXISTS idx_marketing_customer_events_rejection_reason;
+
+ALTER TABLE marketing_customer_events
+DROP CONSTRAINT IF EXISTS fk_marketing_customer_event_rejection_reason,
+DROP COLUMN IF EXISTS rejection_reason_id;
+
+ALTER TABLE marketing_customers
+DROP CONSTRAINT IF EXISTS fk_marketing_customer_rejection_reason,
+DROP COLUMN IF EXISTS rejection_reason_id;
+
+DROP TABLE IF EXISTS rejection_reasons;
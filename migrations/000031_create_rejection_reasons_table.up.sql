@@ -0,0 +1,50 @@
+CREATE TABLE
+    rejection_reasons (
+        id SERIAL PRIMARY KEY,
+        code VARCHAR(50) NOT NULL UNIQUE,
+        label VARCHAR(100) NOT NULL,
+        active BOOLEAN NOT NULL DEFAULT TRUE,
+        sort_order INT NOT NULL DEFAULT 0,
+        created_at TIMESTAMP
+        WITH
+            TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            updated_at TIMESTAMP
+        WITH
+            TIME ZONE DEFAULT CURRENT_TIMESTAMP
+    );
+
+INSERT INTO
+    rejection_reasons (code, label, sort_order)
+VALUES
+    ('margin_too_high', 'Suku margin terlalu tinggi', 10),
+    ('has_other_bank', 'Sudah punya di bank lain', 20),
+    ('age_not_eligible', 'Tidak memenuhi syarat usia', 30),
+    ('income_not_eligible', 'Penghasilan tidak memenuhi syarat', 40),
+    ('not_needed', 'Belum membutuhkan produk', 50),
+    ('unreachable', 'Tidak dapat dihubungi', 60),
+    ('other', 'Lainnya', 100);
+
+ALTER TABLE marketing_customers
+ADD COLUMN rejection_reason_id INT,
+ADD CONSTRAINT fk_marketing_customer_rejection_reason FOREIGN KEY (rejection_reason_id) REFERENCES rejection_reasons (id) ON UPDATE CASCADE ON DELETE SET NULL;
+
+ALTER TABLE marketing_customer_events
+ADD COLUMN rejection_reason_id INT,
+ADD CONSTRAINT fk_marketing_customer_event_rejection_reason FOREIGN KEY (rejection_reason_id) REFERENCES rejection_reasons (id) ON UPDATE CASCADE ON DELETE RESTRICT;
+
+CREATE INDEX idx_marketing_customer_events_rejection_reason ON marketing_customer_events (rejection_reason_id, created_at)
+WHERE
+    rejection_reason_id IS NOT NULL;
+
+CREATE OR REPLACE FUNCTION prevent_mc_event_update () RETURNS TRIGGER AS $$
+BEGIN
+    IF (NEW.marketing_customer_id, NEW.event_type, NEW.old_status, NEW.new_status, NEW.actor_id,
+        NEW.product_id, NEW.amount, NEW.channel, NEW.rejection_reason_id, NEW.created_at)
+       IS DISTINCT FROM
+       (OLD.marketing_customer_id, OLD.event_type, OLD.old_status, OLD.new_status, OLD.actor_id,
+        OLD.product_id, OLD.amount, OLD.channel, OLD.rejection_reason_id, OLD.created_at) THEN
+        RAISE EXCEPTION 'marketing_customer_events is append-only';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
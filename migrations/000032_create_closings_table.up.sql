@@ -0,0 +1,81 @@
+-- A closing is one product a customer took up through a lead. A lead can
+-- carry several, so closing one product leaves the other recommendations
+-- open. Achievement is attributed to the marketer who booked the closing,
+-- on the business date it closed.
+CREATE TABLE
+    closings (
+        id BIGSERIAL PRIMARY KEY,
+        marketing_customer_id INT NOT NULL,
+        customer_id BIGINT NOT NULL,
+        marketing_id INT NOT NULL,
+        product_id INT NOT NULL,
+        amount BIGINT NOT NULL CHECK (amount > 0),
+        tenor_months INT CHECK (tenor_months > 0),
+        closed_at DATE NOT NULL,
+        account_reference VARCHAR(50),
+        notes TEXT,
+        created_by INT,
+        created_at TIMESTAMP
+        WITH
+            TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            updated_at TIMESTAMP
+        WITH
+            TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            deleted_at TIMESTAMP
+        WITH
+            TIME ZONE,
+            CONSTRAINT fk_closing_marketing_customer FOREIGN KEY (marketing_customer_id) REFERENCES marketing_customers (id) ON UPDATE CASCADE ON DELETE RESTRICT,
+            CONSTRAINT fk_closing_customer FOREIGN KEY (customer_id) REFERENCES customers (id) ON UPDATE CASCADE ON DELETE RESTRICT,
+            CONSTRAINT fk_closing_marketing FOREIGN KEY (marketing_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE RESTRICT,
+            CONSTRAINT fk_closing_product FOREIGN KEY (product_id) REFERENCES products (id) ON UPDATE CASCADE ON DELETE RESTRICT,
+            CONSTRAINT fk_closing_created_by FOREIGN KEY (created_by) REFERENCES users (id) ON UPDATE CASCADE ON DELETE SET NULL
+    );
+
+CREATE INDEX idx_closings_marketing_customer ON closings (marketing_customer_id)
+WHERE
+    deleted_at IS NULL;
+
+CREATE INDEX idx_closings_marketing_closed_at ON closings (marketing_id, closed_at)
+WHERE
+    deleted_at IS NULL;
+
+CREATE INDEX idx_closings_product_closed_at ON closings (product_id, closed_at)
+WHERE
+    deleted_at IS NULL;
+
+-- An account can only be booked once per product.
+CREATE UNIQUE INDEX idx_unique_closing_account ON closings (product_id, account_reference)
+WHERE
+    account_reference IS NOT NULL
+    AND deleted_at IS NULL;
+
+-- Every lead closed before line items existed becomes its single closing.
+INSERT INTO
+    closings (
+        marketing_customer_id,
+        customer_id,
+        marketing_id,
+        product_id,
+        amount,
+        closed_at,
+        created_by,
+        created_at,
+        updated_at
+    )
+SELECT
+    mc.id,
+    mc.customer_id,
+    mc.marketing_id,
+    mc.product_id,
+    mc.amount,
+    mc.updated_at::date,
+    mc.marketing_id,
+    mc.updated_at,
+    mc.updated_at
+FROM
+    marketing_customers mc
+WHERE
+    mc.status = 'closed'
+    AND mc.product_id IS NOT NULL
+    AND mc.amount > 0
+    AND mc.deleted_at IS NULL;
@@ -0,0 +1,2 @@
+-- Re-dated closings keep their business date.
+DROP TABLE IF EXISTS closing_periods;
@@ -0,0 +1,39 @@
+-- A locked period no longer accepts closings dated in it, and its closings
+-- can no longer be voided, so reported achievement stays fixed.
+CREATE TABLE
+    closing_periods (
+        id SERIAL PRIMARY KEY,
+        tahun INT NOT NULL,
+        bulan INT NOT NULL CHECK (bulan BETWEEN 1 AND 12),
+        locked_at TIMESTAMP
+        WITH
+            TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+            locked_by INT,
+            CONSTRAINT uq_closing_period UNIQUE (tahun, bulan),
+            CONSTRAINT fk_closing_period_locked_by FOREIGN KEY (locked_by) REFERENCES users (id) ON UPDATE CASCADE ON DELETE SET NULL
+    );
+
+-- Closings backfilled from leads were dated by updated_at, which moves with
+-- any later edit of the lead. Re-date them to the day the lead was moved to
+-- closed, falling back to its last status change.
+UPDATE closings cl
+SET
+    closed_at = COALESCE(ev.closed_on, mc.status_changed_at::date, cl.closed_at)
+FROM
+    marketing_customers mc
+    LEFT JOIN (
+        SELECT
+            e.marketing_customer_id,
+            MAX(e.created_at)::date AS closed_on
+        FROM
+            marketing_customer_events e
+        WHERE
+            e.event_type = 'status_changed'
+            AND e.new_status = 'closed'
+        GROUP BY
+            e.marketing_customer_id
+    ) ev ON ev.marketing_customer_id = mc.id
+WHERE
+    mc.id = cl.marketing_customer_id
+    AND cl.created_at = mc.updated_at
+    AND cl.closed_at = mc.updated_at::date;
@@ -0,0 +1,17 @@
+DROP INDEX IF EXISTS idx_closings_pending;
+
+DROP INDEX IF EXISTS idx_unique_closing_account;
+
+CREATE UNIQUE INDEX idx_unique_closing_account ON closings (product_id, account_reference)
+WHERE
+    account_reference IS NOT NULL
+    AND deleted_at IS NULL;
+
+ALTER TABLE closings
+DROP CONSTRAINT IF EXISTS fk_closing_verified_by,
+DROP CONSTRAINT IF EXISTS chk_closing_verification_status,
+DROP COLUMN IF EXISTS verification_note,
+DROP COLUMN IF EXISTS verified_at,
+DROP COLUMN IF EXISTS verified_by,
+DROP COLUMN IF EXISTS evidence_reference,
+DROP COLUMN IF EXISTS verification_status;
@@ -0,0 +1,35 @@
+-- Closings wait for the BM to verify them before they count toward
+-- achievement. Closings booked before verification existed already counted
+-- and are treated as verified.
+ALTER TABLE closings
+ADD COLUMN verification_status VARCHAR(20) NOT NULL DEFAULT 'pending_verification',
+ADD COLUMN evidence_reference VARCHAR(100),
+ADD COLUMN verified_by INT,
+ADD COLUMN verified_at TIMESTAMP
+WITH
+    TIME ZONE,
+ADD COLUMN verification_note TEXT,
+ADD CONSTRAINT chk_closing_verification_status CHECK (
+    verification_status IN ('pending_verification', 'verified', 'rejected')
+),
+ADD CONSTRAINT fk_closing_verified_by FOREIGN KEY (verified_by) REFERENCES users (id) ON UPDATE CASCADE ON DELETE SET NULL;
+
+UPDATE closings
+SET
+    verification_status = 'verified',
+    verified_at = created_at;
+
+CREATE INDEX idx_closings_pending ON closings (marketing_id, created_at)
+WHERE
+    verification_status = 'pending_verification'
+    AND deleted_at IS NULL;
+
+-- A rejected closing no longer holds its account reference, so the marketer
+-- can book it again with corrected details.
+DROP INDEX IF EXISTS idx_unique_closing_account;
+
+CREATE UNIQUE INDEX idx_unique_closing_account ON closings (product_id, account_reference)
+WHERE
+    account_reference IS NOT NULL
+    AND verification_status <> 'rejected'
+    AND deleted_at IS NULL;
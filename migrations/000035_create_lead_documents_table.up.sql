@@ -0,0 +1,32 @@
+-- Documents a marketer collects on a lead, e.g. the customer's KTP, salary
+-- slip or signed application. The file itself lives in the configured
+-- storage under storage_key.
+CREATE TABLE
+    lead_documents (
+        id BIGSERIAL PRIMARY KEY,
+        marketing_customer_id INT NOT NULL,
+        customer_id BIGINT NOT NULL,
+        document_type VARCHAR(30) NOT NULL,
+        file_name VARCHAR(255) NOT NULL,
+        content_type VARCHAR(100) NOT NULL,
+        size_bytes BIGINT NOT NULL,
+        checksum_sha256 CHAR(64) NOT NULL,
+        storage_key VARCHAR(255) NOT NULL UNIQUE,
+        uploaded_by INT NOT NULL,
+        created_at TIMESTAMP
+        WITH
+            TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            deleted_at TIMESTAMP
+        WITH
+            TIME ZONE,
+            CONSTRAINT chk_lead_document_type CHECK (
+                document_type IN ('ktp', 'slip_gaji', 'signed_application', 'other')
+            ),
+            CONSTRAINT fk_lead_document_marketing_customer FOREIGN KEY (marketing_customer_id) REFERENCES marketing_customers (id) ON UPDATE CASCADE ON DELETE RESTRICT,
+            CONSTRAINT fk_lead_document_customer FOREIGN KEY (customer_id) REFERENCES customers (id) ON UPDATE CASCADE ON DELETE RESTRICT,
+            CONSTRAINT fk_lead_document_uploaded_by FOREIGN KEY (uploaded_by) REFERENCES users (id) ON UPDATE CASCADE ON DELETE RESTRICT
+    );
+
+CREATE INDEX idx_lead_documents_customer ON lead_documents (customer_id, created_at)
+WHERE
+    deleted_at IS NULL;
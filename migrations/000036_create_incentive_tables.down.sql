@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS incentive_statement_lines;
+DROP TABLE IF EXISTS incentive_statements;
+DROP TABLE IF EXISTS incentive_scheme_tiers;
+DROP TABLE IF EXISTS incentive_schemes;
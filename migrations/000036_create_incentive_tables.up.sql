@@ -0,0 +1,91 @@
+-- An incentive scheme pays marketers for one product over a range of months:
+-- the highest attainment tier reached, a flat fee per verified closing, and
+-- at most cap_amount per month in total. Months are stored as their first
+-- day; effective_to is the last month included, NULL while open-ended.
+CREATE TABLE
+    incentive_schemes (
+        id SERIAL PRIMARY KEY,
+        product_id INT NOT NULL,
+        name VARCHAR(100) NOT NULL,
+        effective_from DATE NOT NULL,
+        effective_to DATE,
+        per_closing_fee BIGINT NOT NULL DEFAULT 0 CHECK (per_closing_fee >= 0),
+        cap_amount BIGINT CHECK (cap_amount > 0),
+        created_by INT,
+        created_at TIMESTAMP
+        WITH
+            TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            updated_at TIMESTAMP
+        WITH
+            TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            deleted_at TIMESTAMP
+        WITH
+            TIME ZONE,
+            CONSTRAINT chk_incentive_scheme_range CHECK (effective_to IS NULL OR effective_to >= effective_from),
+            CONSTRAINT fk_incentive_schemes_product FOREIGN KEY (product_id) REFERENCES products (id) ON UPDATE CASCADE ON DELETE CASCADE,
+            CONSTRAINT fk_incentive_schemes_created_by FOREIGN KEY (created_by) REFERENCES users (id) ON UPDATE CASCADE ON DELETE SET NULL
+    );
+
+CREATE INDEX idx_incentive_schemes_product ON incentive_schemes (product_id, effective_from);
+
+-- A tier pays fixed_amount plus rate_percent of the achieved amount once
+-- attainment reaches min_attainment percent.
+CREATE TABLE
+    incentive_scheme_tiers (
+        id SERIAL PRIMARY KEY,
+        scheme_id INT NOT NULL,
+        min_attainment NUMERIC(6, 2) NOT NULL CHECK (min_attainment >= 0),
+        fixed_amount BIGINT NOT NULL DEFAULT 0 CHECK (fixed_amount >= 0),
+        rate_percent NUMERIC(6, 3) NOT NULL DEFAULT 0 CHECK (rate_percent >= 0),
+        CONSTRAINT uq_incentive_scheme_tier UNIQUE (scheme_id, min_attainment),
+        CONSTRAINT fk_incentive_scheme_tiers_scheme FOREIGN KEY (scheme_id) REFERENCES incentive_schemes (id) ON UPDATE CASCADE ON DELETE CASCADE
+    );
+
+CREATE TABLE
+    incentive_statements (
+        id BIGSERIAL PRIMARY KEY,
+        marketing_id INT NOT NULL,
+        kantor_cabang_id INT,
+        tahun INT NOT NULL,
+        bulan INT NOT NULL CHECK (bulan BETWEEN 1 AND 12),
+        total_amount BIGINT NOT NULL DEFAULT 0,
+        status VARCHAR(20) NOT NULL DEFAULT 'pending_approval',
+        calculated_at TIMESTAMP
+        WITH
+            TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+            reviewed_by INT,
+            reviewed_at TIMESTAMP
+        WITH
+            TIME ZONE,
+            review_note TEXT,
+            CONSTRAINT uq_incentive_statement UNIQUE (marketing_id, tahun, bulan),
+            CONSTRAINT chk_incentive_statement_status CHECK (status IN ('pending_approval', 'approved', 'rejected')),
+            CONSTRAINT fk_incentive_statements_marketing FOREIGN KEY (marketing_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE,
+            CONSTRAINT fk_incentive_statements_kantor_cabang FOREIGN KEY (kantor_cabang_id) REFERENCES kantor_cabang (id) ON UPDATE CASCADE ON DELETE SET NULL,
+            CONSTRAINT fk_incentive_statements_reviewed_by FOREIGN KEY (reviewed_by) REFERENCES users (id) ON UPDATE CASCADE ON DELETE SET NULL
+    );
+
+CREATE INDEX idx_incentive_statements_period ON incentive_statements (tahun, bulan, kantor_cabang_id);
+
+-- Lines justify a statement. Closing lines keep the closing id without a
+-- foreign key so an approved statement stays as it was paid.
+CREATE TABLE
+    incentive_statement_lines (
+        id BIGSERIAL PRIMARY KEY,
+        statement_id BIGINT NOT NULL,
+        scheme_id INT,
+        product_id INT,
+        line_type VARCHAR(20) NOT NULL,
+        description TEXT NOT NULL,
+        closing_id BIGINT,
+        target_amount BIGINT NOT NULL DEFAULT 0,
+        achieved_amount BIGINT NOT NULL DEFAULT 0,
+        attainment NUMERIC(8, 2) NOT NULL DEFAULT 0,
+        amount BIGINT NOT NULL,
+        CONSTRAINT chk_incentive_line_type CHECK (line_type IN ('tier', 'closing_fee', 'cap')),
+        CONSTRAINT fk_incentive_lines_statement FOREIGN KEY (statement_id) REFERENCES incentive_statements (id) ON UPDATE CASCADE ON DELETE CASCADE,
+        CONSTRAINT fk_incentive_lines_scheme FOREIGN KEY (scheme_id) REFERENCES incentive_schemes (id) ON UPDATE CASCADE ON DELETE SET NULL,
+        CONSTRAINT fk_incentive_lines_product FOREIGN KEY (product_id) REFERENCES products (id) ON UPDATE CASCADE ON DELETE SET NULL
+    );
+
+CREATE INDEX idx_incentive_statement_lines_statement ON incentive_statement_lines (statement_id);
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS customer_branch_rules;
+
+DROP INDEX IF EXISTS idx_customers_kantor_cabang;
+
+ALTER TABLE customers
+DROP CONSTRAINT IF EXISTS fk_customer_kantor_cabang,
+DROP COLUMN IF EXISTS kantor_cabang_id;
@@ -0,0 +1,39 @@
+-- Customers belong to the pool of one branch. Customers already held by a
+-- marketer are placed in that marketer's branch; the rest stay unrouted
+-- until a routing rule or an admin places them.
+ALTER TABLE customers
+ADD COLUMN kantor_cabang_id INT,
+ADD CONSTRAINT fk_customer_kantor_cabang FOREIGN KEY (kantor_cabang_id) REFERENCES kantor_cabang (id) ON UPDATE CASCADE ON DELETE SET NULL;
+
+UPDATE customers c
+SET
+    kantor_cabang_id = u.kantor_cabang_id
+FROM
+    marketing_customers mc
+    JOIN users u ON u.id = mc.marketing_id
+WHERE
+    mc.customer_id = c.id
+    AND mc.deleted_at IS NULL;
+
+CREATE INDEX idx_customers_kantor_cabang ON customers (kantor_cabang_id)
+WHERE
+    deleted_at IS NULL;
+
+-- Rules that route new and imported customers to a branch, either by the
+-- company paying their salary or by a fragment of their address.
+CREATE TABLE
+    customer_branch_rules (
+        id SERIAL PRIMARY KEY,
+        kantor_cabang_id INT NOT NULL,
+        match_field VARCHAR(20) NOT NULL,
+        pattern VARCHAR(100) NOT NULL,
+        created_by INT,
+        created_at TIMESTAMP
+        WITH
+            TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            CONSTRAINT chk_customer_branch_rule_field CHECK (match_field IN ('company', 'address')),
+            CONSTRAINT fk_customer_branch_rule_kantor_cabang FOREIGN KEY (kantor_cabang_id) REFERENCES kantor_cabang (id) ON UPDATE CASCADE ON DELETE CASCADE,
+            CONSTRAINT fk_customer_branch_rule_created_by FOREIGN KEY (created_by) REFERENCES users (id) ON UPDATE CASCADE ON DELETE SET NULL
+    );
+
+CREATE UNIQUE INDEX idx_unique_customer_branch_rule ON customer_branch_rules (match_field, LOWER(pattern));
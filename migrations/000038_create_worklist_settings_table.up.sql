@@ -0,0 +1,26 @@
+-- Ranking weights of the marketers' daily worklist, per branch. Branches
+-- without a row use the defaults of the application.
+CREATE TABLE
+    worklist_settings (
+        kantor_cabang_id INT PRIMARY KEY,
+        weight_score INT NOT NULL,
+        weight_plafond INT NOT NULL,
+        weight_follow_up INT NOT NULL,
+        weight_target_gap INT NOT NULL,
+        updated_by INT,
+        created_at TIMESTAMP
+        WITH
+            TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            updated_at TIMESTAMP
+        WITH
+            TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            CONSTRAINT fk_worklist_settings_kantor_cabang FOREIGN KEY (kantor_cabang_id) REFERENCES kantor_cabang (id) ON UPDATE CASCADE ON DELETE CASCADE,
+            CONSTRAINT fk_worklist_settings_user FOREIGN KEY (updated_by) REFERENCES users (id) ON UPDATE CASCADE ON DELETE SET NULL,
+            CONSTRAINT chk_worklist_weights CHECK (
+                weight_score BETWEEN 0 AND 100
+                AND weight_plafond BETWEEN 0 AND 100
+                AND weight_follow_up BETWEEN 0 AND 100
+                AND weight_target_gap BETWEEN 0 AND 100
+                AND weight_score + weight_plafond + weight_follow_up + weight_target_gap > 0
+            )
+    );
@@ -0,0 +1,3 @@
+ALTER TABLE pii_access_logs
+ADD CONSTRAINT fk_pii_access_logs_viewer FOREIGN KEY (viewer_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE NOT VALID,
+ADD CONSTRAINT fk_pii_access_logs_customer FOREIGN KEY (customer_id) REFERENCES customers (id) ON UPDATE CASCADE ON DELETE CASCADE NOT VALID;
@@ -0,0 +1,7 @@
+-- The access log must outlive both the viewer and the customer it names:
+-- purging a customer or deleting a user would otherwise erase the record of
+-- who saw the unmasked data. The ids are kept as plain references, like
+-- retention_run_items.customer_id.
+ALTER TABLE pii_access_logs
+DROP CONSTRAINT IF EXISTS fk_pii_access_logs_viewer,
+DROP CONSTRAINT IF EXISTS fk_pii_access_logs_customer;
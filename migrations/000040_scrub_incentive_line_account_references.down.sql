@@ -0,0 +1 @@
+-- The removed account numbers cannot be restored.
@@ -0,0 +1,9 @@
+-- Closing fee lines used to quote the closing's account number. The closing
+-- id on the line is enough to trace it, so the number is removed from lines
+-- already written.
+UPDATE incentive_statement_lines
+SET
+    description = regexp_replace(description, ' \(rekening [^)]*\)$', '')
+WHERE
+    line_type = 'closing_fee'
+    AND description ~ ' \(rekening [^)]*\)$';
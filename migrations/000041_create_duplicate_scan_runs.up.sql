@@ -0,0 +1,19 @@
+CREATE TABLE
+    duplicate_scan_runs (
+        id BIGSERIAL PRIMARY KEY,
+        trigger VARCHAR(20) NOT NULL,
+        triggered_by INT,
+        status VARCHAR(20) NOT NULL DEFAULT 'running',
+        scanned INT NOT NULL DEFAULT 0,
+        candidates INT NOT NULL DEFAULT 0,
+        saved BIGINT NOT NULL DEFAULT 0,
+        error TEXT,
+        started_at TIMESTAMP
+        WITH
+            TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+            finished_at TIMESTAMP
+        WITH
+            TIME ZONE,
+            CONSTRAINT fk_duplicate_scan_runs_user FOREIGN KEY (triggered_by) REFERENCES users (id) ON UPDATE CASCADE ON DELETE SET NULL,
+            CONSTRAINT chk_duplicate_scan_run_status CHECK (status IN ('running', 'succeeded', 'failed'))
+    );
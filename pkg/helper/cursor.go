@@ -0,0 +1,33 @@
+package helper
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidCursor is returned by DecodeCursor for a token it did not issue,
+// e.g. one edited or truncated by the client.
+var ErrInvalidCursor = errors.New("cursor tidak valid")
+
+// EncodeCursor serializes a keyset position into an opaque, URL-safe token.
+func EncodeCursor(position interface{}) (string, error) {
+	raw, err := json.Marshal(position)
+	if err != nil {
+		return "", fmt.Errorf("gagal membuat cursor: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// DecodeCursor restores a keyset position previously produced by EncodeCursor.
+func DecodeCursor(cursor string, position interface{}) error {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	if err := json.Unmarshal(raw, position); err != nil {
+		return ErrInvalidCursor
+	}
+	return nil
+}
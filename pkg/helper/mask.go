@@ -0,0 +1,47 @@
+package helper
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// MaskPhone keeps the first four and last three digits, e.g. 0812*****789.
+func MaskPhone(phone string) string {
+	return maskMiddle(phone, 4, 3)
+}
+
+// MaskAccount keeps only the last four characters of an account number.
+func MaskAccount(account string) string {
+	return maskMiddle(account, 0, 4)
+}
+
+// MaskEmail keeps the first character of the local part and the domain,
+// e.g. j*******@example.com.
+func MaskEmail(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok {
+		return maskMiddle(email, 1, 0)
+	}
+	return maskMiddle(local, 1, 0) + "@" + domain
+}
+
+// MaskAddress keeps the first word so the general area stays recognisable.
+func MaskAddress(address string) string {
+	if address == "" {
+		return ""
+	}
+	first, _, _ := strings.Cut(strings.TrimSpace(address), " ")
+	return first + " ***"
+}
+
+func maskMiddle(value string, keepStart, keepEnd int) string {
+	length := utf8.RuneCountInString(value)
+	if length == 0 {
+		return ""
+	}
+	if keepStart+keepEnd >= length {
+		return strings.Repeat("*", length)
+	}
+	runes := []rune(value)
+	return string(runes[:keepStart]) + strings.Repeat("*", length-keepStart-keepEnd) + string(runes[length-keepEnd:])
+}
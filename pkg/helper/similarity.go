@@ -0,0 +1,103 @@
+package helper
+
+import (
+	"strings"
+	"unicode"
+)
+
+// nameNoise lists honorifics and titles that are dropped before names are
+// compared, so "Bpk. H. Ahmad S.E." and "Ahmad" are treated alike.
+var nameNoise = map[string]bool{
+	"bapak": true, "bpk": true, "ibu": true, "sdr": true, "sdri": true,
+	"h": true, "hj": true, "dr": true, "ir": true, "drs": true,
+	"se": true, "sh": true, "st": true, "skom": true, "mm": true,
+}
+
+// NormalizeName lowercases a person or company name, strips punctuation and
+// common titles, and collapses whitespace.
+func NormalizeName(name string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			return unicode.ToLower(r)
+		case unicode.IsSpace(r):
+			return ' '
+		default:
+			return -1
+		}
+	}, name)
+
+	words := strings.Fields(cleaned)
+	kept := words[:0]
+	for _, word := range words {
+		if !nameNoise[word] {
+			kept = append(kept, word)
+		}
+	}
+	return strings.Join(kept, " ")
+}
+
+// NameSimilarity returns the Jaro-Winkler similarity of two normalised names,
+// from 0 (nothing in common) to 1 (identical).
+func NameSimilarity(a, b string) float64 {
+	a, b = NormalizeName(a), NormalizeName(b)
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+
+	ra, rb := []rune(a), []rune(b)
+	jaro := jaroSimilarity(ra, rb)
+
+	prefix := 0
+	for prefix < 4 && prefix < len(ra) && prefix < len(rb) && ra[prefix] == rb[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+func jaroSimilarity(a, b []rune) float64 {
+	window := max(len(a), len(b))/2 - 1
+	if window < 0 {
+		window = 0
+	}
+
+	matchedA := make([]bool, len(a))
+	matchedB := make([]bool, len(b))
+	matches := 0
+	for i := range a {
+		start := max(0, i-window)
+		end := min(len(b), i+window+1)
+		for j := start; j < end; j++ {
+			if matchedB[j] || a[i] != b[j] {
+				continue
+			}
+			matchedA[i], matchedB[j] = true, true
+			matches++
+			break
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	transpositions := 0
+	j := 0
+	for i := range a {
+		if !matchedA[i] {
+			continue
+		}
+		for !matchedB[j] {
+			j++
+		}
+		if a[i] != b[j] {
+			transpositions++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	return (m/float64(len(a)) + m/float64(len(b)) + (m-float64(transpositions)/2)/m) / 3
+}
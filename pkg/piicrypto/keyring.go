@@ -0,0 +1,320 @@
+// Package piicrypto implements application-level envelope encryption and
+// HMAC blind indexes for customer PII columns.
+//
+// Every value is encrypted with its own random data key (AES-256-GCM). The data
+// key is wrapped with a versioned master key from configuration, so master keys
+// can be rotated by re-wrapping rows without any downtime: old key versions
+// remain readable for as long as they stay in the keyring.
+package piicrypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+const (
+	prefix  = "enc"
+	version = "v1"
+
+	// devIndexKey keeps blind indexes deterministic on developer machines that
+	// run without any PII keys configured. It is never used in production.
+	devIndexKey = "ml-prediction-dev-blind-index"
+)
+
+var (
+	defaultMu      sync.RWMutex
+	defaultKeyring = &Keyring{indexKey: []byte(devIndexKey)}
+)
+
+// Keyring holds the master keys used to wrap data keys and the key used to
+// compute blind indexes.
+type Keyring struct {
+	activeID string
+	keys     map[string][]byte
+	indexKey []byte
+}
+
+// Options describes where master keys come from. KeyFile takes precedence over
+// Keys when both are set.
+type Options struct {
+	// Keys lists master keys as "v1:<base64>,v2:<base64>".
+	Keys string
+	// ActiveKeyID selects the master key for new encryptions.
+	ActiveKeyID string
+	// KeyFile is a JSON file with "active", "keys" and "blind_index_key".
+	KeyFile string
+	// BlindIndexKey is the base64 HMAC key for blind indexes.
+	BlindIndexKey string
+}
+
+type keyFile struct {
+	Active        string            `json:"active"`
+	Keys          map[string]string `json:"keys"`
+	BlindIndexKey string            `json:"blind_index_key"`
+}
+
+// NewKeyring builds a keyring from raw 32-byte master keys. activeID selects
+// the key used for new encryptions.
+func NewKeyring(activeID string, keys map[string][]byte, indexKey []byte) (*Keyring, error) {
+	if len(keys) == 0 {
+		if len(indexKey) == 0 {
+			indexKey = []byte(devIndexKey)
+		}
+		return &Keyring{keys: map[string][]byte{}, indexKey: indexKey}, nil
+	}
+	for id, key := range keys {
+		if strings.Contains(id, ":") || id == "" {
+			return nil, fmt.Errorf("id master key tidak valid: %q", id)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("master key %s harus 32 byte", id)
+		}
+	}
+	if _, ok := keys[activeID]; !ok {
+		return nil, fmt.Errorf("master key aktif %q tidak ditemukan", activeID)
+	}
+	if len(indexKey) < 32 {
+		return nil, errors.New("blind index key minimal 32 byte")
+	}
+	return &Keyring{activeID: activeID, keys: keys, indexKey: indexKey}, nil
+}
+
+// LoadKeyring reads master keys from the key file when configured, otherwise
+// from the inline key list.
+func LoadKeyring(opts Options) (*Keyring, error) {
+	activeID := opts.ActiveKeyID
+	encodedKeys := map[string]string{}
+	encodedIndexKey := opts.BlindIndexKey
+
+	if opts.KeyFile != "" {
+		raw, err := os.ReadFile(opts.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("gagal membaca file kunci PII: %v", err)
+		}
+		var file keyFile
+		if err := json.Unmarshal(raw, &file); err != nil {
+			return nil, fmt.Errorf("format file kunci PII tidak valid: %v", err)
+		}
+		encodedKeys = file.Keys
+		if file.Active != "" {
+			activeID = file.Active
+		}
+		if file.BlindIndexKey != "" {
+			encodedIndexKey = file.BlindIndexKey
+		}
+	} else if opts.Keys != "" {
+		for _, entry := range strings.Split(opts.Keys, ",") {
+			id, key, ok := strings.Cut(strings.TrimSpace(entry), ":")
+			if !ok {
+				return nil, fmt.Errorf("format PII_KEYS tidak valid: %q", entry)
+			}
+			encodedKeys[id] = key
+		}
+	}
+
+	keys := make(map[string][]byte, len(encodedKeys))
+	for id, encoded := range encodedKeys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("master key %s bukan base64 yang valid", id)
+		}
+		keys[id] = key
+	}
+
+	var indexKey []byte
+	if encodedIndexKey != "" {
+		decoded, err := base64.StdEncoding.DecodeString(encodedIndexKey)
+		if err != nil {
+			return nil, errors.New("blind index key bukan base64 yang valid")
+		}
+		indexKey = decoded
+	}
+
+	return NewKeyring(activeID, keys, indexKey)
+}
+
+// SetDefault installs the keyring used by the GORM serializer and by
+// BlindIndex.
+func SetDefault(k *Keyring) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultKeyring = k
+}
+
+// Default returns the process-wide keyring.
+func Default() *Keyring {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultKeyring
+}
+
+// Enabled reports whether master keys are configured. A disabled keyring
+// stores values in plaintext.
+func (k *Keyring) Enabled() bool {
+	return len(k.keys) > 0
+}
+
+// ActiveKeyID returns the id of the key used for new encryptions.
+func (k *Keyring) ActiveKeyID() string {
+	return k.activeID
+}
+
+// IsEncrypted reports whether the stored value carries the envelope format.
+func IsEncrypted(stored string) bool {
+	return strings.HasPrefix(stored, prefix+":")
+}
+
+// IsCurrent reports whether the stored value is already encrypted under the
+// active master key, i.e. it does not need to be migrated or rotated.
+func (k *Keyring) IsCurrent(stored string) bool {
+	if stored == "" {
+		return true
+	}
+	if !k.Enabled() {
+		return !IsEncrypted(stored)
+	}
+	parts := strings.Split(stored, ":")
+	return len(parts) == 5 && parts[0] == prefix && parts[1] == version && parts[2] == k.activeID
+}
+
+// Encrypt seals plaintext with a fresh data key wrapped by the active master
+// key. Empty strings are kept empty so optional columns stay comparable.
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	if plaintext == "" || !k.Enabled() {
+		return plaintext, nil
+	}
+
+	dataKey := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return "", fmt.Errorf("gagal membuat data key: %v", err)
+	}
+
+	sealedValue, err := seal(dataKey, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	wrappedKey, err := seal(k.keys[k.activeID], dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join([]string{
+		prefix,
+		version,
+		k.activeID,
+		base64.RawStdEncoding.EncodeToString(wrappedKey),
+		base64.RawStdEncoding.EncodeToString(sealedValue),
+	}, ":"), nil
+}
+
+// Decrypt opens a value produced by Encrypt. Values that are not in the
+// envelope format are returned unchanged so rows written before encryption
+// was enabled stay readable until they are migrated.
+func (k *Keyring) Decrypt(stored string) (string, error) {
+	if !IsEncrypted(stored) {
+		return stored, nil
+	}
+
+	parts := strings.Split(stored, ":")
+	if len(parts) != 5 || parts[1] != version {
+		return "", errors.New("format data terenkripsi tidak dikenali")
+	}
+	masterKey, ok := k.keys[parts[2]]
+	if !ok {
+		return "", fmt.Errorf("master key %s tidak tersedia", parts[2])
+	}
+
+	wrappedKey, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return "", errors.New("data key terenkripsi rusak")
+	}
+	sealedValue, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return "", errors.New("nilai terenkripsi rusak")
+	}
+
+	dataKey, err := open(masterKey, wrappedKey)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(dataKey, sealedValue)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// BlindIndex returns a keyed hash of the normalised value that supports exact
+// match lookups and uniqueness checks without revealing the plaintext. Empty
+// values have no index.
+func (k *Keyring) BlindIndex(field Field, value string) string {
+	normalized := Normalize(field, value)
+	if normalized == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, k.indexKey)
+	mac.Write([]byte(string(field) + ":" + normalized))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// BlindIndex computes a blind index with the default keyring.
+func BlindIndex(field Field, value string) string {
+	return Default().BlindIndex(field, value)
+}
+
+// BlindIndexPtr is BlindIndex for nullable index columns.
+func BlindIndexPtr(field Field, value string) *string {
+	index := BlindIndex(field, value)
+	if index == "" {
+		return nil
+	}
+	return &index
+}
+
+func seal(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("gagal membuat nonce: %v", err)
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(key, sealed []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("nilai terenkripsi terlalu pendek")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("gagal mendekripsi nilai")
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("kunci enkripsi tidak valid: %v", err)
+	}
+	return cipher.NewGCM(block)
+}
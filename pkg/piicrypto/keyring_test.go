@@ -0,0 +1,274 @@
+package piicrypto
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, 32)
+}
+
+func newTestKeyring(t *testing.T, activeID string, ids ...string) *Keyring {
+	t.Helper()
+	keys := make(map[string][]byte, len(ids))
+	for i, id := range ids {
+		keys[id] = testKey(byte(i + 1))
+	}
+	k, err := NewKeyring(activeID, keys, testKey(0xAA))
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	return k
+}
+
+func TestEncryptDecryptRoundTrip(t *testing.T) {
+	k := newTestKeyring(t, "v1", "v1")
+	for _, plaintext := range []string{"081234567890", "Jl. Sudirman No. 1, Jakarta", "nama@contoh.co.id", "ünïcødé ✓", strings.Repeat("x", 4096)} {
+		sealed, err := k.Encrypt(plaintext)
+		if err != nil {
+			t.Fatalf("Encrypt(%q): %v", plaintext, err)
+		}
+		if !IsEncrypted(sealed) || strings.Contains(sealed, plaintext) {
+			t.Fatalf("Encrypt(%q) = %q, want an envelope without the plaintext", plaintext, sealed)
+		}
+		if !k.IsCurrent(sealed) {
+			t.Errorf("IsCurrent(%q) = false right after encryption", sealed)
+		}
+		got, err := k.Decrypt(sealed)
+		if err != nil || got != plaintext {
+			t.Fatalf("Decrypt = %q, %v; want %q", got, err, plaintext)
+		}
+	}
+}
+
+func TestEncryptIsRandomised(t *testing.T) {
+	k := newTestKeyring(t, "v1", "v1")
+	a, _ := k.Encrypt("081234567890")
+	b, _ := k.Encrypt("081234567890")
+	if a == b {
+		t.Fatal("two encryptions of the same value are identical")
+	}
+}
+
+func TestEmptyAndPlaintextValues(t *testing.T) {
+	k := newTestKeyring(t, "v1", "v1")
+	if sealed, err := k.Encrypt(""); err != nil || sealed != "" {
+		t.Fatalf("Encrypt(\"\") = %q, %v; want empty", sealed, err)
+	}
+	// Rows written before encryption was enabled stay readable.
+	if got, err := k.Decrypt("081234567890"); err != nil || got != "081234567890" {
+		t.Fatalf("Decrypt(plaintext) = %q, %v", got, err)
+	}
+	if k.IsCurrent("081234567890") {
+		t.Error("IsCurrent(plaintext) = true with keys configured")
+	}
+}
+
+func TestDisabledKeyringStoresPlaintext(t *testing.T) {
+	k, err := NewKeyring("", nil, nil)
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	if k.Enabled() {
+		t.Fatal("keyring without keys reports enabled")
+	}
+	if sealed, err := k.Encrypt("081234567890"); err != nil || sealed != "081234567890" {
+		t.Fatalf("Encrypt = %q, %v; want the plaintext", sealed, err)
+	}
+	if k.BlindIndex(FieldPhone, "081234567890") == "" {
+		t.Fatal("disabled keyring computes no blind index")
+	}
+}
+
+func TestRotationKeepsOldValuesReadable(t *testing.T) {
+	old := newTestKeyring(t, "v1", "v1")
+	sealed, err := old.Encrypt("1234567890")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+
+	rotated := newTestKeyring(t, "v2", "v1", "v2")
+	if rotated.IsCurrent(sealed) {
+		t.Fatal("value under the old key reported current after rotation")
+	}
+	got, err := rotated.Decrypt(sealed)
+	if err != nil || got != "1234567890" {
+		t.Fatalf("Decrypt after rotation = %q, %v", got, err)
+	}
+
+	resealed, err := rotated.Encrypt(got)
+	if err != nil {
+		t.Fatalf("Encrypt after rotation: %v", err)
+	}
+	if !strings.HasPrefix(resealed, "enc:v1:v2:") || !rotated.IsCurrent(resealed) {
+		t.Fatalf("re-encrypted value %q is not under the active key", resealed)
+	}
+
+	// Once the old key is removed its values can no longer be read.
+	retired := newTestKeyring(t, "v2", "v2")
+	if _, err := retired.Decrypt(sealed); err == nil {
+		t.Fatal("Decrypt succeeded without the master key")
+	}
+}
+
+func TestTamperDetection(t *testing.T) {
+	k := newTestKeyring(t, "v1", "v1")
+	sealed, err := k.Encrypt("1234567890")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	parts := strings.Split(sealed, ":")
+
+	flip := func(encoded string) string {
+		raw, err := base64.RawStdEncoding.DecodeString(encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw[len(raw)-1] ^= 0x01
+		return base64.RawStdEncoding.EncodeToString(raw)
+	}
+	otherKey, _ := k.Encrypt("0987654321")
+	otherParts := strings.Split(otherKey, ":")
+
+	tampered := map[string]string{
+		"value":            strings.Join([]string{parts[0], parts[1], parts[2], parts[3], flip(parts[4])}, ":"),
+		"wrapped key":      strings.Join([]string{parts[0], parts[1], parts[2], flip(parts[3]), parts[4]}, ":"),
+		"swapped data key": strings.Join([]string{parts[0], parts[1], parts[2], otherParts[3], parts[4]}, ":"),
+		"unknown key id":   strings.Join([]string{parts[0], parts[1], "v9", parts[3], parts[4]}, ":"),
+		"unknown version":  strings.Join([]string{parts[0], "v0", parts[2], parts[3], parts[4]}, ":"),
+		"truncated":        strings.Join(parts[:4], ":"),
+		"bad base64":       strings.Join([]string{parts[0], parts[1], parts[2], parts[3], "!!!"}, ":"),
+		"too short":        strings.Join([]string{parts[0], parts[1], parts[2], parts[3], "AAAA"}, ":"),
+	}
+	for name, value := range tampered {
+		if got, err := k.Decrypt(value); err == nil {
+			t.Errorf("%s: Decrypt = %q, want an error", name, got)
+		}
+	}
+}
+
+func TestNewKeyringValidatesKeys(t *testing.T) {
+	cases := map[string]struct {
+		active   string
+		keys     map[string][]byte
+		indexKey []byte
+	}{
+		"short master key":  {"v1", map[string][]byte{"v1": testKey(1)[:16]}, testKey(0xAA)},
+		"missing active":    {"v2", map[string][]byte{"v1": testKey(1)}, testKey(0xAA)},
+		"colon in id":       {"v:1", map[string][]byte{"v:1": testKey(1)}, testKey(0xAA)},
+		"short index key":   {"v1", map[string][]byte{"v1": testKey(1)}, testKey(0xAA)[:8]},
+		"missing index key": {"v1", map[string][]byte{"v1": testKey(1)}, nil},
+	}
+	for name, tc := range cases {
+		if _, err := NewKeyring(tc.active, tc.keys, tc.indexKey); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestLoadKeyring(t *testing.T) {
+	encode := base64.StdEncoding.EncodeToString
+	inline, err := LoadKeyring(Options{
+		Keys:          "v1:" + encode(testKey(1)) + ", v2:" + encode(testKey(2)),
+		ActiveKeyID:   "v2",
+		BlindIndexKey: encode(testKey(0xAA)),
+	})
+	if err != nil {
+		t.Fatalf("LoadKeyring inline: %v", err)
+	}
+	if inline.ActiveKeyID() != "v2" {
+		t.Errorf("ActiveKeyID = %q, want v2", inline.ActiveKeyID())
+	}
+
+	file := filepath.Join(t.TempDir(), "pii-keys.json")
+	raw, _ := json.Marshal(keyFile{
+		Active:        "v1",
+		Keys:          map[string]string{"v1": encode(testKey(1)), "v2": encode(testKey(2))},
+		BlindIndexKey: encode(testKey(0xAA)),
+	})
+	if err := os.WriteFile(file, raw, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// The key file takes precedence over inline keys.
+	fromFile, err := LoadKeyring(Options{Keys: "broken", ActiveKeyID: "v2", KeyFile: file})
+	if err != nil {
+		t.Fatalf("LoadKeyring file: %v", err)
+	}
+	if fromFile.ActiveKeyID() != "v1" {
+		t.Errorf("ActiveKeyID = %q, want v1 from the key file", fromFile.ActiveKeyID())
+	}
+
+	// Both keyrings share the master keys, so each reads the other's values.
+	sealed, _ := inline.Encrypt("rahasia")
+	if got, err := fromFile.Decrypt(sealed); err != nil || got != "rahasia" {
+		t.Fatalf("Decrypt across keyrings = %q, %v", got, err)
+	}
+
+	for _, opts := range []Options{
+		{Keys: "v1", ActiveKeyID: "v1", BlindIndexKey: encode(testKey(0xAA))},
+		{Keys: "v1:not-base64", ActiveKeyID: "v1", BlindIndexKey: encode(testKey(0xAA))},
+		{Keys: "v1:" + encode(testKey(1)), ActiveKeyID: "v1", BlindIndexKey: "not-base64"},
+		{KeyFile: filepath.Join(t.TempDir(), "missing.json")},
+	} {
+		if _, err := LoadKeyring(opts); err == nil {
+			t.Errorf("LoadKeyring(%+v): expected an error", opts)
+		}
+	}
+}
+
+func TestBlindIndexStableUnderNormalisation(t *testing.T) {
+	k := newTestKeyring(t, "v1", "v1")
+	groups := []struct {
+		field  Field
+		values []string
+	}{
+		{FieldPhone, []string{"081234567890", "+62 812-3456-7890", "62812 3456 7890", "812-3456-7890", " (0812) 3456 7890 "}},
+		{FieldEmail, []string{"Nama@Contoh.co.id", "nama@contoh.co.id", "  NAMA@CONTOH.CO.ID "}},
+		{FieldAccount, []string{"123-456-7890", "1234567890", " 1234 5678 90 "}},
+	}
+	for _, group := range groups {
+		want := k.BlindIndex(group.field, group.values[0])
+		if len(want) != 64 {
+			t.Fatalf("%s: BlindIndex = %q, want 64 hex characters", group.field, want)
+		}
+		for _, value := range group.values[1:] {
+			if got := k.BlindIndex(group.field, value); got != want {
+				t.Errorf("%s: BlindIndex(%q) differs from BlindIndex(%q)", group.field, value, group.values[0])
+			}
+		}
+	}
+
+	// The index depends on the index key only, not on the master keys, so it
+	// survives rotation.
+	rotated := newTestKeyring(t, "v2", "v1", "v2")
+	if k.BlindIndex(FieldPhone, "081234567890") != rotated.BlindIndex(FieldPhone, "081234567890") {
+		t.Error("blind index changed after master key rotation")
+	}
+}
+
+func TestBlindIndexSeparatesFieldsAndValues(t *testing.T) {
+	k := newTestKeyring(t, "v1", "v1")
+	if k.BlindIndex(FieldPhone, "081234567890") == k.BlindIndex(FieldAccount, "081234567890") {
+		t.Error("the same digits index identically as phone and account number")
+	}
+	if k.BlindIndex(FieldPhone, "081234567890") == k.BlindIndex(FieldPhone, "081234567891") {
+		t.Error("different phone numbers share a blind index")
+	}
+	if k.BlindIndex(FieldPhone, " - ") != "" || BlindIndexPtr(FieldEmail, "") != nil {
+		t.Error("empty values must have no blind index")
+	}
+
+	other, err := NewKeyring("v1", map[string][]byte{"v1": testKey(1)}, testKey(0xBB))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k.BlindIndex(FieldPhone, "081234567890") == other.BlindIndex(FieldPhone, "081234567890") {
+		t.Error("blind index does not depend on the index key")
+	}
+}
@@ -0,0 +1,61 @@
+package piicrypto
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Field identifies which normalisation rules and index namespace apply to a
+// blind-indexed value.
+type Field string
+
+const (
+	FieldPhone   Field = "nomor_hp"
+	FieldEmail   Field = "email"
+	FieldAccount Field = "nomor_rekening"
+)
+
+// Normalize brings equivalent spellings of the same value to one canonical
+// form before hashing, e.g. "+62 812-3456" and "08123456" index identically.
+func Normalize(field Field, value string) string {
+	value = strings.TrimSpace(value)
+	switch field {
+	case FieldPhone:
+		return NormalizePhone(value)
+	case FieldEmail:
+		return NormalizeEmail(value)
+	case FieldAccount:
+		return strings.ToUpper(strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return r
+			}
+			return -1
+		}, value))
+	default:
+		return value
+	}
+}
+
+// NormalizePhone keeps digits only and rewrites the Indonesian country code
+// to the domestic trunk prefix.
+func NormalizePhone(phone string) string {
+	digits := strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, phone)
+	if strings.HasPrefix(digits, "62") {
+		digits = "0" + digits[2:]
+	} else if strings.HasPrefix(digits, "8") {
+		digits = "0" + digits
+	}
+	return digits
+}
+
+// NormalizeEmail lowercases the address. Provider-specific aliasing is not
+// folded here because it would make uniqueness stricter than the bank's
+// customer records.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
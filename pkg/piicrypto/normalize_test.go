@@ -0,0 +1,27 @@
+package piicrypto
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	cases := []struct {
+		field Field
+		in    string
+		want  string
+	}{
+		{FieldPhone, "081234567890", "081234567890"},
+		{FieldPhone, "+62 812-3456-7890", "081234567890"},
+		{FieldPhone, "6281234567890", "081234567890"},
+		{FieldPhone, "81234567890", "081234567890"},
+		{FieldPhone, "(021) 555-0123", "0215550123"},
+		{FieldPhone, "", ""},
+		{FieldEmail, "  Nama@Contoh.CO.ID ", "nama@contoh.co.id"},
+		{FieldAccount, "123-456 7890", "1234567890"},
+		{FieldAccount, "ab-12.34", "AB1234"},
+		{Field("address"), "  Jl. Sudirman  ", "Jl. Sudirman"},
+	}
+	for _, tc := range cases {
+		if got := Normalize(tc.field, tc.in); got != tc.want {
+			t.Errorf("Normalize(%s, %q) = %q, want %q", tc.field, tc.in, got, tc.want)
+		}
+	}
+}
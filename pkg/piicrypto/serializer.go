@@ -0,0 +1,46 @@
+package piicrypto
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm/schema"
+)
+
+func init() {
+	schema.RegisterSerializer("pii", Serializer{})
+}
+
+// Serializer transparently encrypts string fields tagged `serializer:pii` on
+// write and decrypts them on read using the default keyring.
+type Serializer struct{}
+
+// Scan implements schema.SerializerInterface.
+func (Serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var stored string
+	switch v := dbValue.(type) {
+	case nil:
+	case []byte:
+		stored = string(v)
+	case string:
+		stored = v
+	default:
+		return fmt.Errorf("tipe kolom PII tidak didukung: %T", dbValue)
+	}
+
+	plaintext, err := Default().Decrypt(stored)
+	if err != nil {
+		return fmt.Errorf("gagal mendekripsi kolom %s: %v", field.DBName, err)
+	}
+	return field.Set(ctx, dst, plaintext)
+}
+
+// Value implements schema.SerializerValuerInterface.
+func (Serializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	plaintext, ok := fieldValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("kolom PII %s harus bertipe string", field.DBName)
+	}
+	return Default().Encrypt(plaintext)
+}
@@ -0,0 +1,92 @@
+package piicrypto
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"gorm.io/gorm/schema"
+)
+
+type serializerRecord struct {
+	ID    uint
+	Phone string `gorm:"serializer:pii"`
+}
+
+func withDefault(t *testing.T, k *Keyring) {
+	t.Helper()
+	previous := Default()
+	SetDefault(k)
+	t.Cleanup(func() { SetDefault(previous) })
+}
+
+func phoneField(t *testing.T) *schema.Field {
+	t.Helper()
+	s, err := schema.Parse(&serializerRecord{}, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		t.Fatalf("schema.Parse: %v", err)
+	}
+	field := s.LookUpField("phone")
+	if field == nil {
+		t.Fatal("phone field not found")
+	}
+	return field
+}
+
+func TestSerializerRoundTrip(t *testing.T) {
+	withDefault(t, newTestKeyring(t, "v1", "v1"))
+	field := phoneField(t)
+	ctx := context.Background()
+
+	stored, err := Serializer{}.Value(ctx, field, reflect.Value{}, "081234567890")
+	if err != nil {
+		t.Fatalf("Value: %v", err)
+	}
+	sealed, ok := stored.(string)
+	if !ok || !IsEncrypted(sealed) || strings.Contains(sealed, "081234567890") {
+		t.Fatalf("Value = %#v, want an encrypted string", stored)
+	}
+
+	for _, dbValue := range []interface{}{sealed, []byte(sealed)} {
+		var record serializerRecord
+		if err := (Serializer{}).Scan(ctx, field, reflect.ValueOf(&record).Elem(), dbValue); err != nil {
+			t.Fatalf("Scan(%T): %v", dbValue, err)
+		}
+		if record.Phone != "081234567890" {
+			t.Errorf("Scan(%T) = %q, want the plaintext", dbValue, record.Phone)
+		}
+	}
+}
+
+func TestSerializerReadsLegacyAndNullValues(t *testing.T) {
+	withDefault(t, newTestKeyring(t, "v1", "v1"))
+	field := phoneField(t)
+	ctx := context.Background()
+
+	record := serializerRecord{Phone: "stale"}
+	if err := (Serializer{}).Scan(ctx, field, reflect.ValueOf(&record).Elem(), "081234567890"); err != nil || record.Phone != "081234567890" {
+		t.Fatalf("Scan(plaintext) = %q, %v", record.Phone, err)
+	}
+	if err := (Serializer{}).Scan(ctx, field, reflect.ValueOf(&record).Elem(), nil); err != nil || record.Phone != "" {
+		t.Fatalf("Scan(nil) = %q, %v", record.Phone, err)
+	}
+}
+
+func TestSerializerRejectsBadValues(t *testing.T) {
+	withDefault(t, newTestKeyring(t, "v1", "v1"))
+	field := phoneField(t)
+	ctx := context.Background()
+
+	var record serializerRecord
+	if err := (Serializer{}).Scan(ctx, field, reflect.ValueOf(&record).Elem(), 42); err == nil {
+		t.Error("Scan of a non-string column: expected an error")
+	}
+	if err := (Serializer{}).Scan(ctx, field, reflect.ValueOf(&record).Elem(), "enc:v1:v9:AAAA:AAAA"); err == nil {
+		t.Error("Scan of a value under an unknown key: expected an error")
+	}
+	if _, err := (Serializer{}).Value(ctx, field, reflect.Value{}, 42); err == nil {
+		t.Error("Value of a non-string field: expected an error")
+	}
+}
@@ -28,3 +28,13 @@ func ErrorField(c *fiber.Ctx, status int, err string, validationErrors map[strin
 		"errors":  validationErrors,
 	})
 }
+
+// ErrorDetail represents an error response carrying structured details
+func ErrorDetail(c *fiber.Ctx, status int, err string, message string, detail interface{}) error {
+	return c.Status(status).JSON(fiber.Map{
+		"success": false,
+		"error":   err,
+		"message": message,
+		"detail":  detail,
+	})
+}
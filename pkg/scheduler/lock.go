@@ -0,0 +1,44 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// ErrLocked is returned by WithAdvisoryLock when another process holds the
+// lock.
+var ErrLocked = errors.New("job sedang dijalankan oleh proses lain")
+
+// WithAdvisoryLock runs fn while holding a Postgres session advisory lock
+// named key, so a job runs on only one API instance at a time. The lock lives
+// on a dedicated connection and is released when fn returns.
+func WithAdvisoryLock(ctx context.Context, db *gorm.DB, key string, fn func() error) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("gagal mengambil koneksi database: %v", err)
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("gagal membuka koneksi lock: %v", err)
+	}
+	defer conn.Close()
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock(hashtext($1))", key).Scan(&locked); err != nil {
+		return fmt.Errorf("gagal mengambil lock %s: %v", key, err)
+	}
+	if !locked {
+		return ErrLocked
+	}
+	defer unlock(conn, key)
+
+	return fn()
+}
+
+func unlock(conn *sql.Conn, key string) {
+	_, _ = conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock(hashtext($1))", key)
+}
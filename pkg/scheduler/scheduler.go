@@ -0,0 +1,79 @@
+// Package scheduler runs periodic background jobs inside the API process.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Job is a task that runs every Interval until the scheduler is stopped.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+type Scheduler struct {
+	log  *zap.Logger
+	jobs []Job
+	wg   sync.WaitGroup
+}
+
+func New(log *zap.Logger) *Scheduler {
+	return &Scheduler{log: log}
+}
+
+// Add registers a job. Jobs added after Start are not run.
+func (s *Scheduler) Add(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+// Start runs every job on its own ticker until ctx is cancelled. The first run
+// happens one interval after start so a restart loop cannot hammer the
+// database.
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(ctx, job)
+	}
+}
+
+// Wait blocks until every job loop has returned after cancellation.
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.runOnce(ctx, job)
+		}
+	}
+}
+
+func (s *Scheduler) runOnce(ctx context.Context, job Job) {
+	started := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			s.log.Error("scheduled job panicked", zap.String("job", job.Name), zap.String("panic", fmt.Sprint(r)))
+		}
+	}()
+
+	if err := job.Run(ctx); err != nil {
+		s.log.Error("scheduled job failed", zap.String("job", job.Name), zap.Error(err))
+		return
+	}
+	s.log.Info("scheduled job finished", zap.String("job", job.Name), zap.Duration("duration", time.Since(started)))
+}
@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCleanKey(t *testing.T) {
+	valid := []string{"leads/42/3f9a", "a", "leads/42/slip gaji.pdf"}
+	for _, key := range valid {
+		if got, err := cleanKey(key); err != nil || got != key {
+			t.Errorf("cleanKey(%q) = %q, %v; want the key unchanged", key, got, err)
+		}
+	}
+
+	invalid := []string{"", "/", "/leads/1", "../x", "leads/../../x", "leads/../x", "leads//x", "leads/./x", "leads/x/", "..", "."}
+	for _, key := range invalid {
+		if got, err := cleanKey(key); err == nil {
+			t.Errorf("cleanKey(%q) = %q; want an error", key, got)
+		}
+	}
+}
+
+func TestLocalPutOpenDelete(t *testing.T) {
+	root := t.TempDir()
+	s, err := NewLocal(root)
+	if err != nil {
+		t.Fatalf("NewLocal: %v", err)
+	}
+	ctx := context.Background()
+
+	key := "leads/42/3f9a"
+	if err := s.Put(ctx, key, strings.NewReader("isi dokumen"), 11, "application/pdf"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "leads", "42", "3f9a")); err != nil {
+		t.Fatalf("stored file: %v", err)
+	}
+	entries, err := os.ReadDir(filepath.Join(root, "leads", "42"))
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("temporary files left behind: %v, %v", entries, err)
+	}
+
+	reader, err := s.Open(ctx, key)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	body, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil || string(body) != "isi dokumen" {
+		t.Fatalf("Open = %q, %v", body, err)
+	}
+
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := s.Open(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Open after Delete: err = %v, want ErrNotFound", err)
+	}
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete of a missing file: %v", err)
+	}
+}
+
+func TestLocalRejectsPathTraversal(t *testing.T) {
+	parent := t.TempDir()
+	root := filepath.Join(parent, "storage")
+	s, err := NewLocal(root)
+	if err != nil {
+		t.Fatalf("NewLocal: %v", err)
+	}
+	outside := filepath.Join(parent, "outside")
+	if err := os.WriteFile(outside, []byte("rahasia"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	for _, key := range []string{"../outside", "leads/../../outside", "/outside", outside} {
+		if err := s.Put(ctx, key, strings.NewReader("x"), 1, "text/plain"); err == nil {
+			t.Errorf("Put %q: expected an error", key)
+		}
+		if reader, err := s.Open(ctx, key); err == nil {
+			reader.Close()
+			t.Errorf("Open %q: expected an error", key)
+		}
+		if err := s.Delete(ctx, key); err == nil {
+			t.Errorf("Delete %q: expected an error", key)
+		}
+	}
+
+	if body, err := os.ReadFile(outside); err != nil || string(body) != "rahasia" {
+		t.Fatalf("file outside the root was touched: %q, %v", body, err)
+	}
+}
+
+func TestNewLocalRequiresRoot(t *testing.T) {
+	if _, err := NewLocal(""); err == nil {
+		t.Fatal("NewLocal(\"\"): expected an error")
+	}
+}
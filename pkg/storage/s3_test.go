@@ -0,0 +1,260 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const (
+	testAccessKey = "AKIDEXAMPLE"
+	testSecretKey = "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"
+	testRegion    = "ap-southeast-1"
+	testBucket    = "documents"
+)
+
+// fakeS3 is a minimal path-style object store that checks the Signature
+// Version 4 of every request the way S3 does, computed independently of the
+// signer under test.
+type fakeS3 struct {
+	t       *testing.T
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *httptest.Server) {
+	t.Helper()
+	fake := &fakeS3{t: t, objects: map[string][]byte{}, types: map[string]string{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := f.verify(r); err != nil {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, err.Error())
+		return
+	}
+
+	prefix := "/" + testBucket + "/"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, prefix)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if r.ContentLength != int64(len(body)) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.objects[key] = body
+		f.types[key] = r.Header.Get("Content-Type")
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet:
+		body, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(body)
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeS3) verify(r *http.Request) error {
+	auth := r.Header.Get("Authorization")
+	amzDate := r.Header.Get("X-Amz-Date")
+	payloadHash := r.Header.Get("X-Amz-Content-Sha256")
+	if auth == "" || len(amzDate) != 16 || payloadHash == "" {
+		return errors.New("missing signature headers")
+	}
+
+	day := amzDate[:8]
+	scope := day + "/" + testRegion + "/s3/aws4_request"
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		r.URL.EscapedPath(),
+		r.URL.RawQuery,
+		"host:" + r.Host + "\nx-amz-content-sha256:" + payloadHash + "\nx-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	mac := func(key []byte, data string) []byte {
+		h := hmac.New(sha256.New, key)
+		h.Write([]byte(data))
+		return h.Sum(nil)
+	}
+	signingKey := mac(mac(mac(mac([]byte("AWS4"+testSecretKey), day), testRegion), "s3"), "aws4_request")
+	expected := fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		testAccessKey, scope, signedHeaders, hex.EncodeToString(mac(signingKey, stringToSign)))
+	if auth != expected {
+		return fmt.Errorf("SignatureDoesNotMatch: got %q want %q", auth, expected)
+	}
+	return nil
+}
+
+func newTestS3(t *testing.T, endpoint, secretKey string) *S3 {
+	t.Helper()
+	s, err := NewS3(Options{
+		S3Endpoint:  endpoint,
+		S3Region:    testRegion,
+		S3Bucket:    testBucket,
+		S3AccessKey: testAccessKey,
+		S3SecretKey: secretKey,
+		S3PathStyle: true,
+	})
+	if err != nil {
+		t.Fatalf("NewS3: %v", err)
+	}
+	return s
+}
+
+func TestS3PutOpenDelete(t *testing.T) {
+	fake, server := newFakeS3(t)
+	s := newTestS3(t, server.URL, testSecretKey)
+	ctx := context.Background()
+
+	for _, key := range []string{"leads/42/3f9a", "leads/42/slip gaji+juni (1).pdf", "leads/42/ktp_ä.jpg"} {
+		content := "isi " + key
+		if err := s.Put(ctx, key, strings.NewReader(content), int64(len(content)), "application/pdf"); err != nil {
+			t.Fatalf("Put %q: %v", key, err)
+		}
+		if got := fake.types[key]; got != "application/pdf" {
+			t.Errorf("content type of %q = %q", key, got)
+		}
+
+		reader, err := s.Open(ctx, key)
+		if err != nil {
+			t.Fatalf("Open %q: %v", key, err)
+		}
+		body, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			t.Fatalf("read %q: %v", key, err)
+		}
+		if string(body) != content {
+			t.Errorf("Open %q = %q, want %q", key, body, content)
+		}
+
+		if err := s.Delete(ctx, key); err != nil {
+			t.Fatalf("Delete %q: %v", key, err)
+		}
+		if _, err := s.Open(ctx, key); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Open after Delete %q: err = %v, want ErrNotFound", key, err)
+		}
+	}
+}
+
+func TestS3DeleteMissingSucceeds(t *testing.T) {
+	_, server := newFakeS3(t)
+	s := newTestS3(t, server.URL, testSecretKey)
+	if err := s.Delete(context.Background(), "leads/1/missing"); err != nil {
+		t.Fatalf("Delete of a missing object: %v", err)
+	}
+}
+
+func TestS3WrongSecretIsRejected(t *testing.T) {
+	_, server := newFakeS3(t)
+	s := newTestS3(t, server.URL, "not-the-secret")
+	ctx := context.Background()
+
+	err := s.Put(ctx, "leads/1/a", strings.NewReader("x"), 1, "text/plain")
+	if err == nil || !strings.Contains(err.Error(), "status 403") {
+		t.Fatalf("Put with a wrong secret: err = %v, want status 403", err)
+	}
+	if _, err := s.Open(ctx, "leads/1/a"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Fatalf("Open with a wrong secret: err = %v, want a signature error", err)
+	}
+}
+
+func TestS3RejectsUnsafeKeys(t *testing.T) {
+	_, server := newFakeS3(t)
+	s := newTestS3(t, server.URL, testSecretKey)
+	for _, key := range []string{"../etc/passwd", "/leads/1", "leads/../../x", ""} {
+		if err := s.Put(context.Background(), key, strings.NewReader("x"), 1, "text/plain"); err == nil {
+			t.Errorf("Put %q: expected an error", key)
+		}
+	}
+}
+
+func TestS3RequestAddressing(t *testing.T) {
+	tests := []struct {
+		name      string
+		endpoint  string
+		pathStyle bool
+		want      string
+	}{
+		{"path style", "http://localhost:9000", true, "http://localhost:9000/documents/leads/1/a%20b.pdf"},
+		{"virtual hosted", "https://s3.ap-southeast-1.amazonaws.com/", false, "https://documents.s3.ap-southeast-1.amazonaws.com/leads/1/a%20b.pdf"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewS3(Options{
+				S3Endpoint:  tt.endpoint,
+				S3Bucket:    testBucket,
+				S3AccessKey: testAccessKey,
+				S3SecretKey: testSecretKey,
+				S3PathStyle: tt.pathStyle,
+			})
+			if err != nil {
+				t.Fatalf("NewS3: %v", err)
+			}
+			req, err := s.request(context.Background(), http.MethodGet, "leads/1/a b.pdf", nil)
+			if err != nil {
+				t.Fatalf("request: %v", err)
+			}
+			if got := req.URL.String(); got != tt.want {
+				t.Errorf("URL = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestS3EscapePath(t *testing.T) {
+	got := s3EscapePath("/documents/leads/1/slip gaji+juni(1)~_-.pdf")
+	want := "/documents/leads/1/slip%20gaji%2Bjuni%281%29~_-.pdf"
+	if got != want {
+		t.Errorf("s3EscapePath = %q, want %q", got, want)
+	}
+}
+
+func TestNewS3RequiresConfiguration(t *testing.T) {
+	for _, opts := range []Options{
+		{S3Bucket: testBucket, S3AccessKey: testAccessKey, S3SecretKey: testSecretKey},
+		{S3Endpoint: "http://localhost:9000", S3AccessKey: testAccessKey, S3SecretKey: testSecretKey},
+		{S3Endpoint: "http://localhost:9000", S3Bucket: testBucket},
+		{S3Endpoint: "localhost", S3Bucket: testBucket, S3AccessKey: testAccessKey, S3SecretKey: testSecretKey},
+	} {
+		if _, err := NewS3(opts); err == nil {
+			t.Errorf("NewS3(%+v): expected an error", opts)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ErrInfected is returned by a Scanner that rejected a file.
+var ErrInfected = errors.New("file terdeteksi mengandung malware")
+
+// Scanner inspects an uploaded file, saved at path, before it is stored.
+type Scanner interface {
+	Scan(ctx context.Context, path string) error
+}
+
+// NewScanner returns a scanner that runs command with the file path as its
+// last argument, or one that accepts every file when command is empty.
+func NewScanner(command string) Scanner {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return NopScanner{}
+	}
+	return CommandScanner{Name: fields[0], Args: fields[1:]}
+}
+
+// NopScanner accepts every file.
+type NopScanner struct{}
+
+func (NopScanner) Scan(ctx context.Context, path string) error {
+	return nil
+}
+
+// CommandScanner runs an external scanner such as clamdscan. Exit code 0
+// means clean and 1 means infected, the convention of ClamAV; any other
+// failure is reported as a scanner error.
+type CommandScanner struct {
+	Name string
+	Args []string
+}
+
+func (s CommandScanner) Scan(ctx context.Context, path string) error {
+	args := append(append([]string{}, s.Args...), path)
+	output, err := exec.CommandContext(ctx, s.Name, args...).CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return ErrInfected
+	}
+	return fmt.Errorf("pemindaian file gagal: %v: %s", err, strings.TrimSpace(string(output)))
+}
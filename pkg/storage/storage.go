@@ -0,0 +1,63 @@
+// Package storage keeps uploaded files in a pluggable backend: the local
+// filesystem or an S3-compatible object store.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// ErrNotFound is returned by Open when no file is stored under the key.
+var ErrNotFound = errors.New("file tidak ditemukan di storage")
+
+// Storage stores files under slash-separated keys such as
+// "leads/42/3f9a...".
+type Storage interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Options selects and configures the backend. Driver is "local" (the
+// default) or "s3".
+type Options struct {
+	Driver string
+
+	// LocalPath is the root directory of the local backend.
+	LocalPath string
+
+	// S3Endpoint is the base URL of the object store, e.g.
+	// "https://s3.ap-southeast-1.amazonaws.com" or "http://localhost:9000".
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	// S3PathStyle addresses objects as endpoint/bucket/key instead of
+	// bucket.endpoint/key, as most S3-compatible stores expect.
+	S3PathStyle bool
+}
+
+// New builds the backend described by opts.
+func New(opts Options) (Storage, error) {
+	switch opts.Driver {
+	case "", "local":
+		return NewLocal(opts.LocalPath)
+	case "s3":
+		return NewS3(opts)
+	}
+	return nil, fmt.Errorf("storage driver tidak dikenal: %s", opts.Driver)
+}
+
+// cleanKey rejects keys that could escape the storage root.
+func cleanKey(key string) (string, error) {
+	cleaned := path.Clean("/" + key)[1:]
+	if cleaned == "" || cleaned != key || strings.HasPrefix(key, "/") {
+		return "", fmt.Errorf("key storage tidak valid: %q", key)
+	}
+	return cleaned, nil
+}
@@ -39,7 +39,11 @@ type predictionPair struct {
 	Value float64
 }
 
-func ImportInitialCustomerData(ctx context.Context, db *gorm.DB) error {
+// BranchResolver routes a customer to a branch with the customer branch
+// rules, returning nil when none matches.
+type BranchResolver func(rules []model.CustomerBranchRule, customer *model.Customer) *uint
+
+func ImportInitialCustomerData(ctx context.Context, db *gorm.DB, resolveBranch BranchResolver) error {
 	startTime := time.Now()
 
 	var count int64
@@ -95,6 +99,14 @@ func ImportInitialCustomerData(ctx context.Context, db *gorm.DB) error {
 		productMap[strings.ToLower(p.Prediksi)] = p
 	}
 
+	// Imported customers are routed to a branch by their payroll company or
+	// address; those no rule matches wait for an admin.
+	var branchRules []model.CustomerBranchRule
+	if err := tx.Find(&branchRules).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to fetch customer branch rules: %v", err)
+	}
+
 	reader := csv.NewReader(file)
 
 	if _, err := reader.Read(); err != nil {
@@ -171,11 +183,32 @@ func ImportInitialCustomerData(ctx context.Context, db *gorm.DB) error {
 
 		customerWithoutProducts := result.customer
 		customerWithoutProducts.CustomerProduk = nil
+		customerWithoutProducts.Source = model.CustomerSourceCSVImport
+		customerWithoutProducts.KantorCabangID = resolveBranch(branchRules, &customerWithoutProducts)
 		if err := tx.Create(&customerWithoutProducts).Error; err != nil {
 			tx.Rollback()
 			return fmt.Errorf("error creating customer at line %d: %v", result.lineNum, err)
 		}
 
+		scores := make(map[string]float64, len(result.sortedPreds))
+		for _, pred := range result.sortedPreds {
+			scores[pred.Key] = pred.Value
+		}
+		scoresJSON, err := json.Marshal(scores)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("error encoding prediction scores at line %d: %v", result.lineNum, err)
+		}
+		run := &model.CustomerPredictionRun{
+			CustomerID: customerWithoutProducts.Id,
+			Source:     model.CustomerSourceCSVImport,
+			Scores:     string(scoresJSON),
+		}
+		if err := tx.Create(run).Error; err != nil {
+			tx.Rollback()
+			return fmt.Errorf("error creating prediction run at line %d: %v", result.lineNum, err)
+		}
+
 		for i := 0; i < len(result.sortedPreds); i++ {
 			prodName := result.sortedPreds[i].Key
 			if result.sortedPreds[i].Value == 0 {
@@ -178,6 +178,37 @@ func imageMaxSizeValidator(fl validator.FieldLevel) bool {
 	return file.Size <= maxSize
 }
 
+// SniffDocument checks an uploaded document against the size limit and the
+// allowed content types. The type is detected from the file content, not from
+// the extension or the client's header, and is returned on success.
+func SniffDocument(fileHeader *multipart.FileHeader, maxSize int64, allowed []string) (string, error) {
+	if fileHeader.Size <= 0 {
+		return "", fmt.Errorf("file kosong")
+	}
+	if fileHeader.Size > maxSize {
+		return "", fmt.Errorf("ukuran file tidak boleh melebihi %d MB", maxSize>>20)
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return "", fmt.Errorf("gagal membaca file: %v", err)
+	}
+	defer file.Close()
+
+	head := make([]byte, 512)
+	n, err := file.Read(head)
+	if err != nil && n == 0 {
+		return "", fmt.Errorf("gagal membaca file: %v", err)
+	}
+	contentType := strings.TrimSpace(strings.Split(http.DetectContentType(head[:n]), ";")[0])
+	for _, t := range allowed {
+		if strings.TrimSpace(t) == contentType {
+			return contentType, nil
+		}
+	}
+	return "", fmt.Errorf("tipe file %s tidak diizinkan", contentType)
+}
+
 func validateIsBool(fl validator.FieldLevel) bool {
 	return fl.Field().Kind() == reflect.Bool
 }